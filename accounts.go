@@ -11,6 +11,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Masterminds/semver"
@@ -76,11 +77,28 @@ type Account struct {
 
 	Theme Theme
 
+	// APITokens are the bearer tokens which can be used to authenticate as this account.
+	APITokens []*APIToken
+
 	// Deprecated: Use Groups instead.
 	DeprecatedGroup Group `json:"Group"`
+
+	// apiToken is set when the account has been authenticated by an APIToken for the current request.
+	apiToken *APIToken
 }
 
 func (a Account) Group() Group {
+	group := a.accountGroup()
+
+	if a.apiToken != nil {
+		// api tokens can never have more privileges than the account they belong to
+		return lowestGroup(group, a.apiToken.Group())
+	}
+
+	return group
+}
+
+func (a Account) accountGroup() Group {
 	if a.Groups == nil {
 		return GroupNoAccess
 	}
@@ -178,11 +196,32 @@ const (
 	GroupAdmin    Group = "admin"
 )
 
+// Groups are the groups that an account can be in, from least to most privileged.
+var Groups = []Group{GroupNoAccess, GroupRead, GroupWrite, GroupDelete, GroupAdmin}
+
+var ErrInvalidGroup = errors.New("servermanager: invalid group")
+
+// ParseGroup returns the Group with the given name.
+func ParseGroup(name string) (Group, error) {
+	for _, group := range Groups {
+		if string(group) == name {
+			return group, nil
+		}
+	}
+
+	return "", ErrInvalidGroup
+}
+
 var OpenAccount *Account
 
 // MustLoginMiddleware determines whether an account needs to log in to access a given Group page
 func (ah *AccountHandler) MustLoginMiddleware(requiredGroup Group, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token, ok := bearerToken(r); ok {
+			ah.apiTokenLogin(token, requiredGroup, next, w, r)
+			return
+		}
+
 		sess := getSession(r)
 
 		accountID, ok := sess.Values[sessionAccountID].(string)
@@ -522,7 +561,7 @@ func (ah *AccountHandler) deleteAccount(w http.ResponseWriter, r *http.Request)
 func (ah *AccountHandler) resetPassword(w http.ResponseWriter, r *http.Request) {
 	accountID := chi.URLParam(r, "id")
 
-	account, err := ah.accountManager.resetPassword(accountID)
+	account, err := ah.accountManager.ResetPassword(accountID)
 
 	if err != nil {
 		AddErrorFlash(w, r, "Unable to reset account password")
@@ -539,11 +578,16 @@ var ErrInvalidUsernameOrPassword = errors.New("servermanager: invalid username o
 
 type AccountManager struct {
 	store Store
+
+	// apiTokenMutex must be held while an account's APITokens are changed, or apiTokenAccounts is used.
+	apiTokenMutex    sync.Mutex
+	apiTokenAccounts map[uuid.UUID]string
 }
 
 func NewAccountManager(store Store) *AccountManager {
 	return &AccountManager{
-		store: store,
+		store:            store,
+		apiTokenAccounts: make(map[uuid.UUID]string),
 	}
 }
 
@@ -597,7 +641,8 @@ func (am *AccountManager) login(r *http.Request, w http.ResponseWriter) error {
 	return ErrInvalidUsernameOrPassword
 }
 
-func (am *AccountManager) resetPassword(accountID string) (*Account, error) {
+// ResetPassword generates a new password for an account, which must be changed when the account next logs in.
+func (am *AccountManager) ResetPassword(accountID string) (*Account, error) {
 	account, err := am.store.FindAccountByID(accountID)
 
 	if err != nil {
@@ -617,6 +662,46 @@ func (am *AccountManager) resetPassword(accountID string) (*Account, error) {
 	return account, am.store.UpsertAccount(account)
 }
 
+var ErrAccountExists = errors.New("servermanager: an account with that name already exists")
+
+// CreateAccount creates an account in the given group for this server. The account is given a generated
+// password, which must be changed when it first logs in.
+func (am *AccountManager) CreateAccount(name string, group Group) (*Account, error) {
+	if existing, err := am.store.FindAccountByName(name); err == nil && existing.Deleted.IsZero() {
+		return nil, ErrAccountExists
+	}
+
+	defaultPass, err := diceware.Generate(4)
+
+	if err != nil {
+		return nil, err
+	}
+
+	account := NewAccount()
+	account.Name = name
+	account.DefaultPassword = strings.Join(defaultPass, "-")
+	account.Groups[serverID] = group
+
+	return account, am.store.UpsertAccount(account)
+}
+
+// SetGroup changes the group of an account for this server, or for every server that the account has access to.
+func (am *AccountManager) SetGroup(account *Account, group Group, allServers bool) error {
+	if account.Groups == nil {
+		account.Groups = make(map[ServerID]Group)
+	}
+
+	account.Groups[serverID] = group
+
+	if allServers {
+		for serverID := range account.Groups {
+			account.Groups[serverID] = group
+		}
+	}
+
+	return am.store.UpsertAccount(account)
+}
+
 func (am *AccountManager) SetCurrentVersion(account *Account) error {
 	account.LastSeenVersion = BuildVersion
 
@@ -0,0 +1,1527 @@
+package servermanager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/etcd-io/bbolt"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// apiPrefix is the path that all routes of the current version of the JSON API are served under.
+const apiPrefix = "/api/v1"
+
+var (
+	ErrAPIEventNotRunning = errors.New("servermanager: the requested event is not currently running")
+)
+
+// APIHandler serves a versioned JSON API for managing Custom Races, Championships and Race Weekends.
+// Request bodies are validated using the same rules as the web forms before anything is persisted.
+type APIHandler struct {
+	*BaseHandler
+
+	store               Store
+	process             ServerProcess
+	raceManager         *RaceManager
+	championshipManager *ChampionshipManager
+	raceWeekendManager  *RaceWeekendManager
+}
+
+func NewAPIHandler(
+	baseHandler *BaseHandler,
+	store Store,
+	process ServerProcess,
+	raceManager *RaceManager,
+	championshipManager *ChampionshipManager,
+	raceWeekendManager *RaceWeekendManager,
+) *APIHandler {
+	return &APIHandler{
+		BaseHandler:         baseHandler,
+		store:               store,
+		process:             process,
+		raceManager:         raceManager,
+		championshipManager: championshipManager,
+		raceWeekendManager:  raceWeekendManager,
+	}
+}
+
+// APIFieldError describes a single problem with a field in an API request body.
+type APIFieldError struct {
+	Field   string
+	Message string
+}
+
+// APIValidationError is returned when an API request body fails validation.
+type APIValidationError []APIFieldError
+
+func (e APIValidationError) Error() string {
+	var messages []string
+
+	for _, field := range e {
+		messages = append(messages, field.Field+": "+field.Message)
+	}
+
+	return "servermanager: invalid request: " + strings.Join(messages, ", ")
+}
+
+func (e *APIValidationError) add(field, format string, args ...interface{}) {
+	*e = append(*e, APIFieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (e APIValidationError) errOrNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+type apiErrorResponse struct {
+	Error  string
+	Fields []APIFieldError `json:",omitempty"`
+}
+
+func writeAPIJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(data); err != nil {
+		logrus.WithError(err).Errorf("Could not encode api response")
+	}
+}
+
+// writeAPIError converts err into an appropriate HTTP status and writes it as JSON.
+func writeAPIError(w http.ResponseWriter, err error) {
+	var validationErr APIValidationError
+
+	switch {
+	case errors.As(err, &validationErr):
+		writeAPIJSON(w, http.StatusUnprocessableEntity, apiErrorResponse{Error: "validation failed", Fields: validationErr})
+		return
+	case err == ErrCustomRaceNotFound,
+		err == ErrChampionshipNotFound,
+		err == ErrInvalidChampionshipEvent,
+		err == ErrRaceWeekendNotFound,
+		err == ErrRaceWeekendSessionNotFound,
+		err == ErrDriverNotFound,
+		err == ErrResultsPageNotFound,
+		err == bbolt.ErrBucketNotFound,
+		os.IsNotExist(err):
+		writeAPIJSON(w, http.StatusNotFound, apiErrorResponse{Error: http.StatusText(http.StatusNotFound)})
+		return
+	case err == ErrAPIEventNotRunning:
+		writeAPIJSON(w, http.StatusConflict, apiErrorResponse{Error: err.Error()})
+		return
+	}
+
+	logrus.WithError(err).Errorf("API request failed")
+	writeAPIJSON(w, http.StatusInternalServerError, apiErrorResponse{Error: http.StatusText(http.StatusInternalServerError)})
+}
+
+func decodeAPIRequest(r *http.Request, out interface{}) error {
+	defer r.Body.Close()
+
+	if err := json.NewDecoder(r.Body).Decode(out); err != nil {
+		return APIValidationError{{Field: "body", Message: err.Error()}}
+	}
+
+	return nil
+}
+
+// apiEntryList builds an EntryList from a list of entrants, in the same way as the entry list form.
+// Entrants without a PitBox are put in the first pit box which isn't used by another entrant.
+func apiEntryList(entrants []*Entrant) EntryList {
+	entryList := make(EntryList)
+	usedPitBoxes := make(map[int]bool)
+
+	for _, entrant := range entrants {
+		if entrant != nil && entrant.PitBox != 0 {
+			usedPitBoxes[entrant.PitBox] = true
+		}
+	}
+
+	nextPitBox := 0
+
+	for _, entrant := range entrants {
+		if entrant == nil {
+			continue
+		}
+
+		if entrant.InternalUUID == uuid.Nil {
+			entrant.InternalUUID = uuid.New()
+		}
+
+		entrant.GUID = NormaliseEntrantGUID(entrant.GUID)
+
+		pitBox := entrant.PitBox
+
+		if pitBox == 0 {
+			for usedPitBoxes[nextPitBox] {
+				nextPitBox++
+			}
+
+			pitBox = nextPitBox
+			usedPitBoxes[pitBox] = true
+		}
+
+		entryList.AddInPitBox(entrant, pitBox)
+	}
+
+	return entryList
+}
+
+func validateAPIEntrants(errs *APIValidationError, field string, entrants []*Entrant, cars []string) {
+	validCars := make(map[string]bool)
+
+	for _, car := range cars {
+		validCars[car] = true
+	}
+
+	pitBoxes := make(map[int]bool)
+
+	for i, entrant := range entrants {
+		entrantField := fmt.Sprintf("%s[%d]", field, i)
+
+		if entrant == nil {
+			errs.add(entrantField, "entrant must not be null")
+			continue
+		}
+
+		if entrant.Model == "" {
+			errs.add(entrantField+".Model", "a car model is required")
+		} else if len(validCars) > 0 && entrant.Model != AnyCarModel && !validCars[entrant.Model] {
+			errs.add(entrantField+".Model", "car %q is not in the list of available cars", entrant.Model)
+		}
+
+		if entrant.Ballast < 0 {
+			errs.add(entrantField+".Ballast", "ballast must not be negative")
+		}
+
+		if entrant.Restrictor < 0 || entrant.Restrictor > maxRestrictor {
+			errs.add(entrantField+".Restrictor", "restrictor must be between 0 and %d", maxRestrictor)
+		}
+
+		if entrant.PitBox != 0 {
+			if pitBoxes[entrant.PitBox] {
+				errs.add(entrantField+".PitBox", "pit box %d is used by more than one entrant", entrant.PitBox)
+			}
+
+			pitBoxes[entrant.PitBox] = true
+		}
+	}
+}
+
+// validateAPIRaceConfig checks a CurrentRaceConfig using the rules enforced by the race setup form.
+func validateAPIRaceConfig(errs *APIValidationError, field string, raceConfig *CurrentRaceConfig, requireCars bool) {
+	if raceConfig.Track == "" {
+		errs.add(field+".Track", "a track is required")
+	}
+
+	if raceConfig.TrackLayout == "<default>" {
+		raceConfig.TrackLayout = ""
+	}
+
+	if requireCars && len(varSplit(raceConfig.Cars)) == 0 {
+		errs.add(field+".Cars", "at least one car is required")
+	}
+
+	if len(raceConfig.Sessions) == 0 {
+		errs.add(field+".Sessions", "at least one session is required")
+	}
+
+	for sessionType, session := range raceConfig.Sessions {
+		validSessionType := false
+
+		for _, available := range AvailableSessions {
+			if sessionType == available {
+				validSessionType = true
+				break
+			}
+		}
+
+		if !validSessionType {
+			errs.add(field+".Sessions", "unknown session type %q", sessionType)
+			continue
+		}
+
+		if session == nil {
+			errs.add(field+".Sessions."+sessionType.String(), "session must not be null")
+			continue
+		}
+
+		if session.Time < 0 || session.Laps < 0 || session.WaitTime < 0 {
+			errs.add(field+".Sessions."+sessionType.String(), "session time, laps and wait time must not be negative")
+		}
+
+		if sessionType == SessionTypeRace && session.Time == 0 && session.Laps == 0 {
+			errs.add(field+".Sessions."+sessionType.String(), "a race session needs either a time or a number of laps")
+		}
+	}
+
+	if len(raceConfig.Weather) == 0 {
+		errs.add(field+".Weather", "at least one weather configuration is required")
+	}
+
+	for key, weather := range raceConfig.Weather {
+		if weather == nil || weather.Graphics == "" {
+			errs.add(field+".Weather."+key, "weather graphics are required")
+		}
+	}
+
+	if raceConfig.MaxClients < 0 {
+		errs.add(field+".MaxClients", "max clients must not be negative")
+	}
+
+	if raceConfig.MaxBallastKilograms < 0 {
+		errs.add(field+".MaxBallastKilograms", "max ballast must not be negative")
+	}
+
+	if raceConfig.RacePitWindowEnd < raceConfig.RacePitWindowStart {
+		errs.add(field+".RacePitWindowEnd", "pit window end must not be before pit window start")
+	}
+
+	if !Premium() {
+		raceConfig.DriverSwapEnabled = 0
+		raceConfig.TimeAttack = false
+	}
+}
+
+// APIScheduleRequest is the body used to schedule an event.
+type APIScheduleRequest struct {
+	Time       time.Time
+	Recurrence string
+
+	// StartWhenParentHasFinished is only used by Race Weekend sessions.
+	StartWhenParentHasFinished bool
+}
+
+func (s *APIScheduleRequest) Validate() error {
+	var errs APIValidationError
+
+	if s.Time.IsZero() && !s.StartWhenParentHasFinished {
+		errs.add("Time", "a scheduled time is required")
+	} else if !s.Time.IsZero() && s.Time.Before(time.Now()) {
+		errs.add("Time", "scheduled time must be in the future")
+	}
+
+	return errs.errOrNil()
+}
+
+/*
+	Custom Races
+*/
+
+// APICustomRaceRequest is the body used to create or update a CustomRace.
+type APICustomRaceRequest struct {
+	Name                 string
+	OverridePassword     bool
+	ReplacementPassword  string
+	ForceStopTime        int
+	ForceStopWithDrivers bool
+	Starred              bool
+
+	RaceConfig CurrentRaceConfig
+	EntryList  []*Entrant
+}
+
+func (cr *APICustomRaceRequest) Validate() error {
+	var errs APIValidationError
+
+	validateAPIRaceConfig(&errs, "RaceConfig", &cr.RaceConfig, true)
+
+	if !cr.RaceConfig.HasSession(SessionTypeBooking) {
+		validateAPIEntrants(&errs, "EntryList", cr.EntryList, varSplit(cr.RaceConfig.Cars))
+	}
+
+	if cr.ForceStopTime < 0 {
+		errs.add("ForceStopTime", "force stop time must not be negative")
+	}
+
+	return errs.errOrNil()
+}
+
+func (ah *APIHandler) listCustomRaces(w http.ResponseWriter, r *http.Request) {
+	races, err := ah.store.ListCustomRaces()
+
+	if err != nil && err != bbolt.ErrBucketNotFound {
+		writeAPIError(w, err)
+		return
+	}
+
+	if races == nil {
+		races = []*CustomRace{}
+	}
+
+	writeAPIJSON(w, http.StatusOK, races)
+}
+
+func (ah *APIHandler) getCustomRace(w http.ResponseWriter, r *http.Request) {
+	race, err := ah.store.FindCustomRaceByID(chi.URLParam(r, "uuid"))
+
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeAPIJSON(w, http.StatusOK, race)
+}
+
+func (ah *APIHandler) createCustomRace(w http.ResponseWriter, r *http.Request) {
+	var req APICustomRaceRequest
+
+	if err := decodeAPIRequest(r, &req); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	entryList := apiEntryList(req.EntryList)
+
+	if err := ah.raceManager.SaveEntrantsForAutoFill(entryList); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	race, err := ah.raceManager.SaveCustomRace(req.Name, req.OverridePassword, req.ReplacementPassword, req.RaceConfig, entryList, req.Starred, req.ForceStopTime, req.ForceStopWithDrivers)
+
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeAPIJSON(w, http.StatusCreated, race)
+}
+
+func (ah *APIHandler) updateCustomRace(w http.ResponseWriter, r *http.Request) {
+	race, err := ah.store.FindCustomRaceByID(chi.URLParam(r, "uuid"))
+
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	var req APICustomRaceRequest
+
+	if err := decodeAPIRequest(r, &req); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	entryList := apiEntryList(req.EntryList)
+
+	if err := ah.raceManager.SaveEntrantsForAutoFill(entryList); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	if req.Name != "" {
+		race.Name = req.Name
+		race.HasCustomName = true
+	}
+
+	race.OverridePassword = req.OverridePassword
+	race.ReplacementPassword = req.ReplacementPassword
+	race.ForceStopTime = req.ForceStopTime
+	race.ForceStopWithDrivers = req.ForceStopWithDrivers
+	race.Starred = req.Starred
+	race.RaceConfig = req.RaceConfig
+	race.EntryList = entryList
+
+	if err := ah.store.UpsertCustomRace(race); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeAPIJSON(w, http.StatusOK, race)
+}
+
+func (ah *APIHandler) deleteCustomRace(w http.ResponseWriter, r *http.Request) {
+	if err := ah.raceManager.DeleteCustomRace(chi.URLParam(r, "uuid")); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ah *APIHandler) startCustomRace(w http.ResponseWriter, r *http.Request) {
+	race, err := ah.raceManager.StartCustomRace(chi.URLParam(r, "uuid"), false)
+
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeAPIJSON(w, http.StatusOK, race)
+}
+
+func (ah *APIHandler) stopCustomRace(w http.ResponseWriter, r *http.Request) {
+	race, err := ah.store.FindCustomRaceByID(chi.URLParam(r, "uuid"))
+
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	running, ok := ah.process.Event().(*CustomRace)
+
+	if !ah.process.IsRunning() || !ok || running.UUID != race.UUID {
+		writeAPIError(w, ErrAPIEventNotRunning)
+		return
+	}
+
+	if err := ah.process.Stop(); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ah *APIHandler) scheduleCustomRace(w http.ResponseWriter, r *http.Request) {
+	var req APIScheduleRequest
+
+	if err := decodeAPIRequest(r, &req); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	raceID := chi.URLParam(r, "uuid")
+
+	if err := ah.raceManager.ScheduleRace(raceID, req.Time, "add", req.Recurrence); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	race, err := ah.store.FindCustomRaceByID(raceID)
+
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeAPIJSON(w, http.StatusOK, race)
+}
+
+func (ah *APIHandler) removeCustomRaceSchedule(w http.ResponseWriter, r *http.Request) {
+	if err := ah.raceManager.ScheduleRace(chi.URLParam(r, "uuid"), time.Time{}, "remove", ""); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+/*
+	Championships
+*/
+
+// APIChampionshipClassRequest describes a single class within an APIChampionshipRequest.
+type APIChampionshipClassRequest struct {
+	ID            uuid.UUID
+	Name          string
+	AvailableCars []string
+	Points        *ChampionshipPoints
+	Entrants      []*Entrant
+
+	SuccessBallast *SuccessBallastRules
+}
+
+// APIChampionshipRequest is the body used to create or update a Championship.
+type APIChampionshipRequest struct {
+	Name                string
+	Info                string
+	OGImage             string
+	OverridePassword    bool
+	ReplacementPassword string
+	OpenEntrants        bool
+	PersistOpenEntrants bool
+	SignUpForm          ChampionshipSignUpForm
+	DefaultTab          ChampionshipTab
+
+	SpectatorCar        Entrant
+	SpectatorCarEnabled bool
+
+	Classes []*APIChampionshipClassRequest
+}
+
+func (c *APIChampionshipRequest) Validate() error {
+	var errs APIValidationError
+
+	if c.Name == "" {
+		errs.add("Name", "a championship name is required")
+	}
+
+	if len(c.Classes) == 0 {
+		errs.add("Classes", "at least one class is required")
+	}
+
+	for i, class := range c.Classes {
+		field := fmt.Sprintf("Classes[%d]", i)
+
+		if class == nil {
+			errs.add(field, "class must not be null")
+			continue
+		}
+
+		if len(class.AvailableCars) == 0 && len(class.Entrants) == 0 {
+			errs.add(field+".AvailableCars", "at least one car or entrant is required")
+		}
+
+		if class.Points != nil {
+			for j, points := range class.Points.Places {
+				if points < 0 {
+					errs.add(fmt.Sprintf("%s.Points.Places[%d]", field, j), "points must not be negative")
+				}
+			}
+
+			if class.Points.SecondRaceMultiplier < 0 {
+				errs.add(field+".Points.SecondRaceMultiplier", "multiplier must not be negative")
+			}
+
+			for j, points := range class.Points.QualifyingPlaces {
+				if points < 0 {
+					errs.add(fmt.Sprintf("%s.Points.QualifyingPlaces[%d]", field, j), "points must not be negative")
+				}
+			}
+
+			if class.Points.BestLapMaxPosition < 0 {
+				errs.add(field+".Points.BestLapMaxPosition", "position must not be negative")
+			}
+
+			if class.Points.MinimumRaceDistance < 0 || class.Points.MinimumRaceDistance > 100 {
+				errs.add(field+".Points.MinimumRaceDistance", "minimum race distance must be a percentage between 0 and 100")
+			}
+
+			if class.Points.FinalRoundMultiplier < 0 {
+				errs.add(field+".Points.FinalRoundMultiplier", "multiplier must not be negative")
+			}
+
+			if class.Points.DropWorstRounds < 0 {
+				errs.add(field+".Points.DropWorstRounds", "dropped rounds must not be negative")
+			}
+		}
+
+		if class.SuccessBallast != nil {
+			switch class.SuccessBallast.Basis {
+			case "", SuccessBallastByFinishingPosition, SuccessBallastByStandings:
+			default:
+				errs.add(field+".SuccessBallast.Basis", fmt.Sprintf("basis must be %q or %q", SuccessBallastByFinishingPosition, SuccessBallastByStandings))
+			}
+
+			for j, ballast := range class.SuccessBallast.Ballast {
+				if ballast < 0 {
+					errs.add(fmt.Sprintf("%s.SuccessBallast.Ballast[%d]", field, j), "ballast must not be negative")
+				}
+			}
+
+			for j, restrictor := range class.SuccessBallast.Restrictor {
+				if restrictor < 0 || restrictor > maxRestrictor {
+					errs.add(fmt.Sprintf("%s.SuccessBallast.Restrictor[%d]", field, j), "restrictor must be between 0 and %d", maxRestrictor)
+				}
+			}
+
+			if class.SuccessBallast.MaxBallast < 0 {
+				errs.add(field+".SuccessBallast.MaxBallast", "max ballast must not be negative")
+			}
+
+			if class.SuccessBallast.MaxRestrictor < 0 || class.SuccessBallast.MaxRestrictor > maxRestrictor {
+				errs.add(field+".SuccessBallast.MaxRestrictor", "max restrictor must be between 0 and %d", maxRestrictor)
+			}
+		}
+
+		validateAPIEntrants(&errs, field+".Entrants", class.Entrants, class.AvailableCars)
+	}
+
+	if c.DefaultTab != "" {
+		validTab := false
+
+		for _, tab := range AvailableChampionshipTabs {
+			if tab == c.DefaultTab {
+				validTab = true
+				break
+			}
+		}
+
+		if !validTab {
+			errs.add("DefaultTab", "unknown tab %q", c.DefaultTab)
+		}
+	}
+
+	return errs.errOrNil()
+}
+
+// apply writes the request onto championship, keeping any existing penalties for classes that are retained.
+func (c *APIChampionshipRequest) apply(championship *Championship) {
+	previousClasses := make(map[uuid.UUID]*ChampionshipClass)
+
+	for _, class := range championship.Classes {
+		previousClasses[class.ID] = class
+	}
+
+	championship.Name = c.Name
+	championship.Info = template.HTML(c.Info)
+	championship.OverridePassword = c.OverridePassword
+	championship.ReplacementPassword = c.ReplacementPassword
+	championship.OpenEntrants = c.OpenEntrants
+	championship.PersistOpenEntrants = c.PersistOpenEntrants
+
+	responses := championship.SignUpForm.Responses
+	championship.SignUpForm = c.SignUpForm
+	championship.SignUpForm.Responses = responses
+
+	if c.DefaultTab != "" {
+		championship.DefaultTab = c.DefaultTab
+	}
+
+	if Premium() {
+		championship.OGImage = c.OGImage
+		championship.SpectatorCar = c.SpectatorCar
+		championship.SpectatorCarEnabled = c.SpectatorCarEnabled
+	}
+
+	championship.Classes = []*ChampionshipClass{}
+
+	for _, classReq := range c.Classes {
+		class := NewChampionshipClass(classReq.Name)
+
+		if classReq.ID != uuid.Nil {
+			class.ID = classReq.ID
+		}
+
+		if classReq.Points != nil {
+			class.Points = *classReq.Points
+		}
+
+		if classReq.SuccessBallast != nil {
+			class.SuccessBallast = *classReq.SuccessBallast
+		}
+
+		class.AvailableCars = classReq.AvailableCars
+		class.Entrants = apiEntryList(classReq.Entrants)
+
+		if previousClass, ok := previousClasses[class.ID]; ok {
+			class.DriverPenalties = previousClass.DriverPenalties
+			class.TeamPenalties = previousClass.TeamPenalties
+		}
+
+		championship.AddClass(class)
+	}
+}
+
+func (ah *APIHandler) listChampionships(w http.ResponseWriter, r *http.Request) {
+	championships, err := ah.championshipManager.ListChampionships()
+
+	if err != nil && err != bbolt.ErrBucketNotFound {
+		writeAPIError(w, err)
+		return
+	}
+
+	if championships == nil {
+		championships = []*Championship{}
+	}
+
+	writeAPIJSON(w, http.StatusOK, championships)
+}
+
+func (ah *APIHandler) getChampionship(w http.ResponseWriter, r *http.Request) {
+	championship, err := ah.championshipManager.LoadChampionship(chi.URLParam(r, "championshipID"))
+
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeAPIJSON(w, http.StatusOK, championship)
+}
+
+func (ah *APIHandler) createChampionship(w http.ResponseWriter, r *http.Request) {
+	var req APIChampionshipRequest
+
+	if err := decodeAPIRequest(r, &req); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	championship := NewChampionship(req.Name)
+	req.apply(championship)
+
+	if err := ah.championshipManager.SaveEntrantsForAutoFill(championship.AllEntrants()); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	if err := ah.championshipManager.UpsertChampionship(championship); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeAPIJSON(w, http.StatusCreated, championship)
+}
+
+func (ah *APIHandler) updateChampionship(w http.ResponseWriter, r *http.Request) {
+	championship, err := ah.championshipManager.LoadChampionship(chi.URLParam(r, "championshipID"))
+
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	var req APIChampionshipRequest
+
+	if err := decodeAPIRequest(r, &req); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	req.apply(championship)
+
+	if err := ah.championshipManager.SaveEntrantsForAutoFill(championship.AllEntrants()); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	if err := ah.championshipManager.UpsertChampionship(championship); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeAPIJSON(w, http.StatusOK, championship)
+}
+
+func (ah *APIHandler) deleteChampionship(w http.ResponseWriter, r *http.Request) {
+	if err := ah.championshipManager.DeleteChampionship(chi.URLParam(r, "championshipID")); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+/*
+	Championship Events
+*/
+
+// APIChampionshipEventRequest is the body used to create or update a ChampionshipEvent.
+// The cars for the event are always taken from the Championship classes.
+type APIChampionshipEventRequest struct {
+	RaceSetup CurrentRaceConfig
+	EntryList []*Entrant
+	GridRules ChampionshipGridRules
+}
+
+func (e *APIChampionshipEventRequest) Validate(championship *Championship) error {
+	var errs APIValidationError
+
+	e.RaceSetup.Cars = strings.Join(championship.ValidCarIDs(), ";")
+
+	validateAPIRaceConfig(&errs, "RaceSetup", &e.RaceSetup, false)
+	validateAPIEntrants(&errs, "EntryList", e.EntryList, championship.ValidCarIDs())
+
+	switch e.GridRules.Type {
+	case ChampionshipGridEntryList, ChampionshipGridStandings, ChampionshipGridReverseStandings, ChampionshipGridHandicap:
+	default:
+		errs.add("GridRules.Type", fmt.Sprintf("grid type must be one of %q, %q, %q or %q", ChampionshipGridEntryList, ChampionshipGridStandings, ChampionshipGridReverseStandings, ChampionshipGridHandicap))
+	}
+
+	if e.GridRules.NumToReverse < -1 {
+		errs.add("GridRules.NumToReverse", "number to reverse must be -1 (all) or more")
+	}
+
+	if e.GridRules.HandicapSecondsPerPosition < 0 {
+		errs.add("GridRules.HandicapSecondsPerPosition", "handicap must not be negative")
+	}
+
+	return errs.errOrNil()
+}
+
+func (ah *APIHandler) listChampionshipEvents(w http.ResponseWriter, r *http.Request) {
+	championship, err := ah.championshipManager.LoadChampionship(chi.URLParam(r, "championshipID"))
+
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	events := championship.Events
+
+	if events == nil {
+		events = []*ChampionshipEvent{}
+	}
+
+	writeAPIJSON(w, http.StatusOK, events)
+}
+
+func (ah *APIHandler) getChampionshipEvent(w http.ResponseWriter, r *http.Request) {
+	_, event, err := ah.championshipManager.GetChampionshipAndEvent(chi.URLParam(r, "championshipID"), chi.URLParam(r, "eventID"))
+
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeAPIJSON(w, http.StatusOK, event)
+}
+
+func (ah *APIHandler) createChampionshipEvent(w http.ResponseWriter, r *http.Request) {
+	championship, err := ah.championshipManager.LoadChampionship(chi.URLParam(r, "championshipID"))
+
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	var req APIChampionshipEventRequest
+
+	if err := decodeAPIRequest(r, &req); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	if err := req.Validate(championship); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	event := NewChampionshipEvent()
+	event.RaceSetup = req.RaceSetup
+	event.EntryList = apiEntryList(req.EntryList)
+	event.GridRules = req.GridRules
+
+	championship.Events = append(championship.Events, event)
+
+	if err := ah.championshipManager.UpsertChampionship(championship); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeAPIJSON(w, http.StatusCreated, event)
+}
+
+func (ah *APIHandler) updateChampionshipEvent(w http.ResponseWriter, r *http.Request) {
+	championship, event, err := ah.championshipManager.GetChampionshipAndEvent(chi.URLParam(r, "championshipID"), chi.URLParam(r, "eventID"))
+
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	var req APIChampionshipEventRequest
+
+	if err := decodeAPIRequest(r, &req); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	if err := req.Validate(championship); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	event.RaceSetup = req.RaceSetup
+	event.EntryList = apiEntryList(req.EntryList)
+	event.GridRules = req.GridRules
+
+	if err := ah.championshipManager.UpsertChampionship(championship); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeAPIJSON(w, http.StatusOK, event)
+}
+
+func (ah *APIHandler) deleteChampionshipEvent(w http.ResponseWriter, r *http.Request) {
+	if err := ah.championshipManager.DeleteEvent(chi.URLParam(r, "championshipID"), chi.URLParam(r, "eventID")); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ah *APIHandler) startChampionshipEvent(w http.ResponseWriter, r *http.Request) {
+	championshipID, eventID := chi.URLParam(r, "championshipID"), chi.URLParam(r, "eventID")
+
+	if err := ah.championshipManager.StartEvent(championshipID, eventID, false); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	_, event, err := ah.championshipManager.GetChampionshipAndEvent(championshipID, eventID)
+
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeAPIJSON(w, http.StatusOK, event)
+}
+
+func (ah *APIHandler) stopChampionshipEvent(w http.ResponseWriter, r *http.Request) {
+	championshipID, eventID := chi.URLParam(r, "championshipID"), chi.URLParam(r, "eventID")
+
+	if _, _, err := ah.championshipManager.GetChampionshipAndEvent(championshipID, eventID); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	active := ah.championshipManager.activeChampionship
+
+	if !ah.championshipManager.ChampionshipEventIsRunning() || active.ChampionshipID.String() != championshipID || active.EventID.String() != eventID {
+		writeAPIError(w, ErrAPIEventNotRunning)
+		return
+	}
+
+	if err := ah.championshipManager.CancelEvent(championshipID, eventID); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ah *APIHandler) scheduleChampionshipEvent(w http.ResponseWriter, r *http.Request) {
+	championshipID, eventID := chi.URLParam(r, "championshipID"), chi.URLParam(r, "eventID")
+
+	var req APIScheduleRequest
+
+	if err := decodeAPIRequest(r, &req); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	if err := ah.championshipManager.ScheduleEvent(championshipID, eventID, req.Time, "add", req.Recurrence); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	_, event, err := ah.championshipManager.GetChampionshipAndEvent(championshipID, eventID)
+
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeAPIJSON(w, http.StatusOK, event)
+}
+
+func (ah *APIHandler) removeChampionshipEventSchedule(w http.ResponseWriter, r *http.Request) {
+	if err := ah.championshipManager.ScheduleEvent(chi.URLParam(r, "championshipID"), chi.URLParam(r, "eventID"), time.Time{}, "remove", ""); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+/*
+	Race Weekends
+*/
+
+// APIRaceWeekendRequest is the body used to create or update a RaceWeekend. ChampionshipID is only used
+// when creating a RaceWeekend, and if set the EntryList is taken from the Championship.
+type APIRaceWeekendRequest struct {
+	Name           string
+	ChampionshipID uuid.UUID
+
+	EntryList           []*Entrant
+	SpectatorCar        Entrant
+	SpectatorCarEnabled bool
+}
+
+func (rw *APIRaceWeekendRequest) Validate() error {
+	var errs APIValidationError
+
+	if rw.Name == "" {
+		errs.add("Name", "a race weekend name is required")
+	}
+
+	if rw.ChampionshipID == uuid.Nil {
+		validateAPIEntrants(&errs, "EntryList", rw.EntryList, nil)
+	}
+
+	return errs.errOrNil()
+}
+
+func (ah *APIHandler) listRaceWeekends(w http.ResponseWriter, r *http.Request) {
+	raceWeekends, err := ah.raceWeekendManager.ListRaceWeekends()
+
+	if err != nil && err != bbolt.ErrBucketNotFound {
+		writeAPIError(w, err)
+		return
+	}
+
+	if raceWeekends == nil {
+		raceWeekends = []*RaceWeekend{}
+	}
+
+	writeAPIJSON(w, http.StatusOK, raceWeekends)
+}
+
+func (ah *APIHandler) getRaceWeekend(w http.ResponseWriter, r *http.Request) {
+	raceWeekend, err := ah.raceWeekendManager.LoadRaceWeekend(chi.URLParam(r, "raceWeekendID"))
+
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeAPIJSON(w, http.StatusOK, raceWeekend)
+}
+
+func (ah *APIHandler) createRaceWeekend(w http.ResponseWriter, r *http.Request) {
+	var req APIRaceWeekendRequest
+
+	if err := decodeAPIRequest(r, &req); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	raceWeekend := NewRaceWeekend()
+	raceWeekend.Name = req.Name
+
+	if req.ChampionshipID != uuid.Nil {
+		championship, err := ah.championshipManager.LoadChampionship(req.ChampionshipID.String())
+
+		if err != nil {
+			writeAPIError(w, err)
+			return
+		}
+
+		raceWeekend.ChampionshipID = championship.ID
+
+		// add a championship event for this race weekend
+		event := NewChampionshipEvent()
+		event.RaceWeekendID = raceWeekend.ID
+
+		championship.Events = append(championship.Events, event)
+
+		if err := ah.store.UpsertChampionship(championship); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+	} else {
+		raceWeekend.EntryList = apiEntryList(req.EntryList)
+		raceWeekend.SpectatorCar = req.SpectatorCar
+		raceWeekend.SpectatorCarEnabled = req.SpectatorCarEnabled
+
+		if err := ah.raceManager.SaveEntrantsForAutoFill(raceWeekend.EntryList); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+	}
+
+	if err := ah.raceWeekendManager.UpsertRaceWeekend(raceWeekend); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeAPIJSON(w, http.StatusCreated, raceWeekend)
+}
+
+func (ah *APIHandler) updateRaceWeekend(w http.ResponseWriter, r *http.Request) {
+	raceWeekend, err := ah.raceWeekendManager.LoadRaceWeekend(chi.URLParam(r, "raceWeekendID"))
+
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	var req APIRaceWeekendRequest
+
+	if err := decodeAPIRequest(r, &req); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	// a race weekend's championship cannot be changed once it is created
+	req.ChampionshipID = raceWeekend.ChampionshipID
+
+	if err := req.Validate(); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	raceWeekend.Name = req.Name
+
+	if !raceWeekend.HasLinkedChampionship() {
+		raceWeekend.EntryList = apiEntryList(req.EntryList)
+		raceWeekend.SpectatorCar = req.SpectatorCar
+		raceWeekend.SpectatorCarEnabled = req.SpectatorCarEnabled
+
+		if err := ah.raceManager.SaveEntrantsForAutoFill(raceWeekend.EntryList); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+	}
+
+	if err := ah.raceWeekendManager.UpsertRaceWeekend(raceWeekend); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeAPIJSON(w, http.StatusOK, raceWeekend)
+}
+
+func (ah *APIHandler) deleteRaceWeekend(w http.ResponseWriter, r *http.Request) {
+	if err := ah.raceWeekendManager.DeleteRaceWeekend(chi.URLParam(r, "raceWeekendID")); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+/*
+	Race Weekend Sessions
+*/
+
+// APIRaceWeekendSessionRequest is the body used to create or update a RaceWeekendSession. The RaceConfig
+// must contain exactly one session. If no ParentIDs are given, the session is a child of the Race Weekend Entry List.
+type APIRaceWeekendSessionRequest struct {
+	RaceConfig          CurrentRaceConfig
+	ParentIDs           []uuid.UUID
+	OverridePassword    bool
+	ReplacementPassword string
+
+	// Points are only used if the Race Weekend is linked to a Championship. They are keyed by Championship Class ID.
+	Points map[uuid.UUID]*ChampionshipPoints
+}
+
+func (s *APIRaceWeekendSessionRequest) Validate(raceWeekend *RaceWeekend, sessionID uuid.UUID) error {
+	var errs APIValidationError
+
+	s.RaceConfig.Cars = strings.Join(raceWeekend.GetEntryList().CarIDs(), ";")
+
+	validateAPIRaceConfig(&errs, "RaceConfig", &s.RaceConfig, false)
+
+	if len(s.RaceConfig.Sessions) > 1 {
+		errs.add("RaceConfig.Sessions", "a race weekend session must contain exactly one session")
+	}
+
+	for i, parentID := range s.ParentIDs {
+		field := fmt.Sprintf("ParentIDs[%d]", i)
+
+		if parentID == sessionID {
+			errs.add(field, "a session cannot be its own parent")
+			continue
+		}
+
+		if _, err := raceWeekend.FindSessionByID(parentID.String()); err != nil {
+			errs.add(field, "parent session %s not found", parentID)
+		}
+	}
+
+	for classID := range s.Points {
+		if !raceWeekend.HasLinkedChampionship() || raceWeekend.Championship == nil {
+			errs.add("Points", "points can only be set on race weekends which are linked to a championship")
+			break
+		}
+
+		if _, err := raceWeekend.Championship.ClassByID(classID.String()); err != nil {
+			errs.add("Points."+classID.String(), "championship class not found")
+		}
+	}
+
+	return errs.errOrNil()
+}
+
+func (s *APIRaceWeekendSessionRequest) apply(raceWeekend *RaceWeekend, session *RaceWeekendSession) {
+	session.RaceConfig = s.RaceConfig
+	session.OverridePassword = s.OverridePassword
+	session.ReplacementPassword = s.ReplacementPassword
+	session.ParentIDs = s.ParentIDs
+
+	if len(session.ParentIDs) == 0 {
+		session.ParentIDs = []uuid.UUID{raceWeekend.ID}
+	}
+
+	if raceWeekend.HasLinkedChampionship() {
+		for classID, points := range s.Points {
+			session.Points[classID] = points
+		}
+	}
+}
+
+func (ah *APIHandler) listRaceWeekendSessions(w http.ResponseWriter, r *http.Request) {
+	raceWeekend, err := ah.raceWeekendManager.LoadRaceWeekend(chi.URLParam(r, "raceWeekendID"))
+
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	sessions := raceWeekend.Sessions
+
+	if sessions == nil {
+		sessions = []*RaceWeekendSession{}
+	}
+
+	writeAPIJSON(w, http.StatusOK, sessions)
+}
+
+func (ah *APIHandler) getRaceWeekendSession(w http.ResponseWriter, r *http.Request) {
+	_, session, err := ah.raceWeekendManager.FindSession(chi.URLParam(r, "raceWeekendID"), chi.URLParam(r, "sessionID"))
+
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeAPIJSON(w, http.StatusOK, session)
+}
+
+func (ah *APIHandler) createRaceWeekendSession(w http.ResponseWriter, r *http.Request) {
+	raceWeekend, err := ah.raceWeekendManager.LoadRaceWeekend(chi.URLParam(r, "raceWeekendID"))
+
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	var req APIRaceWeekendSessionRequest
+
+	if err := decodeAPIRequest(r, &req); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	session := NewRaceWeekendSession()
+
+	if err := req.Validate(raceWeekend, session.ID); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	req.apply(raceWeekend, session)
+	raceWeekend.AddSession(session, nil)
+
+	if err := ah.raceWeekendManager.UpsertRaceWeekend(raceWeekend); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeAPIJSON(w, http.StatusCreated, session)
+}
+
+func (ah *APIHandler) updateRaceWeekendSession(w http.ResponseWriter, r *http.Request) {
+	raceWeekend, session, err := ah.raceWeekendManager.FindSession(chi.URLParam(r, "raceWeekendID"), chi.URLParam(r, "sessionID"))
+
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	var req APIRaceWeekendSessionRequest
+
+	if err := decodeAPIRequest(r, &req); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	if err := req.Validate(raceWeekend, session.ID); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	req.apply(raceWeekend, session)
+
+	if err := ah.raceWeekendManager.UpsertRaceWeekend(raceWeekend); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeAPIJSON(w, http.StatusOK, session)
+}
+
+func (ah *APIHandler) deleteRaceWeekendSession(w http.ResponseWriter, r *http.Request) {
+	raceWeekendID, sessionID := chi.URLParam(r, "raceWeekendID"), chi.URLParam(r, "sessionID")
+
+	if _, _, err := ah.raceWeekendManager.FindSession(raceWeekendID, sessionID); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	if err := ah.raceWeekendManager.DeleteSession(raceWeekendID, sessionID); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ah *APIHandler) startRaceWeekendSession(w http.ResponseWriter, r *http.Request) {
+	raceWeekendID, sessionID := chi.URLParam(r, "raceWeekendID"), chi.URLParam(r, "sessionID")
+
+	if err := ah.raceWeekendManager.StartSession(raceWeekendID, sessionID, false); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	_, session, err := ah.raceWeekendManager.FindSession(raceWeekendID, sessionID)
+
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeAPIJSON(w, http.StatusOK, session)
+}
+
+func (ah *APIHandler) stopRaceWeekendSession(w http.ResponseWriter, r *http.Request) {
+	raceWeekendID, sessionID := chi.URLParam(r, "raceWeekendID"), chi.URLParam(r, "sessionID")
+
+	if _, _, err := ah.raceWeekendManager.FindSession(raceWeekendID, sessionID); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	active := ah.raceWeekendManager.activeRaceWeekend
+
+	if !ah.raceWeekendManager.RaceWeekendSessionIsRunning() || active.RaceWeekendID.String() != raceWeekendID || active.SessionID.String() != sessionID {
+		writeAPIError(w, ErrAPIEventNotRunning)
+		return
+	}
+
+	if err := ah.raceWeekendManager.CancelSession(raceWeekendID, sessionID); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ah *APIHandler) scheduleRaceWeekendSession(w http.ResponseWriter, r *http.Request) {
+	raceWeekendID, sessionID := chi.URLParam(r, "raceWeekendID"), chi.URLParam(r, "sessionID")
+
+	var req APIScheduleRequest
+
+	if err := decodeAPIRequest(r, &req); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	if err := ah.raceWeekendManager.ScheduleSession(raceWeekendID, sessionID, req.Time, req.StartWhenParentHasFinished); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	_, session, err := ah.raceWeekendManager.FindSession(raceWeekendID, sessionID)
+
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeAPIJSON(w, http.StatusOK, session)
+}
+
+func (ah *APIHandler) removeRaceWeekendSessionSchedule(w http.ResponseWriter, r *http.Request) {
+	if err := ah.raceWeekendManager.DeScheduleSession(chi.URLParam(r, "raceWeekendID"), chi.URLParam(r, "sessionID")); err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+const apiMaxResultsPageSize = 250
+
+// APIResultsPage is a page of results files matching an APIHandler.listResults query.
+type APIResultsPage struct {
+	Results  []SessionResults
+	Total    int
+	Page     int
+	PageSize int
+}
+
+func (ah *APIHandler) listResults(w http.ResponseWriter, r *http.Request) {
+	query := ResultsQueryFromRequest(r)
+
+	if pageSize := formValueAsInt(r.URL.Query().Get("pageSize")); pageSize > 0 && pageSize <= apiMaxResultsPageSize {
+		query.PageSize = pageSize
+	}
+
+	results, total, err := FindResults(query, LoadResultWithoutPluginFire)
+
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	if results == nil {
+		results = []SessionResults{}
+	}
+
+	writeAPIJSON(w, http.StatusOK, APIResultsPage{
+		Results:  results,
+		Total:    total,
+		Page:     query.Page,
+		PageSize: query.PageSize,
+	})
+}
+
+func (ah *APIHandler) getDriver(w http.ResponseWriter, r *http.Request) {
+	profile, err := BuildDriverProfile(chi.URLParam(r, "guid"))
+
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	if UseShortenedDriverNames {
+		profile.Name = shortenDriverName(profile.Name)
+	}
+
+	writeAPIJSON(w, http.StatusOK, profile)
+}
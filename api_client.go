@@ -0,0 +1,86 @@
+package servermanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// APIClient makes requests to a Server Manager's JSON API, authenticated with an API token.
+type APIClient struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewAPIClient creates an APIClient for the Server Manager at url, e.g. "http://localhost:8772".
+func NewAPIClient(url, token string, client *http.Client) *APIClient {
+	return &APIClient{
+		url:    strings.TrimSuffix(url, "/"),
+		token:  token,
+		client: client,
+	}
+}
+
+// Request sends a request to the API. body is encoded as JSON if it is not nil, and a successful response is
+// decoded into out if it is not nil. Errors returned by the API are returned as errors, with validation errors
+// returned as an APIValidationError.
+func (c *APIClient) Request(method, path string, body, out interface{}) error {
+	var reqBody io.Reader
+
+	if body != nil {
+		data, err := json.Marshal(body)
+
+		if err != nil {
+			return err
+		}
+
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.url+apiPrefix+path, reqBody)
+
+	if err != nil {
+		return err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.client.Do(req)
+
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		if out == nil || resp.StatusCode == http.StatusNoContent {
+			return nil
+		}
+
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+
+	var apiErr apiErrorResponse
+
+	respBody, _ := ioutil.ReadAll(resp.Body)
+
+	if err := json.Unmarshal(respBody, &apiErr); err != nil || apiErr.Error == "" {
+		return fmt.Errorf("servermanager: server manager responded with status: %s", resp.Status)
+	}
+
+	if len(apiErr.Fields) > 0 {
+		return APIValidationError(apiErr.Fields)
+	}
+
+	return fmt.Errorf("servermanager: server manager responded with: %s", apiErr.Error)
+}
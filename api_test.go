@@ -0,0 +1,337 @@
+package servermanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cj123/sessions"
+	"github.com/go-chi/chi"
+)
+
+type apiTestServer struct {
+	store          Store
+	accountManager *AccountManager
+	router         http.Handler
+}
+
+// newAPITestServer serves the custom race and championship API routes behind the same write access middleware as the router,
+// using a store in dir.
+func newAPITestServer(t *testing.T, dir string) *apiTestServer {
+	store := NewJSONStore(filepath.Join(dir, "store"), filepath.Join(dir, "shared"))
+
+	if err := Migrate(store); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := InitServerID(store); err != nil {
+		t.Fatal(err)
+	}
+
+	if sessionsStore == nil {
+		sessionsStore = sessions.NewCookieStore([]byte("test"))
+	}
+
+	raceManager := NewRaceManager(
+		store,
+		dummyServerProcess{},
+		NewCarManager(NewTrackManager(), false, false),
+		NewTrackManager(),
+		&dummyNotificationManager{},
+		NewRaceControl(NilBroadcaster{}, nilTrackData{}, dummyServerProcess{}, store, NewPenaltiesManager(store), NewBlocklistManager(store)),
+		NewBlocklistManager(store),
+	)
+
+	apiHandler := NewAPIHandler(&BaseHandler{}, store, dummyServerProcess{}, raceManager, NewChampionshipManager(raceManager, &ACSRClient{}), nil)
+	accountManager := NewAccountManager(store)
+	accountHandler := NewAccountHandler(&BaseHandler{}, store, accountManager)
+
+	r := chi.NewRouter()
+
+	r.Group(func(r chi.Router) {
+		r.Use(accountHandler.WriteAccessMiddleware)
+
+		r.Post(apiPrefix+"/custom-races", apiHandler.createCustomRace)
+		r.Put(apiPrefix+"/custom-races/{uuid}", apiHandler.updateCustomRace)
+
+		r.Post(apiPrefix+"/championships", apiHandler.createChampionship)
+		r.Put(apiPrefix+"/championships/{championshipID}", apiHandler.updateChampionship)
+	})
+
+	return &apiTestServer{
+		store:          store,
+		accountManager: accountManager,
+		router:         r,
+	}
+}
+
+// createToken creates an account in accountGroup with an API token scoped to tokenGroup.
+func (s *apiTestServer) createToken(t *testing.T, accountGroup, tokenGroup Group) (*Account, string) {
+	account := NewAccount()
+	account.Name = "api-" + account.ID.String()
+	account.Groups[serverID] = accountGroup
+
+	if err := s.store.UpsertAccount(account); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := s.accountManager.CreateAPIToken(account, "test", tokenGroup, false)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return account, token
+}
+
+func (s *apiTestServer) request(t *testing.T, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	data, err := json.Marshal(body)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(method, apiPrefix+path, bytes.NewReader(data))
+
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	w := httptest.NewRecorder()
+
+	s.router.ServeHTTP(w, req)
+
+	return w
+}
+
+func apiTestCustomRace() APICustomRaceRequest {
+	raceConfig := ConfigIniDefault().CurrentRaceConfig
+	raceConfig.Track = "ks_vallelunga"
+	raceConfig.TrackLayout = "club_circuit"
+	raceConfig.Cars = "ks_mazda_mx5_cup"
+
+	return APICustomRaceRequest{
+		Name:       "API Race",
+		RaceConfig: raceConfig,
+		EntryList: []*Entrant{
+			{Name: "Driver 1", Model: "ks_mazda_mx5_cup"},
+			{Name: "Driver 2", Model: "ks_mazda_mx5_cup", PitBox: 1},
+			{Name: "Driver 3", Model: "ks_mazda_mx5_cup"},
+		},
+	}
+}
+
+func TestAPIHandler_CustomRaces(t *testing.T) {
+	dir, err := ioutil.TempDir("", "api")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	s := newAPITestServer(t, dir)
+	_, token := s.createToken(t, GroupWrite, GroupWrite)
+
+	var created CustomRace
+
+	t.Run("Create", func(t *testing.T) {
+		w := s.request(t, http.MethodPost, "/custom-races", token, apiTestCustomRace())
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+
+		if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+			t.Fatal(err)
+		}
+
+		race, err := s.store.FindCustomRaceByID(created.UUID.String())
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if race.Name != "API Race" || len(race.EntryList) != 3 {
+			t.Errorf("Expected the race to be saved with 3 entrants, got %q with %d", race.Name, len(race.EntryList))
+		}
+
+		// entrants without a pit box must not be put in a pit box that another entrant asked for.
+		pitBoxes := make(map[string]int)
+
+		for _, entrant := range race.EntryList {
+			pitBoxes[entrant.Name] = entrant.PitBox
+		}
+
+		if pitBoxes["Driver 1"] != 0 || pitBoxes["Driver 2"] != 1 || pitBoxes["Driver 3"] != 2 {
+			t.Errorf("Expected pit boxes 0, 1 and 2, got %v", pitBoxes)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		req := apiTestCustomRace()
+		req.Name = "Updated API Race"
+
+		w := s.request(t, http.MethodPut, "/custom-races/"+created.UUID.String(), token, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		race, err := s.store.FindCustomRaceByID(created.UUID.String())
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if race.Name != "Updated API Race" {
+			t.Errorf("Expected the race to be renamed, got %q", race.Name)
+		}
+	})
+
+	t.Run("Updating a race that doesn't exist", func(t *testing.T) {
+		w := s.request(t, http.MethodPut, "/custom-races/00000000-0000-0000-0000-000000000000", token, apiTestCustomRace())
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("Validation errors", func(t *testing.T) {
+		req := apiTestCustomRace()
+		req.RaceConfig.Track = ""
+		req.EntryList[0].Restrictor = maxRestrictor + 1
+		req.EntryList[2].Model = "not_an_available_car"
+
+		w := s.request(t, http.MethodPost, "/custom-races", token, req)
+
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("Expected status 422, got %d: %s", w.Code, w.Body.String())
+		}
+
+		var resp apiErrorResponse
+
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+
+		fields := make(map[string]bool)
+
+		for _, field := range resp.Fields {
+			fields[field.Field] = true
+		}
+
+		for _, field := range []string{"RaceConfig.Track", "EntryList[0].Restrictor", "EntryList[2].Model"} {
+			if !fields[field] {
+				t.Errorf("Expected a validation error for %s, got %v", field, resp.Fields)
+			}
+		}
+	})
+
+	t.Run("Requests without credentials are not allowed", func(t *testing.T) {
+		w := s.request(t, http.MethodPost, "/custom-races", "", apiTestCustomRace())
+
+		if w.Code != http.StatusFound || w.Header().Get("Location") != "/login" {
+			t.Errorf("Expected a redirect to /login, got %d %s", w.Code, w.Header().Get("Location"))
+		}
+	})
+
+	t.Run("Requests with an invalid token are unauthorized", func(t *testing.T) {
+		w := s.request(t, http.MethodPost, "/custom-races", "not-a-token", apiTestCustomRace())
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got %d", w.Code)
+		}
+	})
+}
+
+func TestAPIHandler_Championships(t *testing.T) {
+	dir, err := ioutil.TempDir("", "api")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	s := newAPITestServer(t, dir)
+	_, token := s.createToken(t, GroupWrite, GroupWrite)
+
+	req := APIChampionshipRequest{
+		Name: "API Championship",
+		Classes: []*APIChampionshipClassRequest{
+			{
+				Name:          "GT3",
+				AvailableCars: []string{"ks_audi_r8_lms"},
+				Entrants:      []*Entrant{{Name: "Driver 1", Model: "ks_audi_r8_lms"}},
+			},
+		},
+	}
+
+	var created Championship
+
+	t.Run("Create", func(t *testing.T) {
+		w := s.request(t, http.MethodPost, "/championships", token, req)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+		}
+
+		if err := json.NewDecoder(w.Body).Decode(&created); err != nil {
+			t.Fatal(err)
+		}
+
+		championship, err := s.store.LoadChampionship(created.ID.String())
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(championship.Classes) != 1 || len(championship.Classes[0].Entrants) != 1 {
+			t.Errorf("Expected one class with one entrant, got %d classes", len(championship.Classes))
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		req.Name = "Updated API Championship"
+
+		w := s.request(t, http.MethodPut, "/championships/"+created.ID.String(), token, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		championship, err := s.store.LoadChampionship(created.ID.String())
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if championship.Name != "Updated API Championship" {
+			t.Errorf("Expected the championship to be renamed, got %q", championship.Name)
+		}
+	})
+
+	t.Run("Validation errors", func(t *testing.T) {
+		w := s.request(t, http.MethodPost, "/championships", token, APIChampionshipRequest{})
+
+		if w.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("Expected status 422, got %d: %s", w.Code, w.Body.String())
+		}
+
+		var resp apiErrorResponse
+
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(resp.Fields) != 2 || resp.Fields[0].Field != "Name" || resp.Fields[1].Field != "Classes" {
+			t.Errorf("Expected validation errors for Name and Classes, got %v", resp.Fields)
+		}
+	})
+}
@@ -0,0 +1,397 @@
+package servermanager
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	apiTokenSecretLength      = 32
+	apiTokenLastUsedFrequency = time.Minute
+)
+
+var (
+	ErrAPITokenInvalid            = errors.New("servermanager: invalid api token")
+	ErrAPITokenNotFound           = errors.New("servermanager: api token not found")
+	ErrAPITokenNameRequired       = errors.New("servermanager: api token name is required")
+	ErrAPITokenGroupTooPermissive = errors.New("servermanager: api token group exceeds the account's group")
+)
+
+// APIToken is a named bearer token which can be used in place of a login session. The token itself is only ever
+// shown once, when it is created. Only a hash of its secret is stored alongside the Account.
+type APIToken struct {
+	ID      uuid.UUID
+	Name    string
+	Groups  map[ServerID]Group
+	Hash    string
+	Created time.Time
+
+	LastUsed time.Time
+}
+
+// Group is the group the APIToken is scoped to for this Server Manager instance.
+func (t APIToken) Group() Group {
+	if group, ok := t.Groups[serverID]; ok {
+		return group
+	}
+
+	return GroupNoAccess
+}
+
+func (t APIToken) matches(secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(hashAPITokenSecret(secret)), []byte(t.Hash)) == 1
+}
+
+func hashAPITokenSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+
+	return hex.EncodeToString(hash[:])
+}
+
+var groupPrivilegeOrder = map[Group]int{
+	GroupNoAccess: 0,
+	GroupRead:     1,
+	GroupWrite:    2,
+	GroupDelete:   3,
+	GroupAdmin:    4,
+}
+
+func lowestGroup(a, b Group) Group {
+	if groupPrivilegeOrder[b] < groupPrivilegeOrder[a] {
+		return b
+	}
+
+	return a
+}
+
+// bearerToken returns the token from a request's "Authorization: Bearer <token>" header, if one is present.
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+
+	if len(header) < len("Bearer ") || !strings.EqualFold(header[:len("Bearer ")], "Bearer ") {
+		return "", false
+	}
+
+	return strings.TrimSpace(header[len("Bearer "):]), true
+}
+
+// apiTokenLogin authenticates a request using an API token. Unlike session logins, failures are reported
+// with a status code rather than a redirect, since the caller is not a browser.
+func (ah *AccountHandler) apiTokenLogin(token string, requiredGroup Group, next http.Handler, w http.ResponseWriter, r *http.Request) {
+	account, err := ah.accountManager.findAccountByAPIToken(token)
+
+	if err == ErrAPITokenInvalid {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	} else if err != nil {
+		logrus.WithError(err).Errorf("Could not look up api token")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if !account.HasGroupPrivilege(requiredGroup) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestContextKeyAccount, account)))
+}
+
+// CreateAPIToken adds a new APIToken to the account, scoped to group on this server (or on all of the account's
+// servers). The returned string is the token to be given to the client, it cannot be retrieved again.
+func (am *AccountManager) CreateAPIToken(account *Account, name string, group Group, allServers bool) (string, error) {
+	name = strings.TrimSpace(name)
+
+	if name == "" {
+		return "", ErrAPITokenNameRequired
+	}
+
+	if _, ok := groupPrivilegeOrder[group]; !ok || group == GroupNoAccess {
+		return "", ErrAPITokenGroupTooPermissive
+	}
+
+	token := &APIToken{
+		ID:      uuid.New(),
+		Name:    name,
+		Groups:  make(map[ServerID]Group),
+		Created: time.Now(),
+	}
+
+	token.Groups[serverID] = group
+
+	if allServers {
+		for id := range account.Groups {
+			token.Groups[id] = group
+		}
+	}
+
+	for id, tokenGroup := range token.Groups {
+		accountGroup, ok := account.Groups[id]
+
+		if !ok && id == serverID {
+			accountGroup = account.Group()
+		}
+
+		if lowestGroup(accountGroup, tokenGroup) != tokenGroup {
+			return "", ErrAPITokenGroupTooPermissive
+		}
+	}
+
+	secret := make([]byte, apiTokenSecretLength)
+
+	if _, err := io.ReadFull(rand.Reader, secret); err != nil {
+		return "", err
+	}
+
+	encodedSecret := hex.EncodeToString(secret)
+	token.Hash = hashAPITokenSecret(encodedSecret)
+
+	err := am.updateAPITokens(account, func(tokens []*APIToken) ([]*APIToken, error) {
+		return append(tokens, token), nil
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return token.ID.String() + "." + encodedSecret, nil
+}
+
+// RevokeAPIToken removes an APIToken from the account. Any client using it will no longer be able to authenticate.
+func (am *AccountManager) RevokeAPIToken(account *Account, tokenID string) error {
+	return am.updateAPITokens(account, func(tokens []*APIToken) ([]*APIToken, error) {
+		for i, token := range tokens {
+			if token.ID.String() == tokenID {
+				return append(tokens[:i], tokens[i+1:]...), nil
+			}
+		}
+
+		return nil, ErrAPITokenNotFound
+	})
+}
+
+// updateAPITokens applies fn to the stored copy of the account's tokens, so that token changes are never made
+// to (or overwritten by) an out of date copy of the account. account's tokens are updated to match.
+func (am *AccountManager) updateAPITokens(account *Account, fn func(tokens []*APIToken) ([]*APIToken, error)) error {
+	am.apiTokenMutex.Lock()
+	defer am.apiTokenMutex.Unlock()
+
+	stored, err := am.store.FindAccountByName(account.Name)
+
+	if err != nil {
+		return err
+	}
+
+	tokens, err := fn(stored.APITokens)
+
+	if err != nil {
+		return err
+	}
+
+	stored.APITokens = tokens
+
+	if err := am.store.UpsertAccount(stored); err != nil {
+		return err
+	}
+
+	for _, token := range tokens {
+		am.apiTokenAccounts[token.ID] = stored.Name
+	}
+
+	account.APITokens = tokens
+
+	return nil
+}
+
+// findAccountByAPIToken finds the account which owns a given token. The account returned is scoped to the
+// token's group, and the token's last used time is updated.
+func (am *AccountManager) findAccountByAPIToken(token string) (*Account, error) {
+	parts := strings.SplitN(token, ".", 2)
+
+	if len(parts) != 2 {
+		return nil, ErrAPITokenInvalid
+	}
+
+	tokenID, err := uuid.Parse(parts[0])
+
+	if err != nil {
+		return nil, ErrAPITokenInvalid
+	}
+
+	secret := parts[1]
+
+	am.apiTokenMutex.Lock()
+	defer am.apiTokenMutex.Unlock()
+
+	account, apiToken, err := am.findAPIToken(tokenID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !apiToken.matches(secret) {
+		return nil, ErrAPITokenInvalid
+	}
+
+	if time.Since(apiToken.LastUsed) > apiTokenLastUsedFrequency {
+		// account was read with apiTokenMutex held, so no token changes can be lost by writing it back.
+		apiToken.LastUsed = time.Now()
+
+		if err := am.store.UpsertAccount(account); err != nil {
+			logrus.WithError(err).Errorf("Could not update last used time for api token: %s", tokenID)
+		}
+	}
+
+	account.apiToken = apiToken
+
+	return account, nil
+}
+
+// findAPIToken looks up the account which owns tokenID using apiTokenAccounts. Accounts can be changed outside
+// of the AccountManager (e.g. restored from the recycle bin, or from a backup), so if the token is not where
+// apiTokenAccounts says it is, apiTokenAccounts is rebuilt from the store. am.apiTokenMutex must be held.
+func (am *AccountManager) findAPIToken(tokenID uuid.UUID) (*Account, *APIToken, error) {
+	if name, ok := am.apiTokenAccounts[tokenID]; ok {
+		account, err := am.store.FindAccountByName(name)
+
+		if err != nil && err != ErrAccountNotFound {
+			return nil, nil, err
+		}
+
+		if apiToken := findActiveAPIToken(account, tokenID); apiToken != nil {
+			return account, apiToken, nil
+		}
+	}
+
+	accounts, err := am.store.ListAccounts()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	am.apiTokenAccounts = make(map[uuid.UUID]string)
+
+	var owner *Account
+
+	for _, account := range accounts {
+		for _, apiToken := range account.APITokens {
+			am.apiTokenAccounts[apiToken.ID] = account.Name
+
+			if apiToken.ID == tokenID {
+				owner = account
+			}
+		}
+	}
+
+	if apiToken := findActiveAPIToken(owner, tokenID); apiToken != nil {
+		return owner, apiToken, nil
+	}
+
+	return nil, nil, ErrAPITokenInvalid
+}
+
+// findActiveAPIToken returns the account's token with the given ID. Tokens stop working when their account
+// is deleted, and work again if it is restored.
+func findActiveAPIToken(account *Account, tokenID uuid.UUID) *APIToken {
+	if account == nil || !account.Deleted.IsZero() {
+		return nil
+	}
+
+	for _, apiToken := range account.APITokens {
+		if apiToken.ID == tokenID {
+			return apiToken
+		}
+	}
+
+	return nil
+}
+
+type apiTokensTemplateVars struct {
+	BaseTemplateVars
+
+	Tokens   []*APIToken
+	NewToken string
+}
+
+func (ah *AccountHandler) apiTokens(w http.ResponseWriter, r *http.Request) {
+	account := AccountFromRequest(r)
+
+	if account == OpenAccount || account.Name == "" {
+		AddErrorFlash(w, r, "You must be logged in to manage API tokens.")
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	if account.apiToken != nil {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	var newToken string
+
+	if r.Method == http.MethodPost {
+		var err error
+
+		newToken, err = ah.accountManager.CreateAPIToken(account, r.FormValue("Name"), Group(r.FormValue("Group")), formValueAsInt(r.FormValue("AllServers")) == 1)
+
+		switch err {
+		case nil:
+			AddFlash(w, r, "API token successfully created. Make sure to copy it now, it will not be shown again.")
+		case ErrAPITokenNameRequired:
+			AddErrorFlash(w, r, "Please give your API token a name")
+		case ErrAPITokenGroupTooPermissive:
+			AddErrorFlash(w, r, "API tokens cannot have more permissions than your account")
+		default:
+			logrus.WithError(err).Errorf("Could not create api token for account id: %s", account.ID.String())
+			AddErrorFlash(w, r, "Unable to create API token")
+		}
+	}
+
+	tokens := make([]*APIToken, len(account.APITokens))
+	copy(tokens, account.APITokens)
+
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].Created.After(tokens[j].Created)
+	})
+
+	ah.viewRenderer.MustLoadTemplate(w, r, "accounts/api-tokens.html", &apiTokensTemplateVars{
+		Tokens:   tokens,
+		NewToken: newToken,
+	})
+}
+
+func (ah *AccountHandler) revokeAPIToken(w http.ResponseWriter, r *http.Request) {
+	account := AccountFromRequest(r)
+
+	if account == OpenAccount || account.Name == "" {
+		http.Redirect(w, r, "/login", http.StatusFound)
+		return
+	}
+
+	if account.apiToken != nil {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	if err := ah.accountManager.RevokeAPIToken(account, chi.URLParam(r, "id")); err != nil {
+		logrus.WithError(err).Errorf("Could not revoke api token for account id: %s", account.ID.String())
+		AddErrorFlash(w, r, "Unable to revoke API token")
+	} else {
+		AddFlash(w, r, "API token successfully revoked")
+	}
+
+	http.Redirect(w, r, "/accounts/api-tokens", http.StatusFound)
+}
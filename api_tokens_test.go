@@ -0,0 +1,221 @@
+package servermanager
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAPITokenAuthentication(t *testing.T) {
+	dir, err := ioutil.TempDir("", "api-tokens")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	store := NewJSONStore(filepath.Join(dir, "store"), filepath.Join(dir, "shared"))
+
+	if err := InitServerID(store); err != nil {
+		t.Fatal(err)
+	}
+
+	accountManager := NewAccountManager(store)
+	accountHandler := NewAccountHandler(&BaseHandler{}, store, accountManager)
+
+	var authenticated *Account
+
+	handler := func(group Group) http.Handler {
+		return accountHandler.MustLoginMiddleware(group, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authenticated = AccountFromRequest(r)
+		}))
+	}
+
+	request := func(group Group, token string) int {
+		authenticated = nil
+
+		req := httptest.NewRequest(http.MethodPost, apiPrefix+"/custom-races", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		w := httptest.NewRecorder()
+		handler(group).ServeHTTP(w, req)
+
+		return w.Code
+	}
+
+	account := NewAccount()
+	account.Name = "api"
+	account.Groups[serverID] = GroupAdmin
+
+	if err := store.UpsertAccount(account); err != nil {
+		t.Fatal(err)
+	}
+
+	writeToken, err := accountManager.CreateAPIToken(account, "write", GroupWrite, false)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	readToken, err := accountManager.CreateAPIToken(account, "read", GroupRead, false)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Valid tokens authenticate as their account", func(t *testing.T) {
+		if code := request(GroupWrite, writeToken); code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", code)
+		}
+
+		if authenticated == nil || authenticated.ID != account.ID {
+			t.Fatalf("Expected the request to be authenticated as %s, got %v", account.Name, authenticated)
+		}
+
+		if authenticated.Group() != GroupWrite {
+			t.Errorf("Expected the account to be limited to the token's group, got %s", authenticated.Group())
+		}
+	})
+
+	t.Run("Read only tokens can't use write routes", func(t *testing.T) {
+		if code := request(GroupWrite, readToken); code != http.StatusForbidden {
+			t.Errorf("Expected status 403, got %d", code)
+		}
+
+		if code := request(GroupRead, readToken); code != http.StatusOK {
+			t.Errorf("Expected read only tokens to be able to use read routes, got %d", code)
+		}
+	})
+
+	t.Run("Unknown tokens are unauthorized", func(t *testing.T) {
+		for _, token := range []string{"", "not-a-token", account.ID.String() + ".secret", writeToken + "0"} {
+			if code := request(GroupRead, token); code != http.StatusUnauthorized {
+				t.Errorf("Expected status 401 for token %q, got %d", token, code)
+			}
+		}
+	})
+
+	t.Run("Token groups are capped by the account's group", func(t *testing.T) {
+		account, err := store.FindAccountByID(account.ID.String())
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		account.Groups[serverID] = GroupRead
+
+		if err := store.UpsertAccount(account); err != nil {
+			t.Fatal(err)
+		}
+
+		defer func() {
+			account.Groups[serverID] = GroupAdmin
+			_ = store.UpsertAccount(account)
+		}()
+
+		if code := request(GroupWrite, writeToken); code != http.StatusForbidden {
+			t.Errorf("Expected status 403, got %d", code)
+		}
+
+		if code := request(GroupRead, writeToken); code != http.StatusOK || authenticated.Group() != GroupRead {
+			t.Errorf("Expected the token to be limited to the account's group, got %d", code)
+		}
+
+		if _, err := accountManager.CreateAPIToken(account, "admin", GroupAdmin, false); err != ErrAPITokenGroupTooPermissive {
+			t.Errorf("Expected ErrAPITokenGroupTooPermissive, got: %v", err)
+		}
+	})
+
+	t.Run("Using a token records when it was last used", func(t *testing.T) {
+		if code := request(GroupRead, readToken); code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", code)
+		}
+
+		account, err := store.FindAccountByID(account.ID.String())
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if account.APITokens[1].LastUsed.IsZero() {
+			t.Errorf("Expected the token's last used time to be set")
+		}
+	})
+
+	t.Run("Token changes made with an out of date account aren't lost", func(t *testing.T) {
+		outOfDate, err := store.FindAccountByID(account.ID.String())
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		newToken, err := accountManager.CreateAPIToken(account, "new", GroupRead, false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := accountManager.RevokeAPIToken(outOfDate, outOfDate.APITokens[len(outOfDate.APITokens)-1].ID.String()); err != nil {
+			t.Fatal(err)
+		}
+
+		if code := request(GroupRead, newToken); code != http.StatusOK {
+			t.Errorf("Expected the new token to keep working, got %d", code)
+		}
+
+		if len(outOfDate.APITokens) != 2 {
+			t.Errorf("Expected the account's tokens to be updated, got %d tokens", len(outOfDate.APITokens))
+		}
+	})
+
+	t.Run("Revoked tokens are unauthorized", func(t *testing.T) {
+		account, err := store.FindAccountByID(account.ID.String())
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := accountManager.RevokeAPIToken(account, account.APITokens[1].ID.String()); err != nil {
+			t.Fatal(err)
+		}
+
+		if code := request(GroupRead, readToken); code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got %d", code)
+		}
+
+		if code := request(GroupRead, writeToken); code != http.StatusOK {
+			t.Errorf("Expected other tokens to keep working, got %d", code)
+		}
+	})
+
+	t.Run("Tokens of deleted accounts are unauthorized", func(t *testing.T) {
+		if err := store.DeleteAccount(account.ID.String()); err != nil {
+			t.Fatal(err)
+		}
+
+		if code := request(GroupRead, writeToken); code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got %d", code)
+		}
+
+		deleted, err := store.FindAccountByName(account.Name)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		deleted.Deleted = time.Time{}
+
+		if err := store.UpsertAccount(deleted); err != nil {
+			t.Fatal(err)
+		}
+
+		if code := request(GroupRead, writeToken); code != http.StatusOK {
+			t.Errorf("Expected tokens to work again once their account is restored, got %d", code)
+		}
+	})
+}
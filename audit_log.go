@@ -14,6 +14,9 @@ type AuditEntry struct {
 	URL       string
 	User      string
 	Time      time.Time
+
+	// Details describes actions which aren't clear from the URL alone, e.g. protest decisions.
+	Details string
 }
 
 var ignoredURLs = [5]string{
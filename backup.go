@@ -0,0 +1,1117 @@
+package servermanager
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/go-chi/chi"
+	"github.com/sirupsen/logrus"
+)
+
+// BackupsPath is the folder that backups are kept in.
+var BackupsPath = "backups"
+
+const (
+	// BackupFormatVersion is incremented whenever the layout of a backup archive changes.
+	BackupFormatVersion = 1
+
+	backupManifestFile     = "manifest.json"
+	backupStoreFile        = "store/store.db"
+	backupStorePrivateDir  = "store/private"
+	backupStoreSharedDir   = "store/shared"
+	backupInstallDir       = "assetto"
+	backupFileExtension    = ".zip"
+	backupRestoreDir       = "restore"
+	backupPendingFile      = "pending.json"
+	backupScheduleInterval = 10 * time.Minute
+	backupUploadMemory     = 32 << 20
+)
+
+var (
+	ErrBackupNotFound          = errors.New("servermanager: backup not found")
+	ErrBackupStoreTypeMismatch = errors.New("servermanager: backup was made with a different store type")
+	ErrNoPendingRestore        = errors.New("servermanager: there is no pending restore")
+)
+
+// BackupValidationError is returned when a backup archive is incomplete, corrupt, or was made by a newer
+// version of Server Manager.
+type BackupValidationError struct {
+	Reason string
+}
+
+func (e BackupValidationError) Error() string {
+	return "servermanager: invalid backup: " + e.Reason
+}
+
+type BackupTrigger string
+
+const (
+	BackupTriggerManual    BackupTrigger = "Manual"
+	BackupTriggerScheduled BackupTrigger = "Scheduled"
+	BackupTriggerRestore   BackupTrigger = "Before Restore"
+)
+
+// BackupManifest describes the contents of a backup archive. It is written to manifest.json in the root of
+// the archive, and is used to check the archive before it is restored.
+type BackupManifest struct {
+	Version              int
+	Created              time.Time
+	Trigger              BackupTrigger
+	ServerManagerVersion string
+	StoreType            string
+	StoreVersion         int
+	Files                []*BackupFile
+}
+
+type BackupFile struct {
+	Path   string
+	Size   int64
+	SHA256 string
+}
+
+// Backup is an archive in the BackupsPath. Manifest is nil if the archive could not be read.
+type Backup struct {
+	Name     string
+	Size     int64
+	Created  time.Time
+	Manifest *BackupManifest
+}
+
+func (b *Backup) SizeText() string {
+	return humanize.Bytes(uint64(b.Size))
+}
+
+// PendingRestore is a backup which has been checked and extracted, ready to replace the current data the next
+// time that Server Manager starts.
+type PendingRestore struct {
+	Backup  string
+	Created time.Time
+	Staged  time.Time
+}
+
+func backupRestorePath() string {
+	return filepath.Join(BackupsPath, backupRestoreDir)
+}
+
+// backupInstallPaths are the files and folders in the ServerInstallPath which are included in backups,
+// relative to the ServerInstallPath.
+func backupInstallPaths() []string {
+	paths := []string{"results", "setups", ServerConfigPath, BlocklistFilename, MOTDFilename}
+
+	pluginConfigs := []string{
+		filepath.Join(StrackerFolderPath(), strackerConfigIniFilename),
+		KissMyRankConfigPath(),
+		filepath.Join(RealPenaltyFolderPath(), realPenaltyAppConfigIniPath),
+		filepath.Join(RealPenaltyFolderPath(), realPenaltySettingsIniPath),
+		filepath.Join(RealPenaltyFolderPath(), realPenaltyACSettingsIniPath),
+	}
+
+	for _, pluginConfig := range pluginConfigs {
+		rel, err := filepath.Rel(ServerInstallPath, pluginConfig)
+
+		if err != nil {
+			continue
+		}
+
+		paths = append(paths, rel)
+	}
+
+	return paths
+}
+
+// backupStore is implemented by each Store, adding a consistent copy of the store's data to a backup.
+type backupStore interface {
+	backup(bw *backupWriter) error
+}
+
+// backupWriter writes files into a backup archive, recording their size and checksum in the manifest.
+type backupWriter struct {
+	zip   *zip.Writer
+	files []*BackupFile
+}
+
+type byteCounter struct {
+	n int64
+}
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+
+	return len(p), nil
+}
+
+func (bw *backupWriter) writeFile(name string, modified time.Time, fn func(w io.Writer) error) error {
+	w, err := bw.zip.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modified,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.New()
+	counter := &byteCounter{}
+
+	if err := fn(io.MultiWriter(w, hash, counter)); err != nil {
+		return err
+	}
+
+	bw.files = append(bw.files, &BackupFile{
+		Path:   name,
+		Size:   counter.n,
+		SHA256: hex.EncodeToString(hash.Sum(nil)),
+	})
+
+	return nil
+}
+
+// addPath adds a file, or a folder and everything in it, to the backup. Paths which don't exist are skipped.
+func (bw *backupWriter) addPath(name, location string) error {
+	return filepath.Walk(location, func(file string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(location, file)
+
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(file)
+
+		if err != nil {
+			return err
+		}
+
+		defer f.Close()
+
+		return bw.writeFile(path.Join(name, filepath.ToSlash(rel)), info.ModTime(), func(w io.Writer) error {
+			_, err := io.Copy(w, f)
+
+			return err
+		})
+	})
+}
+
+type BackupManager struct {
+	store       Store
+	storeConfig StoreConfig
+
+	mutex sync.Mutex
+}
+
+func NewBackupManager(store Store, storeConfig StoreConfig) *BackupManager {
+	return &BackupManager{
+		store:       store,
+		storeConfig: storeConfig,
+	}
+}
+
+func backupPath(name string) (string, error) {
+	if name != filepath.Base(name) || filepath.Ext(name) != backupFileExtension {
+		return "", ErrBackupNotFound
+	}
+
+	location := filepath.Join(BackupsPath, name)
+
+	if _, err := os.Stat(location); os.IsNotExist(err) {
+		return "", ErrBackupNotFound
+	} else if err != nil {
+		return "", err
+	}
+
+	return location, nil
+}
+
+// newBackupName names a backup by the time it was created, adding a suffix if there is already a backup
+// with that name.
+func newBackupName(created time.Time) string {
+	base := "backup_" + created.UTC().Format("2006-01-02_15-04-05")
+	name := base + backupFileExtension
+
+	for i := 2; ; i++ {
+		if _, err := os.Stat(filepath.Join(BackupsPath, name)); os.IsNotExist(err) {
+			return name
+		}
+
+		name = fmt.Sprintf("%s_%d%s", base, i, backupFileExtension)
+	}
+}
+
+// ListBackups returns the backups in the BackupsPath, newest first.
+func (bm *BackupManager) ListBackups() ([]*Backup, error) {
+	files, err := ioutil.ReadDir(BackupsPath)
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var backups []*Backup
+
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != backupFileExtension {
+			continue
+		}
+
+		backup := &Backup{
+			Name:    file.Name(),
+			Size:    file.Size(),
+			Created: file.ModTime(),
+		}
+
+		manifest, err := readBackupManifest(filepath.Join(BackupsPath, file.Name()))
+
+		if err != nil {
+			logrus.WithError(err).Warnf("Could not read backup manifest: %s", file.Name())
+		} else {
+			backup.Manifest = manifest
+			backup.Created = manifest.Created
+		}
+
+		backups = append(backups, backup)
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Created.After(backups[j].Created)
+	})
+
+	return backups, nil
+}
+
+// CreateBackup writes the store, results, setups, server configuration and plugin configuration to a new
+// backup archive, then prunes old backups.
+func (bm *BackupManager) CreateBackup(trigger BackupTrigger) (*Backup, error) {
+	bm.mutex.Lock()
+	defer bm.mutex.Unlock()
+
+	backup, err := bm.createBackup(trigger)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bm.pruneBackups(); err != nil {
+		logrus.WithError(err).Errorf("Could not prune old backups")
+	}
+
+	return backup, nil
+}
+
+func (bm *BackupManager) createBackup(trigger BackupTrigger) (*Backup, error) {
+	store, ok := bm.store.(backupStore)
+
+	if !ok {
+		return nil, fmt.Errorf("servermanager: store type %T can't be backed up", bm.store)
+	}
+
+	if err := os.MkdirAll(BackupsPath, 0755); err != nil {
+		return nil, err
+	}
+
+	manifest := &BackupManifest{
+		Version:              BackupFormatVersion,
+		Created:              time.Now(),
+		Trigger:              trigger,
+		ServerManagerVersion: BuildVersion,
+		StoreType:            bm.storeConfig.Type,
+		StoreVersion:         CurrentMigrationVersion,
+	}
+
+	name := newBackupName(manifest.Created)
+	location := filepath.Join(BackupsPath, name)
+
+	// the archive is written to a temporary file, so incomplete backups are never listed.
+	f, err := os.Create(location + ".tmp")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer os.Remove(location + ".tmp")
+	defer f.Close()
+
+	bw := &backupWriter{zip: zip.NewWriter(f)}
+
+	if err := store.backup(bw); err != nil {
+		return nil, fmt.Errorf("servermanager: could not back up store: %w", err)
+	}
+
+	for _, installPath := range backupInstallPaths() {
+		err := bw.addPath(path.Join(backupInstallDir, filepath.ToSlash(installPath)), filepath.Join(ServerInstallPath, installPath))
+
+		if err != nil {
+			return nil, fmt.Errorf("servermanager: could not back up %s: %w", installPath, err)
+		}
+	}
+
+	manifest.Files = bw.files
+
+	w, err := bw.zip.Create(backupManifestFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(manifest); err != nil {
+		return nil, err
+	}
+
+	if err := bw.zip.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := os.Rename(location+".tmp", location); err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(location)
+
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Created backup: %s (%s)", name, humanize.Bytes(uint64(info.Size())))
+
+	return &Backup{
+		Name:     name,
+		Size:     info.Size(),
+		Created:  manifest.Created,
+		Manifest: manifest,
+	}, nil
+}
+
+// pruneBackups deletes the oldest backups, keeping the number of backups set in the server options.
+func (bm *BackupManager) pruneBackups() error {
+	opts, err := bm.store.LoadServerOptions()
+
+	if err != nil {
+		return err
+	}
+
+	if opts.BackupsToKeep <= 0 {
+		return nil
+	}
+
+	backups, err := bm.ListBackups()
+
+	if err != nil {
+		return err
+	}
+
+	if len(backups) <= opts.BackupsToKeep {
+		return nil
+	}
+
+	for _, backup := range backups[opts.BackupsToKeep:] {
+		if err := os.Remove(filepath.Join(BackupsPath, backup.Name)); err != nil {
+			return err
+		}
+
+		logrus.Infof("Deleted old backup: %s", backup.Name)
+	}
+
+	return nil
+}
+
+func (bm *BackupManager) DeleteBackup(name string) error {
+	location, err := backupPath(name)
+
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(location)
+}
+
+// ImportBackup checks an uploaded backup archive and adds it to the BackupsPath.
+func (bm *BackupManager) ImportBackup(r io.Reader) (*Backup, error) {
+	if err := os.MkdirAll(BackupsPath, 0755); err != nil {
+		return nil, err
+	}
+
+	f, err := ioutil.TempFile(BackupsPath, "upload-*.tmp")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	size, err := io.Copy(f, r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	manifest, err := checkBackup(f.Name(), "")
+
+	if err != nil {
+		return nil, err
+	}
+
+	name := newBackupName(manifest.Created)
+
+	if err := os.Rename(f.Name(), filepath.Join(BackupsPath, name)); err != nil {
+		return nil, err
+	}
+
+	return &Backup{
+		Name:     name,
+		Size:     size,
+		Created:  manifest.Created,
+		Manifest: manifest,
+	}, nil
+}
+
+func readBackupManifest(location string) (*BackupManifest, error) {
+	r, err := zip.OpenReader(location)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer r.Close()
+
+	for _, file := range r.File {
+		if file.Name == backupManifestFile {
+			return decodeBackupManifest(file)
+		}
+	}
+
+	return nil, BackupValidationError{Reason: "the archive has no " + backupManifestFile}
+}
+
+func decodeBackupManifest(file *zip.File) (*BackupManifest, error) {
+	rc, err := file.Open()
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rc.Close()
+
+	var manifest *BackupManifest
+
+	if err := json.NewDecoder(rc).Decode(&manifest); err != nil {
+		return nil, BackupValidationError{Reason: backupManifestFile + " could not be read"}
+	}
+
+	return manifest, nil
+}
+
+// checkBackup verifies every file in a backup archive against the checksums in its manifest. If extractTo is
+// not empty, the files are also extracted into it.
+func checkBackup(location, extractTo string) (*BackupManifest, error) {
+	r, err := zip.OpenReader(location)
+
+	if err != nil {
+		return nil, BackupValidationError{Reason: "the file is not a zip archive"}
+	}
+
+	defer r.Close()
+
+	files := make(map[string]*zip.File)
+
+	for _, file := range r.File {
+		files[file.Name] = file
+	}
+
+	manifestFile, ok := files[backupManifestFile]
+
+	if !ok {
+		return nil, BackupValidationError{Reason: "the archive has no " + backupManifestFile}
+	}
+
+	manifest, err := decodeBackupManifest(manifestFile)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if manifest.Version < 1 || manifest.Version > BackupFormatVersion {
+		return nil, BackupValidationError{Reason: fmt.Sprintf("backup format version %d is not supported by this version of Server Manager", manifest.Version)}
+	}
+
+	if manifest.StoreVersion > CurrentMigrationVersion {
+		return nil, BackupValidationError{Reason: fmt.Sprintf("the backup was made by a newer version of Server Manager (%s)", manifest.ServerManagerVersion)}
+	}
+
+	if len(files) != len(manifest.Files)+1 {
+		return nil, BackupValidationError{Reason: "the archive contains files which are not in the manifest"}
+	}
+
+	for _, backupFile := range manifest.Files {
+		if !isValidBackupFilePath(backupFile.Path) {
+			return nil, BackupValidationError{Reason: "invalid file path: " + backupFile.Path}
+		}
+
+		file, ok := files[backupFile.Path]
+
+		if !ok {
+			return nil, BackupValidationError{Reason: "missing file: " + backupFile.Path}
+		}
+
+		if err := checkBackupFile(file, backupFile, extractTo); err != nil {
+			return nil, err
+		}
+	}
+
+	return manifest, nil
+}
+
+func isValidBackupFilePath(p string) bool {
+	return p == path.Clean(p) && !path.IsAbs(p) && p != ".." && !strings.HasPrefix(p, "../") && !strings.Contains(p, "\\") && p != backupManifestFile
+}
+
+func checkBackupFile(file *zip.File, backupFile *BackupFile, extractTo string) error {
+	rc, err := file.Open()
+
+	if err != nil {
+		return err
+	}
+
+	defer rc.Close()
+
+	hash := sha256.New()
+	counter := &byteCounter{}
+	w := io.MultiWriter(hash, counter)
+
+	if extractTo != "" {
+		location := filepath.Join(extractTo, filepath.FromSlash(backupFile.Path))
+
+		if err := os.MkdirAll(filepath.Dir(location), 0755); err != nil {
+			return err
+		}
+
+		f, err := os.Create(location)
+
+		if err != nil {
+			return err
+		}
+
+		defer f.Close()
+
+		w = io.MultiWriter(w, f)
+	}
+
+	if _, err := io.Copy(w, rc); err != nil {
+		return BackupValidationError{Reason: "could not read " + backupFile.Path}
+	}
+
+	if counter.n != backupFile.Size || hex.EncodeToString(hash.Sum(nil)) != backupFile.SHA256 {
+		return BackupValidationError{Reason: "checksum mismatch for " + backupFile.Path}
+	}
+
+	return nil
+}
+
+// RestoreBackup checks a backup and extracts it, then runs Migrate on the extracted store. The restored
+// data replaces the current data when Server Manager is next started. A backup of the current data is made
+// first, so that the restore can be undone.
+func (bm *BackupManager) RestoreBackup(name string) (*PendingRestore, error) {
+	bm.mutex.Lock()
+	defer bm.mutex.Unlock()
+
+	location, err := backupPath(name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := readBackupManifest(location)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if manifest.StoreType != bm.storeConfig.Type {
+		return nil, ErrBackupStoreTypeMismatch
+	}
+
+	if err := os.RemoveAll(backupRestorePath()); err != nil {
+		return nil, err
+	}
+
+	staging := filepath.Join(backupRestorePath(), "files")
+
+	if _, err := checkBackup(location, staging); err != nil {
+		_ = os.RemoveAll(backupRestorePath())
+		return nil, err
+	}
+
+	if err := migrateStagedStore(bm.storeConfig.Type, staging); err != nil {
+		_ = os.RemoveAll(backupRestorePath())
+		return nil, fmt.Errorf("servermanager: could not migrate restored store: %w", err)
+	}
+
+	if _, err := bm.createBackup(BackupTriggerRestore); err != nil {
+		_ = os.RemoveAll(backupRestorePath())
+		return nil, fmt.Errorf("servermanager: could not back up current data: %w", err)
+	}
+
+	pending := &PendingRestore{
+		Backup:  name,
+		Created: manifest.Created,
+		Staged:  time.Now(),
+	}
+
+	b, err := json.Marshal(pending)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(backupRestorePath(), backupPendingFile), b, 0644); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("Backup %s is ready to be restored, restart Server Manager to finish restoring it", name)
+
+	return pending, nil
+}
+
+// migrateStagedStore opens the store in an extracted backup, running any migrations which are needed to bring
+// it up to date with this version of Server Manager.
+func migrateStagedStore(storeType, staging string) error {
+	storeConfig := StoreConfig{
+		Type:       storeType,
+		Path:       filepath.Join(staging, filepath.FromSlash(backupStoreFile)),
+		SharedPath: filepath.Join(staging, filepath.FromSlash(backupStoreSharedDir)),
+	}
+
+	if storeType == "json" {
+		storeConfig.Path = filepath.Join(staging, filepath.FromSlash(backupStorePrivateDir))
+	}
+
+	store, err := storeConfig.BuildStore()
+
+	if err != nil {
+		return err
+	}
+
+	if closer, ok := store.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+func (bm *BackupManager) PendingRestore() (*PendingRestore, error) {
+	return readPendingRestore()
+}
+
+func readPendingRestore() (*PendingRestore, error) {
+	b, err := ioutil.ReadFile(filepath.Join(backupRestorePath(), backupPendingFile))
+
+	if os.IsNotExist(err) {
+		return nil, ErrNoPendingRestore
+	} else if err != nil {
+		return nil, err
+	}
+
+	var pending *PendingRestore
+
+	if err := json.Unmarshal(b, &pending); err != nil {
+		return nil, err
+	}
+
+	return pending, nil
+}
+
+func (bm *BackupManager) CancelRestore() error {
+	bm.mutex.Lock()
+	defer bm.mutex.Unlock()
+
+	if _, err := readPendingRestore(); err != nil {
+		return err
+	}
+
+	return os.RemoveAll(backupRestorePath())
+}
+
+// ApplyPendingRestore replaces the current data with a backup that was restored before Server Manager was
+// last stopped. It must be called before the store is opened.
+func ApplyPendingRestore(storeConfig StoreConfig) error {
+	pending, err := readPendingRestore()
+
+	if err == ErrNoPendingRestore {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	logrus.Infof("Restoring backup: %s", pending.Backup)
+
+	staging := filepath.Join(backupRestorePath(), "files")
+
+	switch storeConfig.Type {
+	case "boltdb", "sqlite":
+		for _, suffix := range []string{"-wal", "-shm"} {
+			if err := os.Remove(storeConfig.Path + suffix); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+
+		if err := replacePath(filepath.Join(staging, filepath.FromSlash(backupStoreFile)), storeConfig.Path); err != nil {
+			return err
+		}
+	case "json":
+		sharedPath := storeConfig.SharedPath
+
+		if sharedPath == "" {
+			sharedPath = storeConfig.Path
+		}
+
+		for _, dir := range []string{storeConfig.Path, sharedPath} {
+			if err := os.RemoveAll(dir); err != nil {
+				return err
+			}
+		}
+
+		if err := moveDirContents(filepath.Join(staging, filepath.FromSlash(backupStorePrivateDir)), storeConfig.Path); err != nil {
+			return err
+		}
+
+		if err := moveDirContents(filepath.Join(staging, filepath.FromSlash(backupStoreSharedDir)), sharedPath); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("servermanager: can't restore store type: %s", storeConfig.Type)
+	}
+
+	// files which were not in the backup are left as they are.
+	for _, installPath := range backupInstallPaths() {
+		restored := filepath.Join(staging, backupInstallDir, installPath)
+
+		if _, err := os.Stat(restored); os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		if err := replacePath(restored, filepath.Join(ServerInstallPath, installPath)); err != nil {
+			return err
+		}
+	}
+
+	logrus.Infof("Successfully restored backup: %s", pending.Backup)
+
+	return os.RemoveAll(backupRestorePath())
+}
+
+func moveDirContents(from, to string) error {
+	if err := os.MkdirAll(to, 0755); err != nil {
+		return err
+	}
+
+	files, err := ioutil.ReadDir(from)
+
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if err := replacePath(filepath.Join(from, file.Name()), filepath.Join(to, file.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// replacePath moves a file or folder to a new location, replacing anything that is already there.
+func replacePath(from, to string) error {
+	if err := os.RemoveAll(to); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return err
+	}
+
+	if err := os.Rename(from, to); err == nil {
+		return nil
+	}
+
+	// renaming fails if the backups folder is on a different filesystem, so fall back to copying.
+	return filepath.Walk(from, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(from, file)
+
+		if err != nil {
+			return err
+		}
+
+		dest := filepath.Join(to, rel)
+
+		if info.IsDir() {
+			return os.MkdirAll(dest, 0755)
+		}
+
+		return copyFile(file, dest)
+	})
+}
+
+func copyFile(from, to string) error {
+	in, err := os.Open(from)
+
+	if err != nil {
+		return err
+	}
+
+	defer in.Close()
+
+	out, err := os.Create(to)
+
+	if err != nil {
+		return err
+	}
+
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+
+	return out.Close()
+}
+
+// RunScheduledBackup makes a backup if the most recent backup is older than the backup interval in the
+// server options.
+func (bm *BackupManager) RunScheduledBackup() error {
+	opts, err := bm.store.LoadServerOptions()
+
+	if err != nil {
+		return err
+	}
+
+	if opts.BackupIntervalHours <= 0 {
+		return nil
+	}
+
+	backups, err := bm.ListBackups()
+
+	if err != nil {
+		return err
+	}
+
+	if len(backups) > 0 && time.Since(backups[0].Created) < time.Duration(opts.BackupIntervalHours)*time.Hour {
+		return nil
+	}
+
+	_, err = bm.CreateBackup(BackupTriggerScheduled)
+
+	return err
+}
+
+// WatchForScheduledBackups periodically checks whether a scheduled backup is due.
+func (bm *BackupManager) WatchForScheduledBackups() {
+	ticker := time.NewTicker(backupScheduleInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := bm.RunScheduledBackup(); err != nil {
+			logrus.WithError(err).Errorf("Could not make scheduled backup")
+		}
+	}
+}
+
+type BackupsHandler struct {
+	*BaseHandler
+
+	backupManager *BackupManager
+}
+
+func NewBackupsHandler(baseHandler *BaseHandler, backupManager *BackupManager) *BackupsHandler {
+	return &BackupsHandler{
+		BaseHandler:   baseHandler,
+		backupManager: backupManager,
+	}
+}
+
+type backupsTemplateVars struct {
+	BaseTemplateVars
+
+	Backups        []*Backup
+	PendingRestore *PendingRestore
+	Opts           *GlobalServerConfig
+}
+
+func (bh *BackupsHandler) list(w http.ResponseWriter, r *http.Request) {
+	backups, err := bh.backupManager.ListBackups()
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not list backups")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	pendingRestore, err := bh.backupManager.PendingRestore()
+
+	if err != nil && err != ErrNoPendingRestore {
+		logrus.WithError(err).Errorf("Could not load pending restore")
+	}
+
+	opts, err := bh.backupManager.store.LoadServerOptions()
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not load server options")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	bh.viewRenderer.MustLoadTemplate(w, r, "server/backups.html", &backupsTemplateVars{
+		Backups:        backups,
+		PendingRestore: pendingRestore,
+		Opts:           opts,
+	})
+}
+
+func (bh *BackupsHandler) create(w http.ResponseWriter, r *http.Request) {
+	backup, err := bh.backupManager.CreateBackup(BackupTriggerManual)
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not create backup")
+		AddErrorFlash(w, r, "Could not create backup")
+	} else {
+		AddFlash(w, r, "Successfully created backup: "+backup.Name)
+	}
+
+	http.Redirect(w, r, r.Referer(), http.StatusFound)
+}
+
+func (bh *BackupsHandler) download(w http.ResponseWriter, r *http.Request) {
+	location, err := backupPath(chi.URLParam(r, "name"))
+
+	if err == ErrBackupNotFound {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		logrus.WithError(err).Errorf("Could not find backup")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Disposition", "attachment; filename=\""+filepath.Base(location)+"\"")
+
+	http.ServeFile(w, r, location)
+}
+
+func (bh *BackupsHandler) upload(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(backupUploadMemory); err != nil {
+		logrus.WithError(err).Errorf("Could not parse backup upload")
+		AddErrorFlash(w, r, "Could not upload backup")
+		http.Redirect(w, r, r.Referer(), http.StatusFound)
+		return
+	}
+
+	file, _, err := r.FormFile("Backup")
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not read uploaded backup")
+		AddErrorFlash(w, r, "Could not upload backup")
+		http.Redirect(w, r, r.Referer(), http.StatusFound)
+		return
+	}
+
+	defer file.Close()
+
+	backup, err := bh.backupManager.ImportBackup(file)
+
+	if validationErr, ok := err.(BackupValidationError); ok {
+		AddErrorFlash(w, r, "The uploaded backup is invalid: "+validationErr.Reason)
+	} else if err != nil {
+		logrus.WithError(err).Errorf("Could not import uploaded backup")
+		AddErrorFlash(w, r, "Could not upload backup")
+	} else {
+		AddFlash(w, r, "Successfully uploaded backup: "+backup.Name)
+	}
+
+	http.Redirect(w, r, r.Referer(), http.StatusFound)
+}
+
+func (bh *BackupsHandler) restore(w http.ResponseWriter, r *http.Request) {
+	_, err := bh.backupManager.RestoreBackup(chi.URLParam(r, "name"))
+
+	if validationErr, ok := err.(BackupValidationError); ok {
+		AddErrorFlash(w, r, "This backup can't be restored: "+validationErr.Reason)
+	} else if err == ErrBackupStoreTypeMismatch {
+		AddErrorFlash(w, r, "This backup can't be restored, it was made by a Server Manager using a different store type")
+	} else if err == ErrBackupNotFound {
+		AddErrorFlash(w, r, "Could not find that backup")
+	} else if err != nil {
+		logrus.WithError(err).Errorf("Could not restore backup")
+		AddErrorFlash(w, r, "Could not restore backup")
+	} else {
+		AddFlash(w, r, "The backup has been checked and is ready to be restored. Restart Server Manager to finish restoring it.")
+	}
+
+	http.Redirect(w, r, r.Referer(), http.StatusFound)
+}
+
+func (bh *BackupsHandler) cancelRestore(w http.ResponseWriter, r *http.Request) {
+	if err := bh.backupManager.CancelRestore(); err != nil && err != ErrNoPendingRestore {
+		logrus.WithError(err).Errorf("Could not cancel restore")
+		AddErrorFlash(w, r, "Could not cancel restore")
+	} else {
+		AddFlash(w, r, "The restore has been cancelled")
+	}
+
+	http.Redirect(w, r, r.Referer(), http.StatusFound)
+}
+
+func (bh *BackupsHandler) delete(w http.ResponseWriter, r *http.Request) {
+	if err := bh.backupManager.DeleteBackup(chi.URLParam(r, "name")); err == ErrBackupNotFound {
+		AddErrorFlash(w, r, "Could not find that backup")
+	} else if err != nil {
+		logrus.WithError(err).Errorf("Could not delete backup")
+		AddErrorFlash(w, r, "Could not delete backup")
+	} else {
+		AddFlash(w, r, "Successfully deleted backup")
+	}
+
+	http.Redirect(w, r, r.Referer(), http.StatusFound)
+}
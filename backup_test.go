@@ -0,0 +1,251 @@
+package servermanager
+
+import (
+	"archive/zip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestBackupManager(t *testing.T) {
+	dir, err := ioutil.TempDir("", "backups")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	oldInstallPath, oldBackupsPath := ServerInstallPath, BackupsPath
+	ServerInstallPath = filepath.Join(dir, "assetto")
+	BackupsPath = filepath.Join(dir, "backups")
+
+	defer func() {
+		ServerInstallPath, BackupsPath = oldInstallPath, oldBackupsPath
+	}()
+
+	storeConfig := StoreConfig{
+		Type:       "json",
+		Path:       filepath.Join(dir, "store"),
+		SharedPath: filepath.Join(dir, "store-shared"),
+	}
+
+	store, err := storeConfig.BuildStore()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backupManager := NewBackupManager(store, storeConfig)
+
+	customRace := &CustomRace{Name: "Backed Up Race", UUID: uuid.New()}
+
+	if err := store.UpsertCustomRace(customRace); err != nil {
+		t.Fatal(err)
+	}
+
+	account := NewAccount()
+	account.Name = "backed-up"
+
+	if err := store.UpsertAccount(account); err != nil {
+		t.Fatal(err)
+	}
+
+	resultsFile := filepath.Join(ServerInstallPath, "results", "2020_1_1_12_0_RACE.json")
+
+	if err := os.MkdirAll(filepath.Dir(resultsFile), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(resultsFile, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var backup *Backup
+
+	t.Run("Backups contain the store and results", func(t *testing.T) {
+		backup, err = backupManager.CreateBackup(BackupTriggerManual)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		paths := make(map[string]bool)
+
+		for _, file := range backup.Manifest.Files {
+			paths[file.Path] = true
+		}
+
+		for _, expected := range []string{"store/shared/custom_races/" + customRace.UUID.String() + ".json", "store/shared/accounts/backed-up.json", "assetto/results/2020_1_1_12_0_RACE.json"} {
+			if !paths[expected] {
+				t.Errorf("Expected %s to be in the backup, got %v", expected, paths)
+			}
+		}
+
+		if _, err := checkBackup(filepath.Join(BackupsPath, backup.Name), ""); err != nil {
+			t.Errorf("Expected the backup to be valid, got: %v", err)
+		}
+	})
+
+	t.Run("Backups with bad checksums are invalid", func(t *testing.T) {
+		tampered := filepath.Join(dir, "tampered.zip")
+
+		if err := tamperWithBackup(filepath.Join(BackupsPath, backup.Name), tampered, "assetto/results/2020_1_1_12_0_RACE.json"); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := checkBackup(tampered, ""); err == nil {
+			t.Errorf("Expected a tampered backup to be invalid")
+		} else if _, ok := err.(BackupValidationError); !ok {
+			t.Errorf("Expected a BackupValidationError, got: %v", err)
+		}
+	})
+
+	t.Run("Backups can't be restored to a different store type", func(t *testing.T) {
+		boltBackupManager := NewBackupManager(store, StoreConfig{Type: "boltdb"})
+
+		if _, err := boltBackupManager.RestoreBackup(backup.Name); err != ErrBackupStoreTypeMismatch {
+			t.Errorf("Expected ErrBackupStoreTypeMismatch, got: %v", err)
+		}
+	})
+
+	t.Run("Restored backups replace the current data", func(t *testing.T) {
+		if _, err := backupManager.RestoreBackup(backup.Name); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := backupManager.PendingRestore(); err != nil {
+			t.Fatalf("Expected a pending restore, got: %v", err)
+		}
+
+		// changes made after the restore was started are lost
+		if err := store.DeleteCustomRace(customRace); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.Remove(resultsFile); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := store.PurgeAccount(account.Name); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ApplyPendingRestore(storeConfig); err != nil {
+			t.Fatal(err)
+		}
+
+		restoredStore, err := storeConfig.BuildStore()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		restoredRace, err := restoredStore.FindCustomRaceByID(customRace.UUID.String())
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !restoredRace.Deleted.IsZero() {
+			t.Errorf("Expected the custom race to be restored")
+		}
+
+		if _, err := os.Stat(resultsFile); err != nil {
+			t.Errorf("Expected the results file to be restored, got: %v", err)
+		}
+
+		// accounts are kept in the shared directory, which is separate from the store directory here.
+		if _, err := restoredStore.FindAccountByName(account.Name); err != nil {
+			t.Errorf("Expected the account to be restored, got: %v", err)
+		}
+
+		if _, err := backupManager.PendingRestore(); err != ErrNoPendingRestore {
+			t.Errorf("Expected the pending restore to be cleared, got: %v", err)
+		}
+	})
+
+	t.Run("Old backups are pruned", func(t *testing.T) {
+		opts, err := store.LoadServerOptions()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		opts.BackupsToKeep = 2
+
+		if err := store.UpsertServerOptions(opts); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := backupManager.CreateBackup(BackupTriggerManual); err != nil {
+			t.Fatal(err)
+		}
+
+		backups, err := backupManager.ListBackups()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(backups) != 2 {
+			t.Errorf("Expected 2 backups to be kept, got %d", len(backups))
+		}
+	})
+}
+
+// tamperWithBackup copies a backup, changing the contents of one of its files.
+func tamperWithBackup(from, to, tamperedFile string) error {
+	r, err := zip.OpenReader(from)
+
+	if err != nil {
+		return err
+	}
+
+	defer r.Close()
+
+	f, err := os.Create(to)
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+
+	for _, file := range r.File {
+		fw, err := w.Create(file.Name)
+
+		if err != nil {
+			return err
+		}
+
+		if file.Name == tamperedFile {
+			if _, err := fw.Write([]byte("tampered")); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		rc, err := file.Open()
+
+		if err != nil {
+			return err
+		}
+
+		_, err = io.Copy(fw, rc)
+		rc.Close()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return w.Close()
+}
@@ -0,0 +1,549 @@
+package servermanager
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	blocklistMetaKey  = "blocklist"
+	BlocklistFilename = "blacklist.txt"
+
+	blocklistSyncInterval = time.Minute
+)
+
+var (
+	ErrBlocklistInvalidGUID = errors.New("servermanager: a valid driver GUID is needed to ban a driver")
+	ErrBlocklistNotBanned   = errors.New("servermanager: driver is not banned")
+)
+
+// BlocklistEntry is a ban of a single driver. Bans are never deleted, when a ban expires or the driver is unbanned it
+// is kept as part of the history of the blocklist.
+type BlocklistEntry struct {
+	ID         uuid.UUID `json:"ID"`
+	GUID       string    `json:"GUID"`
+	DriverName string    `json:"DriverName"`
+	Reason     string    `json:"Reason"`
+	IssuedBy   string    `json:"IssuedBy"`
+	Created    time.Time `json:"Created"`
+
+	// Expires is zero for permanent bans.
+	Expires time.Time `json:"Expires"`
+
+	// Lifted is when the ban ended, either because it expired or because the driver was unbanned.
+	Lifted   time.Time `json:"Lifted"`
+	LiftedBy string    `json:"LiftedBy"`
+}
+
+func (b *BlocklistEntry) IsPermanent() bool {
+	return b.Expires.IsZero()
+}
+
+func (b *BlocklistEntry) IsActive() bool {
+	return b.isActiveAt(time.Now())
+}
+
+func (b *BlocklistEntry) isActiveAt(t time.Time) bool {
+	return b.Lifted.IsZero() && (b.IsPermanent() || t.Before(b.Expires))
+}
+
+func (b *BlocklistEntry) Status() string {
+	switch {
+	case b.IsActive():
+		return "Active"
+	case b.LiftedBy == "":
+		return "Expired"
+	default:
+		return "Unbanned"
+	}
+}
+
+func (b *BlocklistEntry) Name() string {
+	if b.DriverName != "" {
+		return b.DriverName
+	}
+
+	return b.GUID
+}
+
+// Blocklist is the list of bans which is kept in the Store.
+type Blocklist struct {
+	Entries []*BlocklistEntry `json:"Entries"`
+
+	// FileGUIDs are the GUIDs which were last written to blacklist.txt. Any other GUIDs found in the file were added
+	// outside of Server Manager, e.g. by the server when it kicks a driver with blacklist mode 2.
+	FileGUIDs []string `json:"FileGUIDs"`
+}
+
+// ActiveGUIDs are the sorted GUIDs of every driver who is banned at the given time.
+func (b *Blocklist) ActiveGUIDs(t time.Time) []string {
+	seen := make(map[string]bool)
+
+	var out []string
+
+	for _, entry := range b.Entries {
+		if entry.isActiveAt(t) && !seen[entry.GUID] {
+			seen[entry.GUID] = true
+			out = append(out, entry.GUID)
+		}
+	}
+
+	sort.Strings(out)
+
+	return out
+}
+
+type blocklistDuration struct {
+	Name     string
+	Duration time.Duration
+}
+
+// blocklistDurations are the lengths of ban which can be chosen in the UI. A zero Duration is a permanent ban.
+var blocklistDurations = []blocklistDuration{
+	{"Permanent", 0},
+	{"1 Hour", time.Hour},
+	{"1 Day", 24 * time.Hour},
+	{"1 Week", 7 * 24 * time.Hour},
+	{"30 Days", 30 * 24 * time.Hour},
+}
+
+// BlocklistManager keeps the bans in the Store in sync with the blacklist.txt file read by the server.
+type BlocklistManager struct {
+	store Store
+	mutex sync.Mutex
+}
+
+func NewBlocklistManager(store Store) *BlocklistManager {
+	return &BlocklistManager{
+		store: store,
+	}
+}
+
+func (bm *BlocklistManager) load() (*Blocklist, error) {
+	blocklist := &Blocklist{}
+
+	err := bm.store.GetMeta(blocklistMetaKey, blocklist)
+
+	if err != nil && err != ErrValueNotSet {
+		return nil, err
+	}
+
+	return blocklist, nil
+}
+
+// ListBlocklist returns every ban, newest first.
+func (bm *BlocklistManager) ListBlocklist() ([]*BlocklistEntry, error) {
+	bm.mutex.Lock()
+	defer bm.mutex.Unlock()
+
+	blocklist, err := bm.load()
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(blocklist.Entries, func(i, j int) bool {
+		return blocklist.Entries[i].Created.After(blocklist.Entries[j].Created)
+	})
+
+	return blocklist.Entries, nil
+}
+
+// ActiveBans returns the bans which are currently in place, newest first.
+func (bm *BlocklistManager) ActiveBans() ([]*BlocklistEntry, error) {
+	entries, err := bm.ListBlocklist()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*BlocklistEntry
+
+	for _, entry := range entries {
+		if entry.IsActive() {
+			out = append(out, entry)
+		}
+	}
+
+	return out, nil
+}
+
+// Ban bans the driver with the given GUID until expires, or permanently if expires is zero. Any existing ban for the
+// driver is replaced.
+func (bm *BlocklistManager) Ban(guid, driverName, reason string, expires time.Time, account *Account) (*BlocklistEntry, error) {
+	guid = strings.TrimSpace(guid)
+
+	if guid == "" || strings.ContainsAny(guid, " \t\r\n") {
+		return nil, ErrBlocklistInvalidGUID
+	}
+
+	bm.mutex.Lock()
+	defer bm.mutex.Unlock()
+
+	blocklist, err := bm.load()
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	entry := &BlocklistEntry{
+		ID:         uuid.New(),
+		GUID:       guid,
+		DriverName: strings.TrimSpace(driverName),
+		Reason:     strings.TrimSpace(reason),
+		IssuedBy:   accountName(account),
+		Created:    now,
+		Expires:    expires,
+	}
+
+	for _, existing := range blocklist.Entries {
+		if existing.GUID == guid && existing.isActiveAt(now) {
+			existing.Lifted = now
+			existing.LiftedBy = entry.IssuedBy
+
+			if entry.DriverName == "" {
+				entry.DriverName = existing.DriverName
+			}
+		}
+	}
+
+	blocklist.Entries = append(blocklist.Entries, entry)
+
+	if err := bm.save(blocklist, now); err != nil {
+		return nil, err
+	}
+
+	details := fmt.Sprintf("Banned %s (%s)", entry.Name(), entry.GUID)
+
+	if entry.IsPermanent() {
+		details += " permanently"
+	} else {
+		details += " until " + entry.Expires.Format(time.RFC822)
+	}
+
+	if entry.Reason != "" {
+		details += ": " + entry.Reason
+	}
+
+	bm.audit(account, details)
+
+	return entry, nil
+}
+
+// Unban lifts every active ban for the driver with the given GUID.
+func (bm *BlocklistManager) Unban(guid string, account *Account) error {
+	guid = strings.TrimSpace(guid)
+
+	bm.mutex.Lock()
+	defer bm.mutex.Unlock()
+
+	blocklist, err := bm.load()
+
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	var lifted *BlocklistEntry
+
+	for _, entry := range blocklist.Entries {
+		if entry.GUID == guid && entry.isActiveAt(now) {
+			entry.Lifted = now
+			entry.LiftedBy = accountName(account)
+			lifted = entry
+		}
+	}
+
+	if lifted == nil {
+		return ErrBlocklistNotBanned
+	}
+
+	if err := bm.save(blocklist, now); err != nil {
+		return err
+	}
+
+	bm.audit(account, fmt.Sprintf("Unbanned %s (%s)", lifted.Name(), lifted.GUID))
+
+	return nil
+}
+
+// SyncBlocklistFile records any bans which were added to blacklist.txt outside of Server Manager, lifts any bans which
+// have expired and then writes the active bans to blacklist.txt.
+func (bm *BlocklistManager) SyncBlocklistFile() error {
+	bm.mutex.Lock()
+	defer bm.mutex.Unlock()
+
+	blocklist, err := bm.load()
+
+	if err != nil {
+		return err
+	}
+
+	return bm.save(blocklist, time.Now())
+}
+
+// save writes the blocklist to the Store and to blacklist.txt. bm.mutex must be held.
+func (bm *BlocklistManager) save(blocklist *Blocklist, now time.Time) error {
+	fileGUIDs, err := readBlocklistFile()
+
+	if err != nil {
+		return err
+	}
+
+	written := make(map[string]bool)
+
+	for _, guid := range blocklist.FileGUIDs {
+		written[guid] = true
+	}
+
+	active := make(map[string]bool)
+
+	for _, guid := range blocklist.ActiveGUIDs(now) {
+		active[guid] = true
+	}
+
+	for _, guid := range fileGUIDs {
+		if written[guid] || active[guid] {
+			continue
+		}
+
+		entry := &BlocklistEntry{
+			ID:       uuid.New(),
+			GUID:     guid,
+			Reason:   "Added to " + BlocklistFilename + " outside of Server Manager",
+			IssuedBy: BlocklistFilename,
+			Created:  now,
+		}
+
+		blocklist.Entries = append(blocklist.Entries, entry)
+		active[guid] = true
+
+		bm.audit(nil, fmt.Sprintf("Banned %s, found in %s", guid, BlocklistFilename))
+	}
+
+	for _, entry := range blocklist.Entries {
+		if entry.Lifted.IsZero() && !entry.isActiveAt(now) {
+			entry.Lifted = entry.Expires
+
+			bm.audit(nil, fmt.Sprintf("Ban for %s (%s) expired", entry.Name(), entry.GUID))
+		}
+	}
+
+	blocklist.FileGUIDs = blocklist.ActiveGUIDs(now)
+
+	if err := bm.store.SetMeta(blocklistMetaKey, blocklist); err != nil {
+		return err
+	}
+
+	if stringSlicesEqual(fileGUIDs, blocklist.FileGUIDs) {
+		return nil
+	}
+
+	return writeBlocklistFile(blocklist.FileGUIDs)
+}
+
+// WatchForExpiredBans periodically syncs blacklist.txt, so that temporary bans are removed from it when they expire.
+func (bm *BlocklistManager) WatchForExpiredBans() {
+	ticker := time.NewTicker(blocklistSyncInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := bm.SyncBlocklistFile(); err != nil {
+			logrus.WithError(err).Errorf("Could not sync %s", BlocklistFilename)
+		}
+	}
+}
+
+func (bm *BlocklistManager) audit(account *Account, details string) {
+	entry := &AuditEntry{
+		Method:  "BLOCKLIST",
+		URL:     "/blacklist",
+		Time:    time.Now(),
+		Details: details,
+	}
+
+	if account != nil {
+		entry.User = account.Name
+		entry.UserGroup = account.Group()
+	}
+
+	if err := bm.store.AddAuditEntry(entry); err != nil {
+		logrus.WithError(err).Errorf("Couldn't add audit entry for blocklist change")
+	}
+}
+
+func accountName(account *Account) string {
+	if account == nil {
+		return ""
+	}
+
+	return account.Name
+}
+
+// readBlocklistFile returns the sorted, unique GUIDs in blacklist.txt.
+func readBlocklistFile() ([]string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(ServerInstallPath, BlocklistFilename))
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+
+	var guids []string
+
+	for _, guid := range strings.Fields(string(b)) {
+		if !seen[guid] {
+			seen[guid] = true
+			guids = append(guids, guid)
+		}
+	}
+
+	sort.Strings(guids)
+
+	return guids, nil
+}
+
+func writeBlocklistFile(guids []string) error {
+	text := strings.Join(guids, "\n")
+
+	if text != "" {
+		text += "\n"
+	}
+
+	return ioutil.WriteFile(filepath.Join(ServerInstallPath, BlocklistFilename), []byte(text), 0644)
+}
+
+func stringSlicesEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+type BlocklistHandler struct {
+	*BaseHandler
+
+	blocklistManager *BlocklistManager
+	raceControl      *RaceControl
+}
+
+func NewBlocklistHandler(baseHandler *BaseHandler, blocklistManager *BlocklistManager, raceControl *RaceControl) *BlocklistHandler {
+	return &BlocklistHandler{
+		BaseHandler:      baseHandler,
+		blocklistManager: blocklistManager,
+		raceControl:      raceControl,
+	}
+}
+
+type blocklistTemplateVars struct {
+	BaseTemplateVars
+
+	Active  []*BlocklistEntry
+	History []*BlocklistEntry
+}
+
+func (bh *BlocklistHandler) list(w http.ResponseWriter, r *http.Request) {
+	if err := bh.blocklistManager.SyncBlocklistFile(); err != nil {
+		logrus.WithError(err).Errorf("Could not sync %s", BlocklistFilename)
+	}
+
+	entries, err := bh.blocklistManager.ListBlocklist()
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not load blocklist")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	vars := &blocklistTemplateVars{}
+
+	for _, entry := range entries {
+		if entry.IsActive() {
+			vars.Active = append(vars.Active, entry)
+		} else {
+			vars.History = append(vars.History, entry)
+		}
+	}
+
+	bh.viewRenderer.MustLoadTemplate(w, r, "server/blacklist.html", vars)
+}
+
+// ban bans a driver, kicking them from the server if they are connected.
+func (bh *BlocklistHandler) ban(w http.ResponseWriter, r *http.Request) {
+	guid := r.FormValue("GUID")
+	driverName := r.FormValue("DriverName")
+
+	if guid == "default-driver-spacer" {
+		// no drivers were connected when the live timing page was loaded
+		guid = ""
+	}
+
+	if driver, ok := bh.raceControl.ConnectedDrivers.Get(udp.DriverGUID(guid)); ok {
+		if driverName == "" {
+			driverName = driver.CarInfo.DriverName
+		}
+
+		if err := bh.raceControl.KickDriver(guid); err != nil {
+			logrus.WithError(err).Errorf("Could not kick banned driver: %s", guid)
+		}
+	}
+
+	var expires time.Time
+
+	if duration, err := time.ParseDuration(r.FormValue("Duration")); err == nil && duration > 0 {
+		expires = time.Now().Add(duration)
+	}
+
+	entry, err := bh.blocklistManager.Ban(guid, driverName, r.FormValue("Reason"), expires, AccountFromRequest(r))
+
+	if err == ErrBlocklistInvalidGUID {
+		AddErrorFlash(w, r, "A valid Driver GUID is needed to ban a driver")
+	} else if err != nil {
+		logrus.WithError(err).Errorf("Could not ban driver: %s", guid)
+		AddErrorFlash(w, r, "Could not ban driver")
+	} else {
+		AddFlash(w, r, fmt.Sprintf("%s has been banned", entry.Name()))
+	}
+
+	http.Redirect(w, r, r.Referer(), http.StatusFound)
+}
+
+func (bh *BlocklistHandler) unban(w http.ResponseWriter, r *http.Request) {
+	err := bh.blocklistManager.Unban(r.FormValue("GUID"), AccountFromRequest(r))
+
+	if err == ErrBlocklistNotBanned {
+		AddErrorFlash(w, r, "That driver is not banned")
+	} else if err != nil {
+		logrus.WithError(err).Errorf("Could not unban driver: %s", r.FormValue("GUID"))
+		AddErrorFlash(w, r, "Could not unban driver")
+	} else {
+		AddFlash(w, r, "Driver has been unbanned")
+	}
+
+	http.Redirect(w, r, r.Referer(), http.StatusFound)
+}
@@ -0,0 +1,176 @@
+package servermanager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBlocklistManager(t *testing.T) {
+	installPath, err := ioutil.TempDir("", "blocklist")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(installPath)
+
+	oldInstallPath := ServerInstallPath
+	ServerInstallPath = installPath
+
+	defer func() {
+		ServerInstallPath = oldInstallPath
+	}()
+
+	blocklistFile := filepath.Join(installPath, BlocklistFilename)
+
+	// a ban which was in blacklist.txt before the blocklist was stored
+	if err := ioutil.WriteFile(blocklistFile, []byte("76561198000000001\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store := NewJSONStore(filepath.Join(installPath, "store"), filepath.Join(installPath, "store-shared"))
+	blocklistManager := NewBlocklistManager(store)
+	admin := &Account{Name: "admin"}
+
+	fileGUIDs := func(t *testing.T) string {
+		b, err := ioutil.ReadFile(blocklistFile)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return strings.Join(strings.Fields(string(b)), ",")
+	}
+
+	t.Run("Existing bans are imported from blacklist.txt", func(t *testing.T) {
+		if err := blocklistManager.SyncBlocklistFile(); err != nil {
+			t.Fatal(err)
+		}
+
+		bans, err := blocklistManager.ActiveBans()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(bans) != 1 || bans[0].GUID != "76561198000000001" || !bans[0].IsPermanent() {
+			t.Errorf("Expected a permanent ban to be imported from %s, got %v", BlocklistFilename, bans)
+		}
+	})
+
+	t.Run("Bans are written to blacklist.txt", func(t *testing.T) {
+		entry, err := blocklistManager.Ban("76561198000000002", "Driver 2", "Wrong way driving", time.Time{}, admin)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if entry.IssuedBy != "admin" || entry.Reason != "Wrong way driving" {
+			t.Errorf("Expected the ban to record who issued it and why, got %v", entry)
+		}
+
+		if guids := fileGUIDs(t); guids != "76561198000000001,76561198000000002" {
+			t.Errorf("Expected both bans in %s, got %s", BlocklistFilename, guids)
+		}
+	})
+
+	t.Run("Invalid GUIDs cannot be banned", func(t *testing.T) {
+		if _, err := blocklistManager.Ban(" ", "", "", time.Time{}, admin); err != ErrBlocklistInvalidGUID {
+			t.Errorf("Expected ErrBlocklistInvalidGUID, got: %v", err)
+		}
+	})
+
+	t.Run("Unbanned drivers are removed from blacklist.txt", func(t *testing.T) {
+		if err := blocklistManager.Unban("76561198000000001", admin); err != nil {
+			t.Fatal(err)
+		}
+
+		if guids := fileGUIDs(t); guids != "76561198000000002" {
+			t.Errorf("Expected only the remaining ban in %s, got %s", BlocklistFilename, guids)
+		}
+
+		if err := blocklistManager.Unban("76561198000000001", admin); err != ErrBlocklistNotBanned {
+			t.Errorf("Expected ErrBlocklistNotBanned, got: %v", err)
+		}
+	})
+
+	t.Run("Expired bans are removed from blacklist.txt", func(t *testing.T) {
+		if _, err := blocklistManager.Ban("76561198000000003", "Driver 3", "", time.Now().Add(time.Millisecond), admin); err != nil {
+			t.Fatal(err)
+		}
+
+		if guids := fileGUIDs(t); guids != "76561198000000002,76561198000000003" {
+			t.Errorf("Expected the temporary ban in %s, got %s", BlocklistFilename, guids)
+		}
+
+		time.Sleep(5 * time.Millisecond)
+
+		if err := blocklistManager.SyncBlocklistFile(); err != nil {
+			t.Fatal(err)
+		}
+
+		if guids := fileGUIDs(t); guids != "76561198000000002" {
+			t.Errorf("Expected the expired ban to be removed from %s, got %s", BlocklistFilename, guids)
+		}
+
+		entries, err := blocklistManager.ListBlocklist()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, entry := range entries {
+			if entry.GUID == "76561198000000003" && entry.Status() != "Expired" {
+				t.Errorf("Expected the ban to have expired, got %s", entry.Status())
+			}
+		}
+	})
+
+	t.Run("Drivers added to blacklist.txt by the server are banned", func(t *testing.T) {
+		if err := ioutil.WriteFile(blocklistFile, []byte("76561198000000002\n76561198000000004\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := blocklistManager.SyncBlocklistFile(); err != nil {
+			t.Fatal(err)
+		}
+
+		bans, err := blocklistManager.ActiveBans()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(bans) != 2 {
+			t.Errorf("Expected 2 active bans, got %d", len(bans))
+		}
+
+		if guids := fileGUIDs(t); guids != "76561198000000002,76561198000000004" {
+			t.Errorf("Expected the server's ban to be kept in %s, got %s", BlocklistFilename, guids)
+		}
+	})
+
+	t.Run("Changes are audit logged", func(t *testing.T) {
+		entries, err := store.GetAuditEntries()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var details []string
+
+		for _, entry := range entries {
+			if entry.Method == "BLOCKLIST" {
+				details = append(details, entry.Details)
+			}
+		}
+
+		if len(details) != 6 {
+			t.Errorf("Expected 6 audit entries (2 imports, 2 bans, 1 unban and 1 expiry), got %d: %v", len(details), details)
+		}
+	})
+}
@@ -0,0 +1,377 @@
+package servermanager
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"github.com/sirupsen/logrus"
+)
+
+// championshipRoundPoints are the points that each driver in a class scored in each completed round of a Championship.
+type championshipRoundPoints struct {
+	Rounds []*ChampionshipEvent
+
+	// Points are the points scored in each round, by driver GUID.
+	Points map[string][]float64
+}
+
+// roundPoints builds the points scored by each driver in the class in each completed round of the Championship.
+func (c *ChampionshipClass) roundPoints(championship *Championship) *championshipRoundPoints {
+	out := &championshipRoundPoints{
+		Points: make(map[string][]float64),
+	}
+
+	completed := make(map[string]bool)
+
+	for _, roundID := range completedChampionshipRounds(ExtractRaceWeekendSessionsIntoIndividualEvents(championship.Events)) {
+		completed[roundID.String()] = true
+	}
+
+	for _, event := range championship.Events {
+		if completed[event.ID.String()] {
+			out.Rounds = append(out.Rounds, event)
+		}
+	}
+
+	for roundIndex, event := range out.Rounds {
+		for _, standing := range c.StandingsForEvent(championship, event) {
+			guid := standing.Car.GetGUID()
+
+			if _, ok := out.Points[guid]; !ok {
+				out.Points[guid] = make([]float64, len(out.Rounds))
+			}
+
+			out.Points[guid][roundIndex] = standing.Points
+		}
+	}
+
+	return out
+}
+
+// championshipExportEventName is a short name for an event in a Championship export.
+func championshipExportEventName(roundNumber int, event *ChampionshipEvent) string {
+	name := prettifyName(event.RaceSetup.Track, false)
+
+	if event.IsRaceWeekend() {
+		name = "Race Weekend"
+
+		if event.RaceWeekend != nil {
+			name = event.RaceWeekend.Name
+		}
+	} else if info := trackInfo(event.RaceSetup.Track, event.RaceSetup.TrackLayout); info != nil && info.Name != "" {
+		name = info.Name
+	}
+
+	return fmt.Sprintf("R%d %s", roundNumber, name)
+}
+
+// championshipExport builds the tables in a Championship export. Full driver names and GUIDs are only included for
+// organisers, everyone else sees driver names as they are shown on the Championship page.
+type championshipExport struct {
+	championship *Championship
+	organiser    bool
+}
+
+func (e *championshipExport) driverName(name string) string {
+	if e.organiser {
+		return name
+	}
+
+	return driverName(name)
+}
+
+// withGUID adds the GUID header or cell after the driver name for organisers.
+func (e *championshipExport) withGUID(cells []interface{}, guid interface{}) []interface{} {
+	if !e.organiser {
+		return cells
+	}
+
+	return append(cells, guid)
+}
+
+func (e *championshipExport) DriverStandings() *spreadsheetTable {
+	table := newSpreadsheetTable("Driver Standings", e.withGUID([]interface{}{"Class", "Position", "Driver"}, "GUID")...)
+	table.Rows[0] = append(table.Rows[0], "Team", "Car", "Points", "Dropped Points", "Points Penalty")
+
+	for _, class := range e.championship.Classes {
+		for pos, standing := range class.Standings(e.championship, e.championship.Events) {
+			row := e.withGUID([]interface{}{class.Name, pos + 1, e.driverName(standing.Car.GetName())}, standing.Car.GetGUID())
+			row = append(row,
+				standing.TeamSummary(),
+				prettifyName(standing.Car.GetCar(), true),
+				standing.Points,
+				standing.DroppedPoints,
+				class.PenaltyForGUID(standing.Car.Driver.GUID),
+			)
+
+			table.AddRow(row...)
+		}
+	}
+
+	return table
+}
+
+func (e *championshipExport) TeamStandings() *spreadsheetTable {
+	table := newSpreadsheetTable("Team Standings", "Class", "Position", "Team", "Points", "Points Penalty")
+
+	for _, class := range e.championship.Classes {
+		for pos, standing := range class.TeamStandings(e.championship, e.championship.Events) {
+			table.AddRow(class.Name, pos+1, standing.Team, standing.Points, class.PenaltyForTeam(standing.Team))
+		}
+	}
+
+	return table
+}
+
+func (e *championshipExport) RoundPoints() *spreadsheetTable {
+	var table *spreadsheetTable
+
+	for _, class := range e.championship.Classes {
+		roundPoints := class.roundPoints(e.championship)
+
+		if table == nil {
+			headers := e.withGUID([]interface{}{"Class", "Driver"}, "GUID")
+
+			for i, event := range roundPoints.Rounds {
+				headers = append(headers, championshipExportEventName(i+1, event))
+			}
+
+			table = newSpreadsheetTable("Round Points", append(headers, "Total")...)
+		}
+
+		for _, standing := range class.Standings(e.championship, e.championship.Events) {
+			guid := standing.Car.GetGUID()
+			row := e.withGUID([]interface{}{class.Name, e.driverName(standing.Car.GetName())}, guid)
+
+			total := 0.0
+
+			for roundIndex := range roundPoints.Rounds {
+				points := 0.0
+
+				if driverPoints, ok := roundPoints.Points[guid]; ok {
+					points = driverPoints[roundIndex]
+				}
+
+				total += points
+				row = append(row, points)
+			}
+
+			table.AddRow(append(row, total)...)
+		}
+	}
+
+	if table == nil {
+		table = newSpreadsheetTable("Round Points", e.withGUID([]interface{}{"Class", "Driver"}, "GUID")...)
+	}
+
+	return table
+}
+
+func (e *championshipExport) Penalties() *spreadsheetTable {
+	table := newSpreadsheetTable("Penalties", e.withGUID([]interface{}{"Class", "Type", "Name"}, "GUID")...)
+	table.Rows[0] = append(table.Rows[0], "Points")
+
+	names := make(map[string]string)
+
+	for _, entrant := range e.championship.AllEntrants() {
+		names[entrant.GUID] = entrant.Name
+	}
+
+	for _, class := range e.championship.Classes {
+		for _, standing := range class.Standings(e.championship, e.championship.Events, StandingsNoPointsPenalties) {
+			names[standing.Car.Driver.GUID] = standing.Car.GetName()
+		}
+
+		var guids, teams []string
+
+		for guid, penalty := range class.DriverPenalties {
+			if penalty != 0 {
+				guids = append(guids, guid)
+			}
+		}
+
+		for team, penalty := range class.TeamPenalties {
+			if penalty != 0 {
+				teams = append(teams, team)
+			}
+		}
+
+		sort.Strings(guids)
+		sort.Strings(teams)
+
+		for _, guid := range guids {
+			row := e.withGUID([]interface{}{class.Name, "Driver", e.driverName(names[guid])}, guid)
+			table.AddRow(append(row, class.DriverPenalties[guid])...)
+		}
+
+		for _, team := range teams {
+			row := e.withGUID([]interface{}{class.Name, "Team", team}, "")
+			table.AddRow(append(row, class.TeamPenalties[team])...)
+		}
+	}
+
+	return table
+}
+
+func (e *championshipExport) Entrants() *spreadsheetTable {
+	table := newSpreadsheetTable("Entrants", e.withGUID([]interface{}{"Class", "Name"}, "GUID")...)
+	table.Rows[0] = append(table.Rows[0], "Team", "Car", "Skin", "Ballast", "Restrictor")
+
+	for _, class := range e.championship.Classes {
+		for _, entrant := range class.Entrants.AsSlice() {
+			if entrant.Name == "" && entrant.GUID == "" {
+				// empty slots in an open championship
+				continue
+			}
+
+			row := e.withGUID([]interface{}{class.Name, e.driverName(entrant.Name)}, entrant.GUID)
+			row = append(row,
+				entrant.Team,
+				prettifyName(entrant.Model, true),
+				prettifyName(entrant.Skin, true),
+				entrant.Ballast,
+				entrant.Restrictor,
+			)
+
+			table.AddRow(row...)
+		}
+	}
+
+	return table
+}
+
+// championshipExportTables maps the name of each table in an export (as used in CSV export URLs) to the table.
+var championshipExportTables = []struct {
+	Name  string
+	Table func(e *championshipExport) *spreadsheetTable
+}{
+	{"driver-standings", (*championshipExport).DriverStandings},
+	{"team-standings", (*championshipExport).TeamStandings},
+	{"round-points", (*championshipExport).RoundPoints},
+	{"penalties", (*championshipExport).Penalties},
+	{"entrants", (*championshipExport).Entrants},
+}
+
+func (ch *ChampionshipsHandler) newChampionshipExport(w http.ResponseWriter, r *http.Request) (*championshipExport, bool) {
+	championship, err := ch.championshipManager.LoadChampionship(chi.URLParam(r, "championshipID"))
+
+	if err != nil {
+		logrus.WithError(err).Errorf("couldn't export championship")
+		http.NotFound(w, r)
+		return nil, false
+	}
+
+	return &championshipExport{
+		championship: championship,
+		organiser:    AccountFromRequest(r).HasGroupPrivilege(GroupWrite),
+	}, true
+}
+
+// exportCSV exports a single table of the Championship as a CSV file.
+func (ch *ChampionshipsHandler) exportCSV(w http.ResponseWriter, r *http.Request) {
+	export, ok := ch.newChampionshipExport(w, r)
+
+	if !ok {
+		return
+	}
+
+	tableName := chi.URLParam(r, "table")
+
+	for _, exportTable := range championshipExportTables {
+		if exportTable.Name != tableName {
+			continue
+		}
+
+		w.Header().Add("Content-Type", "text/csv")
+		w.Header().Add("Content-Disposition", fmt.Sprintf(`attachment; filename="%s %s.csv"`, export.championship.Name, strings.Title(strings.Replace(tableName, "-", " ", -1))))
+
+		if err := exportTable.Table(export).WriteCSV(w); err != nil {
+			logrus.WithError(err).Errorf("couldn't write championship csv export")
+		}
+
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+// exportXLSX exports every table of the Championship as the sheets of an XLSX workbook.
+func (ch *ChampionshipsHandler) exportXLSX(w http.ResponseWriter, r *http.Request) {
+	export, ok := ch.newChampionshipExport(w, r)
+
+	if !ok {
+		return
+	}
+
+	var tables []*spreadsheetTable
+
+	for _, exportTable := range championshipExportTables {
+		tables = append(tables, exportTable.Table(export))
+	}
+
+	w.Header().Add("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	w.Header().Add("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.xlsx"`, export.championship.Name))
+
+	if err := writeXLSX(w, tables); err != nil {
+		logrus.WithError(err).Errorf("couldn't write championship xlsx export")
+	}
+}
+
+type championshipPrintClass struct {
+	Class         *ChampionshipClass
+	Standings     []*ChampionshipStanding
+	TeamStandings []*TeamStanding
+	RoundPoints   *championshipRoundPoints
+}
+
+// RoundPointsForDriver are the points the driver scored in each round.
+func (c *championshipPrintClass) RoundPointsForDriver(guid string) []float64 {
+	if points, ok := c.RoundPoints.Points[guid]; ok {
+		return points
+	}
+
+	return make([]float64, len(c.RoundPoints.Rounds))
+}
+
+type championshipPrintTemplateVars struct {
+	BaseTemplateVars
+
+	Championship *Championship
+	Classes      []*championshipPrintClass
+}
+
+// print shows the Championship standings on a page laid out for printing.
+func (ch *ChampionshipsHandler) print(w http.ResponseWriter, r *http.Request) {
+	championship, err := ch.championshipManager.LoadChampionship(chi.URLParam(r, "championshipID"))
+
+	if err != nil {
+		logrus.WithError(err).Errorf("couldn't load championship")
+		http.NotFound(w, r)
+		return
+	}
+
+	var classes []*championshipPrintClass
+
+	for _, class := range championship.Classes {
+		printClass := &championshipPrintClass{
+			Class:       class,
+			Standings:   class.Standings(championship, championship.Events),
+			RoundPoints: class.roundPoints(championship),
+		}
+
+		if championship.HasTeamNames() {
+			printClass.TeamStandings = class.TeamStandings(championship, championship.Events)
+		}
+
+		classes = append(classes, printClass)
+	}
+
+	ch.viewRenderer.MustLoadTemplate(w, r, "championships/print.html", &championshipPrintTemplateVars{
+		BaseTemplateVars: BaseTemplateVars{WideContainer: true},
+		Championship:     championship,
+		Classes:          classes,
+	})
+}
@@ -0,0 +1,79 @@
+package servermanager
+
+import (
+	"testing"
+)
+
+func TestChampionshipExport(t *testing.T) {
+	// round 1: driver-1 10, driver-2 5, driver-3 2. round 2: driver-2 10, driver-1 5, driver-3 2.
+	championship, class := newSuccessBallastTestChampionship(SuccessBallastRules{}, []int{1, 2, 3}, []int{2, 1, 3})
+	class.DriverPenalties = map[string]int{"driver-3": 1}
+
+	t.Run("Round points", func(t *testing.T) {
+		roundPoints := class.roundPoints(championship)
+
+		if len(roundPoints.Rounds) != 2 {
+			t.Fatalf("Expected the two completed rounds, got %d", len(roundPoints.Rounds))
+		}
+
+		expected := map[string][]float64{
+			"driver-1": {10, 5},
+			"driver-2": {5, 10},
+			"driver-3": {2, 2},
+		}
+
+		for guid, points := range expected {
+			for i := range points {
+				if roundPoints.Points[guid][i] != points[i] {
+					t.Errorf("Expected %s to have %v points by round, got %v", guid, points, roundPoints.Points[guid])
+					break
+				}
+			}
+		}
+	})
+
+	t.Run("Organiser tables", func(t *testing.T) {
+		export := &championshipExport{championship: championship, organiser: true}
+
+		standings := export.DriverStandings()
+
+		if len(standings.Body()) != 3 {
+			t.Fatalf("Expected 3 drivers in the standings, got %d", len(standings.Body()))
+		}
+
+		// driver-1 and driver-2 are tied on 15 points, driver-3 has 4 less 1 penalty point
+		last := standings.Body()[2]
+
+		if last[1] != 3 || last[3] != "driver-3" || last[6] != 3.0 || last[8] != 1 {
+			t.Errorf("Expected driver-3 to be third on 3 points with a 1 point penalty, got %v", last)
+		}
+
+		roundPoints := export.RoundPoints()
+
+		if headers := roundPoints.Headers(); len(headers) != 6 || headers[5] != "Total" {
+			t.Errorf("Expected a column for each round and the total, got %v", headers)
+		}
+
+		penalties := export.Penalties()
+
+		if len(penalties.Body()) != 1 || penalties.Body()[0][3] != "driver-3" || penalties.Body()[0][4] != 1 {
+			t.Errorf("Expected driver-3's penalty to be exported, got %v", penalties.Body())
+		}
+
+		if entrants := export.Entrants(); len(entrants.Body()) != 3 {
+			t.Errorf("Expected 3 entrants, got %d", len(entrants.Body()))
+		}
+	})
+
+	t.Run("Public tables", func(t *testing.T) {
+		export := &championshipExport{championship: championship}
+
+		for _, exportTable := range championshipExportTables {
+			for _, header := range exportTable.Table(export).Headers() {
+				if header == "GUID" {
+					t.Errorf("Expected %s not to include driver GUIDs", exportTable.Name)
+				}
+			}
+		}
+	})
+}
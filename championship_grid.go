@@ -0,0 +1,221 @@
+package servermanager
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+// ChampionshipGridType decides how the starting grid of a Championship Event is formed.
+type ChampionshipGridType string
+
+const (
+	// ChampionshipGridEntryList uses the order of the Championship's entry list.
+	ChampionshipGridEntryList ChampionshipGridType = ""
+	// ChampionshipGridStandings puts the Championship leader on pole.
+	ChampionshipGridStandings ChampionshipGridType = "standings"
+	// ChampionshipGridReverseStandings reverses the top N drivers in the Championship standings.
+	ChampionshipGridReverseStandings ChampionshipGridType = "reverse-standings"
+	// ChampionshipGridHandicap puts the Championship leader at the back of the grid, and each driver waits longer
+	// than the driver behind them in the standings before starting the race. Handicaps are a fixed time per position
+	// and are advisory: drivers are told theirs in the chat, but early starts aren't detected or penalised.
+	ChampionshipGridHandicap ChampionshipGridType = "handicap"
+)
+
+// ChampionshipGridRules form the starting grid of a Championship Event from the Championship standings. They only
+// affect events without a qualifying session, as Assetto Corsa forms the grid from qualifying if there is one.
+type ChampionshipGridRules struct {
+	Type ChampionshipGridType
+
+	// NumToReverse is the number of drivers at the top of the standings to reverse, for ChampionshipGridReverseStandings.
+	// -1 reverses all of them.
+	NumToReverse int
+
+	// HandicapSecondsPerPosition is how much longer each driver waits than the driver behind them in the standings, for
+	// ChampionshipGridHandicap.
+	HandicapSecondsPerPosition int
+}
+
+// Enabled is true if the rules change the order of the entry list.
+func (g ChampionshipGridRules) Enabled() bool {
+	return g.Type != ChampionshipGridEntryList
+}
+
+func (g ChampionshipGridRules) String() string {
+	switch g.Type {
+	case ChampionshipGridStandings:
+		return "Grid by Championship standings"
+	case ChampionshipGridReverseStandings:
+		if g.NumToReverse < 0 {
+			return "Grid by reversed Championship standings"
+		}
+
+		return fmt.Sprintf("Grid by Championship standings, with the top %d reversed", g.NumToReverse)
+	case ChampionshipGridHandicap:
+		return fmt.Sprintf("Handicap start, %ds per Championship position", g.HandicapSecondsPerPosition)
+	default:
+		return "Grid by entry list"
+	}
+}
+
+// ChampionshipGridSlot is a driver's place on the starting grid of a Championship Event.
+type ChampionshipGridSlot struct {
+	PitBox     int
+	DriverGUID string
+	DriverName string
+
+	// Handicap is how long the driver waits after the start of the race before they can start.
+	Handicap time.Duration
+}
+
+// applyGridRules reorders the entrants of each class in the entryList using the event's grid rules, and returns the
+// resulting grid. Classes keep the pit boxes they were given in the entry list, and entrants who haven't scored
+// any points start at the back of their class.
+func (cr *ChampionshipEvent) applyGridRules(championship *Championship, entryList EntryList) []*ChampionshipGridSlot {
+	if !cr.GridRules.Enabled() {
+		return nil
+	}
+
+	var grid []*ChampionshipGridSlot
+
+	entrants := entryList.AsSlice()
+
+	for _, class := range championship.Classes {
+		classCars := make(map[string]bool)
+
+		for _, car := range class.ValidCarIDs() {
+			classCars[car] = true
+		}
+
+		var pitBoxes []int
+		var classEntrants []*Entrant
+
+		for _, entrant := range entrants {
+			if championship.HasSpectatorCar() && entrant.GUID == championship.SpectatorCar.GUID {
+				continue
+			}
+
+			if classCars[entrant.Model] {
+				pitBoxes = append(pitBoxes, entrant.PitBox)
+				classEntrants = append(classEntrants, entrant)
+			}
+		}
+
+		if len(classEntrants) == 0 {
+			continue
+		}
+
+		standings := make(map[string]int)
+
+		for pos, standing := range class.Standings(championship, championship.Events) {
+			// drivers without points have no meaningful order in the standings, so they keep their entry list order
+			if standing.Points <= 0 {
+				continue
+			}
+
+			standings[standing.Car.GetGUID()] = pos
+		}
+
+		numInStandings := sortEntrantsByStandings(classEntrants, standings)
+
+		switch cr.GridRules.Type {
+		case ChampionshipGridReverseStandings:
+			numToReverse := cr.GridRules.NumToReverse
+
+			if numToReverse < 0 || numToReverse > numInStandings {
+				numToReverse = numInStandings
+			}
+
+			reverseEntrantOrder(classEntrants[:numToReverse])
+		case ChampionshipGridHandicap:
+			reverseEntrantOrder(classEntrants)
+		}
+
+		for i, entrant := range classEntrants {
+			// copy the entrant so that the Championship's own entry list keeps its order
+			gridEntrant := *entrant
+			gridEntrant.PitBox = pitBoxes[i]
+
+			entryList[fmt.Sprintf("CAR_%d", pitBoxes[i])] = &gridEntrant
+
+			slot := &ChampionshipGridSlot{
+				PitBox:     pitBoxes[i],
+				DriverGUID: entrant.GUID,
+				DriverName: entrant.Name,
+			}
+
+			if cr.GridRules.Type == ChampionshipGridHandicap {
+				slot.Handicap = time.Duration(i*cr.GridRules.HandicapSecondsPerPosition) * time.Second
+			}
+
+			grid = append(grid, slot)
+		}
+	}
+
+	sort.Slice(grid, func(i, j int) bool {
+		return grid[i].PitBox < grid[j].PitBox
+	})
+
+	return grid
+}
+
+// sortEntrantsByStandings sorts entrants by their position in the standings, and returns how many of them are in the
+// standings. Entrants who aren't in the standings keep their order, behind those who are.
+func sortEntrantsByStandings(entrants []*Entrant, standings map[string]int) int {
+	sort.SliceStable(entrants, func(i, j int) bool {
+		posI, okI := standings[entrants[i].GUID]
+		posJ, okJ := standings[entrants[j].GUID]
+
+		if okI && okJ {
+			return posI < posJ
+		}
+
+		return okI && !okJ
+	})
+
+	numInStandings := 0
+
+	for _, entrant := range entrants {
+		if _, ok := standings[entrant.GUID]; ok {
+			numInStandings++
+		}
+	}
+
+	return numInStandings
+}
+
+func reverseEntrantOrder(entrants []*Entrant) {
+	for i, j := 0, len(entrants)-1; i < j; i, j = i+1, j-1 {
+		entrants[i], entrants[j] = entrants[j], entrants[i]
+	}
+}
+
+// sendHandicapMessage tells a driver how long they must wait before starting a handicap race.
+func (cm *ChampionshipManager) sendHandicapMessage(slot *ChampionshipGridSlot) {
+	if slot.DriverGUID == "" {
+		return
+	}
+
+	var message string
+
+	if slot.Handicap == 0 {
+		message = "This is a handicap race. You can start as soon as the race begins."
+	} else {
+		message = fmt.Sprintf("This is a handicap race. You must wait %s after the race begins before you start. Early starts may be penalised by the stewards.", slot.Handicap.String())
+	}
+
+	sendChat, err := udp.NewSendChat(udp.CarID(slot.PitBox), message)
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Unable to build handicap message for: %s", slot.DriverName)
+		return
+	}
+
+	if err := cm.process.SendUDPMessage(sendChat); err != nil {
+		logrus.WithError(err).Errorf("Unable to send handicap message to: %s", slot.DriverName)
+	}
+}
@@ -0,0 +1,129 @@
+package servermanager
+
+import (
+	"testing"
+	"time"
+)
+
+func gridTestOrder(entryList EntryList) []string {
+	var order []string
+
+	for _, entrant := range entryList.AsSlice() {
+		order = append(order, entrant.GUID)
+	}
+
+	return order
+}
+
+func TestChampionshipEvent_ApplyGridRules(t *testing.T) {
+	// standings after these rounds: driver-3 20, driver-2 10, driver-1 4
+	rounds := [][]int{{3, 2, 1}, {3, 2, 1}}
+
+	testCases := []struct {
+		name      string
+		rules     ChampionshipGridRules
+		rounds    [][]int
+		order     []string
+		handicaps []time.Duration
+	}{
+		{
+			name:   "Entry list",
+			rules:  ChampionshipGridRules{},
+			rounds: rounds,
+		},
+		{
+			name:   "Standings",
+			rules:  ChampionshipGridRules{Type: ChampionshipGridStandings},
+			rounds: rounds,
+			order:  []string{"driver-3", "driver-2", "driver-1"},
+		},
+		{
+			name:   "Reverse top two",
+			rules:  ChampionshipGridRules{Type: ChampionshipGridReverseStandings, NumToReverse: 2},
+			rounds: rounds,
+			order:  []string{"driver-2", "driver-3", "driver-1"},
+		},
+		{
+			name:   "Reverse all",
+			rules:  ChampionshipGridRules{Type: ChampionshipGridReverseStandings, NumToReverse: -1},
+			rounds: rounds,
+			order:  []string{"driver-1", "driver-2", "driver-3"},
+		},
+		{
+			name:  "Reverse with no standings",
+			rules: ChampionshipGridRules{Type: ChampionshipGridReverseStandings, NumToReverse: -1},
+		},
+		{
+			name:      "Handicap",
+			rules:     ChampionshipGridRules{Type: ChampionshipGridHandicap, HandicapSecondsPerPosition: 10},
+			rounds:    rounds,
+			order:     []string{"driver-1", "driver-2", "driver-3"},
+			handicaps: []time.Duration{0, 10 * time.Second, 20 * time.Second},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			championship, _ := newSuccessBallastTestChampionship(SuccessBallastRules{}, testCase.rounds...)
+
+			event := championship.Events[len(championship.Events)-1]
+			event.GridRules = testCase.rules
+			entryList := event.CombineEntryLists(championship)
+			entryListOrder := gridTestOrder(entryList)
+
+			grid := event.applyGridRules(championship, entryList)
+			order := gridTestOrder(entryList)
+
+			expectedOrder := testCase.order
+
+			if expectedOrder == nil {
+				// drivers without points keep their entry list order
+				expectedOrder = entryListOrder
+			}
+
+			if len(order) != len(expectedOrder) {
+				t.Fatalf("Expected %d entrants, got %d", len(expectedOrder), len(order))
+			}
+
+			for i, guid := range expectedOrder {
+				if order[i] != guid {
+					t.Errorf("Expected %s in grid slot %d, got %s", guid, i, order[i])
+				}
+			}
+
+			if !testCase.rules.Enabled() {
+				if grid != nil {
+					t.Errorf("Expected no grid to be recorded, got %d slots", len(grid))
+				}
+
+				return
+			}
+
+			if len(grid) != len(expectedOrder) {
+				t.Fatalf("Expected %d grid slots, got %d", len(expectedOrder), len(grid))
+			}
+
+			for i, slot := range grid {
+				if slot.DriverGUID != expectedOrder[i] || slot.PitBox != i {
+					t.Errorf("Expected %s in pit box %d, got %s in pit box %d", expectedOrder[i], i, slot.DriverGUID, slot.PitBox)
+				}
+
+				var handicap time.Duration
+
+				if testCase.handicaps != nil {
+					handicap = testCase.handicaps[i]
+				}
+
+				if slot.Handicap != handicap {
+					t.Errorf("Expected %s to have a handicap of %s, got %s", slot.DriverGUID, handicap, slot.Handicap)
+				}
+			}
+
+			for i, entrant := range championship.Classes[0].Entrants.AsSlice() {
+				if entrant.GUID != entryListOrder[i] || entrant.PitBox != i {
+					t.Errorf("Expected the Championship's entry list to be unchanged, %s is in pit box %d", entrant.GUID, entrant.PitBox)
+				}
+			}
+		})
+	}
+}
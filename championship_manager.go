@@ -8,6 +8,7 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"os"
 	"path/filepath"
 	"regexp"
 	"sort"
@@ -292,6 +293,16 @@ func (cm *ChampionshipManager) HandleCreateChampionship(r *http.Request) (champi
 	championship.SignUpForm.HideCarChoice = !(r.FormValue("Championship.SignUpForm.HideCarChoice") == "on" || r.FormValue("Championship.SignUpForm.HideCarChoice") == "1")
 	championship.SignUpForm.RequiresApproval = r.FormValue("Championship.SignUpForm.RequiresApproval") == "on" || r.FormValue("Championship.SignUpForm.RequiresApproval") == "1"
 
+	for i, eventID := range r.Form["Events.ID"] {
+		event, _, err := championship.EventByID(eventID)
+
+		if err != nil || i >= len(r.Form["Events.PointsMultiplier"]) {
+			continue
+		}
+
+		event.PointsMultiplier = formValueAsFloat(r.Form["Events.PointsMultiplier"][i])
+	}
+
 	championship.SignUpForm.ExtraFields = []string{}
 
 	for _, question := range r.Form["Championship.SignUpForm.ExtraFields"] {
@@ -377,9 +388,21 @@ func (cm *ChampionshipManager) HandleCreateChampionship(r *http.Request) (champi
 			class.Points.Places = append(class.Points.Places, formValueAsInt(r.Form["Points.Place"][i]))
 		}
 
-		class.Points.PolePosition = formValueAsInt(r.Form["Points.PolePosition"][i])
+		class.Points.QualifyingPlaces = formValueAsIntSlice(r.Form["Points.QualifyingPlaces"][i])
 		class.Points.BestLap = formValueAsInt(r.Form["Points.BestLap"][i])
+		class.Points.BestLapMaxPosition = formValueAsInt(r.Form["Points.BestLapMaxPosition"][i])
+		class.Points.MinimumRaceDistance = formValueAsInt(r.Form["Points.MinimumRaceDistance"][i])
 		class.Points.SecondRaceMultiplier = formValueAsFloat(r.Form["Points.SecondRaceMultiplier"][i])
+		class.Points.FinalRoundMultiplier = formValueAsFloat(r.Form["Points.FinalRoundMultiplier"][i])
+		class.Points.DropWorstRounds = formValueAsInt(r.Form["Points.DropWorstRounds"][i])
+
+		class.SuccessBallast = SuccessBallastRules{
+			Basis:         SuccessBallastBasis(r.Form["SuccessBallast.Basis"][i]),
+			Ballast:       formValueAsIntSlice(r.Form["SuccessBallast.Ballast"][i]),
+			Restrictor:    formValueAsIntSlice(r.Form["SuccessBallast.Restrictor"][i]),
+			MaxBallast:    formValueAsInt(r.Form["SuccessBallast.MaxBallast"][i]),
+			MaxRestrictor: formValueAsInt(r.Form["SuccessBallast.MaxRestrictor"][i]),
+		}
 		class.Points.CollisionWithDriver = formValueAsInt(r.Form["Points.CollisionWithDriver"][i])
 		class.Points.CollisionWithEnv = formValueAsInt(r.Form["Points.CollisionWithEnv"][i])
 		class.Points.CutTrack = formValueAsInt(r.Form["Points.CutTrack"][i])
@@ -522,6 +545,7 @@ func (cm *ChampionshipManager) BuildChampionshipEventOpts(r *http.Request) (*Rac
 		opts.EditingID = editEventID
 		opts.CurrentEntrants = event.CombineEntryLists(championship)
 		opts.ChampionshipEventIndex = eventIndex
+		opts.ChampionshipEventGridRules = event.GridRules
 	} else {
 		// creating a new championship event
 		opts.IsEditing = false
@@ -617,6 +641,12 @@ func (cm *ChampionshipManager) SaveChampionshipEvent(r *http.Request) (champions
 		championship.Events = append(championship.Events, event)
 	}
 
+	event.GridRules = ChampionshipGridRules{
+		Type:                       ChampionshipGridType(r.FormValue("GridRules.Type")),
+		NumToReverse:               formValueAsInt(r.FormValue("GridRules.NumToReverse")),
+		HandicapSecondsPerPosition: formValueAsInt(r.FormValue("GridRules.HandicapSecondsPerPosition")),
+	}
+
 	return championship, event, edited, cm.UpsertChampionship(championship)
 }
 
@@ -708,6 +738,13 @@ func (cm *ChampionshipManager) FinalEventConfigurationFiles(championship *Champi
 		entryList = nil
 	} else {
 		raceSetup.MaxClients = len(entryList)
+
+		championship.applyTeamNames(entryList, time.Now())
+
+		if !isPreChampionshipPracticeEvent {
+			event.Grid = event.applyGridRules(championship, entryList)
+			event.SuccessBallast = championship.applySuccessBallast(event, raceSetup, entryList)
+		}
 	}
 
 	return raceSetup, entryList
@@ -722,6 +759,17 @@ func (cm *ChampionshipManager) StartEvent(championshipID string, eventID string,
 
 	raceSetup, entryList := cm.FinalEventConfigurationFiles(championship, event, isPreChampionshipPracticeEvent)
 
+	if !isPreChampionshipPracticeEvent {
+		if carLimitErrs := championship.teamCarLimitErrors(entryList, time.Now()); len(carLimitErrs) > 0 {
+			return carLimitErrs[0]
+		}
+
+		// keep a record of the success ballast given to entrants in this event
+		if err := cm.UpsertChampionship(championship); err != nil {
+			return err
+		}
+	}
+
 	if config.Lua.Enabled && Premium() {
 		err := championshipEventStartPlugin(event, championship, &entryList)
 
@@ -825,6 +873,8 @@ func (cm *ChampionshipManager) ScheduleEvent(championshipID string, eventID stri
 		return err
 	}
 
+	originalDate := event.Scheduled
+
 	event.Scheduled = date
 	event.ScheduledServerID = serverID
 
@@ -878,6 +928,8 @@ func (cm *ChampionshipManager) ScheduleEvent(championshipID string, eventID stri
 			return err
 		}
 
+		cm.notificationManager.SendEventScheduledWebhook(championshipEventWebhookDetails(championship, event, date))
+
 		if cm.notificationManager.HasNotificationReminders() {
 			for _, timer := range cm.notificationManager.GetNotificationReminders() {
 				thisTimer := timer
@@ -897,6 +949,10 @@ func (cm *ChampionshipManager) ScheduleEvent(championshipID string, eventID stri
 		}
 	} else {
 		event.ClearRecurrenceRule()
+
+		if !originalDate.IsZero() {
+			cm.notificationManager.SendEventCancelledWebhook(championshipEventWebhookDetails(championship, event, originalDate))
+		}
 	}
 
 	return cm.UpsertChampionship(championship)
@@ -958,6 +1014,39 @@ func (cm *ChampionshipManager) StartScheduledEvent(championship *Championship, e
 		}
 	}
 
+	raceEvent, err := cm.championshipRaceEvent(championship.ID.String(), event.ID.String())
+
+	if err != nil {
+		return err
+	}
+
+	if validationErr := cm.validateScheduledEvent(raceEvent); validationErr != nil {
+		if event.HasRecurrenceRule() {
+			// the next occurrence has been scheduled as a copy of this event, which won't be started, so it
+			// shouldn't be shown as scheduled any more.
+			championship, err = cm.store.LoadChampionship(championship.ID.String())
+
+			if err != nil {
+				return err
+			}
+
+			event, _, err = championship.EventByID(event.ID.String())
+
+			if err != nil {
+				return err
+			}
+
+			event.Scheduled = time.Time{}
+			event.ClearRecurrenceRule()
+
+			if err := cm.UpsertChampionship(championship); err != nil {
+				return err
+			}
+		}
+
+		return validationErr
+	}
+
 	return cm.StartEvent(championship.ID.String(), event.ID.String(), false)
 }
 
@@ -1124,6 +1213,12 @@ func (cm *ChampionshipManager) handleSessionChanges(message udp.Message, champio
 				}
 			}
 
+			if sessionType == SessionTypeRace && championship.Events[currentEventIndex].GridRules.Type == ChampionshipGridHandicap {
+				for _, slot := range championship.Events[currentEventIndex].Grid {
+					cm.sendHandicapMessage(slot)
+				}
+			}
+
 			previousSessionType := cm.activeChampionship.SessionType
 			previousSession, hasPreviousSession := championship.Events[currentEventIndex].Sessions[previousSessionType]
 			previousSessionNumLaps := cm.activeChampionship.NumLapsCompleted
@@ -1149,6 +1244,19 @@ func (cm *ChampionshipManager) handleSessionChanges(message udp.Message, champio
 		} else {
 			saveChampionship = false
 		}
+	case udp.ClientLoaded:
+		saveChampionship = false
+
+		if cm.activeChampionship.SessionType != SessionTypeRace || championship.Events[currentEventIndex].GridRules.Type != ChampionshipGridHandicap {
+			return
+		}
+
+		// drivers who load in to the race late are told their handicap too
+		for _, slot := range championship.Events[currentEventIndex].Grid {
+			if slot.PitBox == int(a) {
+				cm.sendHandicapMessage(slot)
+			}
+		}
 	case udp.LapCompleted:
 		cm.activeChampionship.NumLapsCompleted++
 
@@ -1188,6 +1296,10 @@ func (cm *ChampionshipManager) handleSessionChanges(message udp.Message, champio
 			logrus.Infof("End of %s Session detected. Marking championship event %s complete", lastSession.String(), cm.activeChampionship.EventID.String())
 			championship.Events[currentEventIndex].CompletedTime = time.Now()
 
+			if err := cm.notificationManager.SendChampionshipResultsMessage(championship, championship.Events[currentEventIndex]); err != nil {
+				logrus.WithError(err).Errorf("Could not send championship results notification")
+			}
+
 			// clear out all current session stuff
 			cm.activeChampionship = nil
 
@@ -1321,21 +1433,17 @@ func (cm *ChampionshipManager) ListAvailableResultsFilesForEvent(championshipID
 		return nil, nil, err
 	}
 
-	results, err := ListAllResults()
+	results, _, err := FindResults(ResultsQuery{
+		Track:            event.RaceSetup.Track,
+		TrackLayout:      event.RaceSetup.TrackLayout,
+		MatchTrackLayout: true,
+	}, LoadResultWithoutPluginFire)
 
 	if err != nil {
 		return nil, nil, err
 	}
 
-	var filteredResults []SessionResults
-
-	for _, result := range results {
-		if result.TrackName == event.RaceSetup.Track && result.TrackConfig == event.RaceSetup.TrackLayout {
-			filteredResults = append(filteredResults, result)
-		}
-	}
-
-	return event, filteredResults, nil
+	return event, results, nil
 }
 
 func (cm *ChampionshipManager) ImportChampionship(jsonData string) (string, error) {
@@ -1360,6 +1468,80 @@ func (cm *ChampionshipManager) ImportChampionship(jsonData string) (string, erro
 	return championship.ID.String(), cm.UpsertChampionship(championship)
 }
 
+// RecalculateResults reloads the results of each completed session in a Championship from their results files,
+// and attaches the Championship's current classes and entrants to them again. Standings are then calculated from
+// the updated results, e.g. after penalties have been applied to a results file.
+func (cm *ChampionshipManager) RecalculateResults(championshipID string) (*Championship, error) {
+	championship, err := cm.LoadChampionship(championshipID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range championship.Events {
+		if event.IsRaceWeekend() {
+			if event.RaceWeekend == nil {
+				continue
+			}
+
+			event.RaceWeekend.Championship = championship
+
+			for _, session := range event.RaceWeekend.Sessions {
+				if session.Results == nil {
+					continue
+				}
+
+				results, err := reloadChampionshipResults(session.Results)
+
+				if err != nil {
+					return nil, err
+				}
+
+				event.RaceWeekend.EnhanceResults(results)
+				session.Results = results
+			}
+
+			if err := cm.store.UpsertRaceWeekend(event.RaceWeekend); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		for _, session := range event.Sessions {
+			if session.Results == nil {
+				continue
+			}
+
+			results, err := reloadChampionshipResults(session.Results)
+
+			if err != nil {
+				return nil, err
+			}
+
+			championship.EnhanceResults(results)
+			session.Results = results
+		}
+	}
+
+	return championship, cm.UpsertChampionship(championship)
+}
+
+// reloadChampionshipResults loads the results file for a Championship session's results. If the file no longer
+// exists, the results which were stored with the Championship are kept.
+func reloadChampionshipResults(results *SessionResults) (*SessionResults, error) {
+	reloaded, err := LoadResult(results.SessionFile+".json", LoadResultWithoutPluginFire)
+
+	if os.IsNotExist(err) {
+		logrus.Warnf("Results file for %s no longer exists, keeping the stored results", results.SessionFile)
+		return results, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return reloaded, nil
+}
+
 func (cm *ChampionshipManager) ImportEventSetup(championshipID string, eventID string) error {
 	race, err := cm.store.FindCustomRaceByID(eventID)
 
@@ -1764,7 +1946,15 @@ func (cm *ChampionshipManager) HandleChampionshipSignUp(r *http.Request) (respon
 		championship.SignUpForm.Responses = append(championship.SignUpForm.Responses, signUpResponse)
 	}
 
-	return signUpResponse, foundSlot, cm.UpsertChampionship(championship)
+	if err := cm.UpsertChampionship(championship); err != nil {
+		return signUpResponse, foundSlot, err
+	}
+
+	if err := cm.notificationManager.SendChampionshipSignUpMessage(championship, signUpResponse); err != nil {
+		logrus.WithError(err).Errorf("Could not send championship sign up notification")
+	}
+
+	return signUpResponse, foundSlot, nil
 }
 
 func (cm *ChampionshipManager) InitScheduledChampionships() error {
@@ -1886,6 +2076,20 @@ func (cm *ChampionshipManager) DuplicateChampionship(championshipID string) (*Ch
 	// clear sign up form responses
 	duplicateChampionship.SignUpForm.Responses = nil
 
+	// keep each team's current drivers, in the team from the start of the new championship
+	for _, team := range duplicateChampionship.Teams {
+		var members []*ChampionshipTeamMember
+
+		for _, member := range team.CurrentMembers() {
+			members = append(members, &ChampionshipTeamMember{
+				DriverGUID: member.DriverGUID,
+				DriverName: member.DriverName,
+			})
+		}
+
+		team.Members = members
+	}
+
 	logrus.Infof("New Championship: %s, %s. Duplicate of %s", duplicateChampionship.Name, duplicateChampionship.ID.String(), championshipID)
 
 	return duplicateChampionship, cm.UpsertChampionship(duplicateChampionship)
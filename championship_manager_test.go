@@ -2,6 +2,7 @@ package servermanager
 
 import (
 	"encoding/json"
+	"io/ioutil"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
 	"github.com/JustaPenguin/assetto-server-manager/pkg/udp/replay"
+	"github.com/JustaPenguin/assetto-server-manager/pkg/when"
 
 	"github.com/etcd-io/bbolt"
 )
@@ -142,6 +144,10 @@ func (d dummyNotificationManager) SendRaceStartMessage(config ServerConfig, even
 	return nil
 }
 
+func (d dummyNotificationManager) SendEventValidationFailedMessage(event RaceEvent, validation *EventValidation) error {
+	return nil
+}
+
 func (d dummyNotificationManager) GetCarList(cars string) string {
 	return "nil"
 }
@@ -158,6 +164,12 @@ func (d dummyNotificationManager) SendRaceCancelledMessage(event *CustomRace, da
 	return nil
 }
 
+func (d dummyNotificationManager) SendEventScheduledWebhook(details *WebhookEventDetails) {
+}
+
+func (d dummyNotificationManager) SendEventCancelledWebhook(details *WebhookEventDetails) {
+}
+
 func (d dummyNotificationManager) SendRaceReminderMessage(event *CustomRace, timer int) error {
 	return nil
 }
@@ -166,6 +178,22 @@ func (d dummyNotificationManager) SendChampionshipReminderMessage(championship *
 	return nil
 }
 
+func (d dummyNotificationManager) SendLapRecordMessage(record *LapRecord) error {
+	return nil
+}
+
+func (d dummyNotificationManager) SendSessionEndedMessage(results *SessionResults) error {
+	return nil
+}
+
+func (d dummyNotificationManager) SendChampionshipResultsMessage(championship *Championship, event *ChampionshipEvent) error {
+	return nil
+}
+
+func (d dummyNotificationManager) SendChampionshipSignUpMessage(championship *Championship, signUp *ChampionshipSignUpResponse) error {
+	return nil
+}
+
 func (d dummyNotificationManager) SaveServerOptions(oldServerOpts *GlobalServerConfig, newServerOpts *GlobalServerConfig) error {
 	return nil
 }
@@ -179,7 +207,8 @@ func init() {
 			NewCarManager(NewTrackManager(), false, false),
 			NewTrackManager(),
 			&dummyNotificationManager{},
-			NewRaceControl(NilBroadcaster{}, nilTrackData{}, dummyServerProcess{}, testStore, NewPenaltiesManager(testStore)),
+			NewRaceControl(NilBroadcaster{}, nilTrackData{}, dummyServerProcess{}, testStore, NewPenaltiesManager(testStore), NewBlocklistManager(testStore)),
+			NewBlocklistManager(testStore),
 		),
 		&ACSRClient{Enabled: false},
 	)
@@ -502,3 +531,85 @@ func TestChampionshipManager_ChampionshipEventCallbackOpenChampionshipExample(t
 		eventNum++
 	}
 }
+
+func TestChampionshipManager_StartScheduledEventValidationFailed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scheduled-championship")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	oldInstallPath := ServerInstallPath
+	ServerInstallPath = dir
+
+	defer func() {
+		ServerInstallPath = oldInstallPath
+	}()
+
+	store := NewJSONStore(filepath.Join(dir, "store"), filepath.Join(dir, "shared"))
+
+	// the track isn't installed, so scheduled events fail validation.
+	raceManager := NewRaceManager(
+		store,
+		dummyServerProcess{},
+		NewCarManager(NewTrackManager(), false, false),
+		NewTrackManager(),
+		&dummyNotificationManager{},
+		NewRaceControl(NilBroadcaster{}, nilTrackData{}, dummyServerProcess{}, store, NewPenaltiesManager(store), NewBlocklistManager(store)),
+		NewBlocklistManager(store),
+	)
+
+	cm := NewChampionshipManager(raceManager, &ACSRClient{Enabled: false})
+	cm.championshipEventStartTimers = make(map[string]*when.Timer)
+	cm.championshipEventReminderTimers = make(map[string]*when.Timer)
+
+	for _, recurring := range []bool{false, true} {
+		championship := NewChampionship("Scheduled Championship")
+		event := NewChampionshipEvent()
+		event.RaceSetup = ConfigIniDefault().CurrentRaceConfig
+		event.RaceSetup.Track = "not_installed"
+		event.Scheduled = time.Now().Add(-time.Minute)
+
+		if recurring {
+			if err := event.SetRecurrenceRule("FREQ=DAILY"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		championship.Events = append(championship.Events, event)
+
+		if err := store.UpsertChampionship(championship); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := cm.StartScheduledEvent(championship, event); err != ErrEventValidationFailed {
+			t.Fatalf("Expected ErrEventValidationFailed, got: %v", err)
+		}
+
+		championship, err := store.LoadChampionship(championship.ID.String())
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		event, _, err = championship.EventByID(event.ID.String())
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !event.Scheduled.IsZero() || event.HasRecurrenceRule() {
+			t.Errorf("Expected the schedule to be cleared (recurring: %t), got %s", recurring, event.Scheduled)
+		}
+
+		if recurring {
+			if len(championship.Events) != 2 || !championship.Events[1].Scheduled.After(time.Now()) || !championship.Events[1].HasRecurrenceRule() {
+				t.Errorf("Expected the next occurrence of the event to be scheduled")
+			} else {
+				cm.championshipEventStartTimers[championship.Events[1].ID.String()].Stop()
+			}
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package servermanager
+
+import (
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// championshipPointsSession is a completed session which is being scored for a ChampionshipClass.
+type championshipPointsSession struct {
+	sessionType SessionType
+	session     *ChampionshipSession
+	classID     uuid.UUID
+
+	// points are the class points, or the points for the session if it is part of a Race Weekend.
+	points     ChampionshipPoints
+	multiplier float64
+
+	// results are the class's results, in finishing order.
+	results []*SessionResult
+}
+
+// scoresFinishingPositions is true for races, and for every session in a Race Weekend.
+func (s *championshipPointsSession) scoresFinishingPositions() bool {
+	return s.session.IsRaceWeekend() || s.sessionType == SessionTypeRace || s.sessionType == SessionTypeSecondRace
+}
+
+func (s *championshipPointsSession) isRace() bool {
+	return s.sessionType == SessionTypeRace || s.sessionType == SessionTypeSecondRace
+}
+
+func (s *championshipPointsSession) finished(result *SessionResult) bool {
+	return result.TotalTime > 0 && !result.Disqualified
+}
+
+// classified drivers finished the session, and completed the minimum race distance if there is one.
+func (s *championshipPointsSession) classified(result *SessionResult) bool {
+	if !s.finished(result) {
+		return false
+	}
+
+	if s.points.MinimumRaceDistance <= 0 || !s.isRace() {
+		return true
+	}
+
+	leaderLaps := 0
+
+	for _, classResult := range s.results {
+		if laps := s.session.Results.GetNumLaps(classResult.DriverGUID, classResult.CarModel); laps > leaderLaps {
+			leaderLaps = laps
+		}
+	}
+
+	laps := s.session.Results.GetNumLaps(result.DriverGUID, result.CarModel)
+
+	return laps*100 >= leaderLaps*s.points.MinimumRaceDistance
+}
+
+type championshipPointsGiver func(driverGUID string, points float64, reason PointsReason)
+
+// A championshipPointsRule gives points to (or takes them from) drivers in a session.
+type championshipPointsRule func(session *championshipPointsSession, givePoints championshipPointsGiver)
+
+// championshipPointsRules are applied in order to every completed session in a Championship.
+var championshipPointsRules = []championshipPointsRule{
+	finishingPositionsPointsRule,
+	qualifyingPositionsPointsRule,
+	fastestLapPointsRule,
+	incidentsPointsRule,
+}
+
+// finishingPositionsPointsRule gives points to classified drivers for their finishing position.
+func finishingPositionsPointsRule(session *championshipPointsSession, givePoints championshipPointsGiver) {
+	if !session.scoresFinishingPositions() {
+		return
+	}
+
+	for pos, result := range session.results {
+		if !session.classified(result) {
+			continue
+		}
+
+		givePoints(result.DriverGUID, session.points.ForPos(pos)*session.multiplier, PointsEventFinish)
+	}
+}
+
+// qualifyingPositionsPointsRule gives points for qualifying positions outside of Race Weekends. Race Weekend
+// qualifying sessions are scored by their own finishing position points.
+func qualifyingPositionsPointsRule(session *championshipPointsSession, givePoints championshipPointsGiver) {
+	if session.session.IsRaceWeekend() || session.sessionType != SessionTypeQualifying {
+		return
+	}
+
+	for pos, result := range session.results {
+		if pos >= len(session.points.QualifyingPlaces) {
+			break
+		}
+
+		if !session.finished(result) {
+			continue
+		}
+
+		givePoints(result.DriverGUID, float64(session.points.QualifyingPlaces[pos])*session.multiplier, PointsQualifyingPosition)
+	}
+}
+
+// fastestLapPointsRule gives points to the driver with the fastest lap in the class, as long as they are classified
+// and finished high enough up the order.
+func fastestLapPointsRule(session *championshipPointsSession, givePoints championshipPointsGiver) {
+	if !session.scoresFinishingPositions() || session.points.BestLap == 0 {
+		return
+	}
+
+	fastestLap := session.session.Results.FastestLapInClass(session.classID)
+
+	if fastestLap == nil {
+		return
+	}
+
+	for pos, result := range session.results {
+		if result.DriverGUID != fastestLap.DriverGUID || !session.classified(result) {
+			continue
+		}
+
+		if session.points.BestLapMaxPosition > 0 && pos >= session.points.BestLapMaxPosition {
+			continue
+		}
+
+		givePoints(result.DriverGUID, float64(session.points.BestLap)*session.multiplier, PointsFastestLap)
+	}
+}
+
+// incidentsPointsRule takes points from drivers who finished a race for collisions and cuts.
+func incidentsPointsRule(session *championshipPointsSession, givePoints championshipPointsGiver) {
+	if !session.scoresFinishingPositions() || !session.isRace() {
+		return
+	}
+
+	for _, result := range session.results {
+		if !session.finished(result) {
+			continue
+		}
+
+		collisionsWithCars := session.session.Results.GetCrashesOfType(result.DriverGUID, result.CarModel, "COLLISION_WITH_CAR")
+		collisionsWithEnv := session.session.Results.GetCrashesOfType(result.DriverGUID, result.CarModel, "COLLISION_WITH_ENV")
+		cuts := session.session.Results.GetCuts(result.DriverGUID, result.CarModel)
+
+		givePoints(result.DriverGUID, float64(session.points.CollisionWithDriver*collisionsWithCars)*session.multiplier*-1, PointsCollisionWithCar)
+		givePoints(result.DriverGUID, float64(session.points.CollisionWithEnv*collisionsWithEnv)*session.multiplier*-1, PointsCollisionWithEnvironment)
+		givePoints(result.DriverGUID, float64(session.points.CutTrack*cuts)*session.multiplier*-1, PointsCutTrack)
+	}
+}
+
+// pointsMultiplier combines the second race, round and final round multipliers for a session.
+func (c *ChampionshipClass) pointsMultiplier(championship *Championship, event *ChampionshipEvent, sessionType SessionType, session *ChampionshipSession, points ChampionshipPoints) float64 {
+	multiplier := 1.0
+
+	if !session.IsRaceWeekend() && sessionType == SessionTypeSecondRace {
+		multiplier = points.SecondRaceMultiplier
+	}
+
+	round := event.championshipRound()
+
+	multiplier *= round.GetPointsMultiplier()
+
+	if c.Points.FinalRoundMultiplier != 0 && championship.IsFinalRound(round) {
+		multiplier *= c.Points.FinalRoundMultiplier
+	}
+
+	return multiplier
+}
+
+// droppedRoundsPoints is the total of a driver's worst rounds, which don't count towards their points. Rounds the
+// driver didn't score in count as zero. Rounds are only dropped once more than dropWorstRounds have been completed.
+func droppedRoundsPoints(dropWorstRounds int, completedRounds []uuid.UUID, pointsByRound map[uuid.UUID]float64) float64 {
+	if dropWorstRounds <= 0 || len(completedRounds) <= dropWorstRounds {
+		return 0
+	}
+
+	roundPoints := make([]float64, len(completedRounds))
+
+	for i, round := range completedRounds {
+		roundPoints[i] = pointsByRound[round]
+	}
+
+	sort.Float64s(roundPoints)
+
+	var dropped float64
+
+	for _, points := range roundPoints[:dropWorstRounds] {
+		dropped += points
+	}
+
+	return dropped
+}
@@ -0,0 +1,367 @@
+package servermanager
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// newPointsTestSessionResults creates results for a class where the drivers finished in order, having completed the
+// given number of laps. The driver at fastestLapIndex set the fastest lap.
+func newPointsTestSessionResults(classID uuid.UUID, laps []int, fastestLapIndex int) *SessionResults {
+	results := &SessionResults{}
+
+	for i, numLaps := range laps {
+		carID := i + 1
+		guid := fmt.Sprintf("driver-%d", carID)
+
+		results.Cars = append(results.Cars, &SessionCar{
+			CarID:  carID,
+			Model:  "ks_mazda_mx5_cup",
+			Driver: SessionDriver{GUID: guid, Name: fmt.Sprintf("Driver %d", carID)},
+		})
+
+		results.Result = append(results.Result, &SessionResult{
+			CarID:      carID,
+			CarModel:   "ks_mazda_mx5_cup",
+			DriverGUID: guid,
+			DriverName: fmt.Sprintf("Driver %d", carID),
+			TotalTime:  numLaps * 100000,
+			ClassID:    classID,
+		})
+
+		for lap := 0; lap < numLaps; lap++ {
+			lapTime := 100000 + carID*100
+
+			if i == fastestLapIndex && lap == 0 {
+				lapTime = 90000
+			}
+
+			results.Laps = append(results.Laps, &SessionLap{
+				CarID:      carID,
+				CarModel:   "ks_mazda_mx5_cup",
+				DriverGUID: guid,
+				LapTime:    lapTime,
+				ClassID:    classID,
+				Timestamp:  lap,
+			})
+		}
+	}
+
+	return results
+}
+
+func newPointsTestSession(sessionType SessionType, points ChampionshipPoints, results *SessionResults) *championshipPointsSession {
+	classID := uuid.Nil
+
+	if len(results.Result) > 0 {
+		classID = results.Result[0].ClassID
+	}
+
+	return &championshipPointsSession{
+		sessionType: sessionType,
+		session: &ChampionshipSession{
+			StartedTime:   time.Now(),
+			CompletedTime: time.Now(),
+			Results:       results,
+		},
+		classID:    classID,
+		points:     points,
+		multiplier: 1,
+		results:    results.Result,
+	}
+}
+
+func applyPointsTestRule(rule championshipPointsRule, session *championshipPointsSession) map[string]float64 {
+	points := make(map[string]float64)
+
+	rule(session, func(driverGUID string, p float64, reason PointsReason) {
+		points[driverGUID] += p
+	})
+
+	return points
+}
+
+func comparePointsTestResults(t *testing.T, expected, actual map[string]float64) {
+	t.Helper()
+
+	if len(expected) != len(actual) {
+		t.Errorf("Expected points for %d drivers, got %d: %v", len(expected), len(actual), actual)
+	}
+
+	for guid, points := range expected {
+		if actual[guid] != points {
+			t.Errorf("Expected %s to have %.2f points, got %.2f", guid, points, actual[guid])
+		}
+	}
+}
+
+func TestFinishingPositionsPointsRule(t *testing.T) {
+	classID := uuid.New()
+	points := ChampionshipPoints{Places: []int{10, 8, 6, 4}, MinimumRaceDistance: 75}
+
+	t.Run("Minimum race distance", func(t *testing.T) {
+		results := newPointsTestSessionResults(classID, []int{20, 20, 15, 14}, 0)
+
+		comparePointsTestResults(t, map[string]float64{
+			"driver-1": 10,
+			"driver-2": 8,
+			"driver-3": 6,
+		}, applyPointsTestRule(finishingPositionsPointsRule, newPointsTestSession(SessionTypeRace, points, results)))
+	})
+
+	t.Run("Disqualified drivers are not classified", func(t *testing.T) {
+		results := newPointsTestSessionResults(classID, []int{20, 20, 20}, 0)
+		results.Result[1].Disqualified = true
+
+		comparePointsTestResults(t, map[string]float64{
+			"driver-1": 10,
+			"driver-3": 6,
+		}, applyPointsTestRule(finishingPositionsPointsRule, newPointsTestSession(SessionTypeRace, points, results)))
+	})
+
+	t.Run("Qualifying does not score finishing positions", func(t *testing.T) {
+		results := newPointsTestSessionResults(classID, []int{5, 5}, 0)
+
+		comparePointsTestResults(t, map[string]float64{}, applyPointsTestRule(finishingPositionsPointsRule, newPointsTestSession(SessionTypeQualifying, points, results)))
+	})
+}
+
+func TestQualifyingPositionsPointsRule(t *testing.T) {
+	classID := uuid.New()
+	points := ChampionshipPoints{Places: []int{10, 8, 6, 4}, QualifyingPlaces: []int{3, 2, 1}}
+
+	t.Run("Qualifying", func(t *testing.T) {
+		results := newPointsTestSessionResults(classID, []int{5, 5, 5, 5}, 0)
+
+		comparePointsTestResults(t, map[string]float64{
+			"driver-1": 3,
+			"driver-2": 2,
+			"driver-3": 1,
+		}, applyPointsTestRule(qualifyingPositionsPointsRule, newPointsTestSession(SessionTypeQualifying, points, results)))
+	})
+
+	t.Run("Race", func(t *testing.T) {
+		results := newPointsTestSessionResults(classID, []int{5, 5, 5, 5}, 0)
+
+		comparePointsTestResults(t, map[string]float64{}, applyPointsTestRule(qualifyingPositionsPointsRule, newPointsTestSession(SessionTypeRace, points, results)))
+	})
+
+	t.Run("Race Weekend", func(t *testing.T) {
+		results := newPointsTestSessionResults(classID, []int{5, 5, 5, 5}, 0)
+		session := newPointsTestSession(SessionTypeQualifying, points, results)
+		session.session.RaceWeekendSession = NewRaceWeekendSession()
+
+		comparePointsTestResults(t, map[string]float64{}, applyPointsTestRule(qualifyingPositionsPointsRule, session))
+	})
+}
+
+func TestFastestLapPointsRule(t *testing.T) {
+	classID := uuid.New()
+	points := ChampionshipPoints{Places: []int{10, 8, 6, 4}, BestLap: 1, BestLapMaxPosition: 2, MinimumRaceDistance: 75}
+
+	t.Run("Within max position", func(t *testing.T) {
+		results := newPointsTestSessionResults(classID, []int{20, 20, 20}, 1)
+
+		comparePointsTestResults(t, map[string]float64{
+			"driver-2": 1,
+		}, applyPointsTestRule(fastestLapPointsRule, newPointsTestSession(SessionTypeRace, points, results)))
+	})
+
+	t.Run("Outside max position", func(t *testing.T) {
+		results := newPointsTestSessionResults(classID, []int{20, 20, 20}, 2)
+
+		comparePointsTestResults(t, map[string]float64{}, applyPointsTestRule(fastestLapPointsRule, newPointsTestSession(SessionTypeRace, points, results)))
+	})
+
+	t.Run("Not classified", func(t *testing.T) {
+		points := points
+		points.BestLapMaxPosition = 0
+
+		results := newPointsTestSessionResults(classID, []int{20, 20, 10}, 2)
+
+		comparePointsTestResults(t, map[string]float64{}, applyPointsTestRule(fastestLapPointsRule, newPointsTestSession(SessionTypeRace, points, results)))
+	})
+}
+
+func TestIncidentsPointsRule(t *testing.T) {
+	classID := uuid.New()
+	points := ChampionshipPoints{Places: []int{10, 8}, CollisionWithDriver: 2, CollisionWithEnv: 1, CutTrack: 1}
+
+	results := newPointsTestSessionResults(classID, []int{5, 5}, 0)
+	results.Events = append(results.Events,
+		&SessionEvent{CarID: 1, Type: "COLLISION_WITH_CAR"},
+		&SessionEvent{CarID: 2, Type: "COLLISION_WITH_ENV"},
+	)
+	results.Laps[0].Cuts = 3
+
+	session := newPointsTestSession(SessionTypeRace, points, results)
+	session.multiplier = 2
+
+	comparePointsTestResults(t, map[string]float64{
+		"driver-1": -10,
+		"driver-2": -2,
+	}, applyPointsTestRule(incidentsPointsRule, session))
+}
+
+func TestChampionshipClass_PointsMultiplier(t *testing.T) {
+	championship := NewChampionship("Test")
+	class := NewChampionshipClass("Class")
+	class.Points.SecondRaceMultiplier = 0.5
+	class.Points.FinalRoundMultiplier = 2
+
+	firstRound := NewChampionshipEvent()
+	firstRound.PointsMultiplier = 3
+	finalRound := NewChampionshipEvent()
+
+	championship.Events = []*ChampionshipEvent{firstRound, finalRound}
+
+	session := &ChampionshipSession{}
+
+	multiplierTests := []struct {
+		event       *ChampionshipEvent
+		sessionType SessionType
+		expected    float64
+	}{
+		{event: firstRound, sessionType: SessionTypeRace, expected: 3},
+		{event: firstRound, sessionType: SessionTypeSecondRace, expected: 1.5},
+		{event: finalRound, sessionType: SessionTypeRace, expected: 2},
+		{event: finalRound, sessionType: SessionTypeSecondRace, expected: 1},
+	}
+
+	for _, x := range multiplierTests {
+		if multiplier := class.pointsMultiplier(championship, x.event, x.sessionType, session, class.Points); multiplier != x.expected {
+			t.Errorf("Expected multiplier of %.2f for %s, got %.2f", x.expected, x.sessionType, multiplier)
+		}
+	}
+}
+
+func TestDroppedRoundsPoints(t *testing.T) {
+	rounds := []uuid.UUID{uuid.New(), uuid.New(), uuid.New(), uuid.New()}
+
+	pointsByRound := map[uuid.UUID]float64{
+		rounds[0]: 25,
+		rounds[1]: 4,
+		rounds[2]: 18,
+		// rounds[3] was missed, so counts as zero
+	}
+
+	droppedTests := []struct {
+		dropWorstRounds int
+		completedRounds []uuid.UUID
+		expected        float64
+	}{
+		{dropWorstRounds: 0, completedRounds: rounds, expected: 0},
+		{dropWorstRounds: 1, completedRounds: rounds, expected: 0},
+		{dropWorstRounds: 2, completedRounds: rounds, expected: 4},
+		{dropWorstRounds: 3, completedRounds: rounds, expected: 22},
+		{dropWorstRounds: 4, completedRounds: rounds, expected: 0},
+		{dropWorstRounds: 1, completedRounds: rounds[:3], expected: 4},
+	}
+
+	for _, x := range droppedTests {
+		if dropped := droppedRoundsPoints(x.dropWorstRounds, x.completedRounds, pointsByRound); dropped != x.expected {
+			t.Errorf("Expected %.2f points to be dropped for %d of %d rounds, got %.2f", x.expected, x.dropWorstRounds, len(x.completedRounds), dropped)
+		}
+	}
+}
+
+func TestChampionshipClass_StandingsWithPointsRules(t *testing.T) {
+	championship := NewChampionship("Test")
+	class := NewChampionshipClass("Class")
+	class.Points = ChampionshipPoints{
+		Places:               []int{10, 5},
+		SecondRaceMultiplier: 1,
+		FinalRoundMultiplier: 2,
+		DropWorstRounds:      1,
+	}
+
+	championship.AddClass(class)
+
+	// driver-1 wins the first two rounds, driver-2 wins the double points final round.
+	for i, laps := range [][]int{{10, 10}, {10, 10}, {10, 10}} {
+		event := NewChampionshipEvent()
+		event.CompletedTime = time.Now().Add(time.Duration(i) * time.Hour)
+
+		results := newPointsTestSessionResults(class.ID, laps, 0)
+
+		if i == 2 {
+			results.Result[0], results.Result[1] = results.Result[1], results.Result[0]
+		}
+
+		event.Sessions[SessionTypeRace] = &ChampionshipSession{
+			StartedTime:   event.CompletedTime,
+			CompletedTime: event.CompletedTime,
+			Results:       results,
+		}
+
+		championship.Events = append(championship.Events, event)
+	}
+
+	standings := class.Standings(championship, championship.Events)
+
+	expected := []struct {
+		guid    string
+		points  float64
+		dropped float64
+	}{
+		{guid: "driver-2", points: 25, dropped: 5},
+		{guid: "driver-1", points: 20, dropped: 10},
+	}
+
+	if len(standings) != len(expected) {
+		t.Fatalf("Expected %d standings, got %d", len(expected), len(standings))
+	}
+
+	for i, x := range expected {
+		standing := standings[i]
+
+		if standing.Car.GetGUID() != x.guid || standing.Points != x.points || standing.DroppedPoints != x.dropped {
+			t.Errorf("Expected %s in position %d with %.2f points (%.2f dropped), got %s with %.2f points (%.2f dropped)", x.guid, i+1, x.points, x.dropped, standing.Car.GetGUID(), standing.Points, standing.DroppedPoints)
+		}
+	}
+}
+
+func TestChampionshipPoints_UnmarshalJSON(t *testing.T) {
+	dir, err := ioutil.TempDir("", "championship-points")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	store := NewJSONStore(filepath.Join(dir, "store"), filepath.Join(dir, "shared"))
+
+	// deleted Championships aren't migrated, so they must have their pole position points moved when they're loaded.
+	championship := NewChampionship("Deleted Championship")
+	class := NewChampionshipClass("Class")
+	class.Points.QualifyingPlaces = nil
+	class.Points.PolePosition = 3
+	championship.AddClass(class)
+
+	if err := store.UpsertChampionship(championship); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.DeleteChampionship(championship.ID.String()); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := store.LoadChampionship(championship.ID.String())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	points := loaded.Classes[0].Points
+
+	if len(points.QualifyingPlaces) != 1 || points.QualifyingPlaces[0] != 3 || points.PolePosition != 0 {
+		t.Errorf("Expected the pole position points to be moved to qualifying places, got %v and %d", points.QualifyingPlaces, points.PolePosition)
+	}
+}
@@ -0,0 +1,217 @@
+package servermanager
+
+import (
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// SuccessBallastBasis is what decides the success ballast and restrictor given to an entrant.
+type SuccessBallastBasis string
+
+const (
+	// SuccessBallastByFinishingPosition uses the entrant's finishing position in the most recent race of the Championship.
+	SuccessBallastByFinishingPosition SuccessBallastBasis = "position"
+	// SuccessBallastByStandings uses the entrant's position in the Championship standings.
+	SuccessBallastByStandings SuccessBallastBasis = "standings"
+)
+
+// maxRestrictor is the largest restrictor that Assetto Corsa will apply to a car.
+const maxRestrictor = 100
+
+// SuccessBallastRules automatically add ballast and restrictor to entrants at the start of each Championship Event,
+// based on how well they have been doing.
+type SuccessBallastRules struct {
+	Basis SuccessBallastBasis
+
+	// Ballast (in kg) and Restrictor (in %) are added to an entrant's own ballast and restrictor for each position,
+	// starting with first place.
+	Ballast    []int
+	Restrictor []int
+
+	// MaxBallast caps an entrant's total ballast. It is always capped to the MaxBallastKilograms of the event.
+	MaxBallast int
+	// MaxRestrictor caps an entrant's total restrictor. Zero caps it to 100%.
+	MaxRestrictor int
+}
+
+// Enabled is true if the rules give any ballast or restrictor.
+func (s SuccessBallastRules) Enabled() bool {
+	for _, ballast := range s.Ballast {
+		if ballast != 0 {
+			return true
+		}
+	}
+
+	for _, restrictor := range s.Restrictor {
+		if restrictor != 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s SuccessBallastRules) forPos(values []int, pos int) int {
+	if pos < 0 || pos >= len(values) {
+		return 0
+	}
+
+	return values[pos]
+}
+
+// AppliedSuccessBallast is the success ballast and restrictor given to an entrant at the start of a Championship Event.
+type AppliedSuccessBallast struct {
+	ClassID    uuid.UUID
+	DriverGUID string
+	DriverName string
+
+	// Position is the position (starting at 0) that the ballast and restrictor were given for.
+	Position int
+
+	// Ballast and Restrictor are the amounts that were added to the entrant, after any caps were applied.
+	Ballast    int
+	Restrictor int
+}
+
+// successBallastPositions finds the position of each driver in a class, by their GUID, to give success ballast for.
+func (c *ChampionshipClass) successBallastPositions(championship *Championship, event *ChampionshipEvent) map[string]int {
+	positions := make(map[string]int)
+
+	switch c.SuccessBallast.Basis {
+	case SuccessBallastByStandings:
+		for pos, standing := range c.Standings(championship, championship.Events) {
+			positions[standing.Car.GetGUID()] = pos
+		}
+	default:
+		var lastRace *ChampionshipSession
+
+		for _, championshipEvent := range ExtractRaceWeekendSessionsIntoIndividualEvents(championship.Events) {
+			if championshipEvent.ID == event.ID {
+				continue
+			}
+
+			for sessionType, session := range championshipEvent.Sessions {
+				if sessionType != SessionTypeRace && sessionType != SessionTypeSecondRace {
+					continue
+				}
+
+				if !session.Completed() || session.Results == nil {
+					continue
+				}
+
+				if lastRace == nil || session.CompletedTime.After(lastRace.CompletedTime) {
+					lastRace = session
+				}
+			}
+		}
+
+		if lastRace == nil {
+			break
+		}
+
+		for pos, result := range c.ResultsForClass(lastRace.Results.Result, championship) {
+			if result.Disqualified {
+				continue
+			}
+
+			positions[result.DriverGUID] = pos
+		}
+	}
+
+	return positions
+}
+
+// applySuccessBallast adds success ballast and restrictor to the entrants in the entryList for each class which has
+// success ballast rules. The entrants are replaced with copies so that the Championship's own entrants are unchanged.
+func (c *Championship) applySuccessBallast(event *ChampionshipEvent, raceSetup CurrentRaceConfig, entryList EntryList) []*AppliedSuccessBallast {
+	var applied []*AppliedSuccessBallast
+
+	for _, class := range c.Classes {
+		if !class.SuccessBallast.Enabled() {
+			continue
+		}
+
+		positions := class.successBallastPositions(c, event)
+		classCars := make(map[string]bool)
+
+		for _, car := range class.ValidCarIDs() {
+			classCars[car] = true
+		}
+
+		var appliedForClass []*AppliedSuccessBallast
+
+		for key, entrant := range entryList {
+			if entrant.GUID == "" {
+				continue
+			}
+
+			if !classCars[entrant.Model] {
+				continue
+			}
+
+			pos, ok := positions[entrant.GUID]
+
+			if !ok {
+				continue
+			}
+
+			ballast := entrant.Ballast + class.SuccessBallast.forPos(class.SuccessBallast.Ballast, pos)
+
+			if class.SuccessBallast.MaxBallast > 0 && ballast > class.SuccessBallast.MaxBallast {
+				ballast = class.SuccessBallast.MaxBallast
+			}
+
+			if ballast > raceSetup.MaxBallastKilograms {
+				ballast = raceSetup.MaxBallastKilograms
+			}
+
+			restrictor := entrant.Restrictor + class.SuccessBallast.forPos(class.SuccessBallast.Restrictor, pos)
+			maxRestrictorForClass := maxRestrictor
+
+			if class.SuccessBallast.MaxRestrictor > 0 && class.SuccessBallast.MaxRestrictor < maxRestrictor {
+				maxRestrictorForClass = class.SuccessBallast.MaxRestrictor
+			}
+
+			if restrictor > maxRestrictorForClass {
+				restrictor = maxRestrictorForClass
+			}
+
+			// never take away an entrant's own ballast or restrictor
+			if ballast < entrant.Ballast {
+				ballast = entrant.Ballast
+			}
+
+			if restrictor < entrant.Restrictor {
+				restrictor = entrant.Restrictor
+			}
+
+			if ballast == entrant.Ballast && restrictor == entrant.Restrictor {
+				continue
+			}
+
+			appliedForClass = append(appliedForClass, &AppliedSuccessBallast{
+				ClassID:    class.ID,
+				DriverGUID: entrant.GUID,
+				DriverName: entrant.Name,
+				Position:   pos,
+				Ballast:    ballast - entrant.Ballast,
+				Restrictor: restrictor - entrant.Restrictor,
+			})
+
+			successEntrant := *entrant
+			successEntrant.Ballast = ballast
+			successEntrant.Restrictor = restrictor
+
+			entryList[key] = &successEntrant
+		}
+
+		sort.Slice(appliedForClass, func(i, j int) bool {
+			return appliedForClass[i].Position < appliedForClass[j].Position
+		})
+
+		applied = append(applied, appliedForClass...)
+	}
+
+	return applied
+}
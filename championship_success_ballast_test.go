@@ -0,0 +1,147 @@
+package servermanager
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func newSuccessBallastTestChampionship(rules SuccessBallastRules, rounds ...[]int) (*Championship, *ChampionshipClass) {
+	championship := NewChampionship("Success Ballast")
+	class := NewChampionshipClass("Class")
+	class.Points = ChampionshipPoints{Places: []int{10, 5, 2}, SecondRaceMultiplier: 1}
+	class.SuccessBallast = rules
+	class.AvailableCars = []string{"ks_mazda_mx5_cup"}
+
+	for i := 1; i <= 3; i++ {
+		entrant := NewEntrant()
+		entrant.Name = fmt.Sprintf("Driver %d", i)
+		entrant.GUID = fmt.Sprintf("driver-%d", i)
+		entrant.Model = "ks_mazda_mx5_cup"
+
+		if i == 3 {
+			entrant.Ballast = 10
+		}
+
+		class.Entrants.AddToBackOfGrid(entrant)
+	}
+
+	championship.AddClass(class)
+
+	// each round lists the drivers (by number) in finishing order
+	for i, finishingOrder := range rounds {
+		event := NewChampionshipEvent()
+		event.CompletedTime = time.Now().Add(time.Duration(i-len(rounds)) * time.Hour)
+
+		results := newPointsTestSessionResults(class.ID, []int{10, 10, 10}, 0)
+		ordered := make([]*SessionResult, len(results.Result))
+
+		for pos, driver := range finishingOrder {
+			ordered[pos] = results.Result[driver-1]
+		}
+
+		results.Result = ordered
+
+		event.Sessions[SessionTypeRace] = &ChampionshipSession{
+			StartedTime:   event.CompletedTime,
+			CompletedTime: event.CompletedTime,
+			Results:       results,
+		}
+
+		championship.Events = append(championship.Events, event)
+	}
+
+	championship.Events = append(championship.Events, NewChampionshipEvent())
+
+	return championship, class
+}
+
+func successBallastByGUID(entryList EntryList) map[string][2]int {
+	out := make(map[string][2]int)
+
+	for _, entrant := range entryList {
+		out[entrant.GUID] = [2]int{entrant.Ballast, entrant.Restrictor}
+	}
+
+	return out
+}
+
+func TestChampionship_ApplySuccessBallast(t *testing.T) {
+	t.Run("Finishing position in the previous race", func(t *testing.T) {
+		championship, _ := newSuccessBallastTestChampionship(SuccessBallastRules{
+			Basis:      SuccessBallastByFinishingPosition,
+			Ballast:    []int{30, 20, 10},
+			Restrictor: []int{10},
+			MaxBallast: 25,
+		}, []int{1, 2, 3}, []int{3, 2, 1})
+
+		event := championship.Events[len(championship.Events)-1]
+		entryList := event.CombineEntryLists(championship)
+
+		applied := championship.applySuccessBallast(event, CurrentRaceConfig{MaxBallastKilograms: 50}, entryList)
+
+		expected := map[string][2]int{
+			// driver-3 won the last race. their own 10kg is added to, and capped to the class max.
+			"driver-3": {25, 10},
+			"driver-2": {20, 0},
+			"driver-1": {10, 0},
+		}
+
+		actual := successBallastByGUID(entryList)
+
+		for guid, values := range expected {
+			if actual[guid] != values {
+				t.Errorf("Expected %s to have ballast and restrictor %v, got %v", guid, values, actual[guid])
+			}
+		}
+
+		if len(applied) != 3 || applied[0].DriverGUID != "driver-3" || applied[0].Ballast != 15 || applied[0].Restrictor != 10 {
+			t.Errorf("Expected driver-3 to be given 15kg and 10%% first, got %+v", applied[0])
+		}
+
+		for _, entrant := range championship.Classes[0].Entrants {
+			if entrant.Restrictor != 0 || (entrant.GUID != "driver-3" && entrant.Ballast != 0) {
+				t.Errorf("Expected the Championship's entrants to be unchanged, %s has %dkg and %d%%", entrant.GUID, entrant.Ballast, entrant.Restrictor)
+			}
+		}
+	})
+
+	t.Run("Championship standings", func(t *testing.T) {
+		championship, _ := newSuccessBallastTestChampionship(SuccessBallastRules{
+			Basis:   SuccessBallastByStandings,
+			Ballast: []int{30, 20},
+		}, []int{1, 2, 3}, []int{1, 3, 2}, []int{3, 2, 1})
+
+		event := championship.Events[len(championship.Events)-1]
+		entryList := event.CombineEntryLists(championship)
+
+		// the event max ballast caps everyone
+		championship.applySuccessBallast(event, CurrentRaceConfig{MaxBallastKilograms: 25}, entryList)
+
+		expected := map[string][2]int{
+			// standings: driver-1 22, driver-3 17, driver-2 12
+			"driver-1": {25, 0},
+			"driver-3": {25, 0},
+			"driver-2": {0, 0},
+		}
+
+		actual := successBallastByGUID(entryList)
+
+		for guid, values := range expected {
+			if actual[guid] != values {
+				t.Errorf("Expected %s to have ballast and restrictor %v, got %v", guid, values, actual[guid])
+			}
+		}
+	})
+
+	t.Run("No previous race", func(t *testing.T) {
+		championship, _ := newSuccessBallastTestChampionship(SuccessBallastRules{Ballast: []int{30}})
+
+		event := championship.Events[0]
+		entryList := event.CombineEntryLists(championship)
+
+		if applied := championship.applySuccessBallast(event, CurrentRaceConfig{MaxBallastKilograms: 50}, entryList); len(applied) != 0 {
+			t.Errorf("Expected no success ballast, got %d", len(applied))
+		}
+	})
+}
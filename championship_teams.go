@@ -0,0 +1,674 @@
+package servermanager
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"4d63.com/tz"
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	ErrInvalidChampionshipTeam       = errors.New("servermanager: invalid championship team")
+	ErrInvalidChampionshipTeamMember = errors.New("servermanager: invalid championship team member")
+)
+
+// ChampionshipTeam is a Team in a Championship, with a roster of the drivers who have driven for it. If a Championship
+// has Teams, Team points are given to the Team that a driver was in on the date of each event, rather than the Team
+// name in the event's results.
+type ChampionshipTeam struct {
+	ID   uuid.UUID
+	Name string
+
+	// MaxCars is the most cars the Team can have in an event's entry list. Zero allows any number of cars.
+	MaxCars int
+
+	// MaxScoringDrivers is the most drivers whose points count towards the Team in each round. If the Team has
+	// nominated its scoring drivers for a round, only the nominated drivers score, otherwise the drivers with the
+	// most points in the round score. Zero allows all drivers to score.
+	MaxScoringDrivers int
+
+	Members []*ChampionshipTeamMember
+}
+
+// NewChampionshipTeam creates a ChampionshipTeam with an ID.
+func NewChampionshipTeam(name string) *ChampionshipTeam {
+	return &ChampionshipTeam{
+		ID:   uuid.New(),
+		Name: name,
+	}
+}
+
+// ChampionshipTeamMember is a spell that a driver has spent in a Team.
+type ChampionshipTeamMember struct {
+	DriverGUID string
+	DriverName string
+
+	// Joined is when the driver joined the Team. A zero Joined time means the driver was in the Team from the start
+	// of the Championship.
+	Joined time.Time
+	// Left is when the driver left the Team. A zero Left time means the driver is still in the Team.
+	Left time.Time
+}
+
+// ActiveAt is true if the driver was in the Team at the given time.
+func (m *ChampionshipTeamMember) ActiveAt(t time.Time) bool {
+	return (m.Joined.IsZero() || !t.Before(m.Joined)) && (m.Left.IsZero() || t.Before(m.Left))
+}
+
+// CurrentMembers are the drivers who are in the Team now.
+func (t *ChampionshipTeam) CurrentMembers() []*ChampionshipTeamMember {
+	return t.MembersAt(time.Now())
+}
+
+// MembersAt are the drivers who were in the Team at the given time.
+func (t *ChampionshipTeam) MembersAt(at time.Time) []*ChampionshipTeamMember {
+	var members []*ChampionshipTeamMember
+
+	for _, member := range t.Members {
+		if member.ActiveAt(at) {
+			members = append(members, member)
+		}
+	}
+
+	return members
+}
+
+// TeamByID finds a ChampionshipTeam by its ID string.
+func (c *Championship) TeamByID(id string) (*ChampionshipTeam, error) {
+	for _, team := range c.Teams {
+		if team.ID.String() == id {
+			return team, nil
+		}
+	}
+
+	return nil, ErrInvalidChampionshipTeam
+}
+
+// TeamByName finds a ChampionshipTeam by its name, ignoring case.
+func (c *Championship) TeamByName(name string) *ChampionshipTeam {
+	for _, team := range c.Teams {
+		if strings.EqualFold(team.Name, name) {
+			return team
+		}
+	}
+
+	return nil
+}
+
+// TeamForDriver finds the Team that a driver was in at the given time, or nil if they weren't in a Team.
+func (c *Championship) TeamForDriver(driverGUID string, at time.Time) *ChampionshipTeam {
+	for _, team := range c.Teams {
+		for _, member := range team.Members {
+			if member.DriverGUID == driverGUID && member.ActiveAt(at) {
+				return team
+			}
+		}
+	}
+
+	return nil
+}
+
+// teamNameForDriver is the name of the Team that a driver scores points for at the given time. Drivers who aren't in
+// a Team's roster score for the Team they were in in the results.
+func (c *Championship) teamNameForDriver(driverGUID, resultTeam string, at time.Time) string {
+	if team := c.TeamForDriver(driverGUID, at); team != nil {
+		return team.Name
+	}
+
+	return resultTeam
+}
+
+// teamForEntrant finds the Team that an Entrant drives for at the given time, either from the Team rosters or from
+// the Team name set on the Entrant.
+func (c *Championship) teamForEntrant(entrant *Entrant, at time.Time) *ChampionshipTeam {
+	if entrant.GUID != "" {
+		if team := c.TeamForDriver(entrant.GUID, at); team != nil {
+			return team
+		}
+	}
+
+	if entrant.Team == "" {
+		return nil
+	}
+
+	return c.TeamByName(entrant.Team)
+}
+
+// TransferDriver moves a driver into a Team from the effective time onwards. Any points the driver scored before then
+// stay with the Team they were in. A nil team removes the driver from their current Team. A zero effective time is
+// the start of the Championship for drivers who have never been in a Team, and now for everyone else.
+func (c *Championship) TransferDriver(driverGUID, driverName string, team *ChampionshipTeam, effective time.Time) error {
+	if driverGUID == "" {
+		return ErrInvalidChampionshipTeamMember
+	}
+
+	var current *ChampionshipTeamMember
+	var currentTeam *ChampionshipTeam
+	var history []*ChampionshipTeamMember
+
+	for _, championshipTeam := range c.Teams {
+		for _, member := range championshipTeam.Members {
+			if member.DriverGUID != driverGUID {
+				continue
+			}
+
+			history = append(history, member)
+
+			if member.Left.IsZero() {
+				current = member
+				currentTeam = championshipTeam
+			}
+		}
+	}
+
+	if effective.IsZero() && len(history) > 0 {
+		effective = time.Now()
+	}
+
+	if current != nil {
+		if team == currentTeam {
+			return fmt.Errorf("servermanager: %s is already in %s", driverName, team.Name)
+		}
+
+		if !effective.After(current.Joined) {
+			return fmt.Errorf("servermanager: %s can't leave %s before they joined it", driverName, currentTeam.Name)
+		}
+
+		current.Left = effective
+	} else if team == nil {
+		return fmt.Errorf("servermanager: %s isn't in a team", driverName)
+	}
+
+	if team == nil {
+		return nil
+	}
+
+	for _, member := range history {
+		if effective.Before(member.Left) {
+			return fmt.Errorf("servermanager: %s was in another team at that time", driverName)
+		}
+	}
+
+	team.Members = append(team.Members, &ChampionshipTeamMember{
+		DriverGUID: driverGUID,
+		DriverName: driverName,
+		Joined:     effective,
+	})
+
+	return nil
+}
+
+// TeamDate is the date used to find which Team each driver was in for the event.
+func (cr *ChampionshipEvent) TeamDate() time.Time {
+	switch {
+	case !cr.CompletedTime.IsZero():
+		return cr.CompletedTime
+	case !cr.StartedTime.IsZero():
+		return cr.StartedTime
+	case !cr.Scheduled.IsZero():
+		return cr.Scheduled
+	default:
+		return time.Now()
+	}
+}
+
+// NominatedDrivers are the drivers whose points count towards a Team in the event, or nil if the Team hasn't
+// nominated any drivers.
+func (cr *ChampionshipEvent) NominatedDrivers(teamID uuid.UUID) []string {
+	return cr.TeamNominations[teamID]
+}
+
+// IsNominated is true if the Team has nominated the driver to score points in the event.
+func (cr *ChampionshipEvent) IsNominated(teamID uuid.UUID, driverGUID string) bool {
+	for _, guid := range cr.TeamNominations[teamID] {
+		if guid == driverGUID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// scoringTeamPoints are the points that a Team scores in a round, from the points scored by each of its drivers.
+func (c *Championship) scoringTeamPoints(round *ChampionshipEvent, teamName string, driverPoints map[string]float64) float64 {
+	team := c.TeamByName(teamName)
+
+	var points []float64
+
+	switch {
+	case team != nil && len(round.NominatedDrivers(team.ID)) > 0:
+		for _, guid := range round.NominatedDrivers(team.ID) {
+			points = append(points, driverPoints[guid])
+		}
+	default:
+		for _, driverPoints := range driverPoints {
+			points = append(points, driverPoints)
+		}
+
+		if team != nil && team.MaxScoringDrivers > 0 && len(points) > team.MaxScoringDrivers {
+			sort.Sort(sort.Reverse(sort.Float64Slice(points)))
+
+			points = points[:team.MaxScoringDrivers]
+		}
+	}
+
+	total := 0.0
+
+	for _, p := range points {
+		total += p
+	}
+
+	return total
+}
+
+// applyTeamNames sets the Team name of each Entrant in the entryList to the Team they are in at the given time. The
+// entrants are replaced with copies so that the Championship's own entrants are unchanged.
+func (c *Championship) applyTeamNames(entryList EntryList, at time.Time) {
+	for key, entrant := range entryList {
+		if entrant.GUID == "" {
+			continue
+		}
+
+		team := c.TeamForDriver(entrant.GUID, at)
+
+		if team == nil || team.Name == entrant.Team {
+			continue
+		}
+
+		teamEntrant := *entrant
+		teamEntrant.Team = team.Name
+
+		entryList[key] = &teamEntrant
+	}
+}
+
+// TeamCarLimitError is returned when a Team has more cars in an entry list than it is allowed.
+type TeamCarLimitError struct {
+	Team    string
+	NumCars int
+	MaxCars int
+}
+
+func (e TeamCarLimitError) Error() string {
+	return fmt.Sprintf("%s has %d cars in the entry list, but is only allowed %d", e.Team, e.NumCars, e.MaxCars)
+}
+
+// teamCarLimitErrors checks that no Team has more cars in the entryList than its MaxCars.
+func (c *Championship) teamCarLimitErrors(entryList EntryList, at time.Time) []TeamCarLimitError {
+	numCars := make(map[uuid.UUID]int)
+
+	for _, entrant := range entryList {
+		if team := c.teamForEntrant(entrant, at); team != nil {
+			numCars[team.ID]++
+		}
+	}
+
+	var errs []TeamCarLimitError
+
+	for _, team := range c.Teams {
+		if team.MaxCars > 0 && numCars[team.ID] > team.MaxCars {
+			errs = append(errs, TeamCarLimitError{
+				Team:    team.Name,
+				NumCars: numCars[team.ID],
+				MaxCars: team.MaxCars,
+			})
+		}
+	}
+
+	return errs
+}
+
+// validateTeamNominations checks that the drivers nominated by a Team for an event are in the Team on the date of the
+// event, and that there aren't more of them than the Team's MaxScoringDrivers.
+func (c *Championship) validateTeamNominations(event *ChampionshipEvent, team *ChampionshipTeam, driverGUIDs []string) error {
+	if team.MaxScoringDrivers > 0 && len(driverGUIDs) > team.MaxScoringDrivers {
+		return fmt.Errorf("servermanager: %s can only nominate %d scoring drivers", team.Name, team.MaxScoringDrivers)
+	}
+
+	members := make(map[string]bool)
+
+	for _, member := range team.MembersAt(event.TeamDate()) {
+		members[member.DriverGUID] = true
+	}
+
+	for _, guid := range driverGUIDs {
+		if !members[guid] {
+			return fmt.Errorf("servermanager: driver %s is not in %s for this event", guid, team.Name)
+		}
+	}
+
+	return nil
+}
+
+// UpsertChampionshipTeam creates a Team in a Championship, or updates it if teamID is set.
+func (cm *ChampionshipManager) UpsertChampionshipTeam(championshipID, teamID, name string, maxCars, maxScoringDrivers int) error {
+	championship, err := cm.LoadChampionship(championshipID)
+
+	if err != nil {
+		return err
+	}
+
+	name = strings.TrimSpace(name)
+
+	if name == "" {
+		return fmt.Errorf("servermanager: a team must have a name")
+	}
+
+	var team *ChampionshipTeam
+
+	if teamID != "" {
+		team, err = championship.TeamByID(teamID)
+
+		if err != nil {
+			return err
+		}
+	} else {
+		team = NewChampionshipTeam(name)
+		championship.Teams = append(championship.Teams, team)
+	}
+
+	if existing := championship.TeamByName(name); existing != nil && existing != team {
+		return fmt.Errorf("servermanager: there is already a team called %s", existing.Name)
+	}
+
+	team.Name = name
+	team.MaxCars = maxCars
+	team.MaxScoringDrivers = maxScoringDrivers
+
+	return cm.UpsertChampionship(championship)
+}
+
+// DeleteChampionshipTeam removes a Team from a Championship, along with its nominations. Points the Team's drivers
+// have already scored go to the Team name in the results.
+func (cm *ChampionshipManager) DeleteChampionshipTeam(championshipID, teamID string) error {
+	championship, err := cm.LoadChampionship(championshipID)
+
+	if err != nil {
+		return err
+	}
+
+	team, err := championship.TeamByID(teamID)
+
+	if err != nil {
+		return err
+	}
+
+	for i, championshipTeam := range championship.Teams {
+		if championshipTeam == team {
+			championship.Teams = append(championship.Teams[:i], championship.Teams[i+1:]...)
+			break
+		}
+	}
+
+	for _, event := range championship.Events {
+		delete(event.TeamNominations, team.ID)
+	}
+
+	return cm.UpsertChampionship(championship)
+}
+
+// TransferChampionshipDriver moves a driver into a Team (or out of their Team, if teamID is empty) from the effective
+// time onwards.
+func (cm *ChampionshipManager) TransferChampionshipDriver(championshipID, driverGUID, teamID string, effective time.Time) error {
+	championship, err := cm.LoadChampionship(championshipID)
+
+	if err != nil {
+		return err
+	}
+
+	var team *ChampionshipTeam
+
+	if teamID != "" {
+		team, err = championship.TeamByID(teamID)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	driverName := driverGUID
+
+	for _, entrant := range championship.AllEntrants() {
+		if entrant.GUID == driverGUID && entrant.Name != "" {
+			driverName = entrant.Name
+			break
+		}
+	}
+
+	if err := championship.TransferDriver(driverGUID, driverName, team, effective); err != nil {
+		return err
+	}
+
+	return cm.UpsertChampionship(championship)
+}
+
+// RemoveChampionshipTeamMember removes a spell in a Team from a driver's history, e.g. if it was added by mistake.
+func (cm *ChampionshipManager) RemoveChampionshipTeamMember(championshipID, teamID string, memberIndex int) error {
+	championship, err := cm.LoadChampionship(championshipID)
+
+	if err != nil {
+		return err
+	}
+
+	team, err := championship.TeamByID(teamID)
+
+	if err != nil {
+		return err
+	}
+
+	if memberIndex < 0 || memberIndex >= len(team.Members) {
+		return ErrInvalidChampionshipTeamMember
+	}
+
+	team.Members = append(team.Members[:memberIndex], team.Members[memberIndex+1:]...)
+
+	return cm.UpsertChampionship(championship)
+}
+
+// NominateChampionshipTeamDrivers sets the drivers whose points count towards each Team in an event. Teams with no
+// nominated drivers score with their best drivers in the event.
+func (cm *ChampionshipManager) NominateChampionshipTeamDrivers(championshipID, eventID string, nominations map[uuid.UUID][]string) error {
+	championship, event, err := cm.GetChampionshipAndEvent(championshipID, eventID)
+
+	if err != nil {
+		return err
+	}
+
+	event.TeamNominations = make(map[uuid.UUID][]string)
+
+	for teamID, driverGUIDs := range nominations {
+		if len(driverGUIDs) == 0 {
+			continue
+		}
+
+		team, err := championship.TeamByID(teamID.String())
+
+		if err != nil {
+			return err
+		}
+
+		if err := championship.validateTeamNominations(event, team, driverGUIDs); err != nil {
+			return err
+		}
+
+		event.TeamNominations[teamID] = driverGUIDs
+	}
+
+	return cm.UpsertChampionship(championship)
+}
+
+type championshipTeamsTemplateVars struct {
+	BaseTemplateVars
+
+	Championship *Championship
+	Drivers      []*Entrant
+	CarLimits    map[uuid.UUID][]TeamCarLimitError
+}
+
+func (ch *ChampionshipsHandler) teams(w http.ResponseWriter, r *http.Request) {
+	championship, err := ch.championshipManager.LoadChampionship(chi.URLParam(r, "championshipID"))
+
+	if err != nil {
+		logrus.WithError(err).Error("couldn't load championship")
+		http.NotFound(w, r)
+		return
+	}
+
+	var drivers []*Entrant
+
+	for _, entrant := range championship.AllEntrants().AlphaSlice() {
+		if entrant.GUID != "" {
+			drivers = append(drivers, entrant)
+		}
+	}
+
+	carLimits := make(map[uuid.UUID][]TeamCarLimitError)
+
+	for _, event := range championship.Events {
+		if event.Completed() || event.IsRaceWeekend() {
+			continue
+		}
+
+		carLimits[event.ID] = championship.teamCarLimitErrors(event.CombineEntryLists(championship), event.TeamDate())
+	}
+
+	ch.viewRenderer.MustLoadTemplate(w, r, "championships/teams.html", &championshipTeamsTemplateVars{
+		Championship: championship,
+		Drivers:      drivers,
+		CarLimits:    carLimits,
+	})
+}
+
+func (ch *ChampionshipsHandler) submitTeam(w http.ResponseWriter, r *http.Request) {
+	championshipID := chi.URLParam(r, "championshipID")
+
+	err := ch.championshipManager.UpsertChampionshipTeam(
+		championshipID,
+		r.FormValue("TeamID"),
+		r.FormValue("Name"),
+		formValueAsInt(r.FormValue("MaxCars")),
+		formValueAsInt(r.FormValue("MaxScoringDrivers")),
+	)
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not save championship team")
+		AddErrorFlash(w, r, "Couldn't save the Team")
+	} else {
+		AddFlash(w, r, "Team successfully saved")
+	}
+
+	http.Redirect(w, r, "/championship/"+championshipID+"/teams", http.StatusFound)
+}
+
+func (ch *ChampionshipsHandler) deleteTeam(w http.ResponseWriter, r *http.Request) {
+	championshipID := chi.URLParam(r, "championshipID")
+
+	if err := ch.championshipManager.DeleteChampionshipTeam(championshipID, chi.URLParam(r, "teamID")); err != nil {
+		logrus.WithError(err).Errorf("Could not delete championship team")
+		AddErrorFlash(w, r, "Couldn't delete the Team")
+	} else {
+		AddFlash(w, r, "Team successfully deleted")
+	}
+
+	http.Redirect(w, r, "/championship/"+championshipID+"/teams", http.StatusFound)
+}
+
+func (ch *ChampionshipsHandler) transferTeamDriver(w http.ResponseWriter, r *http.Request) {
+	championshipID := chi.URLParam(r, "championshipID")
+
+	var effective time.Time
+
+	if date := r.FormValue("TransferDate"); date != "" {
+		location, err := tz.LoadLocation(r.FormValue("TransferTimezone"))
+
+		if err != nil {
+			logrus.WithError(err).Errorf("could not find location: %s", location)
+			location = time.Local
+		}
+
+		transferTime := r.FormValue("TransferTime")
+
+		if transferTime == "" {
+			transferTime = "00:00"
+		}
+
+		effective, err = time.ParseInLocation("2006-01-02-15:04", date+"-"+transferTime, location)
+
+		if err != nil {
+			logrus.WithError(err).Errorf("Could not parse transfer date")
+			AddErrorFlash(w, r, "Couldn't transfer the driver, the date is invalid")
+			http.Redirect(w, r, "/championship/"+championshipID+"/teams", http.StatusFound)
+			return
+		}
+	}
+
+	err := ch.championshipManager.TransferChampionshipDriver(championshipID, r.FormValue("DriverGUID"), r.FormValue("TeamID"), effective)
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not transfer championship driver")
+		AddErrorFlash(w, r, "Couldn't transfer the driver: "+strings.TrimPrefix(err.Error(), "servermanager: "))
+	} else {
+		AddFlash(w, r, "Driver successfully transferred")
+	}
+
+	http.Redirect(w, r, "/championship/"+championshipID+"/teams", http.StatusFound)
+}
+
+func (ch *ChampionshipsHandler) removeTeamMember(w http.ResponseWriter, r *http.Request) {
+	championshipID := chi.URLParam(r, "championshipID")
+
+	err := ch.championshipManager.RemoveChampionshipTeamMember(championshipID, chi.URLParam(r, "teamID"), formValueAsInt(chi.URLParam(r, "memberIndex")))
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not remove championship team member")
+		AddErrorFlash(w, r, "Couldn't remove the driver from the Team")
+	} else {
+		AddFlash(w, r, "Driver successfully removed from the Team")
+	}
+
+	http.Redirect(w, r, "/championship/"+championshipID+"/teams", http.StatusFound)
+}
+
+func (ch *ChampionshipsHandler) nominateTeamDrivers(w http.ResponseWriter, r *http.Request) {
+	championshipID := chi.URLParam(r, "championshipID")
+
+	if err := r.ParseForm(); err != nil {
+		logrus.WithError(err).Errorf("Could not parse nominations form")
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	nominations := make(map[uuid.UUID][]string)
+
+	for key, driverGUIDs := range r.PostForm {
+		if !strings.HasPrefix(key, "Nominations.") {
+			continue
+		}
+
+		teamID, err := uuid.Parse(strings.TrimPrefix(key, "Nominations."))
+
+		if err != nil {
+			continue
+		}
+
+		nominations[teamID] = driverGUIDs
+	}
+
+	err := ch.championshipManager.NominateChampionshipTeamDrivers(championshipID, chi.URLParam(r, "eventID"), nominations)
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not save championship team nominations")
+		AddErrorFlash(w, r, "Couldn't save the nominations: "+strings.TrimPrefix(err.Error(), "servermanager: "))
+	} else {
+		AddFlash(w, r, "Nominations successfully saved")
+	}
+
+	http.Redirect(w, r, "/championship/"+championshipID+"/teams", http.StatusFound)
+}
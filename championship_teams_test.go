@@ -0,0 +1,158 @@
+package servermanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func newTeamsTestChampionship() (*Championship, *ChampionshipClass, *ChampionshipTeam, *ChampionshipTeam) {
+	// round 1: driver-1 10, driver-2 5, driver-3 2. round 2: driver-2 10, driver-1 5, driver-3 2.
+	championship, class := newSuccessBallastTestChampionship(SuccessBallastRules{}, []int{1, 2, 3}, []int{2, 1, 3})
+
+	teamA := NewChampionshipTeam("Team A")
+	teamB := NewChampionshipTeam("Team B")
+
+	championship.Teams = []*ChampionshipTeam{teamA, teamB}
+
+	for _, transfer := range []struct {
+		guid string
+		team *ChampionshipTeam
+	}{
+		{"driver-1", teamA},
+		{"driver-2", teamA},
+		{"driver-3", teamB},
+	} {
+		if err := championship.TransferDriver(transfer.guid, transfer.guid, transfer.team, time.Time{}); err != nil {
+			panic(err)
+		}
+	}
+
+	return championship, class, teamA, teamB
+}
+
+func teamStandingsPoints(standings []*TeamStanding) map[string]float64 {
+	out := make(map[string]float64)
+
+	for _, standing := range standings {
+		out[standing.Team] = standing.Points
+	}
+
+	return out
+}
+
+func TestChampionshipClass_TeamStandings(t *testing.T) {
+	testCases := []struct {
+		name     string
+		setup    func(championship *Championship, teamA, teamB *ChampionshipTeam) error
+		expected map[string]float64
+	}{
+		{
+			name:     "All drivers score",
+			setup:    func(championship *Championship, teamA, teamB *ChampionshipTeam) error { return nil },
+			expected: map[string]float64{"Team A": 30, "Team B": 4},
+		},
+		{
+			name: "Transfer between rounds",
+			setup: func(championship *Championship, teamA, teamB *ChampionshipTeam) error {
+				return championship.TransferDriver("driver-2", "driver-2", teamB, time.Now().Add(-90*time.Minute))
+			},
+			expected: map[string]float64{"Team A": 20, "Team B": 14},
+		},
+		{
+			name: "Best scoring driver",
+			setup: func(championship *Championship, teamA, teamB *ChampionshipTeam) error {
+				teamA.MaxScoringDrivers = 1
+				return nil
+			},
+			expected: map[string]float64{"Team A": 20, "Team B": 4},
+		},
+		{
+			name: "Nominated scoring driver",
+			setup: func(championship *Championship, teamA, teamB *ChampionshipTeam) error {
+				teamA.MaxScoringDrivers = 1
+
+				event := championship.Events[0]
+
+				if err := championship.validateTeamNominations(event, teamA, []string{"driver-2"}); err != nil {
+					return err
+				}
+
+				event.TeamNominations = map[uuid.UUID][]string{teamA.ID: {"driver-2"}}
+
+				return nil
+			},
+			expected: map[string]float64{"Team A": 15, "Team B": 4},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			championship, class, teamA, teamB := newTeamsTestChampionship()
+
+			if err := testCase.setup(championship, teamA, teamB); err != nil {
+				t.Fatal(err)
+			}
+
+			actual := teamStandingsPoints(class.TeamStandings(championship, championship.Events))
+
+			for team, points := range testCase.expected {
+				if actual[team] != points {
+					t.Errorf("Expected %s to have %.0f points, got %.0f", team, points, actual[team])
+				}
+			}
+		})
+	}
+}
+
+func TestChampionship_TransferDriver(t *testing.T) {
+	championship, _, teamA, teamB := newTeamsTestChampionship()
+
+	if err := championship.TransferDriver("driver-1", "driver-1", teamA, time.Time{}); err == nil {
+		t.Error("Expected an error transferring a driver to the team they are in")
+	}
+
+	if err := championship.TransferDriver("driver-4", "driver-4", nil, time.Time{}); err == nil {
+		t.Error("Expected an error releasing a driver who isn't in a team")
+	}
+
+	transferTime := time.Now().Add(-time.Hour)
+
+	if err := championship.TransferDriver("driver-1", "driver-1", teamB, transferTime); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := championship.TransferDriver("driver-1", "driver-1", teamA, transferTime.Add(-time.Minute)); err == nil {
+		t.Error("Expected an error transferring a driver back before they left their last team")
+	}
+
+	if team := championship.TeamForDriver("driver-1", transferTime.Add(-time.Second)); team != teamA {
+		t.Errorf("Expected driver-1 to be in Team A before the transfer")
+	}
+
+	if team := championship.TeamForDriver("driver-1", transferTime); team != teamB {
+		t.Errorf("Expected driver-1 to be in Team B after the transfer")
+	}
+}
+
+func TestChampionship_TeamCarLimitErrors(t *testing.T) {
+	championship, _, teamA, teamB := newTeamsTestChampionship()
+	teamA.MaxCars = 1
+
+	entryList := championship.AllEntrants()
+
+	errs := championship.teamCarLimitErrors(entryList, time.Now())
+
+	if len(errs) != 1 || errs[0].Team != "Team A" || errs[0].NumCars != 2 {
+		t.Fatalf("Expected Team A to have too many cars, got %v", errs)
+	}
+
+	if err := championship.TransferDriver("driver-2", "driver-2", teamB, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	if errs := championship.teamCarLimitErrors(entryList, time.Now()); len(errs) != 0 {
+		t.Errorf("Expected no teams to have too many cars, got %v", errs)
+	}
+}
@@ -1,6 +1,7 @@
 package servermanager
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"html/template"
@@ -43,7 +44,6 @@ var DefaultChampionshipPoints = ChampionshipPoints{
 		1,
 	},
 	BestLap:              0,
-	PolePosition:         0,
 	SecondRaceMultiplier: 1,
 
 	CollisionWithDriver: 0,
@@ -52,16 +52,36 @@ var DefaultChampionshipPoints = ChampionshipPoints{
 }
 
 // ChampionshipPoints represent the potential points for positions as well as other awards in a Championship.
+// The rules which use them are in championship_points.go.
 type ChampionshipPoints struct {
-	Places       []int
-	BestLap      int
-	PolePosition int
+	Places []int
+
+	// QualifyingPlaces are the points for qualifying positions, starting with pole position. They are not used in
+	// Race Weekends, where qualifying sessions are scored by their own Places.
+	QualifyingPlaces []int
+
+	BestLap int
+	// BestLapMaxPosition only gives the BestLap points to a driver who finishes in this position or higher.
+	// Zero gives them to any classified driver.
+	BestLapMaxPosition int
+
+	// MinimumRaceDistance is the percentage of the leader's laps that a driver must complete in a race to score points.
+	MinimumRaceDistance int
 
 	CollisionWithDriver int
 	CollisionWithEnv    int
 	CutTrack            int
 
 	SecondRaceMultiplier float64
+
+	// FinalRoundMultiplier is applied to points scored in the last event of the Championship, e.g. 2 for a double
+	// points finale. Zero leaves the points as they are.
+	FinalRoundMultiplier float64
+	// DropWorstRounds is the number of a driver's lowest scoring rounds which don't count towards their standings.
+	DropWorstRounds int
+
+	// Deprecated: PolePosition has been replaced by QualifyingPlaces. It is only kept so that old points can be migrated.
+	PolePosition int `json:",omitempty"`
 }
 
 // PointForPos uses the Championship's Points to determine what number should be awarded to a given position
@@ -73,6 +93,24 @@ func (pts *ChampionshipPoints) ForPos(i int) float64 {
 	return float64(pts.Places[i])
 }
 
+// UnmarshalJSON moves the deprecated PolePosition points into QualifyingPlaces. Championships which weren't migrated,
+// such as deleted Championships, keep their pole position points when they're loaded.
+func (pts *ChampionshipPoints) UnmarshalJSON(data []byte) error {
+	type championshipPoints ChampionshipPoints
+
+	if err := json.Unmarshal(data, (*championshipPoints)(pts)); err != nil {
+		return err
+	}
+
+	if pts.PolePosition != 0 && len(pts.QualifyingPlaces) == 0 {
+		pts.QualifyingPlaces = []int{pts.PolePosition}
+	}
+
+	pts.PolePosition = 0
+
+	return nil
+}
+
 // NewChampionship creates a Championship with a given name, creating a UUID for the championship as well.
 func NewChampionship(name string) *Championship {
 	return &Championship{
@@ -126,6 +164,10 @@ type Championship struct {
 	Classes []*ChampionshipClass
 	Events  []*ChampionshipEvent
 
+	// Teams are the Teams in the Championship and their rosters. Championships without Teams score Team points by
+	// the Team names in the results.
+	Teams []*ChampionshipTeam `json:",omitempty"`
+
 	// SpectatorCar is a car defined in the Championship settings that if set up is added to the back of the grid
 	// of every event in the Championship.
 	SpectatorCar        Entrant
@@ -345,6 +387,11 @@ func (c *Championship) GetURL() string {
 	return ""
 }
 
+// IsFinalRound is true if the event is the last event in the Championship.
+func (c *Championship) IsFinalRound(event *ChampionshipEvent) bool {
+	return len(c.Events) > 0 && c.Events[len(c.Events)-1].ID == event.ID
+}
+
 // IsMultiClass is true if the Championship has more than one Class
 func (c *Championship) IsMultiClass() bool {
 	return len(c.Classes) > 1
@@ -417,6 +464,10 @@ func (c *Championship) DriverMeetsACSRGates(rating *ACSRDriverRating) bool {
 }
 
 func (c *Championship) HasTeamNames() bool {
+	if len(c.Teams) > 0 {
+		return true
+	}
+
 	for _, class := range c.Classes {
 		for _, entrant := range class.Entrants {
 			if entrant.Team != "" {
@@ -465,6 +516,30 @@ func (c *Championship) ClearEntrant(entrantGUID string) {
 
 var ErrClassNotFound = errors.New("servermanager: championship class not found")
 
+// loadChampionshipWithRaceWeekends loads a championship along with any race weekends in it, so that their sessions
+// are counted in the standings.
+func loadChampionshipWithRaceWeekends(store Store, id string) (*Championship, error) {
+	championship, err := store.LoadChampionship(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range championship.Events {
+		if event.IsRaceWeekend() {
+			event.RaceWeekend, err = store.LoadRaceWeekend(event.RaceWeekendID.String())
+
+			if err == ErrRaceWeekendNotFound {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return championship, nil
+}
+
 func (c *Championship) FindClassForCarModel(model string) (*ChampionshipClass, error) {
 	for _, class := range c.Classes {
 		for _, car := range class.ValidCarIDs() {
@@ -527,6 +602,8 @@ type ChampionshipClass struct {
 	Points        ChampionshipPoints
 	AvailableCars []string
 
+	SuccessBallast SuccessBallastRules
+
 	DriverPenalties, TeamPenalties map[string]int
 }
 
@@ -612,7 +689,6 @@ func (c *Championship) ImportEvent(eventToImport interface{}) (*ChampionshipEven
 					session.Points[class.ID] = &ChampionshipPoints{
 						Places:               points,
 						BestLap:              0,
-						PolePosition:         0,
 						CollisionWithDriver:  0,
 						CollisionWithEnv:     0,
 						CutTrack:             0,
@@ -944,6 +1020,9 @@ type ChampionshipStanding struct {
 	// Teams is a map of Team Name to how many Events per team were completed.
 	Teams  map[string]int
 	Points float64
+
+	// DroppedPoints are the points from the driver's worst rounds, which have been taken off their Points.
+	DroppedPoints float64
 }
 
 func (cs *ChampionshipStanding) AddEventForTeam(team string) {
@@ -1071,7 +1150,7 @@ type PointsReason int
 
 const (
 	PointsEventFinish PointsReason = iota
-	PointsPolePosition
+	PointsQualifyingPosition
 	PointsFastestLap
 	PointsCollisionWithCar
 	PointsCollisionWithEnvironment
@@ -1094,7 +1173,6 @@ func (c *ChampionshipClass) standings(championship *Championship, events []*Cham
 			}
 
 			points := c.Points
-			pointsMultiplier := 1.0
 
 			if session.IsRaceWeekend() {
 				// race weekend sessions are valid points, as specified by the session itself.
@@ -1105,48 +1183,21 @@ func (c *ChampionshipClass) standings(championship *Championship, events []*Cham
 				} else {
 					points = *classPoints
 				}
-			} else {
-				switch sessionType {
-				case SessionTypeQualifying:
-					// non race weekend qualifying results get pole position points
-					for pos, driver := range c.ResultsForClass(session.Results.Result, championship) {
-						if pos != 0 {
-							continue
-						}
-
-						givePoints(event, driver.DriverGUID, float64(points.PolePosition)*pointsMultiplier, PointsPolePosition)
-					}
-
-					continue
-
-				case SessionTypeSecondRace:
-					pointsMultiplier = points.SecondRaceMultiplier
-				case SessionTypeBooking, SessionTypePractice:
-					continue
-
-				default:
-					// race sessions fall through
-				}
 			}
 
-			fastestLap := session.Results.FastestLapInClass(c.ID)
-
-			for pos, driver := range c.ResultsForClass(session.Results.Result, championship) {
-				if driver.TotalTime <= 0 || driver.Disqualified {
-					continue
-				}
-
-				givePoints(event, driver.DriverGUID, points.ForPos(pos)*pointsMultiplier, PointsEventFinish)
-
-				if fastestLap.DriverGUID == driver.DriverGUID {
-					givePoints(event, driver.DriverGUID, float64(points.BestLap)*pointsMultiplier, PointsFastestLap)
-				}
+			pointsSession := &championshipPointsSession{
+				sessionType: sessionType,
+				session:     session,
+				classID:     c.ID,
+				points:      points,
+				multiplier:  c.pointsMultiplier(championship, event, sessionType, session, points),
+				results:     c.ResultsForClass(session.Results.Result, championship),
+			}
 
-				if sessionType == SessionTypeRace || sessionType == SessionTypeSecondRace {
-					givePoints(event, driver.DriverGUID, float64(points.CollisionWithDriver*session.Results.GetCrashesOfType(driver.DriverGUID, driver.CarModel, "COLLISION_WITH_CAR"))*pointsMultiplier*-1, PointsCollisionWithCar)
-					givePoints(event, driver.DriverGUID, float64(points.CollisionWithEnv*session.Results.GetCrashesOfType(driver.DriverGUID, driver.CarModel, "COLLISION_WITH_ENV"))*pointsMultiplier*-1, PointsCollisionWithEnvironment)
-					givePoints(event, driver.DriverGUID, float64(points.CutTrack*session.Results.GetCuts(driver.DriverGUID, driver.CarModel))*pointsMultiplier*-1, PointsCutTrack)
-				}
+			for _, rule := range championshipPointsRules {
+				rule(pointsSession, func(driverGUID string, points float64, reason PointsReason) {
+					givePoints(event, driverGUID, points, reason)
+				})
 			}
 		}
 	}
@@ -1207,6 +1258,7 @@ func (c *ChampionshipClass) Standings(championship *Championship, inEvents []*Ch
 	}
 
 	standings := make(map[string]*ChampionshipStanding)
+	pointsByRound := make(map[string]map[uuid.UUID]float64)
 
 	c.standings(championship, events, func(event *ChampionshipEvent, driverGUID string, points float64, reason PointsReason) {
 		var car *SessionCar
@@ -1241,9 +1293,15 @@ func (c *ChampionshipClass) Standings(championship *Championship, inEvents []*Ch
 
 		standings[driverGUID].Points += points
 
+		if _, ok := pointsByRound[driverGUID]; !ok {
+			pointsByRound[driverGUID] = make(map[uuid.UUID]float64)
+		}
+
+		pointsByRound[driverGUID][event.championshipRound().ID] += points
+
 		if reason == PointsEventFinish {
 			// only increment team finishes for a 'finish' reason
-			standings[driverGUID].AddEventForTeam(car.Driver.Team)
+			standings[driverGUID].AddEventForTeam(championship.teamNameForDriver(driverGUID, car.Driver.Team, event.TeamDate()))
 		}
 	})
 
@@ -1255,11 +1313,16 @@ func (c *ChampionshipClass) Standings(championship *Championship, inEvents []*Ch
 		}
 	}
 
-	for _, standing := range standings {
+	completedRounds := completedChampionshipRounds(events)
+
+	for driverGUID, standing := range standings {
 		if standing.Car.Driver.Name == "" {
 			continue
 		}
 
+		standing.DroppedPoints = droppedRoundsPoints(c.Points.DropWorstRounds, completedRounds, pointsByRound[driverGUID])
+		standing.Points -= standing.DroppedPoints
+
 		if !skipPointsPenalties {
 			standing.Points -= float64(c.PenaltyForGUID(standing.Car.Driver.GUID))
 		}
@@ -1352,6 +1415,7 @@ func ExtractRaceWeekendSessionsIntoIndividualEvents(inEvents []*ChampionshipEven
 				e := NewChampionshipEvent()
 
 				e.ID = session.ID
+				e.round = event
 				e.RaceSetup = session.RaceConfig
 				e.CompletedTime = session.CompletedTime
 				e.StartedTime = session.StartedTime
@@ -1378,10 +1442,15 @@ type TeamStanding struct {
 	Points float64
 }
 
-// TeamStandings returns the current position of Teams in the Championship.
+// TeamStandings returns the current position of Teams in the Championship. Drivers score for the Team they were in on
+// the date of each event, and Teams with a limit on scoring drivers only score with their nominated (or best) drivers
+// in each round.
 func (c *ChampionshipClass) TeamStandings(championship *Championship, inEvents []*ChampionshipEvent) []*TeamStanding {
 	teams := make(map[string]float64)
 
+	// points scored by each driver in each team, for each round
+	roundPoints := make(map[*ChampionshipEvent]map[string]map[string]float64)
+
 	// make a copy of events so we do not persist race weekend sessions
 	events := ExtractRaceWeekendSessionsIntoIndividualEvents(inEvents)
 
@@ -1406,13 +1475,26 @@ func (c *ChampionshipClass) TeamStandings(championship *Championship, inEvents [
 			}
 		}
 
-		if _, ok := teams[team]; !ok {
-			teams[team] = points
-		} else {
-			teams[team] += points
+		team = championship.teamNameForDriver(driverGUID, team, event.TeamDate())
+		round := event.championshipRound()
+
+		if _, ok := roundPoints[round]; !ok {
+			roundPoints[round] = make(map[string]map[string]float64)
+		}
+
+		if _, ok := roundPoints[round][team]; !ok {
+			roundPoints[round][team] = make(map[string]float64)
 		}
+
+		roundPoints[round][team][driverGUID] += points
 	})
 
+	for round, teamPoints := range roundPoints {
+		for team, driverPoints := range teamPoints {
+			teams[team] += championship.scoringTeamPoints(round, team, driverPoints)
+		}
+	}
+
 	var out []*TeamStanding
 
 	for name, pts := range teams {
@@ -1477,13 +1559,74 @@ type ChampionshipEvent struct {
 	StartedTime   time.Time
 	CompletedTime time.Time
 
+	// PointsMultiplier is applied to all points scored in the event. Zero leaves the points as they are.
+	PointsMultiplier float64
+
+	// SuccessBallast is the success ballast and restrictor given to entrants when the event was last started.
+	SuccessBallast []*AppliedSuccessBallast `json:",omitempty"`
+
+	// GridRules form the starting grid from the Championship standings. Grid is the grid they formed when the event
+	// was last started.
+	GridRules ChampionshipGridRules
+	Grid      []*ChampionshipGridSlot `json:",omitempty"`
+
+	// TeamNominations are the drivers (by GUID) that each Team has nominated to score points in the event.
+	TeamNominations map[uuid.UUID][]string `json:",omitempty"`
+
 	championship *Championship
+
+	// round is the Race Weekend event that an event extracted from a Race Weekend session belongs to.
+	round *ChampionshipEvent
 }
 
 func (cr *ChampionshipEvent) IsRaceWeekend() bool {
 	return cr.RaceWeekendID != uuid.Nil
 }
 
+// GetPointsMultiplier is the multiplier applied to points scored in the event.
+func (cr *ChampionshipEvent) GetPointsMultiplier() float64 {
+	if cr.PointsMultiplier == 0 {
+		return 1
+	}
+
+	return cr.PointsMultiplier
+}
+
+// championshipRound is the event which counts as a round of the Championship, which for sessions extracted from a
+// Race Weekend is the Race Weekend's event.
+func (cr *ChampionshipEvent) championshipRound() *ChampionshipEvent {
+	if cr.round != nil {
+		return cr.round
+	}
+
+	return cr
+}
+
+// completedChampionshipRounds are the IDs of rounds with at least one completed session.
+func completedChampionshipRounds(events []*ChampionshipEvent) []uuid.UUID {
+	var rounds []uuid.UUID
+
+	found := make(map[uuid.UUID]bool)
+
+	for _, event := range events {
+		round := event.championshipRound()
+
+		if found[round.ID] {
+			continue
+		}
+
+		for _, session := range event.Sessions {
+			if session.Completed() {
+				found[round.ID] = true
+				rounds = append(rounds, round.ID)
+				break
+			}
+		}
+	}
+
+	return rounds
+}
+
 func (cr *ChampionshipEvent) GetSummary() string {
 	return fmt.Sprintf("(%s)", cr.championship.Name)
 }
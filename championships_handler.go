@@ -20,12 +20,14 @@ type ChampionshipsHandler struct {
 	SteamLoginHandler
 
 	championshipManager *ChampionshipManager
+	protestManager      *ProtestManager
 }
 
-func NewChampionshipsHandler(baseHandler *BaseHandler, championshipManager *ChampionshipManager) *ChampionshipsHandler {
+func NewChampionshipsHandler(baseHandler *BaseHandler, championshipManager *ChampionshipManager, protestManager *ProtestManager) *ChampionshipsHandler {
 	return &ChampionshipsHandler{
 		BaseHandler:         baseHandler,
 		championshipManager: championshipManager,
+		protestManager:      protestManager,
 	}
 }
 
@@ -123,6 +125,7 @@ type championshipViewTemplateVars struct {
 	RaceWeekends    map[uuid.UUID]*RaceWeekend
 	DriverRatings   map[string]*ACSRDriverRating
 	AccountRating   *ACSRDriverRating
+	Protests        []*Protest
 }
 
 // view shows details of a given Championship
@@ -175,6 +178,12 @@ func (ch *ChampionshipsHandler) view(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	protests, err := ch.protestManager.ListProtestsForChampionship(championship.ID.String())
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Couldn't load protests for championship: %s", championship.ID.String())
+	}
+
 	ch.viewRenderer.MustLoadTemplate(w, r, "championships/view.html", &championshipViewTemplateVars{
 		Championship:    championship,
 		EventInProgress: eventInProgress,
@@ -182,6 +191,7 @@ func (ch *ChampionshipsHandler) view(w http.ResponseWriter, r *http.Request) {
 		RaceWeekends:    raceWeekends,
 		DriverRatings:   ratings,
 		AccountRating:   rating,
+		Protests:        protests,
 	})
 }
 
@@ -457,7 +467,11 @@ func (ch *ChampionshipsHandler) startEvent(w http.ResponseWriter, r *http.Reques
 	if err != nil {
 		logrus.WithError(err).Errorf("Could not start championship event")
 
-		AddErrorFlash(w, r, "Couldn't start the Event")
+		if carLimitErr, ok := err.(TeamCarLimitError); ok {
+			AddErrorFlash(w, r, "Couldn't start the Event: "+carLimitErr.Error())
+		} else {
+			AddErrorFlash(w, r, "Couldn't start the Event")
+		}
 	} else {
 		AddFlash(w, r, "Event started successfully!")
 		time.Sleep(time.Second * 1)
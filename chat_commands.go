@@ -0,0 +1,610 @@
+package servermanager
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// serverManagerChatCommandPrefix is the prefix for the chat commands which Server Manager handles itself, e.g. !help.
+	// Commands beginning with chatCommandPrefix are handled by the Assetto Corsa server.
+	serverManagerChatCommandPrefix = "!"
+
+	chatCommandNumStandings   = 5
+	chatCommandNumScheduled   = 3
+	voteKickMinimumDrivers    = 3
+	voteKickTimeout           = 2 * time.Minute
+	chatCommandScheduleFormat = "Mon 2 Jan 15:04 MST"
+
+	// chatCommandMaxPenalty is the longest time penalty that can be given with !penalise, longer penalties should
+	// be disqualifications.
+	chatCommandMaxPenalty = time.Hour
+)
+
+// ChatCommand is a command which drivers can type into the in-game chat, e.g. !best. The reply is sent back to the
+// driver who typed the command.
+type ChatCommand struct {
+	Name        string
+	Arguments   string
+	Description string
+
+	// Admin commands can only be used by drivers whose account GUID matches an account with write access.
+	Admin bool
+
+	run func(sender *RaceControlDriver, args []string) (string, error)
+}
+
+// chatCommandError is returned by a ChatCommand which can't be run, e.g. because a driver name was mistyped. The error
+// message is sent back to the driver.
+type chatCommandError string
+
+func (e chatCommandError) Error() string {
+	return string(e)
+}
+
+func (rc *RaceControl) chatCommands() []*ChatCommand {
+	return []*ChatCommand{
+		{Name: "help", Arguments: "[command]", Description: "Lists the available commands", run: rc.chatCommandHelp},
+		{Name: "standings", Description: "Shows the championship standings, or the current positions if this event isn't part of a championship", run: rc.chatCommandStandings},
+		{Name: "gap", Description: "Shows your gap to the cars ahead of and behind you", run: rc.chatCommandGap},
+		{Name: "best", Description: "Shows your best lap, the best lap of the session and the lap record for your car", run: rc.chatCommandBest},
+		{Name: "schedule", Description: "Shows the next events scheduled on this server", run: rc.chatCommandSchedule},
+		{Name: "penalties", Description: "Shows any penalties you have received in this session", run: rc.chatCommandPenalties},
+		{Name: "votekick", Arguments: "<driver>", Description: "Votes to kick a driver. A majority of drivers must vote to kick them", run: rc.chatCommandVoteKick},
+
+		{Name: "kick", Arguments: "<driver>", Description: "Kicks a driver from the server", Admin: true, run: rc.chatCommandKick},
+		{Name: "ban", Arguments: "<driver> [reason]", Description: "Bans a driver from the server and adds them to the blocklist", Admin: true, run: rc.chatCommandBan},
+		{Name: "restart", Description: "Restarts the current session", Admin: true, run: rc.chatCommandRestart},
+		{Name: "next", Description: "Moves on to the next session", Admin: true, run: rc.chatCommandNextSession},
+		{Name: "penalise", Arguments: "<driver> <seconds|dq|clear>", Description: "Gives a driver a time penalty or disqualification, which is applied to the results at the end of the session", Admin: true, run: rc.chatCommandPenalise},
+	}
+}
+
+func (rc *RaceControl) findChatCommand(name string) *ChatCommand {
+	for _, command := range rc.chatCommands() {
+		if strings.EqualFold(command.Name, name) {
+			return command
+		}
+	}
+
+	return nil
+}
+
+// handleChatCommand looks for a Server Manager chat command in a message sent by a driver. If one is found it is run,
+// and true is returned. Unknown commands are treated as normal chat messages so that Lua plugins can still handle them.
+func (rc *RaceControl) handleChatCommand(sender *RaceControlDriver, message string) bool {
+	if !strings.HasPrefix(message, serverManagerChatCommandPrefix) {
+		return false
+	}
+
+	fields := strings.Fields(strings.TrimPrefix(message, serverManagerChatCommandPrefix))
+
+	if len(fields) == 0 {
+		return false
+	}
+
+	command := rc.findChatCommand(fields[0])
+
+	if command == nil {
+		return false
+	}
+
+	go panicCapture(func() {
+		reply := rc.runChatCommand(command, sender, fields[1:])
+
+		if reply == "" {
+			return
+		}
+
+		if err := rc.splitAndSendChat(reply, string(sender.CarInfo.DriverGUID)); err != nil {
+			logrus.WithError(err).Errorf("Could not reply to chat command: %s", command.Name)
+		}
+	})
+
+	return true
+}
+
+// runChatCommand runs a command for a driver, returning the reply that should be sent to them.
+func (rc *RaceControl) runChatCommand(command *ChatCommand, sender *RaceControlDriver, args []string) string {
+	if command.Admin && !rc.isChatCommandAdmin(sender) {
+		return fmt.Sprintf("You don't have permission to use %s%s", serverManagerChatCommandPrefix, command.Name)
+	}
+
+	logrus.Infof("Driver: %s (%s) ran chat command: %s %s", sender.CarInfo.DriverName, sender.CarInfo.DriverGUID, command.Name, strings.Join(args, " "))
+
+	reply, err := command.run(sender, args)
+
+	if commandErr, ok := err.(chatCommandError); ok {
+		return commandErr.Error()
+	} else if err != nil {
+		logrus.WithError(err).Errorf("Could not run chat command: %s", command.Name)
+		return fmt.Sprintf("Sorry, %s%s failed. Please try again later.", serverManagerChatCommandPrefix, command.Name)
+	}
+
+	return reply
+}
+
+// isChatCommandAdmin returns true if the driver's GUID matches an account with write access.
+func (rc *RaceControl) isChatCommandAdmin(driver *RaceControlDriver) bool {
+	return rc.chatCommandAdminAccount(driver) != nil
+}
+
+// chatCommandAdminAccount finds the account with write access whose GUID matches the driver's GUID, if there is one.
+func (rc *RaceControl) chatCommandAdminAccount(driver *RaceControlDriver) *Account {
+	accounts, err := rc.store.ListAccounts()
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not list accounts to check chat command permissions")
+		return nil
+	}
+
+	for _, account := range accounts {
+		if account.GUID != "" && account.GUID == string(driver.CarInfo.DriverGUID) && account.HasGroupPrivilege(GroupWrite) {
+			return account
+		}
+	}
+
+	return nil
+}
+
+// findChatCommandDriver finds a connected driver by their name, part of their name, or their GUID.
+func (rc *RaceControl) findChatCommandDriver(search string) (*RaceControlDriver, error) {
+	if search == "" {
+		return nil, chatCommandError("Please enter the name of a driver")
+	}
+
+	var exactMatch *RaceControlDriver
+	var partialMatches []*RaceControlDriver
+
+	_ = rc.ConnectedDrivers.Each(func(driverGUID udp.DriverGUID, driver *RaceControlDriver) error {
+		name := strings.ToLower(driver.CarInfo.DriverName)
+
+		switch {
+		case string(driverGUID) == search || name == strings.ToLower(search):
+			exactMatch = driver
+		case strings.Contains(name, strings.ToLower(search)):
+			partialMatches = append(partialMatches, driver)
+		}
+
+		return nil
+	})
+
+	switch {
+	case exactMatch != nil:
+		return exactMatch, nil
+	case len(partialMatches) == 1:
+		return partialMatches[0], nil
+	case len(partialMatches) > 1:
+		return nil, chatCommandError(fmt.Sprintf("More than one driver matches '%s', please enter more of their name", search))
+	default:
+		return nil, chatCommandError(fmt.Sprintf("No connected driver matches '%s'", search))
+	}
+}
+
+func (rc *RaceControl) chatCommandHelp(sender *RaceControlDriver, args []string) (string, error) {
+	isAdmin := rc.isChatCommandAdmin(sender)
+
+	if len(args) > 0 {
+		command := rc.findChatCommand(strings.TrimPrefix(args[0], serverManagerChatCommandPrefix))
+
+		if command == nil || (command.Admin && !isAdmin) {
+			return "", chatCommandError(fmt.Sprintf("Unknown command '%s'", args[0]))
+		}
+
+		usage := serverManagerChatCommandPrefix + command.Name
+
+		if command.Arguments != "" {
+			usage += " " + command.Arguments
+		}
+
+		return fmt.Sprintf("%s: %s", usage, command.Description), nil
+	}
+
+	var names []string
+
+	for _, command := range rc.chatCommands() {
+		if !command.Admin || isAdmin {
+			names = append(names, serverManagerChatCommandPrefix+command.Name)
+		}
+	}
+
+	return fmt.Sprintf("Commands: %s. Type !help <command> for more information.", strings.Join(names, ", ")), nil
+}
+
+func (rc *RaceControl) chatCommandStandings(sender *RaceControlDriver, args []string) (string, error) {
+	championshipID := rc.currentChampionshipID()
+
+	if championshipID == uuid.Nil {
+		var positions []string
+		position := 0
+
+		_ = rc.ConnectedDrivers.Each(func(driverGUID udp.DriverGUID, driver *RaceControlDriver) error {
+			position++
+
+			if position <= chatCommandNumStandings || driverGUID == sender.CarInfo.DriverGUID {
+				positions = append(positions, fmt.Sprintf("%d. %s", position, driverName(driver.CarInfo.DriverName)))
+			}
+
+			return nil
+		})
+
+		return "Positions: " + strings.Join(positions, ", "), nil
+	}
+
+	championship, err := loadChampionshipWithRaceWeekends(rc.store, championshipID.String())
+
+	if err != nil {
+		return "", err
+	}
+
+	class, err := championship.FindClassForCarModel(sender.CarInfo.CarModel)
+
+	if err != nil {
+		return "", chatCommandError(fmt.Sprintf("Your car isn't in any class in the %s championship", championship.Name))
+	}
+
+	var standings []string
+
+	for i, standing := range class.Standings(championship, championship.Events) {
+		if i < chatCommandNumStandings || standing.Car.GetGUID() == string(sender.CarInfo.DriverGUID) {
+			standings = append(standings, fmt.Sprintf("%d. %s %.f pts", i+1, driverName(standing.Car.GetName()), standing.Points))
+		}
+	}
+
+	if len(standings) == 0 {
+		return fmt.Sprintf("There are no standings in %s yet", championship.Name), nil
+	}
+
+	return fmt.Sprintf("%s standings: %s", championship.Name, strings.Join(standings, ", ")), nil
+}
+
+func (rc *RaceControl) chatCommandGap(sender *RaceControlDriver, args []string) (string, error) {
+	var order []*RaceControlDriver
+
+	_ = rc.ConnectedDrivers.Each(func(driverGUID udp.DriverGUID, driver *RaceControlDriver) error {
+		order = append(order, driver)
+		return nil
+	})
+
+	position := -1
+
+	for i, driver := range order {
+		if driver.CarInfo.DriverGUID == sender.CarInfo.DriverGUID {
+			position = i
+			break
+		}
+	}
+
+	if position < 0 || len(order) <= 1 {
+		return "There are no other drivers to compare against", nil
+	}
+
+	parts := []string{fmt.Sprintf("You are P%d", position+1)}
+
+	if position > 0 {
+		parts = append(parts, fmt.Sprintf("Ahead: %s %s", driverName(order[position-1].CarInfo.DriverName), chatCommandSplit(sender.Split)))
+	}
+
+	if position < len(order)-1 {
+		parts = append(parts, fmt.Sprintf("Behind: %s %s", driverName(order[position+1].CarInfo.DriverName), chatCommandSplit(order[position+1].Split)))
+	}
+
+	return strings.Join(parts, ". "), nil
+}
+
+func chatCommandSplit(split string) string {
+	if split == "" {
+		return "(no time)"
+	}
+
+	return "+" + split
+}
+
+func (rc *RaceControl) chatCommandBest(sender *RaceControlDriver, args []string) (string, error) {
+	var parts []string
+
+	if best := sender.CurrentCar().BestLap; best > 0 {
+		parts = append(parts, fmt.Sprintf("Your best: %s", formatDuration(best, true)))
+	} else {
+		parts = append(parts, "You haven't set a valid lap yet")
+	}
+
+	var sessionBest time.Duration
+	var sessionBestDriver string
+
+	for _, driver := range rc.AllLapTimes() {
+		for _, car := range driver.Cars {
+			if car.BestLap > 0 && (sessionBest == 0 || car.BestLap < sessionBest) {
+				sessionBest = car.BestLap
+				sessionBestDriver = driver.CarInfo.DriverName
+			}
+		}
+	}
+
+	if sessionBest > 0 {
+		parts = append(parts, fmt.Sprintf("Session best: %s (%s)", formatDuration(sessionBest, true), driverName(sessionBestDriver)))
+	}
+
+	leaderboard, err := BuildLeaderboard(rc.SessionInfo.Track, rc.SessionInfo.TrackConfig, sender.CarInfo.CarModel, AnyLeaderboardFilter())
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not build leaderboard for chat command")
+	} else if len(leaderboard.Entries) > 0 {
+		record := leaderboard.Entries[0]
+
+		parts = append(parts, fmt.Sprintf("Record: %s (%s)", formatDuration(record.LapTime, true), driverName(record.DriverName)))
+	}
+
+	return strings.Join(parts, ". "), nil
+}
+
+func (rc *RaceControl) chatCommandSchedule(sender *RaceControlDriver, args []string) (string, error) {
+	scheduled, err := rc.scheduledRacesManager.getScheduledRaces(false)
+
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+
+	var upcoming []ScheduledEvent
+
+	for _, event := range scheduled {
+		if event.GetScheduledTime().After(now) {
+			upcoming = append(upcoming, event)
+		}
+	}
+
+	if len(upcoming) == 0 {
+		return "There are no events scheduled on this server", nil
+	}
+
+	sort.Slice(upcoming, func(i, j int) bool {
+		return upcoming[i].GetScheduledTime().Before(upcoming[j].GetScheduledTime())
+	})
+
+	var events []string
+
+	for i, event := range upcoming {
+		if i >= chatCommandNumScheduled {
+			break
+		}
+
+		raceSetup := event.GetRaceSetup()
+		description := trackSummary(raceSetup.Track, raceSetup.TrackLayout)
+
+		if summary := event.GetSummary(); summary != "" {
+			description = summary + " at " + description
+		}
+
+		events = append(events, fmt.Sprintf("%s: %s", event.GetScheduledTime().Local().Format(chatCommandScheduleFormat), description))
+	}
+
+	return "Upcoming events: " + strings.Join(events, ". "), nil
+}
+
+func (rc *RaceControl) chatCommandPenalties(sender *RaceControlDriver, args []string) (string, error) {
+	rc.sessionPenaltiesMutex.Lock()
+	defer rc.sessionPenaltiesMutex.Unlock()
+
+	penalty, ok := rc.sessionPenalties[sender.CarInfo.DriverGUID]
+
+	switch {
+	case !ok || (!penalty.disqualified && penalty.penalty <= 0):
+		return "You have no penalties in this session", nil
+	case penalty.disqualified:
+		return "You have been disqualified from this session", nil
+	default:
+		return fmt.Sprintf("You have a %s time penalty, which will be applied at the end of the session", penalty.penalty.Round(time.Millisecond)), nil
+	}
+}
+
+// voteKick tracks the drivers who have voted to kick another driver.
+type voteKick struct {
+	started time.Time
+	votes   map[udp.DriverGUID]bool
+}
+
+func (rc *RaceControl) chatCommandVoteKick(sender *RaceControlDriver, args []string) (string, error) {
+	target, err := rc.findChatCommandDriver(strings.Join(args, " "))
+
+	if err != nil {
+		return "", err
+	}
+
+	if target.CarInfo.DriverGUID == sender.CarInfo.DriverGUID {
+		return "", chatCommandError("You can't vote to kick yourself")
+	}
+
+	if rc.isChatCommandAdmin(target) {
+		return "", chatCommandError(fmt.Sprintf("%s is an admin and can't be vote kicked", driverName(target.CarInfo.DriverName)))
+	}
+
+	numDrivers := rc.ConnectedDrivers.Len()
+
+	if numDrivers < voteKickMinimumDrivers {
+		return "", chatCommandError(fmt.Sprintf("At least %d drivers must be connected to vote kick", voteKickMinimumDrivers))
+	}
+
+	// a majority of the drivers other than the one being kicked must vote
+	votesRequired := (numDrivers-1)/2 + 1
+
+	rc.voteKicksMutex.Lock()
+
+	vote, ok := rc.voteKicks[target.CarInfo.DriverGUID]
+
+	if !ok || time.Since(vote.started) > voteKickTimeout {
+		vote = &voteKick{
+			started: time.Now(),
+			votes:   make(map[udp.DriverGUID]bool),
+		}
+
+		rc.voteKicks[target.CarInfo.DriverGUID] = vote
+	}
+
+	vote.votes[sender.CarInfo.DriverGUID] = true
+	numVotes := len(vote.votes)
+
+	if numVotes >= votesRequired {
+		delete(rc.voteKicks, target.CarInfo.DriverGUID)
+	}
+
+	rc.voteKicksMutex.Unlock()
+
+	name := driverName(target.CarInfo.DriverName)
+
+	if numVotes < votesRequired {
+		return "", rc.splitAndBroadcastChat(fmt.Sprintf("Vote to kick %s: %d/%d votes. Type !votekick %s to vote.", name, numVotes, votesRequired, name), nil)
+	}
+
+	if err := rc.process.SendUDPMessage(udp.NewKickUser(uint8(target.CarInfo.CarID))); err != nil {
+		return "", err
+	}
+
+	return "", rc.splitAndBroadcastChat(fmt.Sprintf("%s has been kicked by vote", name), nil)
+}
+
+func (rc *RaceControl) chatCommandKick(sender *RaceControlDriver, args []string) (string, error) {
+	target, err := rc.findChatCommandDriver(strings.Join(args, " "))
+
+	if err != nil {
+		return "", err
+	}
+
+	if err := rc.process.SendUDPMessage(udp.NewKickUser(uint8(target.CarInfo.CarID))); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s has been kicked", driverName(target.CarInfo.DriverName)), nil
+}
+
+func (rc *RaceControl) chatCommandBan(sender *RaceControlDriver, args []string) (string, error) {
+	if len(args) == 0 {
+		return "", chatCommandError("Please enter the name of a driver")
+	}
+
+	var target *RaceControlDriver
+	var err error
+	var reason string
+
+	// driver names can contain spaces, so the longest run of arguments which matches a driver is their name, and
+	// anything after it is the reason for the ban.
+	for i := len(args); i > 0; i-- {
+		target, err = rc.findChatCommandDriver(strings.Join(args[:i], " "))
+
+		if err == nil {
+			reason = strings.Join(args[i:], " ")
+			break
+		}
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if rc.blocklistManager != nil {
+		if _, err := rc.blocklistManager.Ban(string(target.CarInfo.DriverGUID), target.CarInfo.DriverName, reason, time.Time{}, rc.chatCommandAdminAccount(sender)); err != nil {
+			return "", err
+		}
+	}
+
+	// the server bans the driver too, which kicks them straight away.
+	command, err := udp.NewAdminCommand(fmt.Sprintf("/ban_id %d", target.CarInfo.CarID))
+
+	if err != nil {
+		return "", err
+	}
+
+	if err := rc.process.SendUDPMessage(command); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s has been banned", driverName(target.CarInfo.DriverName)), nil
+}
+
+func (rc *RaceControl) chatCommandRestart(sender *RaceControlDriver, args []string) (string, error) {
+	if err := rc.process.SendUDPMessage(&udp.RestartSession{}); err != nil {
+		return "", err
+	}
+
+	return "Restarting the session", nil
+}
+
+func (rc *RaceControl) chatCommandNextSession(sender *RaceControlDriver, args []string) (string, error) {
+	if err := rc.process.SendUDPMessage(&udp.NextSession{}); err != nil {
+		return "", err
+	}
+
+	return "Moving on to the next session", nil
+}
+
+func (rc *RaceControl) chatCommandPenalise(sender *RaceControlDriver, args []string) (string, error) {
+	if len(args) < 2 {
+		return "", chatCommandError("Usage: !penalise <driver> <seconds|dq|clear>")
+	}
+
+	target, err := rc.findChatCommandDriver(strings.Join(args[:len(args)-1], " "))
+
+	if err != nil {
+		return "", err
+	}
+
+	name := driverName(target.CarInfo.DriverName)
+	guid := target.CarInfo.DriverGUID
+
+	var reply, message string
+
+	rc.sessionPenaltiesMutex.Lock()
+
+	penalty, ok := rc.sessionPenalties[guid]
+
+	if !ok {
+		penalty = &sessionPenalty{carModel: target.CarInfo.CarModel}
+	}
+
+	switch strings.ToLower(args[len(args)-1]) {
+	case "dq":
+		penalty.disqualified = true
+
+		reply = fmt.Sprintf("%s has been disqualified", name)
+		message = "You have been disqualified from this session by race control"
+	case "clear":
+		penalty.disqualified = false
+		penalty.penalty = 0
+
+		reply = fmt.Sprintf("Penalties for %s have been cleared", name)
+		message = "Your penalties for this session have been cleared by race control"
+	default:
+		seconds, err := strconv.ParseFloat(args[len(args)-1], 64)
+
+		if err != nil || math.IsNaN(seconds) || seconds <= 0 || seconds > chatCommandMaxPenalty.Seconds() {
+			rc.sessionPenaltiesMutex.Unlock()
+			return "", chatCommandError(fmt.Sprintf("The penalty must be a number of seconds up to %s, 'dq' or 'clear'", chatCommandMaxPenalty))
+		}
+
+		penaltyTime := time.Duration(seconds * float64(time.Second))
+		penalty.penalty += penaltyTime
+
+		reply = fmt.Sprintf("%s has been given a %s time penalty (%s in total)", name, penaltyTime, penalty.penalty)
+		message = fmt.Sprintf("You have been given a %s time penalty by race control, which will be applied at the end of the session", penaltyTime)
+	}
+
+	rc.sessionPenalties[guid] = penalty
+
+	rc.sessionPenaltiesMutex.Unlock()
+
+	if err := rc.splitAndSendChat(message, string(guid)); err != nil {
+		logrus.WithError(err).Errorf("Could not notify driver: %s of penalty", guid)
+	}
+
+	return reply, nil
+}
@@ -0,0 +1,148 @@
+package servermanager
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRaceControl_ChatCommands(t *testing.T) {
+	raceControl := NewRaceControl(NilBroadcaster{}, nilTrackData{}, dummyServerProcess{}, testStore, NewPenaltiesManager(testStore), NewBlocklistManager(testStore))
+
+	for _, driver := range drivers[:3] {
+		if err := raceControl.OnClientConnect(driver); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	admin := NewAccount()
+	admin.Name = "chat-command-admin"
+	admin.GUID = string(drivers[0].DriverGUID)
+	admin.Groups[serverID] = GroupWrite
+
+	if err := testStore.UpsertAccount(admin); err != nil {
+		t.Fatal(err)
+	}
+
+	defer testStore.DeleteAccount(admin.ID.String()) //nolint:errcheck
+
+	adminDriver, ok := raceControl.ConnectedDrivers.Get(drivers[0].DriverGUID)
+
+	if !ok {
+		t.Fatal("Admin driver is not connected")
+	}
+
+	driver, ok := raceControl.ConnectedDrivers.Get(drivers[1].DriverGUID)
+
+	if !ok {
+		t.Fatal("Driver is not connected")
+	}
+
+	run := func(sender *RaceControlDriver, name string, args ...string) string {
+		command := raceControl.findChatCommand(name)
+
+		if command == nil {
+			t.Fatalf("Could not find command: %s", name)
+		}
+
+		return raceControl.runChatCommand(command, sender, args)
+	}
+
+	t.Run("Unknown commands are treated as chat", func(t *testing.T) {
+		if raceControl.handleChatCommand(driver, "!notacommand") {
+			t.Error("Expected unknown command not to be handled")
+		}
+
+		if raceControl.handleChatCommand(driver, "hello !help") {
+			t.Error("Expected message without a command prefix not to be handled")
+		}
+	})
+
+	t.Run("Help only lists admin commands for admins", func(t *testing.T) {
+		if reply := run(driver, "help"); strings.Contains(reply, "!kick") || !strings.Contains(reply, "!gap") {
+			t.Errorf("Unexpected help for driver: %s", reply)
+		}
+
+		if reply := run(adminDriver, "help"); !strings.Contains(reply, "!kick") {
+			t.Errorf("Unexpected help for admin: %s", reply)
+		}
+	})
+
+	t.Run("Drivers can't use admin commands", func(t *testing.T) {
+		if reply := run(driver, "penalise", "Test 3", "5"); !strings.Contains(reply, "permission") {
+			t.Errorf("Expected permission error, got: %s", reply)
+		}
+	})
+
+	t.Run("Admins can penalise drivers", func(t *testing.T) {
+		if reply := run(adminDriver, "penalise", "Test", "5"); !strings.Contains(reply, "More than one driver") {
+			t.Errorf("Expected ambiguous driver error, got: %s", reply)
+		}
+
+		run(adminDriver, "penalise", "Test 2", "5")
+		run(adminDriver, "penalise", "test 2", "2.5")
+
+		penalty, ok := raceControl.sessionPenalties[drivers[1].DriverGUID]
+
+		if !ok || penalty.penalty != 7500*time.Millisecond || penalty.carModel != drivers[1].CarModel {
+			t.Fatalf("Unexpected session penalty: %+v", penalty)
+		}
+
+		if reply := run(driver, "penalties"); !strings.Contains(reply, "7.5s") {
+			t.Errorf("Expected penalties reply to contain penalty, got: %s", reply)
+		}
+
+		run(adminDriver, "penalise", "Test 2", "clear")
+
+		if reply := run(driver, "penalties"); reply != "You have no penalties in this session" {
+			t.Errorf("Expected penalties to be cleared, got: %s", reply)
+		}
+	})
+
+	t.Run("Penalties must be a finite number of seconds up to the maximum", func(t *testing.T) {
+		for _, seconds := range []string{"inf", "+Inf", "-inf", "nan", "NaN", "1e300", "0", "-5", "3601"} {
+			if reply := run(adminDriver, "penalise", "Test 2", seconds); !strings.Contains(reply, "The penalty must be") {
+				t.Errorf("Expected a penalty of %s to be rejected, got: %s", seconds, reply)
+			}
+		}
+
+		if penalty, ok := raceControl.sessionPenalties[drivers[1].DriverGUID]; ok && penalty.penalty != 0 {
+			t.Errorf("Expected no penalty to be added, got: %s", penalty.penalty)
+		}
+	})
+
+	t.Run("Admins can ban drivers with a reason", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "chat-command-ban")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer os.RemoveAll(dir)
+
+		oldInstallPath := ServerInstallPath
+		ServerInstallPath = dir
+
+		defer func() {
+			ServerInstallPath = oldInstallPath
+		}()
+
+		if reply := run(adminDriver, "ban", "test 3", "wrecking", "in", "turn", "1"); reply != "Test 3 has been banned" {
+			t.Fatalf("Expected the driver to be banned, got: %s", reply)
+		}
+
+		defer raceControl.blocklistManager.Unban(string(drivers[2].DriverGUID), nil) //nolint:errcheck
+
+		bans, err := raceControl.blocklistManager.ActiveBans()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(bans) != 1 || bans[0].GUID != string(drivers[2].DriverGUID) || bans[0].Reason != "wrecking in turn 1" || bans[0].IssuedBy != admin.Name || !bans[0].IsPermanent() {
+			t.Errorf("Expected a permanent ban issued by %s with a reason, got: %+v", admin.Name, bans)
+		}
+	})
+}
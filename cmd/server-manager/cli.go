@@ -0,0 +1,325 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	servermanager "github.com/JustaPenguin/assetto-server-manager"
+
+	"github.com/etcd-io/bbolt"
+)
+
+// command is a command line subcommand of server-manager. Commands with subcommands print their usage if they
+// are run without one.
+type command struct {
+	Name        string
+	Usage       string
+	Description string
+	Subcommands []*command
+
+	// Run is called with the arguments which follow the command name.
+	Run func(args []string) error
+}
+
+var commands = []*command{
+	accountsCommand,
+	eventsCommand,
+	championshipsCommand,
+	resultsCommand,
+	storeCommand,
+	configCommand,
+}
+
+// storeOpenTimeout is how long commands wait for a boltdb store to be closed by another program.
+const storeOpenTimeout = 5 * time.Second
+
+// runCommand runs the command named by args, returning the exit code for the process.
+func runCommand(args []string) int {
+	cmd, path, rest := findCommand(&command{Subcommands: commands}, nil, args)
+
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", strings.Join(args, " "))
+		printCommands(os.Stderr, "", commands)
+		return 2
+	}
+
+	if cmd.Run == nil {
+		printUsage(os.Stderr, path, cmd)
+		return 2
+	}
+
+	if err := cmd.Run(rest); err == flag.ErrHelp {
+		return 2
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+func findCommand(parent *command, path []string, args []string) (*command, []string, []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return parent, path, args
+	}
+
+	for _, cmd := range parent.Subcommands {
+		if cmd.Name == args[0] {
+			return findCommand(cmd, append(path, cmd.Name), args[1:])
+		}
+	}
+
+	if len(parent.Subcommands) == 0 {
+		return parent, path, args
+	}
+
+	if args[0] == "help" {
+		return parent, path, nil
+	}
+
+	return nil, path, args
+}
+
+func printUsage(w io.Writer, path []string, cmd *command) {
+	if cmd.Description != "" {
+		fmt.Fprintf(w, "%s\n\n", cmd.Description)
+	}
+
+	printCommands(w, strings.Join(path, " "), cmd.Subcommands)
+}
+
+func printCommands(w io.Writer, prefix string, commands []*command) {
+	fmt.Fprintln(w, "Usage:")
+
+	for _, cmd := range commands {
+		name := strings.Join(strings.Fields("server-manager "+prefix+" "+cmd.Name), " ")
+
+		if cmd.Usage != "" {
+			name += " " + cmd.Usage
+		}
+
+		fmt.Fprintf(w, "  %s\n", name)
+
+		if cmd.Description != "" {
+			fmt.Fprintf(w, "        %s\n", cmd.Description)
+		}
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands use the store in config.yml, in the current directory. If you use the boltdb store,")
+	fmt.Fprintln(w, "stop Server Manager before running them, as only one program can open the store at a time.")
+	fmt.Fprintln(w, "Starting, stopping and scheduling events is done through a running Server Manager instead.")
+	fmt.Fprintln(w, "Commands which list or show things print JSON instead of text with the -json flag.")
+}
+
+// newFlagSet returns a flag set for a command, which prints the command's usage on error.
+func newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: server-manager %s %s\n", name, usage)
+		fs.PrintDefaults()
+	}
+
+	return fs
+}
+
+// readConfig reads config.yml from the current directory.
+func readConfig() (*servermanager.Configuration, error) {
+	config, err := servermanager.ReadConfig("config.yml")
+
+	if err != nil {
+		return nil, fmt.Errorf("could not read configuration file (config.yml): %w", err)
+	}
+
+	servermanager.SetAssettoInstallPath(config.Steam.InstallPath)
+
+	config.Store.OpenTimeout = storeOpenTimeout
+
+	return config, nil
+}
+
+// openStore opens the store in config.yml, optionally running any migrations which have not yet been run.
+func openStore(migrate bool) (servermanager.Store, error) {
+	config, err := readConfig()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var store servermanager.Store
+
+	if migrate {
+		store, err = config.Store.BuildStore()
+	} else {
+		store, err = config.Store.OpenStore()
+	}
+
+	if err != nil {
+		return nil, storeOpenError(err)
+	}
+
+	return store, nil
+}
+
+// storeOpenError explains errors from opening the store, which are usually because Server Manager is running.
+func storeOpenError(err error) error {
+	if err == bbolt.ErrTimeout {
+		return fmt.Errorf("could not open server manager storage, it is in use by another program. Stop Server Manager and try again")
+	}
+
+	return fmt.Errorf("could not open server manager storage: %w", err)
+}
+
+func closeStore(store servermanager.Store) {
+	if closer, ok := store.(io.Closer); ok {
+		_ = closer.Close()
+	}
+}
+
+// withStore opens the store in config.yml and calls fn with it, closing the store afterwards.
+func withStore(fn func(store servermanager.Store) error) error {
+	store, err := openStore(true)
+
+	if err != nil {
+		return err
+	}
+
+	defer closeStore(store)
+
+	if err := servermanager.InitServerID(store); err != nil {
+		return err
+	}
+
+	return fn(store)
+}
+
+// jsonFlag adds the -json flag to a command's flag set.
+func jsonFlag(fs *flag.FlagSet) *bool {
+	return fs.Bool("json", false, "print JSON instead of text")
+}
+
+// printJSON writes v to stdout as indented JSON.
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(v)
+}
+
+// newTable returns a writer which aligns tab separated columns, for printing lists. It must be flushed once the
+// list has been written.
+func newTable(headings ...string) *tabwriter.Writer {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(w, strings.Join(headings, "\t"))
+
+	return w
+}
+
+// formatTime formats a time for printing in a table, leaving zero times blank.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+var configCommand = &command{
+	Name:        "config",
+	Description: "Export and import Server Manager's configuration as YAML files.",
+	Subcommands: []*command{
+		{
+			Name:        "export",
+			Usage:       "<dir>",
+			Description: "Write the configuration to a directory of YAML files.",
+			Run:         runConfigExport,
+		},
+		{
+			Name:        "import",
+			Usage:       "[-dry-run] <dir>",
+			Description: "Create or update the configuration from a directory of YAML files.",
+			Run:         runConfigImport,
+		},
+	},
+}
+
+func runConfigExport(args []string) error {
+	fs := newFlagSet("config export", "<dir>")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	return withStore(func(store servermanager.Store) error {
+		if err := servermanager.NewConfigYAMLManager(store).Export(fs.Arg(0)); err != nil {
+			return err
+		}
+
+		fmt.Printf("Configuration exported to %s\n", fs.Arg(0))
+
+		return nil
+	})
+}
+
+func runConfigImport(args []string) error {
+	fs := newFlagSet("config import", "[-dry-run] <dir>")
+	dryRun := fs.Bool("dry-run", false, "show the changes that would be made, without making them")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	return withStore(func(store servermanager.Store) error {
+		result, err := servermanager.NewConfigYAMLManager(store).Import(fs.Arg(0), *dryRun)
+
+		if result != nil {
+			for _, change := range result.Changes {
+				if change.Action == servermanager.ConfigImportUnchanged {
+					continue
+				}
+
+				if change.Name == change.Type {
+					fmt.Printf("%s %s\n", change.Action, change.Type)
+				} else {
+					fmt.Printf("%s %s: %s\n", change.Action, change.Type, change.Name)
+				}
+
+				for _, line := range change.Diff {
+					fmt.Printf("    %s\n", line)
+				}
+			}
+		}
+
+		if err != nil {
+			return err
+		}
+
+		verb := "were"
+
+		if result.DryRun {
+			verb = "would be"
+		}
+
+		fmt.Printf("%d items %s created, %d %s updated and %d are unchanged.\n", result.Count(servermanager.ConfigImportCreated), verb, result.Count(servermanager.ConfigImportUpdated), verb, result.Count(servermanager.ConfigImportUnchanged))
+
+		return nil
+	})
+}
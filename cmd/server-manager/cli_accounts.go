@@ -0,0 +1,247 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	servermanager "github.com/JustaPenguin/assetto-server-manager"
+)
+
+var accountsCommand = &command{
+	Name:        "accounts",
+	Description: "Manage the accounts which can log in to Server Manager.",
+	Subcommands: []*command{
+		{
+			Name:        "list",
+			Usage:       "[-json]",
+			Description: "List every account and its group on this server.",
+			Run:         runAccountsList,
+		},
+		{
+			Name:        "create",
+			Usage:       "[-group <group>] [-json] <name>",
+			Description: "Create an account with a generated password, which must be changed when it first logs in.",
+			Run:         runAccountsCreate,
+		},
+		{
+			Name:        "reset-password",
+			Usage:       "[-password <password>] [-json] <name>",
+			Description: "Reset the password of an account to a generated password, or set it to the given password.",
+			Run:         runAccountsResetPassword,
+		},
+		{
+			Name:        "set-group",
+			Usage:       "[-all-servers] <name> <group>",
+			Description: "Change the group of an account (" + groupNames() + ").",
+			Run:         runAccountsSetGroup,
+		},
+	},
+}
+
+func groupNames() string {
+	var names []string
+
+	for _, group := range servermanager.Groups {
+		names = append(names, string(group))
+	}
+
+	return strings.Join(names, ", ")
+}
+
+// accountOutput is an account as it is printed by the accounts commands. It leaves out password hashes and API
+// tokens. DefaultPassword is only set when the account has a generated password which hasn't been changed yet.
+type accountOutput struct {
+	ID              string              `json:"id"`
+	Name            string              `json:"name"`
+	Group           servermanager.Group `json:"group"`
+	DriverName      string              `json:"driver_name,omitempty"`
+	GUID            string              `json:"guid,omitempty"`
+	Created         time.Time           `json:"created"`
+	DefaultPassword string              `json:"default_password,omitempty"`
+}
+
+func newAccountOutput(account *servermanager.Account) accountOutput {
+	return accountOutput{
+		ID:              account.ID.String(),
+		Name:            account.Name,
+		Group:           account.Group(),
+		DriverName:      account.DriverName,
+		GUID:            account.GUID,
+		Created:         account.Created,
+		DefaultPassword: account.DefaultPassword,
+	}
+}
+
+func runAccountsList(args []string) error {
+	fs := newFlagSet("accounts list", "[-json]")
+	asJSON := jsonFlag(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	return withStore(func(store servermanager.Store) error {
+		accounts, err := store.ListAccounts()
+
+		if err != nil {
+			return err
+		}
+
+		out := make([]accountOutput, 0, len(accounts))
+
+		for _, account := range accounts {
+			if !account.Deleted.IsZero() {
+				continue
+			}
+
+			out = append(out, newAccountOutput(account))
+		}
+
+		if *asJSON {
+			return printJSON(out)
+		}
+
+		w := newTable("NAME", "GROUP", "DRIVER", "GUID", "CREATED")
+
+		for _, account := range out {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", account.Name, account.Group, account.DriverName, account.GUID, formatTime(account.Created))
+		}
+
+		return w.Flush()
+	})
+}
+
+func runAccountsCreate(args []string) error {
+	fs := newFlagSet("accounts create", "[-group <group>] [-json] <name>")
+	groupName := fs.String("group", string(servermanager.GroupRead), "the group of the account ("+groupNames()+")")
+	asJSON := jsonFlag(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	group, err := servermanager.ParseGroup(*groupName)
+
+	if err != nil {
+		return fmt.Errorf("unknown group %q, must be one of: %s", *groupName, groupNames())
+	}
+
+	return withStore(func(store servermanager.Store) error {
+		account, err := servermanager.NewAccountManager(store).CreateAccount(fs.Arg(0), group)
+
+		if err != nil {
+			return err
+		}
+
+		if *asJSON {
+			return printJSON(newAccountOutput(account))
+		}
+
+		fmt.Printf("Created account %s in group %s.\n", account.Name, group)
+		fmt.Printf("Its password is %s, which must be changed when it first logs in.\n", account.DefaultPassword)
+
+		return nil
+	})
+}
+
+func runAccountsResetPassword(args []string) error {
+	fs := newFlagSet("accounts reset-password", "[-password <password>] [-json] <name>")
+	password := fs.String("password", "", "set the account's password to this instead of generating one")
+	asJSON := jsonFlag(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	return withStore(func(store servermanager.Store) error {
+		account, err := findAccount(store, fs.Arg(0))
+
+		if err != nil {
+			return err
+		}
+
+		accountManager := servermanager.NewAccountManager(store)
+
+		if *password != "" {
+			err = accountManager.ChangePassword(account, *password)
+		} else {
+			account, err = accountManager.ResetPassword(account.ID.String())
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if *asJSON {
+			return printJSON(newAccountOutput(account))
+		}
+
+		if account.DefaultPassword != "" {
+			fmt.Printf("The password of %s has been reset to %s, which must be changed when it next logs in.\n", account.Name, account.DefaultPassword)
+		} else {
+			fmt.Printf("The password of %s has been changed.\n", account.Name)
+		}
+
+		return nil
+	})
+}
+
+func runAccountsSetGroup(args []string) error {
+	fs := newFlagSet("accounts set-group", "[-all-servers] <name> <group>")
+	allServers := fs.Bool("all-servers", false, "change the group on every server the account can access, not only this one")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	group, err := servermanager.ParseGroup(fs.Arg(1))
+
+	if err != nil {
+		return fmt.Errorf("unknown group %q, must be one of: %s", fs.Arg(1), groupNames())
+	}
+
+	return withStore(func(store servermanager.Store) error {
+		account, err := findAccount(store, fs.Arg(0))
+
+		if err != nil {
+			return err
+		}
+
+		if err := servermanager.NewAccountManager(store).SetGroup(account, group, *allServers); err != nil {
+			return err
+		}
+
+		fmt.Printf("%s is now in group %s.\n", account.Name, group)
+
+		return nil
+	})
+}
+
+func findAccount(store servermanager.Store, name string) (*servermanager.Account, error) {
+	account, err := store.FindAccountByName(name)
+
+	if err == servermanager.ErrAccountNotFound || (err == nil && !account.Deleted.IsZero()) {
+		return nil, fmt.Errorf("could not find account %q", name)
+	} else if err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	servermanager "github.com/JustaPenguin/assetto-server-manager"
+	"github.com/JustaPenguin/assetto-server-manager/cmd/server-manager/views"
+)
+
+var championshipsCommand = &command{
+	Name:        "championships",
+	Description: "Export, import and recalculate Championships.",
+	Subcommands: []*command{
+		{
+			Name:        "list",
+			Usage:       "[-json]",
+			Description: "List every Championship and its progress.",
+			Run:         runChampionshipsList,
+		},
+		{
+			Name:        "export",
+			Usage:       "<id> [<file>]",
+			Description: "Write a Championship as JSON to a file, or to stdout if no file is given.",
+			Run:         runChampionshipsExport,
+		},
+		{
+			Name:        "import",
+			Usage:       "<file>",
+			Description: "Create or update a Championship from an exported JSON file, or from stdin if the file is '-'.",
+			Run:         runChampionshipsImport,
+		},
+		{
+			Name:        "recalculate",
+			Usage:       "[-json] <id>",
+			Description: "Reload a Championship's results from their results files and print the new standings.",
+			Run:         runChampionshipsRecalculate,
+		},
+	},
+}
+
+// withChampionshipManager opens the store in config.yml and calls fn with a ChampionshipManager which uses it.
+func withChampionshipManager(fn func(championshipManager *servermanager.ChampionshipManager) error) error {
+	return withStore(func(store servermanager.Store) error {
+		resolver, err := servermanager.NewResolver(&views.TemplateLoader{}, false, store)
+
+		if err != nil {
+			return err
+		}
+
+		return fn(resolver.ResolveChampionshipManager())
+	})
+}
+
+type championshipOutput struct {
+	ID              string    `json:"id"`
+	Name            string    `json:"name"`
+	Events          int       `json:"events"`
+	CompletedEvents int       `json:"completed_events"`
+	Updated         time.Time `json:"updated"`
+}
+
+func runChampionshipsList(args []string) error {
+	fs := newFlagSet("championships list", "[-json]")
+	asJSON := jsonFlag(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	return withStore(func(store servermanager.Store) error {
+		championships, err := store.ListChampionships()
+
+		if err != nil {
+			return err
+		}
+
+		out := make([]championshipOutput, 0, len(championships))
+
+		for _, championship := range championships {
+			if !championship.Deleted.IsZero() {
+				continue
+			}
+
+			out = append(out, championshipOutput{
+				ID:              championship.ID.String(),
+				Name:            championship.Name,
+				Events:          len(championship.Events),
+				CompletedEvents: championship.NumCompletedEvents(),
+				Updated:         championship.Updated,
+			})
+		}
+
+		if *asJSON {
+			return printJSON(out)
+		}
+
+		w := newTable("ID", "NAME", "EVENTS", "UPDATED")
+
+		for _, championship := range out {
+			fmt.Fprintf(w, "%s\t%s\t%d/%d\t%s\n", championship.ID, championship.Name, championship.CompletedEvents, championship.Events, formatTime(championship.Updated))
+		}
+
+		return w.Flush()
+	})
+}
+
+func runChampionshipsExport(args []string) error {
+	fs := newFlagSet("championships export", "<id> [<file>]")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() < 1 || fs.NArg() > 2 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	return withChampionshipManager(func(championshipManager *servermanager.ChampionshipManager) error {
+		championship, err := championshipManager.LoadChampionship(fs.Arg(0))
+
+		if err != nil {
+			return fmt.Errorf("could not load championship %s: %w", fs.Arg(0), err)
+		}
+
+		data, err := json.MarshalIndent(championship, "", "  ")
+
+		if err != nil {
+			return err
+		}
+
+		if fs.NArg() == 1 {
+			_, err := fmt.Println(string(data))
+			return err
+		}
+
+		if err := ioutil.WriteFile(fs.Arg(1), data, 0644); err != nil {
+			return err
+		}
+
+		fmt.Printf("Championship %s exported to %s\n", championship.Name, fs.Arg(1))
+
+		return nil
+	})
+}
+
+func runChampionshipsImport(args []string) error {
+	fs := newFlagSet("championships import", "<file>")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	var data []byte
+	var err error
+
+	if fs.Arg(0) == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(fs.Arg(0))
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return withChampionshipManager(func(championshipManager *servermanager.ChampionshipManager) error {
+		championshipID, err := championshipManager.ImportChampionship(string(data))
+
+		if err != nil {
+			return fmt.Errorf("could not import championship: %w", err)
+		}
+
+		fmt.Printf("Championship %s imported.\n", championshipID)
+
+		return nil
+	})
+}
+
+type standingOutput struct {
+	Position int     `json:"position"`
+	Driver   string  `json:"driver"`
+	GUID     string  `json:"guid"`
+	Car      string  `json:"car"`
+	Team     string  `json:"team,omitempty"`
+	Points   float64 `json:"points"`
+}
+
+type classStandingsOutput struct {
+	Class     string           `json:"class"`
+	Standings []standingOutput `json:"standings"`
+}
+
+func runChampionshipsRecalculate(args []string) error {
+	fs := newFlagSet("championships recalculate", "[-json] <id>")
+	asJSON := jsonFlag(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	return withChampionshipManager(func(championshipManager *servermanager.ChampionshipManager) error {
+		championship, err := championshipManager.RecalculateResults(fs.Arg(0))
+
+		if err != nil {
+			return fmt.Errorf("could not recalculate championship %s: %w", fs.Arg(0), err)
+		}
+
+		out := make([]classStandingsOutput, 0, len(championship.Classes))
+
+		for _, class := range championship.Classes {
+			classStandings := classStandingsOutput{Class: class.Name, Standings: []standingOutput{}}
+
+			for i, standing := range class.Standings(championship, championship.Events) {
+				classStandings.Standings = append(classStandings.Standings, standingOutput{
+					Position: i + 1,
+					Driver:   standing.Car.GetName(),
+					GUID:     standing.Car.Driver.GUID,
+					Car:      standing.Car.Model,
+					Team:     standing.TeamSummary(),
+					Points:   standing.Points,
+				})
+			}
+
+			out = append(out, classStandings)
+		}
+
+		if *asJSON {
+			return printJSON(out)
+		}
+
+		fmt.Printf("Recalculated the results of %s.\n", championship.Name)
+
+		for _, class := range out {
+			fmt.Printf("\n%s\n", class.Class)
+
+			w := newTable("POS", "DRIVER", "CAR", "TEAM", "POINTS")
+
+			for _, standing := range class.Standings {
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%g\n", standing.Position, standing.Driver, standing.Car, standing.Team, standing.Points)
+			}
+
+			if err := w.Flush(); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,378 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"time"
+
+	servermanager "github.com/JustaPenguin/assetto-server-manager"
+)
+
+var eventsCommand = &command{
+	Name: "events",
+	Description: "List events, and start, stop or schedule them on a running Server Manager. Events are started " +
+		"using the API, so need an API token with write access (-token or SERVER_MANAGER_API_TOKEN).",
+	Subcommands: []*command{
+		{
+			Name:        "list",
+			Usage:       "[-json]",
+			Description: "List custom races, Championship events and Race Weekend sessions, with their IDs.",
+			Run:         runEventsList,
+		},
+		{
+			Name:        "start",
+			Usage:       "[-url <url>] [-token <token>] <id>",
+			Description: "Start an event.",
+			Run:         runEventsStart,
+		},
+		{
+			Name:        "stop",
+			Usage:       "[-url <url>] [-token <token>] <id>",
+			Description: "Stop an event, if it is running.",
+			Run:         runEventsStop,
+		},
+		{
+			Name:        "schedule",
+			Usage:       "[-url <url>] [-token <token>] [-recurrence <rrule>] [-after-parent] [-remove] <id> [<time>]",
+			Description: "Schedule an event to start at a time, e.g. '2006-01-02 15:04' in local time or RFC 3339, or remove its schedule.",
+			Run:         runEventsSchedule,
+		},
+	},
+}
+
+// eventIDRegex matches event IDs, which are the path of the event in the API.
+var eventIDRegex = regexp.MustCompile(`^(custom-races/[0-9a-f-]{36}|championships/[0-9a-f-]{36}/events/[0-9a-f-]{36}|race-weekends/[0-9a-f-]{36}/sessions/[0-9a-f-]{36})$`)
+
+type eventOutput struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Name      string    `json:"name"`
+	Track     string    `json:"track"`
+	Scheduled time.Time `json:"scheduled"`
+}
+
+func runEventsList(args []string) error {
+	fs := newFlagSet("events list", "[-json]")
+	asJSON := jsonFlag(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	return withStore(func(store servermanager.Store) error {
+		out, err := listEvents(store)
+
+		if err != nil {
+			return err
+		}
+
+		if *asJSON {
+			return printJSON(out)
+		}
+
+		w := newTable("ID", "TYPE", "NAME", "TRACK", "SCHEDULED")
+
+		for _, event := range out {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", event.ID, event.Type, event.Name, event.Track, formatTime(event.Scheduled))
+		}
+
+		return w.Flush()
+	})
+}
+
+func listEvents(store servermanager.Store) ([]eventOutput, error) {
+	out := []eventOutput{}
+
+	customRaces, err := store.ListCustomRaces()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, race := range customRaces {
+		if !race.Deleted.IsZero() {
+			continue
+		}
+
+		event := eventOutput{
+			ID:    "custom-races/" + race.UUID.String(),
+			Type:  "Custom Race",
+			Name:  race.Name,
+			Track: trackName(race.RaceConfig.Track, race.RaceConfig.TrackLayout),
+		}
+
+		// custom races are scheduled per server, show the earliest.
+		for _, scheduled := range race.ScheduledEvents {
+			if !scheduled.Scheduled.IsZero() && (event.Scheduled.IsZero() || scheduled.Scheduled.Before(event.Scheduled)) {
+				event.Scheduled = scheduled.Scheduled
+			}
+		}
+
+		out = append(out, event)
+	}
+
+	championships, err := store.ListChampionships()
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(championships, func(i, j int) bool {
+		return championships[i].Name < championships[j].Name
+	})
+
+	for _, championship := range championships {
+		if !championship.Deleted.IsZero() {
+			continue
+		}
+
+		for i, championshipEvent := range championship.Events {
+			if championshipEvent.IsRaceWeekend() || championshipEvent.Completed() {
+				// race weekend sessions are listed with their race weekend.
+				continue
+			}
+
+			out = append(out, eventOutput{
+				ID:        fmt.Sprintf("championships/%s/events/%s", championship.ID, championshipEvent.ID),
+				Type:      "Championship Event",
+				Name:      fmt.Sprintf("%s, Event %d", championship.Name, i+1),
+				Track:     trackName(championshipEvent.RaceSetup.Track, championshipEvent.RaceSetup.TrackLayout),
+				Scheduled: championshipEvent.Scheduled,
+			})
+		}
+	}
+
+	raceWeekends, err := store.ListRaceWeekends()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, raceWeekend := range raceWeekends {
+		if !raceWeekend.Deleted.IsZero() {
+			continue
+		}
+
+		for _, session := range raceWeekend.Sessions {
+			if session.Completed() {
+				continue
+			}
+
+			out = append(out, eventOutput{
+				ID:        fmt.Sprintf("race-weekends/%s/sessions/%s", raceWeekend.ID, session.ID),
+				Type:      "Race Weekend Session",
+				Name:      fmt.Sprintf("%s, %s", raceWeekend.Name, session.Name()),
+				Track:     trackName(session.RaceConfig.Track, session.RaceConfig.TrackLayout),
+				Scheduled: session.ScheduledTime,
+			})
+		}
+	}
+
+	return out, nil
+}
+
+func trackName(track, layout string) string {
+	if layout == "" {
+		return track
+	}
+
+	return track + " (" + layout + ")"
+}
+
+// apiFlags adds the flags used to connect to a running Server Manager's API to a command's flag set.
+func apiFlags(fs *flag.FlagSet) (url, token *string) {
+	url = fs.String("url", "", "the URL of Server Manager (default: from config.yml)")
+	token = fs.String("token", os.Getenv("SERVER_MANAGER_API_TOKEN"), "an API token with write access (default: $SERVER_MANAGER_API_TOKEN)")
+
+	return url, token
+}
+
+// newAPIClient returns an APIClient for a running Server Manager. If url is empty, the URL of the Server Manager in
+// config.yml is used.
+func newAPIClient(url, token string) (*servermanager.APIClient, error) {
+	if token == "" {
+		return nil, fmt.Errorf("an API token is needed to control events, create one on your account page and pass it with -token")
+	}
+
+	if url == "" {
+		config, err := readConfig()
+
+		if err != nil {
+			return nil, err
+		}
+
+		url = serverManagerURL(config.HTTP)
+	}
+
+	return servermanager.NewAPIClient(url, token, &http.Client{Timeout: 30 * time.Second}), nil
+}
+
+// serverManagerURL works out the URL that Server Manager can be reached at from this machine.
+func serverManagerURL(httpConfig servermanager.HTTPConfig) string {
+	if httpConfig.BaseURL != "" {
+		return httpConfig.BaseURL
+	}
+
+	scheme := "http"
+
+	if httpConfig.TLS.Enabled {
+		scheme = "https"
+	}
+
+	host, port, err := net.SplitHostPort(httpConfig.Hostname)
+
+	if err != nil {
+		return scheme + "://" + httpConfig.Hostname
+	}
+
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+
+	return scheme + "://" + net.JoinHostPort(host, port)
+}
+
+func parseEventArgs(fs *flag.FlagSet, args []string, numArgs int) (string, error) {
+	if err := fs.Parse(args); err != nil {
+		return "", err
+	}
+
+	if fs.NArg() < 1 || fs.NArg() > numArgs {
+		fs.Usage()
+		return "", flag.ErrHelp
+	}
+
+	if !eventIDRegex.MatchString(fs.Arg(0)) {
+		return "", fmt.Errorf("%q is not an event ID, run 'server-manager events list' to find them", fs.Arg(0))
+	}
+
+	return fs.Arg(0), nil
+}
+
+func runEventsStart(args []string) error {
+	fs := newFlagSet("events start", "[-url <url>] [-token <token>] <id>")
+	url, token := apiFlags(fs)
+
+	eventID, err := parseEventArgs(fs, args, 1)
+
+	if err != nil {
+		return err
+	}
+
+	client, err := newAPIClient(*url, *token)
+
+	if err != nil {
+		return err
+	}
+
+	if err := client.Request(http.MethodPost, "/"+eventID+"/start", nil, nil); err != nil {
+		return err
+	}
+
+	fmt.Printf("Started %s\n", eventID)
+
+	return nil
+}
+
+func runEventsStop(args []string) error {
+	fs := newFlagSet("events stop", "[-url <url>] [-token <token>] <id>")
+	url, token := apiFlags(fs)
+
+	eventID, err := parseEventArgs(fs, args, 1)
+
+	if err != nil {
+		return err
+	}
+
+	client, err := newAPIClient(*url, *token)
+
+	if err != nil {
+		return err
+	}
+
+	if err := client.Request(http.MethodPost, "/"+eventID+"/stop", nil, nil); err != nil {
+		return err
+	}
+
+	fmt.Printf("Stopped %s\n", eventID)
+
+	return nil
+}
+
+func runEventsSchedule(args []string) error {
+	fs := newFlagSet("events schedule", "[-url <url>] [-token <token>] [-recurrence <rrule>] [-after-parent] [-remove] <id> [<time>]")
+	url, token := apiFlags(fs)
+	recurrence := fs.String("recurrence", "", "repeat the event with an iCalendar recurrence rule, e.g. 'FREQ=WEEKLY;INTERVAL=1'")
+	afterParent := fs.Bool("after-parent", false, "start a Race Weekend session when its parent sessions have finished")
+	remove := fs.Bool("remove", false, "remove the event's schedule")
+
+	eventID, err := parseEventArgs(fs, args, 2)
+
+	if err != nil {
+		return err
+	}
+
+	client, err := newAPIClient(*url, *token)
+
+	if err != nil {
+		return err
+	}
+
+	if *remove {
+		if err := client.Request(http.MethodDelete, "/"+eventID+"/schedule", nil, nil); err != nil {
+			return err
+		}
+
+		fmt.Printf("Removed the schedule of %s\n", eventID)
+
+		return nil
+	}
+
+	req := servermanager.APIScheduleRequest{
+		Recurrence:                 *recurrence,
+		StartWhenParentHasFinished: *afterParent,
+	}
+
+	if fs.NArg() == 2 {
+		req.Time, err = parseScheduleTime(fs.Arg(1))
+
+		if err != nil {
+			return err
+		}
+	} else if !*afterParent {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	if err := client.Request(http.MethodPost, "/"+eventID+"/schedule", req, nil); err != nil {
+		return err
+	}
+
+	if req.Time.IsZero() {
+		fmt.Printf("Scheduled %s to start when its parent sessions have finished\n", eventID)
+	} else {
+		fmt.Printf("Scheduled %s for %s\n", eventID, req.Time.Local().Format(time.RFC1123))
+	}
+
+	return nil
+}
+
+// parseScheduleTime parses a time in RFC 3339 format, or in local time without seconds.
+func parseScheduleTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02 15:04", value, time.Local)
+
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not read time %q, use '2006-01-02 15:04' or RFC 3339", value)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	servermanager "github.com/JustaPenguin/assetto-server-manager"
+)
+
+var resultsCommand = &command{
+	Name:        "results",
+	Description: "Manage the results files in the Assetto Corsa Server results directory.",
+	Subcommands: []*command{
+		{
+			Name:        "import",
+			Usage:       "[-overwrite] [-json] <file>...",
+			Description: "Copy results files into the results directory and add them to the results index.",
+			Run:         runResultsImport,
+		},
+		{
+			Name:        "reindex",
+			Usage:       "[-json]",
+			Description: "Rebuild the results index from the results directory.",
+			Run:         runResultsReindex,
+		},
+		{
+			Name:        "anonymise",
+			Usage:       "[-json] [<file>...]",
+			Description: "Replace the driver names and GUIDs in results files, or every results file if none are given.",
+			Run:         runResultsAnonymise,
+		},
+	},
+}
+
+// withResultsIndex opens the results index and calls fn, closing the index afterwards. Commands which add or change
+// results files use it so that the index is kept up to date.
+func withResultsIndex(fn func() error) error {
+	if _, err := readConfig(); err != nil {
+		return err
+	}
+
+	if err := servermanager.CheckResultsIndexNotInUse(storeOpenTimeout); err == servermanager.ErrResultsIndexInUse {
+		return fmt.Errorf("the results index is in use by another program. Stop Server Manager and try again")
+	} else if err != nil {
+		return err
+	}
+
+	if err := servermanager.InitResultsIndex(); err != nil {
+		return fmt.Errorf("could not open the results index: %w", err)
+	}
+
+	defer servermanager.CloseResultsIndex()
+
+	return fn()
+}
+
+type resultsFileOutput struct {
+	File  string `json:"file"`
+	Track string `json:"track,omitempty"`
+	Type  string `json:"type,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+func runResultsImport(args []string) error {
+	fs := newFlagSet("results import", "[-overwrite] [-json] <file>...")
+	overwrite := fs.Bool("overwrite", false, "replace results files which have already been imported")
+	asJSON := jsonFlag(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	return withResultsIndex(func() error {
+		var out []resultsFileOutput
+		failed := 0
+
+		for _, location := range fs.Args() {
+			file := resultsFileOutput{File: filepath.Base(location)}
+
+			results, err := servermanager.ImportResultsFile(location, *overwrite)
+
+			if err != nil {
+				file.Error = err.Error()
+				failed++
+			} else {
+				file.Track = results.TrackName
+				file.Type = string(results.Type)
+			}
+
+			out = append(out, file)
+
+			if *asJSON {
+				continue
+			}
+
+			if err == servermanager.ErrResultsFileExists {
+				fmt.Printf("Skipped %s, it has already been imported. Use -overwrite to replace it.\n", location)
+			} else if err != nil {
+				fmt.Printf("Could not import %s: %s\n", location, err)
+			} else {
+				fmt.Printf("Imported %s (%s at %s)\n", file.File, file.Type, file.Track)
+			}
+		}
+
+		if *asJSON {
+			if err := printJSON(out); err != nil {
+				return err
+			}
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d of %d results files could not be imported", failed, len(out))
+		}
+
+		return nil
+	})
+}
+
+func runResultsReindex(args []string) error {
+	fs := newFlagSet("results reindex", "[-json]")
+	asJSON := jsonFlag(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if _, err := readConfig(); err != nil {
+		return err
+	}
+
+	if err := servermanager.CheckResultsIndexNotInUse(storeOpenTimeout); err == servermanager.ErrResultsIndexInUse {
+		return fmt.Errorf("the results index is in use by another program. Stop Server Manager and try again")
+	} else if err != nil {
+		return err
+	}
+
+	count, err := servermanager.RebuildResultsIndex()
+
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return printJSON(struct {
+			Indexed int `json:"indexed"`
+		}{count})
+	}
+
+	fmt.Printf("Rebuilt the results index, %d results files were indexed.\n", count)
+
+	return nil
+}
+
+func runResultsAnonymise(args []string) error {
+	fs := newFlagSet("results anonymise", "[-json] [<file>...]")
+	asJSON := jsonFlag(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	return withResultsIndex(func() error {
+		fileNames := fs.Args()
+
+		if len(fileNames) == 0 {
+			files, err := ioutil.ReadDir(filepath.Join(servermanager.ServerInstallPath, "results"))
+
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+
+			for _, file := range files {
+				if !file.IsDir() && filepath.Ext(file.Name()) == ".json" {
+					fileNames = append(fileNames, file.Name())
+				}
+			}
+		}
+
+		out := struct {
+			Anonymised []string            `json:"anonymised"`
+			Skipped    []string            `json:"skipped"`
+			Failed     []resultsFileOutput `json:"failed"`
+		}{
+			Anonymised: []string{},
+			Skipped:    []string{},
+			Failed:     []resultsFileOutput{},
+		}
+
+		for _, fileName := range fileNames {
+			// results files are named by the file in the results directory, with or without the extension.
+			fileName = filepath.Base(fileName)
+
+			if !strings.HasSuffix(fileName, ".json") {
+				fileName += ".json"
+			}
+
+			anonymised, err := servermanager.AnonymiseResultsFile(fileName)
+
+			switch {
+			case err != nil:
+				out.Failed = append(out.Failed, resultsFileOutput{File: fileName, Error: err.Error()})
+
+				if !*asJSON {
+					fmt.Printf("Could not anonymise %s: %s\n", fileName, err)
+				}
+			case anonymised:
+				out.Anonymised = append(out.Anonymised, fileName)
+			default:
+				out.Skipped = append(out.Skipped, fileName)
+			}
+		}
+
+		if *asJSON {
+			if err := printJSON(out); err != nil {
+				return err
+			}
+		} else {
+			fmt.Printf("%d results files were anonymised, %d had already been anonymised.\n", len(out.Anonymised), len(out.Skipped))
+		}
+
+		if len(out.Failed) > 0 {
+			return fmt.Errorf("%d results files could not be anonymised", len(out.Failed))
+		}
+
+		return nil
+	})
+}
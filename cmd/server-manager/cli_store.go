@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	servermanager "github.com/JustaPenguin/assetto-server-manager"
+
+	"github.com/etcd-io/bbolt"
+)
+
+var storeCommand = &command{
+	Name:        "store",
+	Description: "Maintain the store that Server Manager keeps its data in.",
+	Subcommands: []*command{
+		{
+			Name:        "migrate",
+			Usage:       "[-json]",
+			Description: "Run any migrations which have not yet been run on the store.",
+			Run:         runStoreMigrate,
+		},
+		{
+			Name:        "verify",
+			Usage:       "[-json]",
+			Description: "Check the store for corruption and for data which can't be loaded. Exits with status 1 if there are problems.",
+			Run:         runStoreVerify,
+		},
+		{
+			Name:        "compact",
+			Usage:       "[-json]",
+			Description: "Rewrite a boltdb or sqlite store to reclaim unused space.",
+			Run:         runStoreCompact,
+		},
+	},
+}
+
+type storeMigrateOutput struct {
+	VersionBefore int `json:"version_before"`
+	VersionAfter  int `json:"version_after"`
+}
+
+func runStoreMigrate(args []string) error {
+	fs := newFlagSet("store migrate", "[-json]")
+	asJSON := jsonFlag(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	store, err := openStore(false)
+
+	if err != nil {
+		return err
+	}
+
+	defer closeStore(store)
+
+	var out storeMigrateOutput
+
+	out.VersionBefore, err = servermanager.StoreVersion(store)
+
+	if err != nil {
+		return err
+	}
+
+	if err := servermanager.Migrate(store); err != nil {
+		return err
+	}
+
+	out.VersionAfter, err = servermanager.StoreVersion(store)
+
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return printJSON(out)
+	}
+
+	if out.VersionBefore == out.VersionAfter {
+		fmt.Printf("The store is up to date (version %d).\n", out.VersionAfter)
+	} else {
+		fmt.Printf("Migrated the store from version %d to version %d.\n", out.VersionBefore, out.VersionAfter)
+	}
+
+	return nil
+}
+
+type storeVerifyOutput struct {
+	OK       bool     `json:"ok"`
+	Problems []string `json:"problems"`
+}
+
+func runStoreVerify(args []string) error {
+	fs := newFlagSet("store verify", "[-json]")
+	asJSON := jsonFlag(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	// the store is opened without migrating it, so that verifying it never changes it.
+	store, err := openStore(false)
+
+	if err != nil {
+		return err
+	}
+
+	problems, err := servermanager.VerifyStore(store)
+
+	closeStore(store)
+
+	if err != nil {
+		return err
+	}
+
+	out := storeVerifyOutput{
+		OK:       len(problems) == 0,
+		Problems: problems,
+	}
+
+	if out.Problems == nil {
+		out.Problems = []string{}
+	}
+
+	if *asJSON {
+		if err := printJSON(out); err != nil {
+			return err
+		}
+	} else if out.OK {
+		fmt.Println("No problems were found.")
+	} else {
+		fmt.Printf("%d problems were found:\n", len(problems))
+
+		for _, problem := range problems {
+			fmt.Printf("  - %s\n", problem)
+		}
+	}
+
+	if !out.OK {
+		os.Exit(1)
+	}
+
+	return nil
+}
+
+type storeCompactOutput struct {
+	SizeBefore int64 `json:"size_before"`
+	SizeAfter  int64 `json:"size_after"`
+}
+
+func runStoreCompact(args []string) error {
+	fs := newFlagSet("store compact", "[-json]")
+	asJSON := jsonFlag(fs)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	config, err := readConfig()
+
+	if err != nil {
+		return err
+	}
+
+	before, after, err := servermanager.CompactStore(&config.Store)
+
+	if err == servermanager.ErrStoreCannotBeCompacted {
+		return fmt.Errorf("the %s store can't be compacted, only boltdb and sqlite stores can", config.Store.Type)
+	} else if err == bbolt.ErrTimeout {
+		return storeOpenError(err)
+	} else if err != nil {
+		return fmt.Errorf("could not compact the store: %w", err)
+	}
+
+	if *asJSON {
+		return printJSON(storeCompactOutput{SizeBefore: before, SizeAfter: after})
+	}
+
+	fmt.Printf("Compacted %s from %s to %s.\n", config.Store.Path, formatSize(before), formatSize(after))
+
+	return nil
+}
+
+func formatSize(bytes int64) string {
+	const unit = 1024
+
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	div, exp := int64(unit), 0
+
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
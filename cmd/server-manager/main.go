@@ -36,6 +36,10 @@ func init() {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
 	config, err := servermanager.ReadConfig("config.yml")
 
 	if err != nil {
@@ -47,6 +51,13 @@ func main() {
 		servermanager.InitMonitoring()
 	}
 
+	servermanager.SetAssettoInstallPath(config.Steam.InstallPath)
+
+	if err := servermanager.ApplyPendingRestore(config.Store); err != nil {
+		ServeHTTPWithError(config.HTTP.Hostname, "Restore backup", err)
+		return
+	}
+
 	store, err := config.Store.BuildStore()
 
 	if err != nil {
@@ -80,7 +91,6 @@ func main() {
 		ServeHTTPWithError(config.HTTP.Hostname, "Initialise resolver (internal error)", err)
 		return
 	}
-	servermanager.SetAssettoInstallPath(config.Steam.InstallPath)
 
 	err = servermanager.InstallAssettoCorsaServer(config.Steam.Username, config.Steam.Password, config.Steam.ForceUpdate)
 
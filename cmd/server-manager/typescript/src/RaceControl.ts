@@ -9,6 +9,7 @@ import {randomColor} from "randomcolor/randomColor";
 import {msToTime, prettifyName} from "./utils";
 import moment from "moment";
 import ReconnectingWebSocket from "reconnecting-websocket";
+import {EventSessionReplayStatus, SessionReplay} from "./SessionReplay";
 import ClickEvent = JQuery.ClickEvent;
 import ChangeEvent = JQuery.ChangeEvent;
 
@@ -49,6 +50,7 @@ export class RaceControl {
     private readonly liveMap: LiveMap = new LiveMap(this);
     private readonly liveTimings: LiveTimings = new LiveTimings(this, this.liveMap);
     private readonly $eventTitle: JQuery<HTMLHeadElement>;
+    private sessionReplay: SessionReplay | null = null;
     public status: RaceControlData;
     private firstLoad: boolean = true;
 
@@ -63,12 +65,19 @@ export class RaceControl {
             return;
         }
 
-        let ws = new ReconnectingWebSocket(((window.location.protocol === "https:") ? "wss://" : "ws://") + window.location.host + "/api/race-control", [], {
+        // session replays use the same live map and timings, but connect to a replay of a recorded session.
+        const websocketURL = this.$eventTitle.data("websocket-url") || "/api/race-control";
+
+        let ws = new ReconnectingWebSocket(((window.location.protocol === "https:") ? "wss://" : "ws://") + window.location.host + websocketURL, [], {
             minReconnectionDelay: 0,
         });
 
         ws.onmessage = this.handleWebsocketMessage.bind(this);
 
+        if (SessionReplay.isReplayPage()) {
+            this.sessionReplay = new SessionReplay(ws);
+        }
+
         $(window).on('beforeunload', () => {
             ws.close();
         });
@@ -116,6 +125,12 @@ export class RaceControl {
             case EventNewSession:
                 this.showTrackWeatherImage();
                 break;
+            case EventSessionReplayStatus:
+                if (this.sessionReplay) {
+                    this.sessionReplay.handleStatus(message.Message);
+                }
+
+                return;
             case EventChat:
                 let $chatContainer = $("#chat-container");
 
@@ -505,7 +520,7 @@ class LiveMap implements WebsocketHandler {
         const $driverName = $("<span class='name'/>").text(driverData.DriverInitials);
         const $info = $("<span class='info'/>").text("0").hide();
 
-        const $dot = $("<div class='dot' style='background: " + randomColorForDriver(driverData.DriverGUID) + "'/>").append($driverName, $info).hide().appendTo(this.$map);
+        const $dot = $("<div class='dot' style='background: " + randomColorForDriver(driverData.DriverGUID) + "'/>").attr("data-guid", driverData.DriverGUID).append($driverName, $info).hide().appendTo(this.$map);
 
         if (lastPos !== undefined) {
             let dotPos = this.translateToTrackCoordinate(lastPos);
@@ -1058,6 +1073,7 @@ class LiveTimings implements WebsocketHandler {
     private addDriverToAdminSelects(carInfo: SessionCarInfo) {
         $(".kick-user option[value='default-driver-spacer']").remove();
         $(".chat-user option[value='default-driver-spacer']").remove();
+        $(".ban-user option[value='default-driver-spacer']").remove();
 
         if ($(".kick-user option[value=" + carInfo.DriverGUID + "]").length != 0) {
             // driver already exists
@@ -1078,11 +1094,22 @@ class LiveTimings implements WebsocketHandler {
                 text: carInfo.DriverName,
             }));
         }
+
+        if ($(".ban-user option[value=" + carInfo.DriverGUID + "]").length != 0) {
+            // driver already exists
+        } else {
+            // add driver to admin ban list
+            $('.ban-user').append($('<option>', {
+                value: carInfo.DriverGUID,
+                text: carInfo.DriverName,
+            }));
+        }
     }
 
     private removeDriverFromAdminSelects(carInfo: SessionCarInfo) {
         $(".kick-user option[value=" + carInfo.DriverGUID + "]").remove();
         $(".chat-user option[value=" + carInfo.DriverGUID + "]").remove();
+        $(".ban-user option[value=" + carInfo.DriverGUID + "]").remove();
     }
 }
 
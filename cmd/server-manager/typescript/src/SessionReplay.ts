@@ -0,0 +1,115 @@
+import ReconnectingWebSocket from "reconnecting-websocket";
+import {msToTime} from "./utils";
+
+export const EventSessionReplayStatus = 220;
+
+interface SessionReplayStatus {
+    Position: number;
+    Duration: number;
+    Speed: number;
+    Playing: boolean;
+}
+
+export class SessionReplay {
+    private readonly ws: ReconnectingWebSocket;
+
+    private readonly $play: JQuery<HTMLButtonElement>;
+    private readonly $position: JQuery<HTMLInputElement>;
+    private readonly $time: JQuery<HTMLSpanElement>;
+    private readonly $speed: JQuery<HTMLSelectElement>;
+    private readonly $driver: JQuery<HTMLSelectElement>;
+
+    private status: SessionReplayStatus = {Position: 0, Duration: 0, Speed: 1, Playing: true};
+    private seeking: boolean = false;
+
+    public static isReplayPage(): boolean {
+        return $("#session-replay").length > 0;
+    }
+
+    constructor(ws: ReconnectingWebSocket) {
+        this.ws = ws;
+
+        this.$play = $("#session-replay-play") as JQuery<HTMLButtonElement>;
+        this.$position = $("#session-replay-position") as JQuery<HTMLInputElement>;
+        this.$time = $("#session-replay-time") as JQuery<HTMLSpanElement>;
+        this.$speed = $("#session-replay-speed") as JQuery<HTMLSelectElement>;
+        this.$driver = $("#session-replay-driver") as JQuery<HTMLSelectElement>;
+
+        this.$play.on("click", this.togglePlaying.bind(this));
+        this.$speed.on("change", this.changeSpeed.bind(this));
+        this.$driver.on("change", this.focusDriver.bind(this));
+
+        this.$position.on("input", () => {
+            this.seeking = true;
+            this.showTime(parseInt(this.$position.val() as string));
+        });
+
+        this.$position.on("change", this.seek.bind(this));
+    }
+
+    public handleStatus(status: SessionReplayStatus): void {
+        this.status = status;
+
+        this.$position.attr("max", status.Duration);
+
+        if (!this.seeking) {
+            this.$position.val(status.Position);
+            this.showTime(status.Position);
+        }
+
+        this.$play.text(status.Playing ? "Pause" : "Play");
+        this.focusDriver();
+    }
+
+    private send(action: string, speed: number = 0): void {
+        this.ws.send(JSON.stringify({Action: action, Speed: speed}));
+    }
+
+    private togglePlaying(): void {
+        this.send(this.status.Playing ? "pause" : "play");
+    }
+
+    private changeSpeed(): void {
+        this.send("speed", parseFloat(this.$speed.val() as string));
+    }
+
+    // the live map and timings can't go back in time, so moving the position reloads the replay from there.
+    private seek(): void {
+        const params = new URLSearchParams();
+
+        params.set("position", this.$position.val() as string);
+        params.set("speed", this.$speed.val() as string);
+
+        const driver = this.$driver.val() as string;
+
+        if (driver) {
+            params.set("driver", driver);
+        }
+
+        window.location.search = params.toString();
+    }
+
+    private focusDriver(): void {
+        const driverGUID = this.$driver.val() as string;
+        const $dots = $("#map .dot");
+        const $rows = $("#live-table tr");
+
+        $dots.removeClass("dot-unfocused");
+        $rows.removeClass("table-active");
+
+        if (!driverGUID) {
+            return;
+        }
+
+        $dots.not("[data-guid='" + driverGUID + "']").addClass("dot-unfocused");
+        $rows.filter("[data-guid='" + driverGUID + "']").addClass("table-active");
+    }
+
+    private showTime(position: number): void {
+        this.$time.text(SessionReplay.formatTime(position) + " / " + SessionReplay.formatTime(this.status.Duration));
+    }
+
+    private static formatTime(ms: number): string {
+        return msToTime(ms, false, false) || "00:00:00";
+    }
+}
@@ -0,0 +1,77 @@
+// store-migrate copies an existing boltdb or json Server Manager store into a new sqlite store.
+//
+// Usage:
+//
+//	store-migrate -type boltdb -path server_manager.db -out server_manager.sqlite
+//
+// Once complete, set the store type in config.yml to 'sqlite' and the path to the output file.
+package main
+
+import (
+	"flag"
+	"os"
+
+	servermanager "github.com/JustaPenguin/assetto-server-manager"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	storeType, storePath, sharedPath, outputPath string
+)
+
+func init() {
+	flag.StringVar(&storeType, "type", "boltdb", "type of the store to copy from (boltdb/json)")
+	flag.StringVar(&storePath, "path", "server_manager.db", "path of the store to copy from")
+	flag.StringVar(&sharedPath, "shared-path", "", "shared data path of the store to copy from (json only)")
+	flag.StringVar(&outputPath, "out", "server_manager.sqlite", "path of the sqlite store to create")
+	flag.Parse()
+}
+
+func main() {
+	if storeType != "boltdb" && storeType != "json" {
+		logrus.Fatalf("Can only copy from boltdb or json stores, not: %s", storeType)
+	}
+
+	if _, err := os.Stat(outputPath); err == nil {
+		logrus.Fatalf("Output file %s already exists, refusing to overwrite it", outputPath)
+	}
+
+	storeConfig := &servermanager.StoreConfig{
+		Type:       storeType,
+		Path:       storePath,
+		SharedPath: sharedPath,
+	}
+
+	// the store being copied from is opened without being migrated, so that it is left as it is. the sqlite store
+	// is migrated once everything has been copied into it.
+	from, err := storeConfig.OpenStore()
+
+	if err != nil {
+		logrus.WithError(err).Fatalf("Could not open %s store at: %s", storeType, storePath)
+	}
+
+	db, err := servermanager.OpenSQLiteStore(outputPath)
+
+	if err != nil {
+		logrus.WithError(err).Fatalf("Could not create sqlite database at: %s", outputPath)
+	}
+
+	defer db.Close()
+
+	to, err := servermanager.NewSQLiteStore(db)
+
+	if err != nil {
+		logrus.WithError(err).Fatal("Could not set up sqlite store")
+	}
+
+	if err := servermanager.CopyStore(from, to); err != nil {
+		logrus.WithError(err).Fatal("Could not copy store")
+	}
+
+	if err := servermanager.Migrate(to); err != nil {
+		logrus.WithError(err).Fatal("Could not migrate sqlite store")
+	}
+
+	logrus.Infof("Successfully copied %s store at %s to sqlite store at %s", storeType, storePath, outputPath)
+}
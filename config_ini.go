@@ -212,6 +212,21 @@ type GlobalServerConfig struct {
 	NumberOfACServerLogsToKeep        int                  `ini:"-" show:"open" help:"The number of AC Server logs to keep in the logs folder. (Oldest files will be deleted first. 0 = keep all files)"`
 	ShowEventDetailsPopup             bool                 `ini:"-" help:"Allows all users to view a popup that describes in detail the setup of Custom Races, Championship Events and Race Weekend Sessions."`
 
+	Stewarding                   FormHeading          `ini:"-" json:"-"`
+	StewardsCollisionSpeed       int                  `ini:"-" min:"0" name:"Stewards Collision Speed (Km/h)" help:"Collisions between two cars at or above this speed (in Km/h) are added to the incident queue on the <a href='/stewards'>Stewards</a> page for review. Set to 0 to turn this off."`
+	StewardsTrackLimitsIncidents formulate.BoolNumber `ini:"-" help:"When on, laps with cuts are added to the incident queue on the <a href='/stewards'>Stewards</a> page for review."`
+
+	SessionRecordings             FormHeading          `ini:"-" json:"-"`
+	RecordSessions                formulate.BoolNumber `ini:"-" help:"When on, every session is recorded so that it can be replayed on the live map from its results page. Recordings contain every car position update, so long sessions can take up a lot of disk space."`
+	SessionRecordingRetentionDays int                  `ini:"-" min:"0" name:"Keep Session Recordings For (Days)" help:"Session recordings older than this many days are deleted at the end of each session. Set to 0 to keep recordings forever."`
+
+	RecycleBin              FormHeading `ini:"-" json:"-"`
+	RecycleBinRetentionDays int         `ini:"-" min:"0" name:"Keep Deleted Items For (Days)" help:"Deleted Custom Races, Championships, Race Weekends and Accounts are kept in the <a href='/recycle-bin'>Recycle Bin</a> for this many days before they are permanently deleted. Set to 0 to keep deleted items until they are purged by hand."`
+
+	Backups             FormHeading `ini:"-" json:"-"`
+	BackupIntervalHours int         `ini:"-" min:"0" name:"Back Up Every (Hours)" help:"Server Manager backs up its data, results, setups, server configuration and plugin configuration to the backups folder this often. Set to 0 to turn off scheduled backups. Backups can also be made and restored on the <a href='/backups'>Backups</a> page."`
+	BackupsToKeep       int         `ini:"-" min:"0" help:"The number of backups to keep. The oldest backups are deleted first. Set to 0 to keep every backup."`
+
 	// Discord Integration
 	DiscordIntegration FormHeading `ini:"-" json:"-"`
 	DiscordAPIToken    string      `ini:"-" help:"If set, will enable race start and scheduled reminder messages to the Discord channel ID specified below.  Use your bot's user token, not the OAuth token."`
@@ -223,6 +238,8 @@ type GlobalServerConfig struct {
 	NotificationReminderTimers  string               `ini:"-" help:"If Discord is enabled, a reminder will be sent this many minutes prior to race start.  If 0 or empty, only race start messages will be sent.  You may schedule multiple reminders by using a comma separated list like 120,15."`
 	ShowPasswordInNotifications formulate.BoolNumber `ini:"-" help:"Show the server password in race start notifications."`
 	NotifyWhenScheduled         formulate.BoolNumber `ini:"-" help:"Send a notification when a race is scheduled (or cancelled)."`
+	NotifyWhenLapRecordBroken   formulate.BoolNumber `ini:"-" help:"Send a notification at the end of a session if a driver set the fastest ever valid lap for a track layout and car."`
+	NotifyWhenSessionEnded      formulate.BoolNumber `ini:"-" help:"Send a summary of the results when a session ends, with the podium, fastest lap, winning margin and any penalties. Championship and Race Weekend sessions also include the updated championship standings."`
 
 	// Messages
 	ContentManagerWelcomeMessage string `ini:"-" show:"-"`
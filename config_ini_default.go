@@ -30,6 +30,12 @@ func ConfigIniDefault() ServerConfig {
 			RestartEventOnServerManagerLaunch: 1,
 			ContentManagerWelcomeMessage:      defaultContentManagerDescription,
 			ShowEventDetailsPopup:             true,
+			StewardsCollisionSpeed:            40,
+			RecordSessions:                    1,
+			SessionRecordingRetentionDays:     14,
+			RecycleBinRetentionDays:           30,
+			BackupIntervalHours:               24,
+			BackupsToKeep:                     7,
 		},
 
 		CurrentRaceConfig: CurrentRaceConfig{
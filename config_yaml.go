@@ -0,0 +1,1260 @@
+package servermanager
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	configYAMLServerOptionsFile      = "server_options.yml"
+	configYAMLStrackerOptionsFile    = "stracker_options.yml"
+	configYAMLKissMyRankOptionsFile  = "kissmyrank_options.yml"
+	configYAMLRealPenaltyOptionsFile = "realpenalty_options.yml"
+	configYAMLEntrantsFile           = "entrants.yml"
+	configYAMLCustomRacesDir         = "custom_races"
+	configYAMLChampionshipsDir       = "championships"
+	configYAMLRaceWeekendsDir        = "race_weekends"
+	configYAMLExtension              = ".yml"
+
+	// configYAMLDiffContext is the number of unchanged lines shown around each change in an import diff.
+	configYAMLDiffContext = 3
+)
+
+// configYAMLDirs are the directories in a configuration export which hold one file per item.
+var configYAMLDirs = []string{configYAMLCustomRacesDir, configYAMLChampionshipsDir, configYAMLRaceWeekendsDir}
+
+// configYAMLVolatileFields change every time an item is saved, so they are left out of exports to keep them
+// stable in version control.
+var configYAMLVolatileFields = []string{"Updated"}
+
+// configYAMLSecretFields are replaced with configYAMLRedactedSecret in exports, so that passwords and tokens
+// aren't committed to version control. When a secret is still redacted in an import, its current value is kept.
+var configYAMLSecretFields = []string{
+	"Password", "AdminPassword", "ReplacementPassword", "PostgresPassword", "ACSRAPIKey", "DiscordAPIToken",
+	"web_admin_console_password", "web_admin_console_guest_password", "race_control_password", "ac_chat_admin_password",
+}
+
+const configYAMLRedactedSecret = "<redacted>"
+
+var ErrConfigYAMLMissingID = errors.New("servermanager: configuration file has no ID")
+
+type ConfigImportAction string
+
+const (
+	ConfigImportCreated   ConfigImportAction = "Created"
+	ConfigImportUpdated   ConfigImportAction = "Updated"
+	ConfigImportUnchanged ConfigImportAction = "Unchanged"
+)
+
+// ConfigImportChange describes what an import does (or would do, in a dry run) to a single item.
+type ConfigImportChange struct {
+	Type   string
+	ID     string
+	Name   string
+	Action ConfigImportAction
+	Diff   []DiffLine
+
+	apply func() error
+}
+
+type DiffLine struct {
+	// Op is "+" for an added line, "-" for a removed line, " " for an unchanged line and "..." for skipped
+	// unchanged lines.
+	Op   string
+	Text string
+}
+
+func (d DiffLine) String() string {
+	if d.Op == "..." {
+		return d.Op
+	}
+
+	return d.Op + " " + d.Text
+}
+
+type ConfigImportResult struct {
+	DryRun  bool
+	Changes []*ConfigImportChange
+}
+
+func (r *ConfigImportResult) Count(action ConfigImportAction) int {
+	count := 0
+
+	for _, change := range r.Changes {
+		if change.Action == action {
+			count++
+		}
+	}
+
+	return count
+}
+
+// ConfigYAMLManager exports and imports Server Manager's configuration as a directory of YAML files, so that
+// it can be kept in version control. Items are encoded the same way as they are in the store, and imports
+// create or update items by their ID. Items which are not in an import are left as they are.
+type ConfigYAMLManager struct {
+	store Store
+}
+
+func NewConfigYAMLManager(store Store) *ConfigYAMLManager {
+	return &ConfigYAMLManager{
+		store: store,
+	}
+}
+
+// exportFiles returns the contents of each file in an export, keyed by their slash separated path. Secrets are
+// only left in the files if redactSecrets is false, which is never the case for files that are written out.
+func (cm *ConfigYAMLManager) exportFiles(redactSecrets bool) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+
+	add := func(name string, v interface{}) error {
+		data, err := marshalConfigYAML(v, redactSecrets)
+
+		if err != nil {
+			return fmt.Errorf("servermanager: could not export %s: %w", name, err)
+		}
+
+		files[name] = data
+
+		return nil
+	}
+
+	serverOptions, err := cm.store.LoadServerOptions()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := add(configYAMLServerOptionsFile, serverOptions); err != nil {
+		return nil, err
+	}
+
+	strackerOptions, err := cm.store.LoadStrackerOptions()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := add(configYAMLStrackerOptionsFile, strackerOptions); err != nil {
+		return nil, err
+	}
+
+	kissMyRankOptions, err := cm.store.LoadKissMyRankOptions()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := add(configYAMLKissMyRankOptionsFile, kissMyRankOptions); err != nil {
+		return nil, err
+	}
+
+	realPenaltyOptions, err := cm.store.LoadRealPenaltyOptions()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := add(configYAMLRealPenaltyOptionsFile, realPenaltyOptions); err != nil {
+		return nil, err
+	}
+
+	entrants, err := cm.store.ListEntrants()
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(entrants, func(i, j int) bool {
+		return entrants[i].ID() < entrants[j].ID()
+	})
+
+	if entrants == nil {
+		entrants = []*Entrant{}
+	}
+
+	if err := add(configYAMLEntrantsFile, entrants); err != nil {
+		return nil, err
+	}
+
+	customRaces, err := cm.store.ListCustomRaces()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, customRace := range customRaces {
+		if err := add(path.Join(configYAMLCustomRacesDir, customRace.UUID.String()+configYAMLExtension), customRace); err != nil {
+			return nil, err
+		}
+	}
+
+	championships, err := cm.store.ListChampionships()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, championship := range championships {
+		clearChampionshipRaceWeekends(championship)
+
+		if err := add(path.Join(configYAMLChampionshipsDir, championship.ID.String()+configYAMLExtension), championship); err != nil {
+			return nil, err
+		}
+	}
+
+	raceWeekends, err := cm.store.ListRaceWeekends()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, raceWeekend := range raceWeekends {
+		if err := add(path.Join(configYAMLRaceWeekendsDir, raceWeekend.ID.String()+configYAMLExtension), raceWeekend); err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// clearChampionshipRaceWeekends removes the Race Weekends which are loaded into Championship events, as they
+// are exported separately.
+func clearChampionshipRaceWeekends(championship *Championship) {
+	for _, event := range championship.Events {
+		event.RaceWeekend = nil
+	}
+}
+
+// Export writes the configuration to dir. YAML files for items which no longer exist are removed, so that the
+// directory always matches the current configuration.
+func (cm *ConfigYAMLManager) Export(dir string) error {
+	files, err := cm.exportFiles(true)
+
+	if err != nil {
+		return err
+	}
+
+	for _, itemDir := range configYAMLDirs {
+		existing, err := filepath.Glob(filepath.Join(dir, itemDir, "*"+configYAMLExtension))
+
+		if err != nil {
+			return err
+		}
+
+		for _, file := range existing {
+			if err := os.Remove(file); err != nil {
+				return err
+			}
+		}
+	}
+
+	for name, data := range files {
+		location := filepath.Join(dir, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(location), 0755); err != nil {
+			return err
+		}
+
+		if err := ioutil.WriteFile(location, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ExportZip writes the configuration to w as a zip archive of YAML files.
+func (cm *ConfigYAMLManager) ExportZip(w io.Writer) error {
+	files, err := cm.exportFiles(true)
+
+	if err != nil {
+		return err
+	}
+
+	var names []string
+
+	for name := range files {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	z := zip.NewWriter(w)
+
+	for _, name := range names {
+		f, err := z.Create(name)
+
+		if err != nil {
+			return err
+		}
+
+		if _, err := f.Write(files[name]); err != nil {
+			return err
+		}
+	}
+
+	return z.Close()
+}
+
+// Import reads the configuration from dir. In a dry run, the changes are worked out but not saved.
+func (cm *ConfigYAMLManager) Import(dir string, dryRun bool) (*ConfigImportResult, error) {
+	files := make(map[string][]byte)
+
+	err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() || !isConfigYAMLFile(file) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, file)
+
+		if err != nil {
+			return err
+		}
+
+		data, err := ioutil.ReadFile(file)
+
+		if err != nil {
+			return err
+		}
+
+		files[filepath.ToSlash(rel)] = data
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cm.importFiles(files, dryRun)
+}
+
+// ImportZip reads the configuration from a zip archive made by ExportZip.
+func (cm *ConfigYAMLManager) ImportZip(r io.ReaderAt, size int64, dryRun bool) (*ConfigImportResult, error) {
+	z, err := zip.NewReader(r, size)
+
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+
+	for _, file := range z.File {
+		if file.FileInfo().IsDir() || !isConfigYAMLFile(file.Name) {
+			continue
+		}
+
+		rc, err := file.Open()
+
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := ioutil.ReadAll(rc)
+		rc.Close()
+
+		if err != nil {
+			return nil, err
+		}
+
+		files[path.Clean(file.Name)] = data
+	}
+
+	return cm.importFiles(files, dryRun)
+}
+
+func isConfigYAMLFile(name string) bool {
+	ext := strings.ToLower(filepath.Ext(name))
+
+	return ext == ".yml" || ext == ".yaml"
+}
+
+// importFiles parses every file before anything is saved, so that a mistake in one file doesn't leave an
+// import half finished.
+func (cm *ConfigYAMLManager) importFiles(files map[string][]byte, dryRun bool) (*ConfigImportResult, error) {
+	var names []string
+
+	for name := range files {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	current, err := cm.exportFiles(true)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the current secrets are used to fill in secrets which are still redacted in the import, and to see if
+	// any secrets have changed.
+	currentSecrets, err := cm.exportFiles(false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ConfigImportResult{DryRun: dryRun}
+
+	// changes are applied in this order, so that Championships exist before the Race Weekends linked to them.
+	var options, entrants, customRaces, championships, raceWeekends []*ConfigImportChange
+
+	for _, name := range names {
+		data := files[name]
+		dir := path.Dir(name)
+
+		switch {
+		case isConfigYAMLName(name, configYAMLServerOptionsFile):
+			var opts *GlobalServerConfig
+
+			if err := unmarshalConfigYAML(data, &opts); err != nil {
+				return nil, configYAMLError(name, err)
+			}
+
+			if err := restoreConfigYAMLSecrets(&opts, currentSecrets[configYAMLServerOptionsFile]); err != nil {
+				return nil, configYAMLError(name, err)
+			}
+
+			change := &ConfigImportChange{Type: "Server Options", Name: "Server Options", apply: func() error {
+				if err := cm.store.UpsertServerOptions(opts); err != nil {
+					return err
+				}
+
+				UseShortenedDriverNames = opts.UseShortenedDriverNames == 1
+				UseFallBackSorting = opts.FallBackResultsSorting == 1
+
+				return nil
+			}}
+
+			if err := change.compare(current[configYAMLServerOptionsFile], currentSecrets[configYAMLServerOptionsFile], opts); err != nil {
+				return nil, err
+			}
+
+			options = append(options, change)
+		case isConfigYAMLName(name, configYAMLStrackerOptionsFile):
+			var opts *StrackerConfiguration
+
+			if err := unmarshalConfigYAML(data, &opts); err != nil {
+				return nil, configYAMLError(name, err)
+			}
+
+			if err := restoreConfigYAMLSecrets(&opts, currentSecrets[configYAMLStrackerOptionsFile]); err != nil {
+				return nil, configYAMLError(name, err)
+			}
+
+			change := &ConfigImportChange{Type: "STracker Options", Name: "STracker Options", apply: func() error {
+				return cm.store.UpsertStrackerOptions(opts)
+			}}
+
+			if err := change.compare(current[configYAMLStrackerOptionsFile], currentSecrets[configYAMLStrackerOptionsFile], opts); err != nil {
+				return nil, err
+			}
+
+			options = append(options, change)
+		case isConfigYAMLName(name, configYAMLKissMyRankOptionsFile):
+			var opts *KissMyRankConfig
+
+			if err := unmarshalConfigYAML(data, &opts); err != nil {
+				return nil, configYAMLError(name, err)
+			}
+
+			if err := restoreConfigYAMLSecrets(&opts, currentSecrets[configYAMLKissMyRankOptionsFile]); err != nil {
+				return nil, configYAMLError(name, err)
+			}
+
+			change := &ConfigImportChange{Type: "KissMyRank Options", Name: "KissMyRank Options", apply: func() error {
+				return cm.store.UpsertKissMyRankOptions(opts)
+			}}
+
+			if err := change.compare(current[configYAMLKissMyRankOptionsFile], currentSecrets[configYAMLKissMyRankOptionsFile], opts); err != nil {
+				return nil, err
+			}
+
+			options = append(options, change)
+		case isConfigYAMLName(name, configYAMLRealPenaltyOptionsFile):
+			var opts *RealPenaltyConfig
+
+			if err := unmarshalConfigYAML(data, &opts); err != nil {
+				return nil, configYAMLError(name, err)
+			}
+
+			if err := restoreConfigYAMLSecrets(&opts, currentSecrets[configYAMLRealPenaltyOptionsFile]); err != nil {
+				return nil, configYAMLError(name, err)
+			}
+
+			change := &ConfigImportChange{Type: "Real Penalty Options", Name: "Real Penalty Options", apply: func() error {
+				return cm.store.UpsertRealPenaltyOptions(opts)
+			}}
+
+			if err := change.compare(current[configYAMLRealPenaltyOptionsFile], currentSecrets[configYAMLRealPenaltyOptionsFile], opts); err != nil {
+				return nil, err
+			}
+
+			options = append(options, change)
+		case isConfigYAMLName(name, configYAMLEntrantsFile):
+			changes, err := cm.importEntrants(name, data, current[configYAMLEntrantsFile])
+
+			if err != nil {
+				return nil, err
+			}
+
+			entrants = append(entrants, changes...)
+		case dir == configYAMLCustomRacesDir:
+			var customRace *CustomRace
+
+			if err := unmarshalConfigYAML(data, &customRace); err != nil {
+				return nil, configYAMLError(name, err)
+			}
+
+			if customRace.UUID == uuid.Nil {
+				return nil, configYAMLError(name, ErrConfigYAMLMissingID)
+			}
+
+			currentName := path.Join(configYAMLCustomRacesDir, customRace.UUID.String()+configYAMLExtension)
+
+			if err := restoreConfigYAMLSecrets(&customRace, currentSecrets[currentName]); err != nil {
+				return nil, configYAMLError(name, err)
+			}
+
+			change := &ConfigImportChange{Type: "Custom Race", ID: customRace.UUID.String(), Name: customRace.Name, apply: func() error {
+				return cm.store.UpsertCustomRace(customRace)
+			}}
+
+			if err := change.compare(current[currentName], currentSecrets[currentName], customRace); err != nil {
+				return nil, err
+			}
+
+			customRaces = append(customRaces, change)
+		case dir == configYAMLChampionshipsDir:
+			var championship *Championship
+
+			if err := unmarshalConfigYAML(data, &championship); err != nil {
+				return nil, configYAMLError(name, err)
+			}
+
+			if championship.ID == uuid.Nil {
+				return nil, configYAMLError(name, ErrConfigYAMLMissingID)
+			}
+
+			currentName := path.Join(configYAMLChampionshipsDir, championship.ID.String()+configYAMLExtension)
+
+			if err := restoreConfigYAMLSecrets(&championship, currentSecrets[currentName]); err != nil {
+				return nil, configYAMLError(name, err)
+			}
+
+			clearChampionshipRaceWeekends(championship)
+
+			change := &ConfigImportChange{Type: "Championship", ID: championship.ID.String(), Name: championship.Name, apply: func() error {
+				return cm.store.UpsertChampionship(championship)
+			}}
+
+			if err := change.compare(current[currentName], currentSecrets[currentName], championship); err != nil {
+				return nil, err
+			}
+
+			championships = append(championships, change)
+		case dir == configYAMLRaceWeekendsDir:
+			var raceWeekend *RaceWeekend
+
+			if err := unmarshalConfigYAML(data, &raceWeekend); err != nil {
+				return nil, configYAMLError(name, err)
+			}
+
+			if raceWeekend.ID == uuid.Nil {
+				return nil, configYAMLError(name, ErrConfigYAMLMissingID)
+			}
+
+			currentName := path.Join(configYAMLRaceWeekendsDir, raceWeekend.ID.String()+configYAMLExtension)
+
+			if err := restoreConfigYAMLSecrets(&raceWeekend, currentSecrets[currentName]); err != nil {
+				return nil, configYAMLError(name, err)
+			}
+
+			change := &ConfigImportChange{Type: "Race Weekend", ID: raceWeekend.ID.String(), Name: raceWeekend.Name, apply: func() error {
+				return cm.store.UpsertRaceWeekend(raceWeekend)
+			}}
+
+			if err := change.compare(current[currentName], currentSecrets[currentName], raceWeekend); err != nil {
+				return nil, err
+			}
+
+			raceWeekends = append(raceWeekends, change)
+		default:
+			logrus.Warnf("Skipping unknown configuration file: %s", name)
+			continue
+		}
+	}
+
+	for _, changes := range [][]*ConfigImportChange{options, entrants, customRaces, championships, raceWeekends} {
+		for _, change := range changes {
+			result.Changes = append(result.Changes, change)
+
+			if dryRun || change.Action == ConfigImportUnchanged {
+				continue
+			}
+
+			if err := change.apply(); err != nil {
+				return result, fmt.Errorf("servermanager: could not import %s %s: %w", change.Type, change.Name, err)
+			}
+		}
+	}
+
+	for _, change := range result.Changes {
+		if change.Action != ConfigImportUnchanged {
+			logrus.Debugf("Configuration import (dry run: %t): %s %s %s", dryRun, change.Action, change.Type, change.Name)
+		}
+	}
+
+	return result, nil
+}
+
+// importEntrants creates a change for each entrant in the autofill entrant list, matching entrants by their ID.
+func (cm *ConfigYAMLManager) importEntrants(name string, data, currentData []byte) ([]*ConfigImportChange, error) {
+	var entrants, currentEntrants []*Entrant
+
+	if err := unmarshalConfigYAML(data, &entrants); err != nil {
+		return nil, configYAMLError(name, err)
+	}
+
+	if err := unmarshalConfigYAML(currentData, &currentEntrants); err != nil {
+		return nil, err
+	}
+
+	current := make(map[string]*Entrant)
+
+	for _, entrant := range currentEntrants {
+		current[entrant.ID()] = entrant
+	}
+
+	var changes []*ConfigImportChange
+
+	for _, entrant := range entrants {
+		entrant := entrant
+
+		if entrant.ID() == "" {
+			return nil, configYAMLError(name, ErrConfigYAMLMissingID)
+		}
+
+		change := &ConfigImportChange{Type: "Entrant", ID: entrant.ID(), Name: entrant.Name, apply: func() error {
+			return cm.store.UpsertEntrant(*entrant)
+		}}
+
+		var currentData []byte
+
+		if currentEntrant, ok := current[entrant.ID()]; ok {
+			var err error
+
+			currentData, err = marshalConfigYAML(currentEntrant, true)
+
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if err := change.compare(currentData, currentData, entrant); err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+func isConfigYAMLName(name, expected string) bool {
+	return name == expected || name == strings.TrimSuffix(expected, configYAMLExtension)+".yaml"
+}
+
+func configYAMLError(name string, err error) error {
+	return fmt.Errorf("servermanager: could not read %s: %w", name, err)
+}
+
+// compare sets the action and diff of a change, by comparing the current YAML for an item with the
+// imported item. The imported item is encoded again, so that differences in formatting are ignored.
+// Changes to secrets are found with currentSecretsData, but are left out of the diff.
+func (c *ConfigImportChange) compare(currentData, currentSecretsData []byte, imported interface{}) error {
+	importedData, err := marshalConfigYAML(imported, true)
+
+	if err != nil {
+		return err
+	}
+
+	importedSecretsData, err := marshalConfigYAML(imported, false)
+
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case currentData == nil:
+		c.Action = ConfigImportCreated
+	case bytes.Equal(currentSecretsData, importedSecretsData):
+		c.Action = ConfigImportUnchanged
+		return nil
+	default:
+		c.Action = ConfigImportUpdated
+	}
+
+	c.Diff = diffLines(string(currentData), string(importedData), configYAMLDiffContext)
+
+	return nil
+}
+
+// marshalConfigYAML encodes v as YAML. v is encoded to JSON first, so that the YAML uses the same field names
+// and custom encodings as the store, with fields kept in the order that they are declared.
+func marshalConfigYAML(v interface{}, redactSecrets bool) ([]byte, error) {
+	data, err := json.Marshal(v)
+
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	out, err := jsonToYAMLValue(dec)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if m, ok := out.(yaml.MapSlice); ok {
+		out = removeConfigYAMLVolatileFields(m)
+	}
+
+	if redactSecrets {
+		out = redactConfigYAMLSecrets(out)
+	}
+
+	return yaml.Marshal(out)
+}
+
+func isConfigYAMLSecretField(key interface{}) bool {
+	for _, field := range configYAMLSecretFields {
+		if key == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// redactConfigYAMLSecrets replaces every secret which is set with configYAMLRedactedSecret. Secrets which aren't
+// set are left empty, so that it is clear they aren't set.
+func redactConfigYAMLSecrets(v interface{}) interface{} {
+	switch t := v.(type) {
+	case yaml.MapSlice:
+		for i, item := range t {
+			if s, ok := item.Value.(string); ok && s != "" && isConfigYAMLSecretField(item.Key) {
+				t[i].Value = configYAMLRedactedSecret
+			} else {
+				t[i].Value = redactConfigYAMLSecrets(item.Value)
+			}
+		}
+	case []interface{}:
+		for i, value := range t {
+			t[i] = redactConfigYAMLSecrets(value)
+		}
+	}
+
+	return v
+}
+
+// restoreConfigYAMLSecrets replaces the secrets in v which are still redacted with their values in currentData,
+// the unredacted YAML for the item as it is now. Redacted secrets of new items are left empty.
+func restoreConfigYAMLSecrets(v interface{}, currentData []byte) error {
+	data, err := json.Marshal(v)
+
+	if err != nil {
+		return err
+	}
+
+	var imported, current interface{}
+
+	if err := json.Unmarshal(data, &imported); err != nil {
+		return err
+	}
+
+	if err := yaml.Unmarshal(currentData, &current); err != nil {
+		return err
+	}
+
+	if !restoreConfigYAMLSecretValues(imported, yamlToJSONValue(current)) {
+		return nil
+	}
+
+	data, err = json.Marshal(imported)
+
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// restoreConfigYAMLSecretValues restores the redacted secrets in imported from current, returning true if any
+// secrets were restored.
+func restoreConfigYAMLSecretValues(imported, current interface{}) bool {
+	restored := false
+
+	switch t := imported.(type) {
+	case map[string]interface{}:
+		currentMap, _ := current.(map[string]interface{})
+
+		for key, value := range t {
+			if value == configYAMLRedactedSecret && isConfigYAMLSecretField(key) {
+				currentValue, _ := currentMap[key].(string)
+				t[key] = currentValue
+				restored = true
+			} else if restoreConfigYAMLSecretValues(value, currentMap[key]) {
+				restored = true
+			}
+		}
+	case []interface{}:
+		currentSlice, _ := current.([]interface{})
+
+		for i, value := range t {
+			var currentValue interface{}
+
+			if i < len(currentSlice) {
+				currentValue = currentSlice[i]
+			}
+
+			if restoreConfigYAMLSecretValues(value, currentValue) {
+				restored = true
+			}
+		}
+	}
+
+	return restored
+}
+
+func removeConfigYAMLVolatileFields(m yaml.MapSlice) yaml.MapSlice {
+	var out yaml.MapSlice
+
+	for _, item := range m {
+		volatile := false
+
+		for _, field := range configYAMLVolatileFields {
+			if item.Key == field {
+				volatile = true
+				break
+			}
+		}
+
+		if !volatile {
+			out = append(out, item)
+		}
+	}
+
+	return out
+}
+
+func jsonToYAMLValue(dec *json.Decoder) (interface{}, error) {
+	token, err := dec.Token()
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := token.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			m := yaml.MapSlice{}
+
+			for dec.More() {
+				key, err := dec.Token()
+
+				if err != nil {
+					return nil, err
+				}
+
+				value, err := jsonToYAMLValue(dec)
+
+				if err != nil {
+					return nil, err
+				}
+
+				m = append(m, yaml.MapItem{Key: key, Value: value})
+			}
+
+			// closing '}'
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+
+			return m, nil
+		case '[':
+			s := []interface{}{}
+
+			for dec.More() {
+				value, err := jsonToYAMLValue(dec)
+
+				if err != nil {
+					return nil, err
+				}
+
+				s = append(s, value)
+			}
+
+			// closing ']'
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+
+			return s, nil
+		default:
+			return nil, fmt.Errorf("servermanager: unexpected json delimiter: %s", t)
+		}
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i, nil
+		}
+
+		return t.Float64()
+	default:
+		return t, nil
+	}
+}
+
+// unmarshalConfigYAML decodes YAML written by marshalConfigYAML into v.
+func unmarshalConfigYAML(data []byte, v interface{}) error {
+	var out interface{}
+
+	if err := yaml.Unmarshal(data, &out); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(yamlToJSONValue(out))
+
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// yamlToJSONValue converts the maps decoded by the yaml package, which can have keys of any type, into maps
+// that can be encoded as JSON.
+func yamlToJSONValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+
+		for key, value := range t {
+			m[fmt.Sprint(key)] = yamlToJSONValue(value)
+		}
+
+		return m
+	case []interface{}:
+		for i, value := range t {
+			t[i] = yamlToJSONValue(value)
+		}
+
+		return t
+	default:
+		return t
+	}
+}
+
+// diffLines compares two texts line by line, returning the changed lines with the given number of unchanged
+// lines around them.
+func diffLines(a, b string, context int) []DiffLine {
+	aLines := strings.Split(strings.TrimSuffix(a, "\n"), "\n")
+	bLines := strings.Split(strings.TrimSuffix(b, "\n"), "\n")
+
+	if a == "" {
+		aLines = nil
+	}
+
+	// lines at the start and end which are the same are left out of the comparison, most changes are small.
+	prefix := 0
+
+	for prefix < len(aLines) && prefix < len(bLines) && aLines[prefix] == bLines[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+
+	for suffix < len(aLines)-prefix && suffix < len(bLines)-prefix && aLines[len(aLines)-1-suffix] == bLines[len(bLines)-1-suffix] {
+		suffix++
+	}
+
+	aMiddle := aLines[prefix : len(aLines)-suffix]
+	bMiddle := bLines[prefix : len(bLines)-suffix]
+
+	var lines []DiffLine
+
+	for _, line := range aLines[:prefix] {
+		lines = append(lines, DiffLine{Op: " ", Text: line})
+	}
+
+	lines = append(lines, diffMiddle(aMiddle, bMiddle)...)
+
+	for _, line := range aLines[len(aLines)-suffix:] {
+		lines = append(lines, DiffLine{Op: " ", Text: line})
+	}
+
+	return trimDiffContext(lines, context)
+}
+
+// diffMiddle finds the longest common subsequence of two sets of lines. Very large changes are shown as
+// every line being removed and added.
+func diffMiddle(a, b []string) []DiffLine {
+	var lines []DiffLine
+
+	if len(a)*len(b) > 4000000 {
+		for _, line := range a {
+			lines = append(lines, DiffLine{Op: "-", Text: line})
+		}
+
+		for _, line := range b {
+			lines = append(lines, DiffLine{Op: "+", Text: line})
+		}
+
+		return lines
+	}
+
+	lcs := make([][]int, len(a)+1)
+
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, DiffLine{Op: " ", Text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, DiffLine{Op: "-", Text: a[i]})
+			i++
+		default:
+			lines = append(lines, DiffLine{Op: "+", Text: b[j]})
+			j++
+		}
+	}
+
+	for ; i < len(a); i++ {
+		lines = append(lines, DiffLine{Op: "-", Text: a[i]})
+	}
+
+	for ; j < len(b); j++ {
+		lines = append(lines, DiffLine{Op: "+", Text: b[j]})
+	}
+
+	return lines
+}
+
+func trimDiffContext(lines []DiffLine, context int) []DiffLine {
+	keep := make([]bool, len(lines))
+
+	for i, line := range lines {
+		if line.Op == " " {
+			continue
+		}
+
+		for j := i - context; j <= i+context; j++ {
+			if j >= 0 && j < len(lines) {
+				keep[j] = true
+			}
+		}
+	}
+
+	var out []DiffLine
+	skipped := false
+
+	for i, line := range lines {
+		if !keep[i] {
+			skipped = true
+			continue
+		}
+
+		if skipped && len(out) > 0 {
+			out = append(out, DiffLine{Op: "..."})
+		}
+
+		skipped = false
+		out = append(out, line)
+	}
+
+	return out
+}
+
+type ConfigYAMLHandler struct {
+	*BaseHandler
+
+	configYAMLManager *ConfigYAMLManager
+}
+
+func NewConfigYAMLHandler(baseHandler *BaseHandler, configYAMLManager *ConfigYAMLManager) *ConfigYAMLHandler {
+	return &ConfigYAMLHandler{
+		BaseHandler:       baseHandler,
+		configYAMLManager: configYAMLManager,
+	}
+}
+
+type configYAMLTemplateVars struct {
+	BaseTemplateVars
+
+	Result      *ConfigImportResult
+	ImportToken string
+}
+
+func (ch *ConfigYAMLHandler) view(w http.ResponseWriter, r *http.Request) {
+	ch.viewRenderer.MustLoadTemplate(w, r, "server/configuration.html", &configYAMLTemplateVars{})
+}
+
+func (ch *ConfigYAMLHandler) export(w http.ResponseWriter, r *http.Request) {
+	buf := new(bytes.Buffer)
+
+	if err := ch.configYAMLManager.ExportZip(buf); err != nil {
+		logrus.WithError(err).Errorf("Could not export configuration")
+		AddErrorFlash(w, r, "Could not export configuration")
+		http.Redirect(w, r, r.Referer(), http.StatusFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"server-manager-config_"+time.Now().Format("2006-01-02")+".zip\"")
+
+	_, _ = w.Write(buf.Bytes())
+}
+
+// configImportPath is where an uploaded configuration is kept between previewing and applying an import.
+func configImportPath(token string) (string, error) {
+	if _, err := uuid.Parse(token); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(os.TempDir(), "server-manager-config-import-"+token+".zip"), nil
+}
+
+// importConfig previews the changes in an uploaded configuration. The upload is kept so that the changes can
+// then be applied with applyImport.
+func (ch *ConfigYAMLHandler) importConfig(w http.ResponseWriter, r *http.Request) {
+	file, _, err := r.FormFile("Configuration")
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not read uploaded configuration")
+		AddErrorFlash(w, r, "Could not read the uploaded configuration")
+		http.Redirect(w, r, "/configuration", http.StatusFound)
+		return
+	}
+
+	defer file.Close()
+
+	token := uuid.New().String()
+	location, err := configImportPath(token)
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not save uploaded configuration")
+		AddErrorFlash(w, r, "Could not read the uploaded configuration")
+		http.Redirect(w, r, "/configuration", http.StatusFound)
+		return
+	}
+
+	data, err := ioutil.ReadAll(file)
+
+	if err == nil {
+		err = ioutil.WriteFile(location, data, 0600)
+	}
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not save uploaded configuration")
+		AddErrorFlash(w, r, "Could not read the uploaded configuration")
+		http.Redirect(w, r, "/configuration", http.StatusFound)
+		return
+	}
+
+	result, err := ch.configYAMLManager.ImportZip(bytes.NewReader(data), int64(len(data)), true)
+
+	if err != nil {
+		_ = os.Remove(location)
+		logrus.WithError(err).Errorf("Could not preview configuration import")
+		AddErrorFlash(w, r, "The uploaded configuration could not be imported: "+err.Error())
+		http.Redirect(w, r, "/configuration", http.StatusFound)
+		return
+	}
+
+	if result.Count(ConfigImportCreated) == 0 && result.Count(ConfigImportUpdated) == 0 {
+		_ = os.Remove(location)
+	}
+
+	ch.viewRenderer.MustLoadTemplate(w, r, "server/configuration.html", &configYAMLTemplateVars{
+		Result:      result,
+		ImportToken: token,
+	})
+}
+
+func (ch *ConfigYAMLHandler) applyImport(w http.ResponseWriter, r *http.Request) {
+	location, err := configImportPath(chi.URLParam(r, "token"))
+
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	data, err := ioutil.ReadFile(location)
+
+	if os.IsNotExist(err) {
+		AddErrorFlash(w, r, "This import has already been applied or has expired, please upload the configuration again")
+		http.Redirect(w, r, "/configuration", http.StatusFound)
+		return
+	} else if err != nil {
+		logrus.WithError(err).Errorf("Could not read uploaded configuration")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	defer os.Remove(location)
+
+	result, err := ch.configYAMLManager.ImportZip(bytes.NewReader(data), int64(len(data)), false)
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not import configuration")
+		AddErrorFlash(w, r, "Could not import configuration: "+err.Error())
+	} else {
+		AddFlash(w, r, fmt.Sprintf("Configuration imported. %d items were created and %d were updated.", result.Count(ConfigImportCreated), result.Count(ConfigImportUpdated)))
+	}
+
+	http.Redirect(w, r, "/configuration", http.StatusFound)
+}
@@ -0,0 +1,321 @@
+package servermanager
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestConfigYAMLManager(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-yaml")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	exportDir := filepath.Join(dir, "export")
+
+	from := NewJSONStore(filepath.Join(dir, "from"), filepath.Join(dir, "from-shared"))
+	to := NewJSONStore(filepath.Join(dir, "to"), filepath.Join(dir, "to-shared"))
+
+	if err := Migrate(from); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Migrate(to); err != nil {
+		t.Fatal(err)
+	}
+
+	customRace := &CustomRace{Name: "Exported Race", UUID: uuid.New()}
+	customRace.RaceConfig.Track = "ks_vallelunga"
+
+	if err := from.UpsertCustomRace(customRace); err != nil {
+		t.Fatal(err)
+	}
+
+	raceWeekend := NewRaceWeekend()
+	raceWeekend.Name = "Exported Race Weekend"
+
+	championship := NewChampionship("Exported Championship")
+	event := NewChampionshipEvent()
+	event.RaceWeekendID = raceWeekend.ID
+	event.RaceWeekend = raceWeekend
+	championship.Events = append(championship.Events, event)
+
+	raceWeekend.ChampionshipID = championship.ID
+
+	if err := from.UpsertChampionship(championship); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := from.UpsertRaceWeekend(raceWeekend); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := from.UpsertEntrant(Entrant{Name: "Exported Driver", GUID: "76561198000000001", Model: "ks_mazda_mx5_cup"}); err != nil {
+		t.Fatal(err)
+	}
+
+	serverOptions, err := from.LoadServerOptions()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serverOptions.Name = "Exported Server"
+
+	if err := from.UpsertServerOptions(serverOptions); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := NewConfigYAMLManager(from).Export(exportDir); err != nil {
+		t.Fatal(err)
+	}
+
+	configYAMLManager := NewConfigYAMLManager(to)
+
+	t.Run("Dry runs do not change the store", func(t *testing.T) {
+		result, err := configYAMLManager.Import(exportDir, true)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		created := make(map[string]bool)
+
+		for _, change := range result.Changes {
+			if change.Action == ConfigImportCreated {
+				created[change.ID] = true
+			}
+		}
+
+		for _, id := range []string{customRace.UUID.String(), championship.ID.String(), raceWeekend.ID.String(), "76561198000000001"} {
+			if !created[id] {
+				t.Errorf("Expected %s to be created", id)
+			}
+		}
+
+		if _, err := to.FindCustomRaceByID(customRace.UUID.String()); err == nil {
+			t.Errorf("Expected the custom race not to be imported in a dry run")
+		}
+
+		for _, change := range result.Changes {
+			if change.Type == "Server Options" && (change.Action != ConfigImportUpdated || !diffContains(change.Diff, "+", "Name: Exported Server")) {
+				t.Errorf("Expected the server name to be shown as updated, got %s %v", change.Action, change.Diff)
+			}
+		}
+	})
+
+	t.Run("Configuration is imported", func(t *testing.T) {
+		if _, err := configYAMLManager.Import(exportDir, false); err != nil {
+			t.Fatal(err)
+		}
+
+		exported, err := NewConfigYAMLManager(from).exportFiles(false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		imported, err := configYAMLManager.exportFiles(false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for name, data := range exported {
+			if !bytes.Equal(data, imported[name]) {
+				t.Errorf("Expected %s to be the same after importing, got:\n%s\nwant:\n%s", name, imported[name], data)
+			}
+		}
+	})
+
+	t.Run("Importing again changes nothing", func(t *testing.T) {
+		result, err := configYAMLManager.Import(exportDir, false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result.Count(ConfigImportUnchanged) != len(result.Changes) {
+			t.Errorf("Expected every item to be unchanged, got %d created and %d updated", result.Count(ConfigImportCreated), result.Count(ConfigImportUpdated))
+		}
+	})
+
+	t.Run("Files without an ID are not imported", func(t *testing.T) {
+		if err := ioutil.WriteFile(filepath.Join(exportDir, configYAMLCustomRacesDir, "new.yml"), []byte("Name: No ID\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		defer os.Remove(filepath.Join(exportDir, configYAMLCustomRacesDir, "new.yml"))
+
+		if _, err := configYAMLManager.Import(exportDir, false); err == nil || !strings.Contains(err.Error(), ErrConfigYAMLMissingID.Error()) {
+			t.Errorf("Expected ErrConfigYAMLMissingID, got: %v", err)
+		}
+	})
+}
+
+func TestConfigYAMLManager_Secrets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-yaml-secrets")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	exportDir := filepath.Join(dir, "export")
+	store := NewJSONStore(filepath.Join(dir, "store"), filepath.Join(dir, "shared"))
+
+	if err := Migrate(store); err != nil {
+		t.Fatal(err)
+	}
+
+	serverOptions, err := store.LoadServerOptions()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serverOptions.Password = "server-password"
+	serverOptions.AdminPassword = "admin-password"
+	serverOptions.DiscordAPIToken = "discord-token"
+
+	if err := store.UpsertServerOptions(serverOptions); err != nil {
+		t.Fatal(err)
+	}
+
+	customRace := &CustomRace{Name: "Exported Race", UUID: uuid.New(), OverridePassword: true, ReplacementPassword: "race-password"}
+
+	if err := store.UpsertCustomRace(customRace); err != nil {
+		t.Fatal(err)
+	}
+
+	configYAMLManager := NewConfigYAMLManager(store)
+
+	if err := configYAMLManager.Export(exportDir); err != nil {
+		t.Fatal(err)
+	}
+
+	serverOptionsFile := filepath.Join(exportDir, configYAMLServerOptionsFile)
+	customRaceFile := filepath.Join(exportDir, configYAMLCustomRacesDir, customRace.UUID.String()+configYAMLExtension)
+
+	t.Run("Secrets are redacted in exports", func(t *testing.T) {
+		for _, file := range []string{serverOptionsFile, customRaceFile} {
+			data, err := ioutil.ReadFile(file)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if strings.Contains(string(data), "password") || strings.Contains(string(data), "discord-token") {
+				t.Errorf("Expected the secrets in %s to be redacted, got:\n%s", file, data)
+			}
+
+			if !strings.Contains(string(data), configYAMLRedactedSecret) {
+				t.Errorf("Expected %s to contain %s", file, configYAMLRedactedSecret)
+			}
+		}
+	})
+
+	t.Run("Redacted secrets are kept when importing", func(t *testing.T) {
+		result, err := configYAMLManager.Import(exportDir, false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result.Count(ConfigImportUnchanged) != len(result.Changes) {
+			t.Errorf("Expected every item to be unchanged, got %d created and %d updated", result.Count(ConfigImportCreated), result.Count(ConfigImportUpdated))
+		}
+
+		opts, err := store.LoadServerOptions()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if opts.Password != "server-password" || opts.AdminPassword != "admin-password" || opts.DiscordAPIToken != "discord-token" {
+			t.Errorf("Expected the server option secrets to be kept, got %q, %q, %q", opts.Password, opts.AdminPassword, opts.DiscordAPIToken)
+		}
+	})
+
+	t.Run("Secrets which are replaced are imported", func(t *testing.T) {
+		data, err := ioutil.ReadFile(customRaceFile)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data = bytes.Replace(data, []byte(configYAMLRedactedSecret), []byte("new-race-password"), 1)
+
+		if err := ioutil.WriteFile(customRaceFile, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := configYAMLManager.Import(exportDir, false)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, change := range result.Changes {
+			if change.ID != customRace.UUID.String() {
+				continue
+			}
+
+			if change.Action != ConfigImportUpdated {
+				t.Errorf("Expected the custom race to be updated, got %s", change.Action)
+			}
+
+			for _, line := range change.Diff {
+				if strings.Contains(line.Text, "new-race-password") {
+					t.Errorf("Expected the new secret not to be shown in the diff, got %v", change.Diff)
+				}
+			}
+		}
+
+		race, err := store.FindCustomRaceByID(customRace.UUID.String())
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if race.ReplacementPassword != "new-race-password" {
+			t.Errorf("Expected the replacement password to be imported, got %q", race.ReplacementPassword)
+		}
+	})
+}
+
+func diffContains(diff []DiffLine, op, text string) bool {
+	for _, line := range diff {
+		if line.Op == op && line.Text == text {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestDiffLines(t *testing.T) {
+	diff := diffLines("a\nb\nc\nd\ne\nf\ng\nh\n", "a\nb\nc\nd\nE\nf\ng\nh\ni\n", 1)
+
+	var lines []string
+
+	for _, line := range diff {
+		lines = append(lines, line.String())
+	}
+
+	expected := "  d|- e|+ E|  f|...|  h|+ i"
+
+	if strings.Join(lines, "|") != expected {
+		t.Errorf("Expected diff %q, got %q", expected, strings.Join(lines, "|"))
+	}
+}
@@ -493,30 +493,9 @@ func (cm *CarManager) RandomSkin(model string) string {
 
 // ResultsForCar finds results for a given car.
 func (cm *CarManager) ResultsForCar(car string) ([]SessionResults, error) {
-	results, err := ListAllResults()
+	results, _, err := FindResults(ResultsQuery{Car: car}, LoadResultWithoutPluginFire)
 
-	if err != nil {
-		return nil, err
-	}
-
-	var out []SessionResults
-
-	for _, result := range results {
-		hasCar := false
-
-		for _, driver := range result.Result {
-			if driver.CarModel == car {
-				hasCar = true
-				break
-			}
-		}
-
-		if hasCar {
-			out = append(out, result)
-		}
-	}
-
-	return out, nil
+	return results, err
 }
 
 // DeleteCar removes a car from the file system and search index.
@@ -350,14 +350,16 @@ func NewTrackManager() *TrackManager {
 type trackDetailsTemplateVars struct {
 	BaseTemplateVars
 
-	Track     *Track
-	TrackInfo map[string]*TrackInfo
-	Results   map[string][]SessionResults
+	Track           *Track
+	TrackInfo       map[string]*TrackInfo
+	Results         map[string][]SessionResults
+	LeaderboardCars map[string][]string
 }
 
 func (tm *TrackManager) loadTrackDetailsForTemplate(trackName string) (*trackDetailsTemplateVars, error) {
 	trackInfoMap := make(map[string]*TrackInfo)
 	resultsMap := make(map[string][]SessionResults)
+	leaderboardCarsMap := make(map[string][]string)
 
 	track, err := tm.GetTrackFromName(trackName)
 
@@ -389,6 +391,7 @@ func (tm *TrackManager) loadTrackDetailsForTemplate(trackName string) (*trackDet
 		}
 
 		resultsMap[layout] = results
+		leaderboardCarsMap[layout] = carsWithLaps(results)
 	}
 
 	return &trackDetailsTemplateVars{
@@ -396,25 +399,54 @@ func (tm *TrackManager) loadTrackDetailsForTemplate(trackName string) (*trackDet
 		Track:            track,
 		TrackInfo:        trackInfoMap,
 		Results:          resultsMap,
+		LeaderboardCars:  leaderboardCarsMap,
 	}, nil
 }
 
 func (tm *TrackManager) ResultsForLayout(trackName, layout string) ([]SessionResults, error) {
-	results, err := ListAllResults()
+	if layout == defaultLayoutName {
+		layout = ""
+	}
+
+	results, _, err := FindResults(ResultsQuery{
+		Track:            trackName,
+		TrackLayout:      layout,
+		MatchTrackLayout: true,
+	}, LoadResultWithoutPluginFire)
+
+	return results, err
+}
+
+// CarsForLayout lists the cars which have set laps at a track layout, for use in leaderboards.
+func (tm *TrackManager) CarsForLayout(trackName, layout string) ([]string, error) {
+	results, err := tm.ResultsForLayout(trackName, layout)
 
 	if err != nil {
 		return nil, err
 	}
 
-	var out []SessionResults
+	return carsWithLaps(results), nil
+}
+
+func carsWithLaps(results []SessionResults) []string {
+	seen := make(map[string]bool)
+
+	var cars []string
 
 	for _, result := range results {
-		if result.TrackName == trackName && result.TrackConfig == layout {
-			out = append(out, result)
+		for _, lap := range result.Laps {
+			if lap.CarModel == "" || seen[lap.CarModel] {
+				continue
+			}
+
+			seen[lap.CarModel] = true
+			cars = append(cars, lap.CarModel)
 		}
 	}
 
-	return out, nil
+	sort.Strings(cars)
+
+	return cars
 }
 
 func (tm *TrackManager) ListTracks() ([]Track, error) {
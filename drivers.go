@@ -0,0 +1,375 @@
+package servermanager
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	driverProfileNumRecentSessions = 10
+	kilometresPerMile              = 1.609344
+)
+
+var ErrDriverNotFound = errors.New("servermanager: driver not found")
+
+// DriverProfile is a summary of a driver's career on this server, built from the results files they appear in.
+type DriverProfile struct {
+	GUID string
+	Name string
+	Team string
+
+	RacesEntered             int
+	Wins                     int
+	Podiums                  int
+	Poles                    int
+	FastestLaps              int
+	AverageFinishingPosition float64
+
+	LapsCompleted      int
+	DistanceKilometres float64
+	Collisions         int
+	Cuts               int
+	IncidentsPer100KM  float64
+
+	PersonalBests  []*DriverPersonalBest
+	RecentSessions []*DriverSessionSummary
+}
+
+// DriverPersonalBest is a driver's fastest valid lap for a given track, layout and car.
+type DriverPersonalBest struct {
+	Track       string
+	TrackLayout string
+	Car         string
+	LapTime     time.Duration
+	Tyre        string
+	Date        time.Time
+	SessionFile string
+	SessionType SessionType
+}
+
+// DriverSessionSummary is how a driver finished in a single session.
+type DriverSessionSummary struct {
+	SessionFile string
+	SessionType SessionType
+	Date        time.Time
+	Track       string
+	TrackLayout string
+	Car         string
+	Position    int
+	BestLap     time.Duration
+}
+
+// BuildDriverProfile reads every results file the driver appears in and calculates their career statistics.
+// Finishing positions are counted within the driver's class. Incidents are collisions (with cars and the
+// environment) and track limits cuts, over the distance the driver has covered on tracks with a known length.
+func BuildDriverProfile(guid string) (*DriverProfile, error) {
+	results, _, err := FindResults(ResultsQuery{DriverGUID: guid}, LoadResultWithoutPluginFire)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(results) == 0 {
+		return nil, ErrDriverNotFound
+	}
+
+	profile := &DriverProfile{
+		GUID: guid,
+	}
+
+	personalBests := make(map[string]*DriverPersonalBest)
+	trackLengths := make(map[string]float64)
+	var totalRacePositions, classifiedRaces int
+
+	for i := range results {
+		session := &results[i]
+
+		if profile.Name == "" {
+			for _, car := range session.Cars {
+				if car.Driver.GUID == guid && car.Driver.Name != "" {
+					profile.Name = car.Driver.Name
+					profile.Team = car.Driver.Team
+					break
+				}
+			}
+		}
+
+		var sessionLaps int
+
+		for _, lap := range session.Laps {
+			if lap.DriverGUID != guid {
+				continue
+			}
+
+			sessionLaps++
+			profile.Cuts += lap.Cuts
+
+			if profile.Name == "" {
+				profile.Name = lap.DriverName
+			}
+
+			if lap.Cuts > 0 || lap.LapTime <= 0 {
+				continue
+			}
+
+			key := strings.Join([]string{session.TrackName, session.TrackConfig, lap.CarModel}, "/")
+
+			if pb, ok := personalBests[key]; !ok || lap.GetLapTime() < pb.LapTime {
+				personalBests[key] = &DriverPersonalBest{
+					Track:       session.TrackName,
+					TrackLayout: session.TrackConfig,
+					Car:         lap.CarModel,
+					LapTime:     lap.GetLapTime(),
+					Tyre:        lap.Tyre,
+					Date:        session.Date,
+					SessionFile: session.SessionFile,
+					SessionType: session.Type,
+				}
+			}
+		}
+
+		profile.LapsCompleted += sessionLaps
+
+		if sessionLaps > 0 {
+			trackKey := session.TrackName + "/" + session.TrackConfig
+
+			length, ok := trackLengths[trackKey]
+
+			if !ok {
+				length = trackLengthKilometres(session.TrackName, session.TrackConfig)
+				trackLengths[trackKey] = length
+			}
+
+			profile.DistanceKilometres += length * float64(sessionLaps)
+		}
+
+		for _, event := range session.Events {
+			if event.Driver == nil || event.Driver.GUID != guid {
+				continue
+			}
+
+			if event.Type == "COLLISION_WITH_CAR" || event.Type == "COLLISION_WITH_ENV" {
+				profile.Collisions++
+			}
+		}
+
+		result, position := session.driverClassPosition(guid)
+
+		if result == nil {
+			continue
+		}
+
+		if len(profile.RecentSessions) < driverProfileNumRecentSessions {
+			profile.RecentSessions = append(profile.RecentSessions, &DriverSessionSummary{
+				SessionFile: session.SessionFile,
+				SessionType: session.Type,
+				Date:        session.Date,
+				Track:       session.TrackName,
+				TrackLayout: session.TrackConfig,
+				Car:         result.CarModel,
+				Position:    position,
+				BestLap:     time.Duration(result.BestLap) * time.Millisecond,
+			})
+		}
+
+		switch session.Type {
+		case SessionTypeQualifying:
+			if position == 1 {
+				profile.Poles++
+			}
+		case SessionTypeRace:
+			profile.RacesEntered++
+
+			if result.Disqualified || position == 0 {
+				continue
+			}
+
+			totalRacePositions += position
+			classifiedRaces++
+
+			if position == 1 {
+				profile.Wins++
+			}
+
+			if position <= 3 {
+				profile.Podiums++
+			}
+
+			if fastestLap := session.FastestLapInClass(result.ClassID); fastestLap != nil && fastestLap.Cuts == 0 && fastestLap.DriverGUID == guid {
+				profile.FastestLaps++
+			}
+		}
+	}
+
+	if classifiedRaces > 0 {
+		profile.AverageFinishingPosition = float64(totalRacePositions) / float64(classifiedRaces)
+	}
+
+	if profile.DistanceKilometres > 0 {
+		profile.IncidentsPer100KM = float64(profile.Collisions+profile.Cuts) / profile.DistanceKilometres * 100
+	}
+
+	for _, pb := range personalBests {
+		profile.PersonalBests = append(profile.PersonalBests, pb)
+	}
+
+	sort.Slice(profile.PersonalBests, func(i, j int) bool {
+		a, b := profile.PersonalBests[i], profile.PersonalBests[j]
+
+		if a.Track != b.Track {
+			return a.Track < b.Track
+		}
+
+		if a.TrackLayout != b.TrackLayout {
+			return a.TrackLayout < b.TrackLayout
+		}
+
+		return a.Car < b.Car
+	})
+
+	return profile, nil
+}
+
+// driverClassPosition finds the driver's result in the session, and their finishing position amongst the
+// cars in the same class. Disqualified drivers have no position. Drivers that shared a car in a driver swap
+// are each given that car's result.
+func (s *SessionResults) driverClassPosition(guid string) (*SessionResult, int) {
+	for _, result := range s.Result {
+		if resultHasDriverGUID(result, guid) {
+			return result, s.classPosition(result)
+		}
+	}
+
+	return nil, 0
+}
+
+func (s *SessionResults) classPosition(result *SessionResult) int {
+	var position int
+
+	for _, other := range s.Result {
+		if other.Disqualified || other.ClassID != result.ClassID {
+			continue
+		}
+
+		position++
+
+		if other == result {
+			return position
+		}
+	}
+
+	return 0
+}
+
+func resultHasDriverGUID(result *SessionResult, guid string) bool {
+	for _, resultGUID := range strings.Split(result.DriverGUID, driverSwapEntrantSeparator) {
+		if resultGUID == guid {
+			return true
+		}
+	}
+
+	return false
+}
+
+// trackLengthKilometres looks up the length of a track layout from its ui_track.json. Tracks without a
+// parseable length are treated as having no length, so they do not contribute to distance driven.
+func trackLengthKilometres(track, layout string) float64 {
+	trackInfo, err := GetTrackInfo(track, layout)
+
+	if err != nil {
+		logrus.WithError(err).Debugf("Could not load track info for: %s (%s)", track, layout)
+		return 0
+	}
+
+	length, err := parseTrackLength(trackInfo.Length)
+
+	if err != nil {
+		logrus.WithError(err).Debugf("Could not parse track length for: %s (%s)", track, layout)
+		return 0
+	}
+
+	return length
+}
+
+var trackLengthRegex = regexp.MustCompile(`([0-9]+(?:[.,][0-9]+)*)\s*([a-zA-Z]*)`)
+
+// parseTrackLength converts the free-form length in a track's ui_track.json (e.g. "5245m", "5.2 km",
+// "3,6 km", "2.5 miles") into kilometres. Values without a unit are assumed to be metres if they are
+// too long to be a track length in kilometres.
+func parseTrackLength(length string) (float64, error) {
+	match := trackLengthRegex.FindStringSubmatch(length)
+
+	if match == nil {
+		return 0, errors.New("servermanager: no track length found")
+	}
+
+	number, unit := match[1], strings.ToLower(match[2])
+
+	isKilometres := strings.HasPrefix(unit, "k")
+	isMiles := strings.HasPrefix(unit, "mi")
+
+	if isKilometres || isMiles {
+		// a comma in a value in km or miles is a decimal separator, e.g. "3,6 km"
+		number = strings.Replace(number, ",", ".", 1)
+	}
+
+	number = strings.Replace(number, ",", "", -1)
+
+	value, err := strconv.ParseFloat(number, 64)
+
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case isKilometres:
+		return value, nil
+	case isMiles:
+		return value * kilometresPerMile, nil
+	case unit == "" && value < 100:
+		return value, nil
+	default:
+		return value / 1000, nil
+	}
+}
+
+type DriversHandler struct {
+	*BaseHandler
+}
+
+func NewDriversHandler(baseHandler *BaseHandler) *DriversHandler {
+	return &DriversHandler{
+		BaseHandler: baseHandler,
+	}
+}
+
+type driverProfileTemplateVars struct {
+	BaseTemplateVars
+
+	Profile *DriverProfile
+}
+
+func (dh *DriversHandler) view(w http.ResponseWriter, r *http.Request) {
+	profile, err := BuildDriverProfile(chi.URLParam(r, "guid"))
+
+	if err == ErrDriverNotFound {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	} else if err != nil {
+		logrus.WithError(err).Errorf("Could not build driver profile")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	dh.viewRenderer.MustLoadTemplate(w, r, "drivers/view.html", &driverProfileTemplateVars{
+		Profile: profile,
+	})
+}
@@ -0,0 +1,221 @@
+package servermanager
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseTrackLength(t *testing.T) {
+	lengths := map[string]float64{
+		"5245m":       5.245,
+		"5245 m":      5.245,
+		"5,245 m":     5.245,
+		"5.2 km":      5.2,
+		"3,6 km":      3.6,
+		"4.5KM":       4.5,
+		"2.5 miles":   4.02336,
+		"1 mile":      1.609344,
+		"4381":        4.381,
+		"6.2":         6.2,
+		"approx 900m": 0.9,
+	}
+
+	for length, expected := range lengths {
+		actual, err := parseTrackLength(length)
+
+		if err != nil {
+			t.Errorf("Could not parse %q: %s", length, err)
+			continue
+		}
+
+		if math.Abs(actual-expected) > 0.0001 {
+			t.Errorf("Expected %q to be %f km, got %f km", length, expected, actual)
+		}
+	}
+
+	if _, err := parseTrackLength("unknown"); err == nil {
+		t.Error("Expected an error for a length with no value")
+	}
+}
+
+// writeDriverProfileResults writes results files at a 5km track to a temporary server install path, for
+// building driver profiles from.
+func writeDriverProfileResults(t *testing.T, dir string, sessions map[string]*SessionResults) {
+	trackDir := filepath.Join(dir, "content", "tracks", "test_track", "ui")
+
+	if err := os.MkdirAll(trackDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(trackDir, trackInfoJSONName), []byte(`{"length": "5000m"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, "results"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for fileName, session := range sessions {
+		session.TrackName = "test_track"
+
+		data, err := json.Marshal(session)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(filepath.Join(dir, "results", fileName), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBuildDriverProfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "driver-profile")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	oldInstallPath := ServerInstallPath
+	ServerInstallPath = dir
+
+	defer func() {
+		ServerInstallPath = oldInstallPath
+	}()
+
+	oldResultsIndex := resultsIndex
+	resultsIndex = nil
+
+	defer func() {
+		resultsIndex = oldResultsIndex
+	}()
+
+	const (
+		driver  = "76561100000000001"
+		rival   = "76561100000000002"
+		partner = "76561100000000003"
+	)
+
+	writeDriverProfileResults(t, dir, map[string]*SessionResults{
+		// pole position.
+		"2020_1_1_12_0_QUALIFY.json": {
+			Type: SessionTypeQualifying,
+			Cars: []*SessionCar{{CarID: 0, Model: "ks_mazda_mx5_cup", Driver: SessionDriver{GUID: driver, Name: "Driver", Team: "Team"}}},
+			Result: []*SessionResult{
+				{CarID: 0, DriverGUID: driver, DriverName: "Driver", CarModel: "ks_mazda_mx5_cup", BestLap: 89000, TotalTime: 601000},
+				{CarID: 1, DriverGUID: rival, DriverName: "Rival", CarModel: "ks_mazda_mx5_cup", BestLap: 89500, TotalTime: 602000},
+			},
+		},
+		// a win with the fastest lap, a collision and a cut.
+		"2020_1_1_13_0_RACE.json": {
+			Type: SessionTypeRace,
+			Result: []*SessionResult{
+				{CarID: 0, DriverGUID: driver, DriverName: "Driver", CarModel: "ks_mazda_mx5_cup", BestLap: 90000, TotalTime: 601000},
+				{CarID: 1, DriverGUID: rival, DriverName: "Rival", CarModel: "ks_mazda_mx5_cup", BestLap: 91000, TotalTime: 602000},
+			},
+			Laps: []*SessionLap{
+				{CarID: 0, DriverGUID: driver, CarModel: "ks_mazda_mx5_cup", LapTime: 90000},
+				{CarID: 0, DriverGUID: driver, CarModel: "ks_mazda_mx5_cup", LapTime: 88000, Cuts: 1},
+				{CarID: 1, DriverGUID: rival, CarModel: "ks_mazda_mx5_cup", LapTime: 91000},
+			},
+			Events: []*SessionEvent{
+				{Type: "COLLISION_WITH_CAR", Driver: &SessionDriver{GUID: driver}, OtherDriver: &SessionDriver{GUID: rival}},
+				{Type: "COLLISION_WITH_ENV", Driver: &SessionDriver{GUID: rival}},
+			},
+		},
+		// third place.
+		"2020_1_2_13_0_RACE.json": {
+			Type: SessionTypeRace,
+			Result: []*SessionResult{
+				{CarID: 1, DriverGUID: rival, DriverName: "Rival", CarModel: "ks_mazda_mx5_cup", BestLap: 90000, TotalTime: 601000},
+				{CarID: 2, DriverGUID: partner, DriverName: "Partner", CarModel: "ks_mazda_mx5_cup", BestLap: 91000, TotalTime: 602000},
+				{CarID: 0, DriverGUID: driver, DriverName: "Driver", CarModel: "ks_mazda_mx5_cup", BestLap: 92000, TotalTime: 603000},
+			},
+			Laps: []*SessionLap{
+				{CarID: 1, DriverGUID: rival, CarModel: "ks_mazda_mx5_cup", LapTime: 90000},
+				{CarID: 0, DriverGUID: driver, CarModel: "ks_mazda_mx5_cup", LapTime: 92000},
+			},
+		},
+		// second place, sharing a car with partner in a driver swap.
+		"2020_1_3_13_0_RACE.json": {
+			Type: SessionTypeRace,
+			Result: []*SessionResult{
+				{CarID: 1, DriverGUID: rival, DriverName: "Rival", CarModel: "ks_mazda_mx5_cup", BestLap: 90000, TotalTime: 601000},
+				{CarID: 0, DriverGUID: partner + driverSwapEntrantSeparator + driver, DriverName: "Partner", CarModel: "ks_mazda_mx5_cup", BestLap: 93000, TotalTime: 602000},
+			},
+			Laps: []*SessionLap{
+				{CarID: 1, DriverGUID: rival, CarModel: "ks_mazda_mx5_cup", LapTime: 90000},
+				{CarID: 0, DriverGUID: driver, CarModel: "ks_mazda_mx5_cup", LapTime: 93000},
+			},
+		},
+	})
+
+	profile, err := BuildDriverProfile(driver)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if profile.Name != "Driver" || profile.Team != "Team" {
+		t.Errorf("Expected the driver to be Driver (Team), got %s (%s)", profile.Name, profile.Team)
+	}
+
+	counts := map[string][2]int{
+		"races entered":  {3, profile.RacesEntered},
+		"wins":           {1, profile.Wins},
+		"podiums":        {3, profile.Podiums},
+		"poles":          {1, profile.Poles},
+		"fastest laps":   {1, profile.FastestLaps},
+		"laps completed": {4, profile.LapsCompleted},
+		"collisions":     {1, profile.Collisions},
+		"cuts":           {1, profile.Cuts},
+	}
+
+	for name, count := range counts {
+		if count[0] != count[1] {
+			t.Errorf("Expected %d %s, got %d", count[0], name, count[1])
+		}
+	}
+
+	// finishing positions are 1st, 3rd and 2nd (shared with partner).
+	if profile.AverageFinishingPosition != 2 {
+		t.Errorf("Expected an average finishing position of 2, got %f", profile.AverageFinishingPosition)
+	}
+
+	if profile.DistanceKilometres != 20 {
+		t.Errorf("Expected 4 laps of a 5km track to be 20km, got %f", profile.DistanceKilometres)
+	}
+
+	// one collision and one cut in 20km.
+	if profile.IncidentsPer100KM != 10 {
+		t.Errorf("Expected 10 incidents per 100km, got %f", profile.IncidentsPer100KM)
+	}
+
+	if len(profile.PersonalBests) != 1 || profile.PersonalBests[0].LapTime != 90*time.Second {
+		t.Errorf("Expected a personal best of 1:30.000 (laps with cuts don't count), got %v", profile.PersonalBests)
+	}
+
+	t.Run("Driver swap partners are given the shared car's result", func(t *testing.T) {
+		profile, err := BuildDriverProfile(partner)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if profile.RacesEntered != 2 || profile.Podiums != 2 || profile.AverageFinishingPosition != 2 {
+			t.Errorf("Expected 2 races with 2 podiums averaging 2nd, got %d races with %d podiums averaging %f", profile.RacesEntered, profile.Podiums, profile.AverageFinishingPosition)
+		}
+	})
+
+	if _, err := BuildDriverProfile("76561100000000004"); err != ErrDriverNotFound {
+		t.Errorf("Expected ErrDriverNotFound for a driver with no results, got: %v", err)
+	}
+}
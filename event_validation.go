@@ -0,0 +1,598 @@
+package servermanager
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrEventValidationFailed is returned when a scheduled event is not started because it failed validation.
+var ErrEventValidationFailed = errors.New("servermanager: event failed validation")
+
+// EventValidationIssue is a single problem found with an event's configuration.
+type EventValidationIssue struct {
+	Field   string
+	Message string
+}
+
+// EventValidation is the result of a dry-run check of an event's configuration. Errors would stop the server
+// from starting (or stop drivers from joining it), Warnings are worth checking but won't prevent the event from running.
+type EventValidation struct {
+	Errors   []EventValidationIssue
+	Warnings []EventValidationIssue
+}
+
+func (v *EventValidation) addError(field, format string, args ...interface{}) {
+	v.Errors = append(v.Errors, EventValidationIssue{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *EventValidation) addWarning(field, format string, args ...interface{}) {
+	v.Warnings = append(v.Warnings, EventValidationIssue{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *EventValidation) HasErrors() bool {
+	return len(v.Errors) > 0
+}
+
+func (v *EventValidation) HasWarnings() bool {
+	return len(v.Warnings) > 0
+}
+
+// String lists every error and warning on its own line.
+func (v *EventValidation) String() string {
+	var lines []string
+
+	for _, issue := range v.Errors {
+		lines = append(lines, fmt.Sprintf("Error (%s): %s", issue.Field, issue.Message))
+	}
+
+	for _, issue := range v.Warnings {
+		lines = append(lines, fmt.Sprintf("Warning (%s): %s", issue.Field, issue.Message))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// ValidateEvent checks that the content an event uses is installed and that the server will be able to start with
+// it, without writing any configuration or starting the server.
+func (rm *RaceManager) ValidateEvent(event RaceEvent) (*EventValidation, error) {
+	serverOpts, err := rm.LoadServerOptions()
+
+	if err != nil {
+		return nil, err
+	}
+
+	tyres, err := ListTyres()
+
+	if err != nil {
+		return nil, err
+	}
+
+	weather, err := ListWeather()
+
+	if err != nil {
+		return nil, err
+	}
+
+	validation := &EventValidation{}
+	raceConfig := event.GetRaceConfig()
+	entryList := event.GetEntryList()
+
+	validateEventTrack(validation, raceConfig, entryList)
+	validateEventCars(validation, rm.carManager, raceConfig, entryList, tyres)
+	validateEventWeather(validation, raceConfig, weather)
+	validateEventPorts(validation, serverOpts, !rm.process.IsRunning())
+
+	return validation, nil
+}
+
+func validateEventTrack(validation *EventValidation, raceConfig CurrentRaceConfig, entryList EntryList) {
+	trackPath := filepath.Join(ServerInstallPath, "content", "tracks", raceConfig.Track)
+
+	if raceConfig.TrackLayout != "" && raceConfig.TrackLayout != defaultLayoutName {
+		trackPath = filepath.Join(trackPath, raceConfig.TrackLayout)
+	}
+
+	if _, err := os.Stat(trackPath); err != nil {
+		validation.addError("Track", "%s is not installed on the server", trackSummary(raceConfig.Track, raceConfig.TrackLayout))
+		return
+	}
+
+	if MaxClientsOverride > 0 && len(entryList) > MaxClientsOverride {
+		validation.addError("Entry List", "%d entrants exceeds the maximum of %d entrants allowed on this server", len(entryList), MaxClientsOverride)
+	}
+
+	trackInfo, err := GetTrackInfo(raceConfig.Track, raceConfig.TrackLayout)
+
+	if err != nil {
+		validation.addWarning("Track", "Could not read the track's ui_track.json, so the number of pit boxes can't be checked")
+		return
+	}
+
+	pitboxes, err := strconv.Atoi(strings.TrimSpace(trackInfo.Pitboxes.String()))
+
+	if err != nil || pitboxes <= 0 {
+		validation.addWarning("Track", "The track's ui_track.json doesn't list its number of pit boxes, so the entry list size can't be checked")
+		return
+	}
+
+	if len(entryList) > pitboxes {
+		validation.addError("Entry List", "%d entrants is more than the %d pit boxes at %s", len(entryList), pitboxes, trackSummary(raceConfig.Track, raceConfig.TrackLayout))
+	}
+}
+
+func validateEventCars(validation *EventValidation, carManager *CarManager, raceConfig CurrentRaceConfig, entryList EntryList, tyres Tyres) {
+	carsInEvent := make(map[string]bool)
+	loadedCars := make(map[string]*Car)
+
+	for _, carName := range varSplit(raceConfig.Cars) {
+		if carName != AnyCarModel {
+			carsInEvent[carName] = true
+		}
+	}
+
+	for _, entrant := range entryList.AsSlice() {
+		if entrant.Model != "" && entrant.Model != AnyCarModel {
+			carsInEvent[entrant.Model] = true
+		}
+	}
+
+	if len(carsInEvent) == 0 {
+		validation.addError("Cars", "No cars are selected for this event")
+		return
+	}
+
+	var carNames, legalTyres []string
+
+	for carName := range carsInEvent {
+		carNames = append(carNames, carName)
+	}
+
+	for tyre := range raceConfig.Tyres() {
+		if tyre != "" {
+			legalTyres = append(legalTyres, tyre)
+		}
+	}
+
+	sort.Strings(carNames)
+	sort.Strings(legalTyres)
+
+	for _, carName := range carNames {
+		if _, err := os.Stat(filepath.Join(ServerInstallPath, "content", "cars", carName)); err != nil {
+			validation.addError("Cars", "%s is not installed on the server", carName)
+			continue
+		}
+
+		car, err := carManager.LoadCar(carName, tyres)
+
+		if err != nil {
+			validation.addError("Cars", "Could not load %s: %s", carName, err)
+			continue
+		}
+
+		loadedCars[carName] = car
+
+		if len(car.Tyres) == 0 || len(legalTyres) == 0 {
+			// there is no tyre data for this car, so the tyres can't be checked
+			continue
+		}
+
+		var carHasLegalTyre bool
+
+		for _, tyre := range legalTyres {
+			if _, ok := car.Tyres[tyre]; ok {
+				carHasLegalTyre = true
+				break
+			}
+		}
+
+		if !carHasLegalTyre {
+			validation.addError("Tyres", "None of the allowed tyres (%s) are available for %s", strings.Join(legalTyres, ", "), carName)
+		}
+	}
+
+	for _, tyre := range legalTyres {
+		var tyreIsUsed bool
+
+		for _, car := range loadedCars {
+			if _, ok := car.Tyres[tyre]; ok || len(car.Tyres) == 0 {
+				tyreIsUsed = true
+				break
+			}
+		}
+
+		if !tyreIsUsed {
+			validation.addWarning("Tyres", "The %s tyre is not available for any car in this event", tyre)
+		}
+	}
+
+	for _, entrant := range entryList.AsSlice() {
+		car, ok := loadedCars[entrant.Model]
+
+		if !ok || entrant.Skin == "" || entrant.Skin == "random_skin" {
+			continue
+		}
+
+		var skinExists bool
+
+		for _, skin := range car.Skins {
+			if skin == entrant.Skin {
+				skinExists = true
+				break
+			}
+		}
+
+		if !skinExists {
+			validation.addWarning("Entry List", "The skin %s for %s (%s) is not uploaded to the server", entrant.Skin, entrantDescription(entrant), entrant.Model)
+		}
+	}
+}
+
+// entrantDescription names an entrant in a validation message.
+func entrantDescription(entrant *Entrant) string {
+	if entrant.Name != "" {
+		return driverName(entrant.Name)
+	}
+
+	return fmt.Sprintf("pit box %d", entrant.PitBox+1)
+}
+
+func validateEventWeather(validation *EventValidation, raceConfig CurrentRaceConfig, weather Weather) {
+	if len(raceConfig.Weather) == 0 {
+		validation.addError("Weather", "No weather is configured for this event")
+		return
+	}
+
+	var weatherKeys []string
+
+	for key := range raceConfig.Weather {
+		weatherKeys = append(weatherKeys, key)
+	}
+
+	sort.Strings(weatherKeys)
+
+	for _, key := range weatherKeys {
+		weatherConfig := raceConfig.Weather[key]
+
+		// sol weathers have their settings appended to the graphics folder name, the folder is stored separately
+		graphics := weatherConfig.Graphics
+
+		if weatherConfig.CMGraphics != "" {
+			graphics = weatherConfig.CMGraphics
+		}
+
+		if _, ok := weather[graphics]; !ok {
+			validation.addError("Weather", "The weather %s is not installed on the server", graphics)
+		}
+	}
+}
+
+func validateEventPorts(validation *EventValidation, serverOpts *GlobalServerConfig, checkPortsInUse bool) {
+	tcpPorts := map[string]int{
+		"TCP Port":  serverOpts.TCPPort,
+		"HTTP Port": serverOpts.HTTPPort,
+	}
+
+	if serverOpts.EnableContentManagerWrapper == 1 && serverOpts.ContentManagerWrapperPort > 0 {
+		tcpPorts["Content Manager Wrapper Port"] = serverOpts.ContentManagerWrapperPort
+	}
+
+	if config != nil {
+		if _, port, err := net.SplitHostPort(config.HTTP.Hostname); err == nil {
+			if serverManagerPort, err := strconv.Atoi(port); err == nil {
+				tcpPorts["Server Manager HTTP Port"] = serverManagerPort
+			}
+		}
+	}
+
+	udpPorts := map[string]int{
+		"UDP Port":              serverOpts.UDPPort,
+		"UDP Plugin Local Port": serverOpts.UDPPluginLocalPort,
+	}
+
+	validateEventPortClashes(validation, tcpPorts)
+	validateEventPortClashes(validation, udpPorts)
+
+	if !checkPortsInUse {
+		// the server is running, so its ports are expected to be in use.
+		return
+	}
+
+	for _, name := range []string{"TCP Port", "HTTP Port"} {
+		l, err := net.Listen("tcp", fmt.Sprintf(":%d", tcpPorts[name]))
+
+		if err != nil {
+			validation.addError("Ports", "The %s (%d) is already in use by another program", name, tcpPorts[name])
+			continue
+		}
+
+		_ = l.Close()
+	}
+
+	l, err := net.ListenPacket("udp", fmt.Sprintf(":%d", serverOpts.UDPPort))
+
+	if err != nil {
+		validation.addError("Ports", "The UDP Port (%d) is already in use by another program", serverOpts.UDPPort)
+		return
+	}
+
+	_ = l.Close()
+}
+
+func validateEventPortClashes(validation *EventValidation, ports map[string]int) {
+	names := make(map[int][]string)
+
+	for name, port := range ports {
+		if port > 0 {
+			names[port] = append(names[port], name)
+		}
+	}
+
+	var clashingPorts []int
+
+	for port, portNames := range names {
+		if len(portNames) > 1 {
+			clashingPorts = append(clashingPorts, port)
+		}
+	}
+
+	sort.Ints(clashingPorts)
+
+	for _, port := range clashingPorts {
+		sort.Strings(names[port])
+		validation.addError("Ports", "Port %d is used by more than one setting: %s", port, strings.Join(names[port], ", "))
+	}
+}
+
+// ValidateCustomRace runs a validation pass on a saved custom race.
+func (rm *RaceManager) ValidateCustomRace(uuid string) (*CustomRace, *EventValidation, error) {
+	race, err := rm.store.FindCustomRaceByID(uuid)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	validation, err := rm.ValidateEvent(race)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return race, validation, nil
+}
+
+// validateScheduledEvent is run before a scheduled event starts. If the event has errors, a notification is sent
+// explaining why and ErrEventValidationFailed is returned so that the server isn't started with a broken config.
+func (rm *RaceManager) validateScheduledEvent(event RaceEvent) error {
+	validation, err := rm.ValidateEvent(event)
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not validate scheduled event: %s, starting it anyway", event.EventName())
+		return nil
+	}
+
+	if !validation.HasErrors() {
+		return nil
+	}
+
+	logrus.Errorf("Scheduled event: %s failed validation and will not be started:\n%s", event.EventName(), validation)
+
+	if err := rm.notificationManager.SendEventValidationFailedMessage(event, validation); err != nil {
+		logrus.WithError(err).Errorf("Could not send event validation failed notification")
+	}
+
+	return ErrEventValidationFailed
+}
+
+// championshipRaceEvent builds the RaceEvent that a championship event is started with.
+func (cm *ChampionshipManager) championshipRaceEvent(championshipID, eventID string) (*ActiveChampionship, error) {
+	championship, event, err := cm.GetChampionshipAndEvent(championshipID, eventID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	raceSetup, entryList := cm.FinalEventConfigurationFiles(championship, event, false)
+
+	return &ActiveChampionship{
+		ChampionshipID: championship.ID,
+		EventID:        event.ID,
+		Name:           championship.Name,
+		RaceConfig:     raceSetup,
+		EntryList:      entryList,
+	}, nil
+}
+
+// ValidateChampionshipEvent runs a validation pass on a championship event.
+func (cm *ChampionshipManager) ValidateChampionshipEvent(championshipID, eventID string) (*EventValidation, error) {
+	event, err := cm.championshipRaceEvent(championshipID, eventID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	validation, err := cm.ValidateEvent(event)
+
+	if err != nil {
+		return nil, err
+	}
+
+	championship, err := cm.LoadChampionship(championshipID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, carLimitErr := range championship.teamCarLimitErrors(event.EntryList, time.Now()) {
+		validation.addError("Teams", "%s", carLimitErr.Error())
+	}
+
+	return validation, nil
+}
+
+// raceWeekendRaceEvent builds the RaceEvent that a Race Weekend session is started with.
+func (rwm *RaceWeekendManager) raceWeekendRaceEvent(raceWeekendID, sessionID string) (*ActiveRaceWeekend, error) {
+	raceWeekend, session, err := rwm.FindSession(raceWeekendID, sessionID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	entryList, err := rwm.sessionEntryList(raceWeekend, session, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	raceConfig := session.RaceConfig
+	raceConfig.MaxClients = len(entryList)
+	raceConfig.Cars = strings.Join(entryList.CarIDs(), ";")
+
+	return &ActiveRaceWeekend{
+		Name:          raceWeekend.Name,
+		RaceWeekendID: raceWeekend.ID,
+		SessionID:     session.ID,
+		RaceConfig:    raceConfig,
+		EntryList:     entryList,
+	}, nil
+}
+
+// ValidateSession runs a validation pass on a Race Weekend session.
+func (rwm *RaceWeekendManager) ValidateSession(raceWeekendID, sessionID string) (*EventValidation, error) {
+	event, err := rwm.raceWeekendRaceEvent(raceWeekendID, sessionID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rwm.raceManager.ValidateEvent(event)
+}
+
+type eventValidationTemplateVars struct {
+	BaseTemplateVars
+
+	Name       string
+	Validation *EventValidation
+	StartURL   string
+	BackURL    string
+}
+
+func (crh *CustomRaceHandler) validate(w http.ResponseWriter, r *http.Request) {
+	race, validation, err := crh.raceManager.ValidateCustomRace(chi.URLParam(r, "uuid"))
+
+	if err != nil {
+		logrus.WithError(err).Errorf("couldn't validate custom race")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	crh.viewRenderer.MustLoadTemplate(w, r, "event-validation.html", &eventValidationTemplateVars{
+		Name:       race.EventName(),
+		Validation: validation,
+		StartURL:   "/custom/load/" + race.UUID.String(),
+		BackURL:    "/custom",
+	})
+}
+
+func (ch *ChampionshipsHandler) validateEvent(w http.ResponseWriter, r *http.Request) {
+	championshipID, eventID := chi.URLParam(r, "championshipID"), chi.URLParam(r, "eventID")
+
+	championship, event, err := ch.championshipManager.GetChampionshipAndEvent(championshipID, eventID)
+
+	if err != nil {
+		logrus.WithError(err).Errorf("couldn't load championship event")
+		http.NotFound(w, r)
+		return
+	}
+
+	validation, err := ch.championshipManager.ValidateChampionshipEvent(championshipID, eventID)
+
+	if err != nil {
+		logrus.WithError(err).Errorf("couldn't validate championship event")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	ch.viewRenderer.MustLoadTemplate(w, r, "event-validation.html", &eventValidationTemplateVars{
+		Name:       fmt.Sprintf("%s: %s", championship.Name, trackSummary(event.RaceSetup.Track, event.RaceSetup.TrackLayout)),
+		Validation: validation,
+		StartURL:   fmt.Sprintf("/championship/%s/event/%s/start", championshipID, eventID),
+		BackURL:    "/championship/" + championshipID,
+	})
+}
+
+func (rwh *RaceWeekendHandler) validateSession(w http.ResponseWriter, r *http.Request) {
+	raceWeekendID, sessionID := chi.URLParam(r, "raceWeekendID"), chi.URLParam(r, "sessionID")
+
+	raceWeekend, session, err := rwh.raceWeekendManager.FindSession(raceWeekendID, sessionID)
+
+	if err != nil {
+		logrus.WithError(err).Errorf("couldn't load race weekend session")
+		http.NotFound(w, r)
+		return
+	}
+
+	validation, err := rwh.raceWeekendManager.ValidateSession(raceWeekendID, sessionID)
+
+	if err != nil {
+		logrus.WithError(err).Errorf("couldn't validate race weekend session")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	startURL := ""
+
+	if raceWeekend.SessionCanBeRun(session) {
+		startURL = fmt.Sprintf("/race-weekend/%s/session/%s/start", raceWeekendID, sessionID)
+	}
+
+	rwh.viewRenderer.MustLoadTemplate(w, r, "event-validation.html", &eventValidationTemplateVars{
+		Name:       fmt.Sprintf("%s: %s", raceWeekend.Name, session.Name()),
+		Validation: validation,
+		StartURL:   startURL,
+		BackURL:    "/race-weekend/" + raceWeekendID,
+	})
+}
+
+func (ah *APIHandler) validateCustomRace(w http.ResponseWriter, r *http.Request) {
+	_, validation, err := ah.raceManager.ValidateCustomRace(chi.URLParam(r, "uuid"))
+
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeAPIJSON(w, http.StatusOK, validation)
+}
+
+func (ah *APIHandler) validateChampionshipEvent(w http.ResponseWriter, r *http.Request) {
+	validation, err := ah.championshipManager.ValidateChampionshipEvent(chi.URLParam(r, "championshipID"), chi.URLParam(r, "eventID"))
+
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeAPIJSON(w, http.StatusOK, validation)
+}
+
+func (ah *APIHandler) validateRaceWeekendSession(w http.ResponseWriter, r *http.Request) {
+	validation, err := ah.raceWeekendManager.ValidateSession(chi.URLParam(r, "raceWeekendID"), chi.URLParam(r, "sessionID"))
+
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	writeAPIJSON(w, http.StatusOK, validation)
+}
@@ -0,0 +1,98 @@
+package servermanager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateEvent(t *testing.T) {
+	installPath, err := ioutil.TempDir("", "validate-event")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(installPath)
+
+	oldInstallPath := ServerInstallPath
+	ServerInstallPath = installPath
+
+	defer func() {
+		ServerInstallPath = oldInstallPath
+	}()
+
+	for _, dir := range []string{
+		filepath.Join("content", "tracks", "ks_test", "ui"),
+		filepath.Join("content", "cars", "car_a", "skins", "red"),
+	} {
+		if err := os.MkdirAll(filepath.Join(installPath, dir), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(installPath, "content", "tracks", "ks_test", "ui", trackInfoJSONName), []byte(`{"name": "Test Track", "pitboxes": "2"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	raceConfig := ConfigIniDefault().CurrentRaceConfig
+	raceConfig.Track = "ks_test"
+	raceConfig.TrackLayout = ""
+	raceConfig.Cars = "car_a;car_b"
+	raceConfig.LegalTyres = "H;M"
+	raceConfig.Weather = map[string]*WeatherConfig{
+		"WEATHER_0": {Graphics: "3_clear"},
+		"WEATHER_1": {Graphics: "sol_01_clear_type=0_time=0_mult=1_start=0", CMGraphics: "sol_01_clear"},
+	}
+
+	entryList := EntryList{}
+	entryList.AddToBackOfGrid(&Entrant{Name: "Driver 1", Model: "car_a", Skin: "red"})
+	entryList.AddToBackOfGrid(&Entrant{Name: "Driver 2", Model: "car_a", Skin: "blue"})
+	entryList.AddToBackOfGrid(&Entrant{Name: "Driver 3", Model: "car_b"})
+
+	tyres := Tyres{
+		"car_a": {"S": "Soft"},
+	}
+
+	validation := &EventValidation{}
+
+	validateEventTrack(validation, raceConfig, entryList)
+	validateEventCars(validation, NewCarManager(nil, false, false), raceConfig, entryList, tyres)
+	validateEventWeather(validation, raceConfig, Weather{"3_clear": "Clear"})
+	validateEventPortClashes(validation, map[string]int{"TCP Port": 9600, "HTTP Port": 9600, "Server Manager HTTP Port": 8772})
+
+	expectedErrors := []EventValidationIssue{
+		{Field: "Entry List", Message: "3 entrants is more than the 2 pit boxes at Test Track"},
+		{Field: "Tyres", Message: "None of the allowed tyres (H, M) are available for car_a"},
+		{Field: "Cars", Message: "car_b is not installed on the server"},
+		{Field: "Weather", Message: "The weather sol_01_clear is not installed on the server"},
+		{Field: "Ports", Message: "Port 9600 is used by more than one setting: HTTP Port, TCP Port"},
+	}
+
+	if len(validation.Errors) != len(expectedErrors) {
+		t.Fatalf("Expected %d errors, got %d:\n%s", len(expectedErrors), len(validation.Errors), validation)
+	}
+
+	for i, expected := range expectedErrors {
+		if validation.Errors[i] != expected {
+			t.Errorf("Expected error %d to be %+v, got %+v", i, expected, validation.Errors[i])
+		}
+	}
+
+	expectedWarnings := []EventValidationIssue{
+		{Field: "Tyres", Message: "The H tyre is not available for any car in this event"},
+		{Field: "Tyres", Message: "The M tyre is not available for any car in this event"},
+		{Field: "Entry List", Message: "The skin blue for Driver 2 (car_a) is not uploaded to the server"},
+	}
+
+	if len(validation.Warnings) != len(expectedWarnings) {
+		t.Fatalf("Expected %d warnings, got %d:\n%s", len(expectedWarnings), len(validation.Warnings), validation)
+	}
+
+	for i, expected := range expectedWarnings {
+		if validation.Warnings[i] != expected {
+			t.Errorf("Expected warning %d to be %+v, got %+v", i, expected, validation.Warnings[i])
+		}
+	}
+}
@@ -184,7 +184,7 @@ var ChampionshipMulticlassEndurance = []byte(`{
 1
 ],
 "BestLap": 1,
-"PolePosition": 1,
+"QualifyingPlaces": [1],
 "CollisionWithDriver": 1,
 "CollisionWithEnv": 0,
 "CutTrack": 0,
@@ -416,7 +416,7 @@ var ChampionshipMulticlassEndurance = []byte(`{
 0
 ],
 "BestLap": 1,
-"PolePosition": 1,
+"QualifyingPlaces": [1],
 "CollisionWithDriver": 1,
 "CollisionWithEnv": 0,
 "CutTrack": 0,
@@ -391,7 +391,6 @@ var ChampionshipMX5CrashCourse = []byte(`{
 0
 ],
 "BestLap": 1,
-"PolePosition": 0,
 "CollisionWithDriver": 0,
 "CollisionWithEnv": 0,
 "CutTrack": 0,
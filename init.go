@@ -21,7 +21,8 @@ var (
 	serverIDMetaKey = "server_id"
 )
 
-func initServerID(store Store) error {
+// InitServerID loads the ID of this Server Manager from the store, creating one if it doesn't have one yet.
+func InitServerID(store Store) error {
 	err := store.GetMeta(serverIDMetaKey, &serverID)
 
 	if err == ErrValueNotSet {
@@ -54,7 +55,7 @@ func InitWithResolver(resolver *Resolver) error {
 		return err
 	}
 
-	if err := initServerID(store); err != nil {
+	if err := InitServerID(store); err != nil {
 		return err
 	}
 
@@ -70,10 +71,11 @@ func InitWithResolver(resolver *Resolver) error {
 	UseFallBackSorting = opts != nil && opts.FallBackResultsSorting == 1
 
 	process := resolver.resolveServerProcess()
-	championshipManager := resolver.resolveChampionshipManager()
+	championshipManager := resolver.ResolveChampionshipManager()
 	raceWeekendManager := resolver.resolveRaceWeekendManager()
 	notificationManager := resolver.resolveNotificationManager()
 	raceControl := resolver.ResolveRaceControl()
+	instanceManager := resolver.resolveInstanceManager()
 
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt)
@@ -116,6 +118,8 @@ func InitWithResolver(resolver *Resolver) error {
 
 			raceControl.persistTimingData()
 
+			instanceManager.Stop()
+
 			os.Exit(0)
 		}
 	}()
@@ -123,6 +127,30 @@ func InitWithResolver(resolver *Resolver) error {
 	raceManager := resolver.resolveRaceManager()
 	go panicCapture(raceManager.LoopRaces)
 
+	blocklistManager := resolver.resolveBlocklistManager()
+
+	if err := blocklistManager.SyncBlocklistFile(); err != nil {
+		logrus.WithError(err).Errorf("Could not sync %s", BlocklistFilename)
+	}
+
+	go panicCapture(blocklistManager.WatchForExpiredBans)
+
+	recycleBinManager := resolver.resolveRecycleBinManager()
+
+	if err := recycleBinManager.PurgeExpiredItems(); err != nil {
+		logrus.WithError(err).Errorf("Could not purge expired items from the recycle bin")
+	}
+
+	go panicCapture(recycleBinManager.WatchForExpiredItems)
+
+	backupManager := resolver.resolveBackupManager()
+
+	if err := backupManager.RunScheduledBackup(); err != nil {
+		logrus.WithError(err).Errorf("Could not make scheduled backup")
+	}
+
+	go panicCapture(backupManager.WatchForScheduledBackups)
+
 	err = raceManager.InitScheduledRaces()
 
 	if err != nil {
@@ -141,6 +169,10 @@ func InitWithResolver(resolver *Resolver) error {
 		return err
 	}
 
+	if err := InitResultsIndex(); err != nil {
+		logrus.WithError(err).Error("Could not open results index, results will be read from the results directory instead")
+	}
+
 	carManager := resolver.resolveCarManager()
 
 	go func() {
@@ -190,5 +222,12 @@ func InitWithResolver(resolver *Resolver) error {
 		}()
 	}
 
+	if instanceManager.Enabled() {
+		logrus.Infof("Starting %d Server Manager instances", len(config.Instances.Servers))
+		instanceManager.Start()
+	} else if IsInstance() && len(config.Instances.Servers) > 0 {
+		logrus.Warnf("This Server Manager is an instance of another Server Manager, so it will not start the instances in its config.yml")
+	}
+
 	return nil
 }
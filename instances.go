@@ -0,0 +1,632 @@
+package servermanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"4d63.com/tz"
+	"github.com/go-chi/chi"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// instanceEnvironmentVariable is set to the instance name for each Server Manager run by an InstanceManager.
+	// Instances ignore any instances in their own config, so that they can't start more copies of themselves.
+	instanceEnvironmentVariable = "SERVER_MANAGER_INSTANCE"
+
+	instanceHealthCheckInterval = 10 * time.Second
+	instanceHealthCheckTimeout  = 5 * time.Second
+	instanceStopTimeout         = 30 * time.Second
+	instanceMaxRestartBackoff   = time.Minute
+
+	// an instance which stays up for instanceStableRunTime is considered healthy, and its restart backoff is reset.
+	instanceStableRunTime = 5 * time.Minute
+)
+
+// instanceRestartBackoff is the delay before restarting an instance which has stopped unexpectedly. It doubles
+// each time the instance stops again without having run for instanceStableRunTime.
+var instanceRestartBackoff = 5 * time.Second
+
+var (
+	ErrInstanceNotFound       = errors.New("servermanager: instance not found")
+	ErrInstanceAlreadyRunning = errors.New("servermanager: instance is already running")
+	ErrInstanceNoAPIToken     = errors.New("servermanager: instance has no url or api_token configured")
+)
+
+// IsInstance reports whether this Server Manager was started by another Server Manager's InstanceManager.
+func IsInstance() bool {
+	return os.Getenv(instanceEnvironmentVariable) != ""
+}
+
+// Instance is a Server Manager which is run as a child process of this one. Each instance runs from its own
+// directory with its own config.yml, so it has its own ports, server options, store, Assetto Corsa server
+// and live timing. Instances can share championships, custom races and accounts via a JSON store's
+// shared_data_path, where each instance's accounts and scheduled events are keyed by its ServerID.
+type Instance struct {
+	Config *InstanceConfig
+
+	mutex    sync.Mutex
+	cmd      *exec.Cmd
+	exited   chan struct{}
+	stopping bool
+	stopped  chan struct{}
+
+	started  time.Time
+	exitedAt time.Time
+	restarts int
+	lastErr  error
+
+	health          *HealthCheckResponse
+	healthErr       error
+	lastHealthCheck time.Time
+
+	logs *logBuffer
+}
+
+// InstanceStatus is a snapshot of an Instance, for display.
+type InstanceStatus struct {
+	Name string
+	URL  string
+
+	Running   bool
+	Stopped   bool
+	PID       int
+	Started   time.Time
+	ExitedAt  time.Time
+	Restarts  int
+	LastError string
+
+	Health           *HealthCheckResponse
+	HealthCheckError string
+	LastHealthCheck  time.Time
+}
+
+func newInstance(config *InstanceConfig) *Instance {
+	return &Instance{
+		Config:   config,
+		stopping: true,
+		logs:     newLogBuffer(MaxLogSizeBytes),
+	}
+}
+
+func (i *Instance) executable() (string, error) {
+	if i.Config.Executable != "" {
+		return i.Config.Executable, nil
+	}
+
+	return os.Executable()
+}
+
+// supervise runs the instance, restarting it with backoff whenever it stops, until stopped is closed.
+func (i *Instance) supervise(stopped chan struct{}) {
+	backoff := instanceRestartBackoff
+
+	for {
+		started := time.Now()
+		err := i.run(stopped)
+
+		i.mutex.Lock()
+		i.exitedAt = time.Now()
+		i.lastErr = err
+		i.mutex.Unlock()
+
+		select {
+		case <-stopped:
+			return
+		default:
+		}
+
+		if time.Since(started) > instanceStableRunTime {
+			backoff = instanceRestartBackoff
+		}
+
+		if err != nil {
+			logrus.WithError(err).Errorf("Instance: %s stopped unexpectedly. Restarting in %s", i.Config.Name, backoff)
+		} else {
+			logrus.Warnf("Instance: %s exited. Restarting in %s", i.Config.Name, backoff)
+		}
+
+		select {
+		case <-stopped:
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+
+		if backoff > instanceMaxRestartBackoff {
+			backoff = instanceMaxRestartBackoff
+		}
+
+		i.mutex.Lock()
+		i.restarts++
+		i.mutex.Unlock()
+	}
+}
+
+// run starts the instance's process and waits for it to exit.
+func (i *Instance) run(stopped chan struct{}) error {
+	executable, err := i.executable()
+
+	if err != nil {
+		return err
+	}
+
+	cmd := buildCommand(context.Background(), executable)
+	cmd.Dir = i.Config.Directory
+	cmd.Env = append(os.Environ(), instanceEnvironmentVariable+"="+i.Config.Name)
+	cmd.Stdout = i.logs
+	cmd.Stderr = i.logs
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	logrus.Infof("Instance: %s started [pid: %d]", i.Config.Name, cmd.Process.Pid)
+
+	exited := make(chan struct{})
+
+	i.mutex.Lock()
+	i.cmd = cmd
+	i.exited = exited
+	i.started = time.Now()
+	i.mutex.Unlock()
+
+	select {
+	case <-stopped:
+		// Stop was called while the process was starting, before it could be terminated.
+		if err := terminate(getProcess(cmd)); err != nil {
+			logrus.WithError(err).Errorf("Could not terminate instance: %s", i.Config.Name)
+		}
+	default:
+	}
+
+	err = cmd.Wait()
+
+	i.mutex.Lock()
+	i.cmd = nil
+	i.mutex.Unlock()
+
+	close(exited)
+
+	return err
+}
+
+// Stop asks the instance to shut down, so it can stop any running event and save its state, killing it if it has
+// not stopped within the timeout. The instance will not be restarted.
+func (i *Instance) Stop() error {
+	i.mutex.Lock()
+
+	if i.stopping {
+		i.mutex.Unlock()
+		return nil
+	}
+
+	i.stopping = true
+	close(i.stopped)
+
+	cmd, exited := i.cmd, i.exited
+	i.mutex.Unlock()
+
+	if cmd == nil {
+		return nil
+	}
+
+	logrus.Infof("Instance: %s stopping", i.Config.Name)
+
+	proc := getProcess(cmd)
+
+	if err := terminate(proc); err != nil {
+		select {
+		case <-exited:
+			// the process had already exited by itself
+			return nil
+		case <-time.After(time.Second):
+			return err
+		}
+	}
+
+	select {
+	case <-exited:
+		return nil
+	case <-time.After(instanceStopTimeout):
+		logrus.Warnf("Instance: %s did not stop after %s. Killing...", i.Config.Name, instanceStopTimeout)
+	}
+
+	return kill(proc)
+}
+
+// Start runs the instance, and keeps it running until Stop is called.
+func (i *Instance) Start() error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	if !i.stopping {
+		return ErrInstanceAlreadyRunning
+	}
+
+	stopped := make(chan struct{})
+
+	i.stopping = false
+	i.stopped = stopped
+
+	go panicCapture(func() {
+		i.supervise(stopped)
+	})
+
+	return nil
+}
+
+// Status returns a snapshot of the instance.
+func (i *Instance) Status() InstanceStatus {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	status := InstanceStatus{
+		Name:            i.Config.Name,
+		URL:             i.Config.URL,
+		Running:         i.cmd != nil,
+		Stopped:         i.stopping,
+		Started:         i.started,
+		ExitedAt:        i.exitedAt,
+		Restarts:        i.restarts,
+		Health:          i.health,
+		LastHealthCheck: i.lastHealthCheck,
+	}
+
+	if i.cmd != nil && i.cmd.Process != nil {
+		status.PID = i.cmd.Process.Pid
+	}
+
+	if i.lastErr != nil {
+		status.LastError = i.lastErr.Error()
+	}
+
+	if i.healthErr != nil {
+		status.HealthCheckError = i.healthErr.Error()
+	}
+
+	return status
+}
+
+// Logs returns the most recent output of the instance.
+func (i *Instance) Logs() string {
+	return i.logs.String()
+}
+
+// InstanceManager runs and supervises the Server Manager instances listed in config.yml, checks their health,
+// and schedules events on them using their API.
+type InstanceManager struct {
+	instances []*Instance
+	client    *http.Client
+}
+
+func NewInstanceManager() *InstanceManager {
+	im := &InstanceManager{
+		client: &http.Client{
+			Timeout: instanceHealthCheckTimeout,
+		},
+	}
+
+	if config == nil || IsInstance() {
+		return im
+	}
+
+	for _, instanceConfig := range config.Instances.Servers {
+		instanceConfig.URL = strings.TrimSuffix(instanceConfig.URL, "/")
+
+		im.instances = append(im.instances, newInstance(instanceConfig))
+	}
+
+	return im
+}
+
+// Enabled is true if this Server Manager has any instances to supervise.
+func (im *InstanceManager) Enabled() bool {
+	return len(im.instances) > 0
+}
+
+// Start runs every instance, and starts checking their health.
+func (im *InstanceManager) Start() {
+	if !im.Enabled() {
+		return
+	}
+
+	for _, instance := range im.instances {
+		if err := instance.Start(); err != nil {
+			logrus.WithError(err).Errorf("Could not start instance: %s", instance.Config.Name)
+		}
+	}
+
+	go panicCapture(im.checkHealthLoop)
+}
+
+// Stop stops every instance, waiting for them all to exit.
+func (im *InstanceManager) Stop() {
+	var wg sync.WaitGroup
+
+	for _, instance := range im.instances {
+		wg.Add(1)
+
+		go func(instance *Instance) {
+			defer wg.Done()
+
+			if err := instance.Stop(); err != nil {
+				logrus.WithError(err).Errorf("Could not stop instance: %s", instance.Config.Name)
+			}
+		}(instance)
+	}
+
+	wg.Wait()
+}
+
+func (im *InstanceManager) FindInstance(name string) (*Instance, error) {
+	for _, instance := range im.instances {
+		if instance.Config.Name == name {
+			return instance, nil
+		}
+	}
+
+	return nil, ErrInstanceNotFound
+}
+
+// Statuses returns a snapshot of every instance, in the order they are configured.
+func (im *InstanceManager) Statuses() []InstanceStatus {
+	statuses := make([]InstanceStatus, 0, len(im.instances))
+
+	for _, instance := range im.instances {
+		statuses = append(statuses, instance.Status())
+	}
+
+	return statuses
+}
+
+func (im *InstanceManager) checkHealthLoop() {
+	ticker := time.NewTicker(instanceHealthCheckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		for _, instance := range im.instances {
+			if instance.Config.URL == "" {
+				continue
+			}
+
+			health, err := im.checkHealth(instance)
+
+			instance.mutex.Lock()
+			instance.health = health
+			instance.healthErr = err
+			instance.lastHealthCheck = time.Now()
+			instance.mutex.Unlock()
+		}
+	}
+}
+
+func (im *InstanceManager) checkHealth(instance *Instance) (*HealthCheckResponse, error) {
+	resp, err := im.client.Get(instance.Config.URL + "/healthcheck.json")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("servermanager: instance healthcheck responded with status: %s", resp.Status)
+	}
+
+	var health *HealthCheckResponse
+
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		return nil, err
+	}
+
+	return health, nil
+}
+
+// ScheduleCustomRace schedules a custom race to start on an instance, using the instance's API. The custom race
+// must exist in the instance's store, e.g. by both Server Managers sharing a store's shared_data_path.
+func (im *InstanceManager) ScheduleCustomRace(name, raceID string, date time.Time, recurrence string) error {
+	instance, err := im.FindInstance(name)
+
+	if err != nil {
+		return err
+	}
+
+	return im.apiRequest(instance, http.MethodPost, "/custom-races/"+raceID+"/schedule", &APIScheduleRequest{
+		Time:       date,
+		Recurrence: recurrence,
+	})
+}
+
+func (im *InstanceManager) apiRequest(instance *Instance, method, path string, body interface{}) error {
+	if instance.Config.URL == "" || instance.Config.APIToken == "" {
+		return ErrInstanceNoAPIToken
+	}
+
+	return NewAPIClient(instance.Config.URL, instance.Config.APIToken, im.client).Request(method, path, body, nil)
+}
+
+type InstancesHandler struct {
+	*BaseHandler
+
+	instanceManager *InstanceManager
+	store           Store
+}
+
+func NewInstancesHandler(baseHandler *BaseHandler, instanceManager *InstanceManager, store Store) *InstancesHandler {
+	return &InstancesHandler{
+		BaseHandler:     baseHandler,
+		instanceManager: instanceManager,
+		store:           store,
+	}
+}
+
+type instancesTemplateVars struct {
+	BaseTemplateVars
+
+	Instances   []InstanceStatus
+	CustomRaces []*CustomRace
+}
+
+func (ih *InstancesHandler) list(w http.ResponseWriter, r *http.Request) {
+	customRaces, err := ih.store.ListCustomRaces()
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not list custom races")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	var races []*CustomRace
+
+	for _, race := range customRaces {
+		if race.Deleted.IsZero() {
+			races = append(races, race)
+		}
+	}
+
+	sort.Slice(races, func(i, j int) bool {
+		return races[i].Name < races[j].Name
+	})
+
+	ih.viewRenderer.MustLoadTemplate(w, r, "instances/index.html", &instancesTemplateVars{
+		BaseTemplateVars: BaseTemplateVars{
+			WideContainer: true,
+		},
+		Instances:   ih.instanceManager.Statuses(),
+		CustomRaces: races,
+	})
+}
+
+func (ih *InstancesHandler) findInstance(w http.ResponseWriter, r *http.Request) (*Instance, bool) {
+	name, err := url.PathUnescape(chi.URLParam(r, "name"))
+
+	if err != nil {
+		http.NotFound(w, r)
+		return nil, false
+	}
+
+	instance, err := ih.instanceManager.FindInstance(name)
+
+	if err != nil {
+		http.NotFound(w, r)
+		return nil, false
+	}
+
+	return instance, true
+}
+
+func (ih *InstancesHandler) start(w http.ResponseWriter, r *http.Request) {
+	instance, ok := ih.findInstance(w, r)
+
+	if !ok {
+		return
+	}
+
+	if err := instance.Start(); err != nil {
+		AddErrorFlash(w, r, fmt.Sprintf("Could not start %s: %s", instance.Config.Name, err))
+	} else {
+		AddFlash(w, r, fmt.Sprintf("%s is starting", instance.Config.Name))
+	}
+
+	http.Redirect(w, r, "/instances", http.StatusFound)
+}
+
+func (ih *InstancesHandler) stop(w http.ResponseWriter, r *http.Request) {
+	instance, ok := ih.findInstance(w, r)
+
+	if !ok {
+		return
+	}
+
+	if err := instance.Stop(); err != nil {
+		logrus.WithError(err).Errorf("Could not stop instance: %s", instance.Config.Name)
+		AddErrorFlash(w, r, fmt.Sprintf("Could not stop %s", instance.Config.Name))
+	} else {
+		AddFlash(w, r, fmt.Sprintf("%s has been stopped", instance.Config.Name))
+	}
+
+	http.Redirect(w, r, "/instances", http.StatusFound)
+}
+
+func (ih *InstancesHandler) restart(w http.ResponseWriter, r *http.Request) {
+	instance, ok := ih.findInstance(w, r)
+
+	if !ok {
+		return
+	}
+
+	if err := instance.Stop(); err != nil {
+		logrus.WithError(err).Errorf("Could not stop instance: %s", instance.Config.Name)
+		AddErrorFlash(w, r, fmt.Sprintf("Could not stop %s", instance.Config.Name))
+	} else if err := instance.Start(); err != nil {
+		AddErrorFlash(w, r, fmt.Sprintf("Could not start %s: %s", instance.Config.Name, err))
+	} else {
+		AddFlash(w, r, fmt.Sprintf("%s is restarting", instance.Config.Name))
+	}
+
+	http.Redirect(w, r, "/instances", http.StatusFound)
+}
+
+func (ih *InstancesHandler) logs(w http.ResponseWriter, r *http.Request) {
+	instance, ok := ih.findInstance(w, r)
+
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte(instance.Logs()))
+}
+
+func (ih *InstancesHandler) schedule(w http.ResponseWriter, r *http.Request) {
+	instance, ok := ih.findInstance(w, r)
+
+	if !ok {
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	timezone := r.FormValue("event-schedule-timezone")
+
+	location, err := tz.LoadLocation(timezone)
+
+	if err != nil {
+		logrus.WithError(err).Errorf("could not find location: %s", timezone)
+		location = time.Local
+	}
+
+	date, err := time.ParseInLocation("2006-01-02-15:04", r.FormValue("event-schedule-date")+"-"+r.FormValue("event-schedule-time"), location)
+
+	if err != nil {
+		AddErrorFlash(w, r, "Please enter a valid date and time to schedule the event")
+		http.Redirect(w, r, "/instances", http.StatusFound)
+		return
+	}
+
+	err = ih.instanceManager.ScheduleCustomRace(instance.Config.Name, r.FormValue("CustomRaceID"), date, r.FormValue("event-schedule-recurrence"))
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not schedule custom race on instance: %s", instance.Config.Name)
+		AddErrorFlash(w, r, fmt.Sprintf("Could not schedule the event on %s: %s", instance.Config.Name, err))
+	} else {
+		AddFlash(w, r, fmt.Sprintf("We have scheduled the race to begin on %s at %s", instance.Config.Name, date.Format(time.RFC1123)))
+	}
+
+	http.Redirect(w, r, "/instances", http.StatusFound)
+}
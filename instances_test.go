@@ -0,0 +1,89 @@
+package servermanager
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestInstance_Supervise(t *testing.T) {
+	executable, err := exec.LookPath("true")
+
+	if err != nil {
+		t.Skip("true is not available")
+	}
+
+	instanceRestartBackoff = time.Millisecond
+
+	instance := newInstance(&InstanceConfig{Name: "test", Executable: executable})
+
+	if err := instance.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := instance.Start(); err != ErrInstanceAlreadyRunning {
+		t.Errorf("Expected starting a running instance to fail, got: %v", err)
+	}
+
+	// the process exits immediately, so it should be restarted until the instance is stopped
+	timeout := time.After(5 * time.Second)
+
+	for instance.Status().Restarts < 2 {
+		select {
+		case <-timeout:
+			t.Fatalf("Instance was not restarted, status: %+v", instance.Status())
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	if err := instance.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	if status := instance.Status(); !status.Stopped {
+		t.Errorf("Expected instance to be stopped, got: %+v", status)
+	}
+}
+
+func TestInstanceManager_ScheduleCustomRace(t *testing.T) {
+	date := time.Now().Add(time.Hour).Round(time.Second)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != apiPrefix+"/custom-races/race-id/schedule" {
+			t.Errorf("Unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("Expected API token to be sent, got: %s", r.Header.Get("Authorization"))
+		}
+
+		var req APIScheduleRequest
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+
+		if !req.Time.Equal(date) {
+			t.Errorf("Expected scheduled time %s, got %s", date, req.Time)
+		}
+
+		writeAPIJSON(w, http.StatusNotFound, apiErrorResponse{Error: http.StatusText(http.StatusNotFound)})
+	}))
+	defer server.Close()
+
+	im := &InstanceManager{
+		client:    server.Client(),
+		instances: []*Instance{newInstance(&InstanceConfig{Name: "test", URL: server.URL, APIToken: "token"})},
+	}
+
+	if err := im.ScheduleCustomRace("test", "race-id", date, ""); err == nil {
+		t.Error("Expected an error from the instance's API to be returned")
+	}
+
+	if err := im.ScheduleCustomRace("missing", "race-id", date, ""); err != ErrInstanceNotFound {
+		t.Errorf("Expected ErrInstanceNotFound, got: %v", err)
+	}
+}
@@ -0,0 +1,564 @@
+package servermanager
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/sirupsen/logrus"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+const (
+	lapTelemetryDirectory = "telemetry"
+	lapTelemetryExtension = ".json.gz"
+
+	// laps which take longer than this many samples to complete are discarded, so that cars sitting in the pits
+	// don't keep growing their trace.
+	lapTelemetryMaxSamples = 20000
+
+	// a car crossing the line is only matched to a lap completed message which arrives within this time, so that
+	// crossing the line at the start of a race isn't mistaken for a lap.
+	lapTelemetryCrossingTimeout = 5 * time.Second
+
+	lapTelemetryChartWidth       = 1000
+	lapTelemetrySpeedChartHeight = 250
+	lapTelemetryGearChartHeight  = 80
+)
+
+var ErrLapTelemetryNotFound = errors.New("servermanager: lap telemetry not found")
+
+func lapTelemetryPath(sessionFile string) string {
+	return filepath.Join(ServerInstallPath, lapTelemetryDirectory, sessionFile+lapTelemetryExtension)
+}
+
+// HasTelemetry returns true if per-lap telemetry was recorded for the session.
+func (s *SessionResults) HasTelemetry() bool {
+	_, err := os.Stat(lapTelemetryPath(s.SessionFile))
+
+	return err == nil
+}
+
+// LapTelemetrySample is a single car update within a lap.
+type LapTelemetrySample struct {
+	// Position is the normalised position along the track, from 0 at the start line to 1 at the finish line.
+	Position float32
+	// Speed is in km/h.
+	Speed float64
+	// Gear is -1 for reverse, 0 for neutral and the gear number otherwise.
+	Gear int
+	// Time is the number of milliseconds since the first sample in the lap.
+	Time int64
+}
+
+// LapTelemetry is the trace of a single completed lap.
+type LapTelemetry struct {
+	DriverGUID string
+	CarModel   string
+	LapTime    int
+	Cuts       int
+
+	Samples []LapTelemetrySample
+}
+
+// SessionTelemetry is every lap trace recorded in a session, in the order the laps were completed.
+type SessionTelemetry struct {
+	Laps []*LapTelemetry
+}
+
+// FindLap finds the trace for a lap in the session's results.
+func (st *SessionTelemetry) FindLap(lap *SessionLap) *LapTelemetry {
+	for _, lapTelemetry := range st.Laps {
+		if lapTelemetry.DriverGUID == lap.DriverGUID && lapTelemetry.CarModel == lap.CarModel && lapTelemetry.LapTime == lap.LapTime && lapTelemetry.Cuts == lap.Cuts {
+			return lapTelemetry
+		}
+	}
+
+	return nil
+}
+
+func LoadSessionTelemetry(sessionFile string) (*SessionTelemetry, error) {
+	f, err := os.Open(lapTelemetryPath(sessionFile))
+
+	if os.IsNotExist(err) {
+		return nil, ErrLapTelemetryNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer gz.Close()
+
+	var sessionTelemetry *SessionTelemetry
+
+	if err := json.NewDecoder(gz).Decode(&sessionTelemetry); err != nil {
+		return nil, err
+	}
+
+	return sessionTelemetry, nil
+}
+
+func saveSessionTelemetry(sessionFile string, sessionTelemetry *SessionTelemetry) error {
+	path := lapTelemetryPath(sessionFile)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	gz := gzip.NewWriter(f)
+
+	if err := json.NewEncoder(gz).Encode(sessionTelemetry); err != nil {
+		return err
+	}
+
+	return gz.Close()
+}
+
+// anonymiseSessionTelemetry replaces the driver GUIDs in a session's telemetry, so that they still match the laps in
+// the anonymised results file.
+func anonymiseSessionTelemetry(sessionFile string) error {
+	sessionTelemetry, err := LoadSessionTelemetry(sessionFile)
+
+	if err == ErrLapTelemetryNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	changed := false
+
+	for _, lap := range sessionTelemetry.Laps {
+		if !isAnonymisedDriverGUID(lap.DriverGUID) {
+			lap.DriverGUID = AnonymiseDriverGUID(lap.DriverGUID)
+			changed = true
+		}
+	}
+
+	if !changed {
+		return nil
+	}
+
+	return saveSessionTelemetry(sessionFile, sessionTelemetry)
+}
+
+type lapTelemetryRecordedSample struct {
+	LapTelemetrySample
+
+	received time.Time
+}
+
+type lapTelemetryCar struct {
+	info udp.SessionCarInfo
+
+	// current is the lap the car is driving, and crossed is the lap before it if the car has been seen crossing the
+	// line but the lap completed message for it hasn't arrived yet.
+	current   []lapTelemetryRecordedSample
+	crossed   []lapTelemetryRecordedSample
+	crossedAt time.Time
+
+	// completedBeforeCrossing is set when the lap completed message arrives before the car is seen crossing the line.
+	completedBeforeCrossing bool
+}
+
+func (car *lapTelemetryCar) clear() {
+	car.current = nil
+	car.crossed = nil
+	car.completedBeforeCrossing = false
+}
+
+// LapTelemetryRecorder keeps the position, speed and gear of every car update, split into laps. When the session
+// ends, the laps are saved alongside its results file so that they can be compared on the results page.
+type LapTelemetryRecorder struct {
+	mutex sync.Mutex
+
+	cars map[udp.CarID]*lapTelemetryCar
+	laps []*LapTelemetry
+}
+
+func NewLapTelemetryRecorder() *LapTelemetryRecorder {
+	return &LapTelemetryRecorder{
+		cars: make(map[udp.CarID]*lapTelemetryCar),
+	}
+}
+
+func (ltr *LapTelemetryRecorder) clearLaps() {
+	for _, car := range ltr.cars {
+		car.clear()
+	}
+
+	ltr.laps = nil
+}
+
+func (ltr *LapTelemetryRecorder) UDPCallback(message udp.Message) {
+	ltr.mutex.Lock()
+	defer ltr.mutex.Unlock()
+
+	switch m := message.(type) {
+	case udp.Version:
+		ltr.cars = make(map[udp.CarID]*lapTelemetryCar)
+		ltr.laps = nil
+	case udp.SessionInfo:
+		if m.Event() == udp.EventNewSession {
+			ltr.clearLaps()
+		}
+	case udp.SessionCarInfo:
+		if m.Event() == udp.EventNewConnection {
+			ltr.cars[m.CarID] = &lapTelemetryCar{info: m}
+		} else if m.Event() == udp.EventConnectionClosed {
+			delete(ltr.cars, m.CarID)
+		}
+	case udp.CarUpdate:
+		ltr.onCarUpdate(m)
+	case udp.LapCompleted:
+		ltr.onLapCompleted(m)
+	case udp.EndSession:
+		sessionFile := strings.TrimSuffix(filepath.Base(string(m)), filepath.Ext(string(m)))
+
+		if len(ltr.laps) > 0 {
+			if err := saveSessionTelemetry(sessionFile, &SessionTelemetry{Laps: ltr.laps}); err != nil {
+				logrus.WithError(err).Errorf("Couldn't save lap telemetry for session: %s", sessionFile)
+			}
+		}
+
+		ltr.clearLaps()
+	}
+}
+
+func (ltr *LapTelemetryRecorder) onCarUpdate(update udp.CarUpdate) {
+	car, ok := ltr.cars[update.CarID]
+
+	if !ok {
+		return
+	}
+
+	sample := lapTelemetryRecordedSample{
+		LapTelemetrySample: LapTelemetrySample{
+			Position: update.NormalisedSplinePos,
+			Speed: metersPerSecondToKilometersPerHour(
+				math.Sqrt(math.Pow(float64(update.Velocity.X), 2) + math.Pow(float64(update.Velocity.Z), 2)),
+			),
+			// the server sends 0 for reverse and 1 for neutral
+			Gear: int(update.Gear) - 1,
+		},
+		received: time.Now(),
+	}
+
+	if len(car.current) > 0 && sample.Position < car.current[len(car.current)-1].Position-0.5 {
+		// the car has crossed the line
+		if car.completedBeforeCrossing {
+			car.completedBeforeCrossing = false
+		} else {
+			car.crossed = car.current
+			car.crossedAt = sample.received
+		}
+
+		car.current = nil
+	} else if len(car.current) >= lapTelemetryMaxSamples {
+		car.current = nil
+	}
+
+	car.current = append(car.current, sample)
+}
+
+func (ltr *LapTelemetryRecorder) onLapCompleted(lap udp.LapCompleted) {
+	car, ok := ltr.cars[lap.CarID]
+
+	if !ok {
+		return
+	}
+
+	var samples []lapTelemetryRecordedSample
+
+	if car.crossed != nil && time.Since(car.crossedAt) < lapTelemetryCrossingTimeout {
+		samples = car.crossed
+	} else {
+		// the lap completed message arrived before the car was seen crossing the line, so the lap is still the car's
+		// current one.
+		samples = car.current
+		car.current = nil
+		car.completedBeforeCrossing = true
+	}
+
+	car.crossed = nil
+
+	if len(samples) == 0 {
+		return
+	}
+
+	lapTelemetry := &LapTelemetry{
+		DriverGUID: string(car.info.DriverGUID),
+		CarModel:   car.info.CarModel,
+		LapTime:    int(lap.LapTime),
+		Cuts:       int(lap.Cuts),
+		Samples:    make([]LapTelemetrySample, len(samples)),
+	}
+
+	for i, sample := range samples {
+		lapTelemetry.Samples[i] = sample.LapTelemetrySample
+		lapTelemetry.Samples[i].Time = sample.received.Sub(samples[0].received).Nanoseconds() / int64(time.Millisecond)
+	}
+
+	ltr.laps = append(ltr.laps, lapTelemetry)
+}
+
+// LapTelemetrySectorDelta compares a sector (or the whole lap) between two laps.
+type LapTelemetrySectorDelta struct {
+	Name string
+	A, B time.Duration
+}
+
+// FormattedDelta is the time lap B gained (negative) or lost (positive) against lap A.
+func (d LapTelemetrySectorDelta) FormattedDelta() string {
+	return fmt.Sprintf("%+.3fs", (d.B - d.A).Seconds())
+}
+
+func (d LapTelemetrySectorDelta) Faster() bool {
+	return d.B < d.A
+}
+
+type lapTelemetryComparisonLap struct {
+	Index     int
+	Lap       *SessionLap
+	Telemetry *LapTelemetry
+
+	SpeedTrace string
+	GearTrace  string
+}
+
+type lapTelemetryOption struct {
+	Index int
+	Label string
+}
+
+type lapTelemetryTemplateVars struct {
+	BaseTemplateVars
+
+	Results *SessionResults
+	Options []lapTelemetryOption
+
+	LapA, LapB   *lapTelemetryComparisonLap
+	SectorDeltas []LapTelemetrySectorDelta
+
+	ChartWidth, SpeedChartHeight, GearChartHeight int
+	MaxSpeed, MaxGear                             int
+	SpeedGridLines                                []int
+	UseMPH                                        bool
+}
+
+// SpeedGridLineY is the position of a speed grid line on the speed chart.
+func (ltv *lapTelemetryTemplateVars) SpeedGridLineY(speed int) float64 {
+	return float64(ltv.SpeedChartHeight) - float64(speed)/float64(ltv.MaxSpeed)*float64(ltv.SpeedChartHeight)
+}
+
+// SpeedGridLineLabel is the speed of a grid line in the user's preferred units.
+func (ltv *lapTelemetryTemplateVars) SpeedGridLineLabel(speed int) string {
+	if ltv.UseMPH {
+		return fmt.Sprintf("%.0f MPH", float64(speed)*0.621371)
+	}
+
+	return fmt.Sprintf("%d Km/h", speed)
+}
+
+func (ltv *lapTelemetryTemplateVars) buildCharts() {
+	var maxSpeed float64
+
+	for _, lap := range []*lapTelemetryComparisonLap{ltv.LapA, ltv.LapB} {
+		if lap == nil {
+			continue
+		}
+
+		for _, sample := range lap.Telemetry.Samples {
+			maxSpeed = math.Max(maxSpeed, sample.Speed)
+
+			if sample.Gear > ltv.MaxGear {
+				ltv.MaxGear = sample.Gear
+			}
+		}
+	}
+
+	// round the top of the chart up to the next 50 km/h
+	ltv.MaxSpeed = int(math.Ceil(maxSpeed/50)) * 50
+
+	if ltv.MaxSpeed == 0 {
+		ltv.MaxSpeed = 50
+	}
+
+	if ltv.MaxGear == 0 {
+		ltv.MaxGear = 1
+	}
+
+	for speed := 50; speed < ltv.MaxSpeed; speed += 50 {
+		ltv.SpeedGridLines = append(ltv.SpeedGridLines, speed)
+	}
+
+	for _, lap := range []*lapTelemetryComparisonLap{ltv.LapA, ltv.LapB} {
+		if lap == nil {
+			continue
+		}
+
+		var speedTrace, gearTrace strings.Builder
+
+		for _, sample := range lap.Telemetry.Samples {
+			x := float64(sample.Position) * float64(ltv.ChartWidth)
+			gear := math.Max(float64(sample.Gear), 0)
+
+			fmt.Fprintf(&speedTrace, "%.1f,%.1f ", x, float64(ltv.SpeedChartHeight)-sample.Speed/float64(ltv.MaxSpeed)*float64(ltv.SpeedChartHeight))
+			fmt.Fprintf(&gearTrace, "%.1f,%.1f ", x, float64(ltv.GearChartHeight)-gear/float64(ltv.MaxGear)*float64(ltv.GearChartHeight))
+		}
+
+		lap.SpeedTrace = strings.TrimSpace(speedTrace.String())
+		lap.GearTrace = strings.TrimSpace(gearTrace.String())
+	}
+}
+
+func compareLapSectors(numSectors int, lapA, lapB *SessionLap) []LapTelemetrySectorDelta {
+	var deltas []LapTelemetrySectorDelta
+
+	for i := 0; i < numSectors; i++ {
+		if i >= len(lapA.Sectors) || i >= len(lapB.Sectors) {
+			break
+		}
+
+		deltas = append(deltas, LapTelemetrySectorDelta{
+			Name: fmt.Sprintf("Sector %d", i+1),
+			A:    lapA.GetSector(i),
+			B:    lapB.GetSector(i),
+		})
+	}
+
+	return append(deltas, LapTelemetrySectorDelta{
+		Name: "Lap",
+		A:    lapA.GetLapTime(),
+		B:    lapB.GetLapTime(),
+	})
+}
+
+type LapTelemetryHandler struct {
+	*BaseHandler
+
+	store Store
+}
+
+func NewLapTelemetryHandler(baseHandler *BaseHandler, store Store) *LapTelemetryHandler {
+	return &LapTelemetryHandler{
+		BaseHandler: baseHandler,
+		store:       store,
+	}
+}
+
+func (lth *LapTelemetryHandler) compare(w http.ResponseWriter, r *http.Request) {
+	fileName := chi.URLParam(r, "fileName")
+
+	results, err := LoadResult(fileName + ".json")
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Couldn't load session result: %s", fileName)
+		http.NotFound(w, r)
+		return
+	}
+
+	sessionTelemetry, err := LoadSessionTelemetry(fileName)
+
+	if err == ErrLapTelemetryNotFound {
+		AddErrorFlash(w, r, "No lap telemetry was recorded for this session")
+		http.Redirect(w, r, "/results/"+fileName, http.StatusFound)
+		return
+	} else if err != nil {
+		logrus.WithError(err).Errorf("Couldn't load lap telemetry for session: %s", fileName)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	serverOpts, err := lth.store.LoadServerOptions()
+
+	if err != nil {
+		logrus.WithError(err).Errorf("couldn't load server options")
+	}
+
+	vars := &lapTelemetryTemplateVars{
+		BaseTemplateVars: BaseTemplateVars{
+			WideContainer: true,
+		},
+		Results:          results,
+		ChartWidth:       lapTelemetryChartWidth,
+		SpeedChartHeight: lapTelemetrySpeedChartHeight,
+		GearChartHeight:  lapTelemetryGearChartHeight,
+		UseMPH:           serverOpts != nil && serverOpts.UseMPH == 1,
+	}
+
+	lapNumbers := make(map[string]int)
+	comparisonLaps := make(map[int]*lapTelemetryComparisonLap)
+	fastest := -1
+
+	for i, lap := range results.Laps {
+		lapNumbers[lap.DriverGUID+lap.CarModel]++
+
+		lapTelemetry := sessionTelemetry.FindLap(lap)
+
+		if lapTelemetry == nil {
+			continue
+		}
+
+		comparisonLaps[i] = &lapTelemetryComparisonLap{
+			Index:     i,
+			Lap:       lap,
+			Telemetry: lapTelemetry,
+		}
+
+		vars.Options = append(vars.Options, lapTelemetryOption{
+			Index: i,
+			Label: fmt.Sprintf("%s - Lap %d - %s", driverName(lap.DriverName), lapNumbers[lap.DriverGUID+lap.CarModel], formatDuration(lap.GetLapTime(), true)),
+		})
+
+		if lap.Cuts == 0 && (fastest < 0 || lap.LapTime < results.Laps[fastest].LapTime) {
+			fastest = i
+		}
+	}
+
+	lapFromQuery := func(key string, defaultIndex int) *lapTelemetryComparisonLap {
+		index, err := strconv.Atoi(r.URL.Query().Get(key))
+
+		if err != nil {
+			index = defaultIndex
+		}
+
+		return comparisonLaps[index]
+	}
+
+	vars.LapA = lapFromQuery("lapA", fastest)
+	vars.LapB = lapFromQuery("lapB", -1)
+
+	if vars.LapA != nil && vars.LapB != nil {
+		vars.SectorDeltas = compareLapSectors(len(results.GetNumSectors()), vars.LapA.Lap, vars.LapB.Lap)
+	}
+
+	vars.buildCharts()
+
+	lth.viewRenderer.MustLoadTemplate(w, r, "results/telemetry.html", vars)
+}
@@ -0,0 +1,116 @@
+package servermanager
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+func TestLapTelemetryRecorder(t *testing.T) {
+	installPath, err := ioutil.TempDir("", "lap-telemetry")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(installPath)
+
+	oldInstallPath := ServerInstallPath
+	ServerInstallPath = installPath
+
+	defer func() {
+		ServerInstallPath = oldInstallPath
+	}()
+
+	const sessionFile = "2020_1_1_10_0_PRACTICE"
+
+	driver := drivers[0]
+
+	drive := func(recorder *LapTelemetryRecorder, positions ...float32) {
+		for _, position := range positions {
+			recorder.UDPCallback(udp.CarUpdate{
+				CarID:               driver.CarID,
+				Velocity:            udp.Vec{X: 50 * position},
+				Gear:                3,
+				NormalisedSplinePos: position,
+			})
+		}
+	}
+
+	recorder := NewLapTelemetryRecorder()
+
+	recorder.UDPCallback(driver)
+	recorder.UDPCallback(udp.SessionInfo{Type: udp.SessionTypePractice, EventType: udp.EventNewSession})
+
+	// out lap, leaving the pits half way round
+	drive(recorder, 0.6, 0.8, 0.95, 0.05)
+	recorder.UDPCallback(udp.LapCompleted{CarID: driver.CarID, LapTime: 50000, Cuts: 1})
+
+	// the lap completed message arrives after the car is seen crossing the line
+	drive(recorder, 0.3, 0.6, 0.9, 0.02)
+	recorder.UDPCallback(udp.LapCompleted{CarID: driver.CarID, LapTime: 90000})
+
+	// the lap completed message arrives before the car is seen crossing the line
+	drive(recorder, 0.4, 0.7, 0.98)
+	recorder.UDPCallback(udp.LapCompleted{CarID: driver.CarID, LapTime: 91000})
+	drive(recorder, 0.99, 0.01, 0.5, 0.9, 0.03)
+	recorder.UDPCallback(udp.LapCompleted{CarID: driver.CarID, LapTime: 92000})
+
+	recorder.UDPCallback(udp.EndSession("results/" + sessionFile + ".json"))
+
+	sessionTelemetry, err := LoadSessionTelemetry(sessionFile)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedLaps := []struct {
+		lapTime   int
+		positions []float32
+	}{
+		{lapTime: 50000, positions: []float32{0.6, 0.8, 0.95}},
+		{lapTime: 90000, positions: []float32{0.05, 0.3, 0.6, 0.9}},
+		{lapTime: 91000, positions: []float32{0.02, 0.4, 0.7, 0.98}},
+		{lapTime: 92000, positions: []float32{0.01, 0.5, 0.9}},
+	}
+
+	if len(sessionTelemetry.Laps) != len(expectedLaps) {
+		t.Fatalf("Expected %d laps, got %d", len(expectedLaps), len(sessionTelemetry.Laps))
+	}
+
+	for i, expected := range expectedLaps {
+		lap := sessionTelemetry.Laps[i]
+
+		if lap.LapTime != expected.lapTime || lap.DriverGUID != string(driver.DriverGUID) {
+			t.Errorf("Lap %d: expected lap time %d for %s, got %d for %s", i, expected.lapTime, driver.DriverGUID, lap.LapTime, lap.DriverGUID)
+			continue
+		}
+
+		if len(lap.Samples) != len(expected.positions) {
+			t.Errorf("Lap %d: expected %d samples, got %d", i, len(expected.positions), len(lap.Samples))
+			continue
+		}
+
+		for j, position := range expected.positions {
+			if lap.Samples[j].Position != position {
+				t.Errorf("Lap %d: expected sample %d at %f, got %f", i, j, position, lap.Samples[j].Position)
+			}
+
+			if lap.Samples[j].Gear != 2 {
+				t.Errorf("Lap %d: expected sample %d in 2nd gear, got %d", i, j, lap.Samples[j].Gear)
+			}
+		}
+	}
+
+	sessionLap := &SessionLap{DriverGUID: string(driver.DriverGUID), CarModel: driver.CarModel, LapTime: 91000}
+
+	if lap := sessionTelemetry.FindLap(sessionLap); lap == nil || lap.LapTime != 91000 {
+		t.Errorf("Expected to find the trace for the lap in the results")
+	}
+
+	if !(&SessionResults{SessionFile: sessionFile}).HasTelemetry() {
+		t.Errorf("Expected the session to have telemetry")
+	}
+}
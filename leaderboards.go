@@ -0,0 +1,431 @@
+package servermanager
+
+import (
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+
+	"github.com/go-chi/chi"
+	"github.com/sirupsen/logrus"
+)
+
+// leaderboardAnyValue is used in a LeaderboardFilter to match laps with any ballast or restrictor.
+const leaderboardAnyValue = -1
+
+// LeaderboardFilter narrows down the laps considered for a Leaderboard. Empty values match everything.
+type LeaderboardFilter struct {
+	Tyre        string
+	BallastKG   int
+	Restrictor  int
+	SessionType SessionType
+}
+
+// AnyLeaderboardFilter matches every valid lap.
+func AnyLeaderboardFilter() LeaderboardFilter {
+	return LeaderboardFilter{
+		BallastKG:  leaderboardAnyValue,
+		Restrictor: leaderboardAnyValue,
+	}
+}
+
+// LeaderboardFilterFromRequest builds a LeaderboardFilter from a request's query string, e.g.
+// ?tyre=SM&ballast=0&restrictor=0&type=QUALIFY
+func LeaderboardFilterFromRequest(r *http.Request) LeaderboardFilter {
+	query := r.URL.Query()
+	filter := AnyLeaderboardFilter()
+
+	filter.Tyre = query.Get("tyre")
+	filter.SessionType = SessionType(query.Get("type"))
+
+	if ballast, err := strconv.Atoi(query.Get("ballast")); err == nil && ballast >= 0 {
+		filter.BallastKG = ballast
+	}
+
+	if restrictor, err := strconv.Atoi(query.Get("restrictor")); err == nil && restrictor >= 0 {
+		filter.Restrictor = restrictor
+	}
+
+	return filter
+}
+
+func (f LeaderboardFilter) matches(lap *SessionLap) bool {
+	if f.Tyre != "" && lap.Tyre != f.Tyre {
+		return false
+	}
+
+	if f.BallastKG != leaderboardAnyValue && lap.BallastKG != f.BallastKG {
+		return false
+	}
+
+	if f.Restrictor != leaderboardAnyValue && lap.Restrictor != f.Restrictor {
+		return false
+	}
+
+	return true
+}
+
+// LeaderboardEntry is a driver's best valid lap in a car at a track layout.
+type LeaderboardEntry struct {
+	DriverGUID string
+	DriverName string
+	Car        string
+
+	LapTime         time.Duration
+	Sectors         []time.Duration
+	TheoreticalBest time.Duration
+
+	Tyre       string
+	BallastKG  int
+	Restrictor int
+
+	Date        time.Time
+	SessionFile string
+	SessionType SessionType
+}
+
+// Leaderboard ranks drivers by their best valid lap for a track, layout and car.
+type Leaderboard struct {
+	Track       string
+	TrackLayout string
+	Car         string
+	Filter      LeaderboardFilter
+
+	Entries []*LeaderboardEntry
+
+	// Tyres, BallastKGs and Restrictors are the values seen across all laps in the car, for filtering.
+	Tyres       []string
+	BallastKGs  []int
+	Restrictors []int
+}
+
+// BuildLeaderboard finds every results file for a track layout and car, and ranks each driver's best valid lap.
+func BuildLeaderboard(track, layout, car string, filter LeaderboardFilter) (*Leaderboard, error) {
+	results, _, err := FindResults(ResultsQuery{
+		Track:            track,
+		TrackLayout:      layout,
+		MatchTrackLayout: true,
+		Car:              car,
+		SessionType:      filter.SessionType,
+	}, LoadResultWithoutPluginFire)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newLeaderboard(track, layout, car, filter, results), nil
+}
+
+func newLeaderboard(track, layout, car string, filter LeaderboardFilter, results []SessionResults) *Leaderboard {
+	leaderboard := &Leaderboard{
+		Track:       track,
+		TrackLayout: layout,
+		Car:         car,
+		Filter:      filter,
+	}
+
+	entries := make(map[string]*LeaderboardEntry)
+	bestSectors := make(map[string][]int)
+	tyres := make(map[string]bool)
+	ballasts := make(map[int]bool)
+	restrictors := make(map[int]bool)
+
+	var numSectors int
+
+	for i := range results {
+		session := &results[i]
+
+		if filter.SessionType != "" && session.Type != filter.SessionType {
+			continue
+		}
+
+		for _, lap := range session.Laps {
+			if lap.CarModel != car {
+				continue
+			}
+
+			tyres[lap.Tyre] = true
+			ballasts[lap.BallastKG] = true
+			restrictors[lap.Restrictor] = true
+
+			if lap.Cuts > 0 || lap.LapTime <= 0 || lap.DriverGUID == "" || !filter.matches(lap) {
+				continue
+			}
+
+			if len(lap.Sectors) > numSectors {
+				numSectors = len(lap.Sectors)
+			}
+
+			sectors := bestSectors[lap.DriverGUID]
+
+			for i, sector := range lap.Sectors {
+				if i >= len(sectors) {
+					sectors = append(sectors, sector)
+				} else if sector > 0 && (sectors[i] <= 0 || sector < sectors[i]) {
+					sectors[i] = sector
+				}
+			}
+
+			bestSectors[lap.DriverGUID] = sectors
+
+			if entry, ok := entries[lap.DriverGUID]; ok && entry.LapTime <= lap.GetLapTime() {
+				continue
+			}
+
+			entry := &LeaderboardEntry{
+				DriverGUID:  lap.DriverGUID,
+				DriverName:  lap.DriverName,
+				Car:         lap.CarModel,
+				LapTime:     lap.GetLapTime(),
+				Tyre:        lap.Tyre,
+				BallastKG:   lap.BallastKG,
+				Restrictor:  lap.Restrictor,
+				Date:        session.Date,
+				SessionFile: session.SessionFile,
+				SessionType: session.Type,
+			}
+
+			for i := range lap.Sectors {
+				entry.Sectors = append(entry.Sectors, lap.GetSector(i))
+			}
+
+			entries[lap.DriverGUID] = entry
+		}
+	}
+
+	for guid, entry := range entries {
+		sectors := bestSectors[guid]
+
+		// a theoretical best is only meaningful if the driver has set a time in every sector
+		if len(sectors) == numSectors {
+			for _, sector := range sectors {
+				if sector <= 0 {
+					entry.TheoreticalBest = 0
+					break
+				}
+
+				entry.TheoreticalBest += time.Duration(sector) * time.Millisecond
+			}
+		}
+
+		leaderboard.Entries = append(leaderboard.Entries, entry)
+	}
+
+	sort.Slice(leaderboard.Entries, func(i, j int) bool {
+		if leaderboard.Entries[i].LapTime == leaderboard.Entries[j].LapTime {
+			return leaderboard.Entries[i].Date.Before(leaderboard.Entries[j].Date)
+		}
+
+		return leaderboard.Entries[i].LapTime < leaderboard.Entries[j].LapTime
+	})
+
+	for tyre := range tyres {
+		if tyre != "" {
+			leaderboard.Tyres = append(leaderboard.Tyres, tyre)
+		}
+	}
+
+	for ballast := range ballasts {
+		leaderboard.BallastKGs = append(leaderboard.BallastKGs, ballast)
+	}
+
+	for restrictor := range restrictors {
+		leaderboard.Restrictors = append(leaderboard.Restrictors, restrictor)
+	}
+
+	sort.Strings(leaderboard.Tyres)
+	sort.Ints(leaderboard.BallastKGs)
+	sort.Ints(leaderboard.Restrictors)
+
+	return leaderboard
+}
+
+// Gap is the difference between an entry and the fastest lap on the Leaderboard.
+func (l *Leaderboard) Gap(entry *LeaderboardEntry) time.Duration {
+	if len(l.Entries) == 0 {
+		return 0
+	}
+
+	return entry.LapTime - l.Entries[0].LapTime
+}
+
+// LapRecord describes a lap which beat the previous best lap for a track, layout and car.
+type LapRecord struct {
+	Track       string
+	TrackLayout string
+	Car         string
+
+	Record   *LeaderboardEntry
+	Previous *LeaderboardEntry
+}
+
+// LeaderboardManager watches for sessions ending, and announces any lap records that were broken in them.
+type LeaderboardManager struct {
+	store               Store
+	notificationManager NotificationDispatcher
+}
+
+func NewLeaderboardManager(store Store, notificationManager NotificationDispatcher) *LeaderboardManager {
+	return &LeaderboardManager{
+		store:               store,
+		notificationManager: notificationManager,
+	}
+}
+
+func (lm *LeaderboardManager) UDPCallback(message udp.Message) {
+	if endSession, ok := message.(udp.EndSession); ok {
+		filename := filepath.Base(string(endSession))
+
+		go panicCapture(func() {
+			lm.announceLapRecords(filename)
+		})
+	}
+}
+
+func (lm *LeaderboardManager) announceLapRecords(filename string) {
+	serverOpts, err := lm.store.LoadServerOptions()
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Couldn't load server options, skipping lap record check")
+		return
+	}
+
+	if serverOpts.NotifyWhenLapRecordBroken != 1 {
+		return
+	}
+
+	results, err := LoadResult(filename, LoadResultWithoutPluginFire)
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not load results file to check lap records: %s", filename)
+		return
+	}
+
+	records, err := findLapRecords(results)
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not check lap records for: %s", filename)
+		return
+	}
+
+	for _, record := range records {
+		if err := lm.notificationManager.SendLapRecordMessage(record); err != nil {
+			logrus.WithError(err).Errorf("Could not send lap record notification")
+		}
+	}
+}
+
+// findLapRecords compares the best laps in a session to the Leaderboard of every previous session. Cars which have
+// not been driven at the track layout before do not have a record to break.
+func findLapRecords(results *SessionResults) ([]*LapRecord, error) {
+	cars := make(map[string]bool)
+
+	for _, lap := range results.Laps {
+		cars[lap.CarModel] = true
+	}
+
+	var records []*LapRecord
+
+	for car := range cars {
+		previousResults, _, err := FindResults(ResultsQuery{
+			Track:            results.TrackName,
+			TrackLayout:      results.TrackConfig,
+			MatchTrackLayout: true,
+			Car:              car,
+		}, LoadResultWithoutPluginFire)
+
+		if err != nil {
+			return nil, err
+		}
+
+		var filteredResults []SessionResults
+
+		for _, previousResult := range previousResults {
+			if previousResult.SessionFile != results.SessionFile && previousResult.Date.Before(results.Date) {
+				filteredResults = append(filteredResults, previousResult)
+			}
+		}
+
+		previous := newLeaderboard(results.TrackName, results.TrackConfig, car, AnyLeaderboardFilter(), filteredResults)
+		current := newLeaderboard(results.TrackName, results.TrackConfig, car, AnyLeaderboardFilter(), []SessionResults{*results})
+
+		if len(previous.Entries) == 0 || len(current.Entries) == 0 {
+			continue
+		}
+
+		if current.Entries[0].LapTime < previous.Entries[0].LapTime {
+			records = append(records, &LapRecord{
+				Track:       results.TrackName,
+				TrackLayout: results.TrackConfig,
+				Car:         car,
+				Record:      current.Entries[0],
+				Previous:    previous.Entries[0],
+			})
+		}
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Car < records[j].Car
+	})
+
+	return records, nil
+}
+
+type trackLeaderboardTemplateVars struct {
+	BaseTemplateVars
+
+	Track       *Track
+	Layout      string
+	Cars        []string
+	Leaderboard *Leaderboard
+}
+
+func (th *TracksHandler) leaderboard(w http.ResponseWriter, r *http.Request) {
+	track, err := th.trackManager.GetTrackFromName(chi.URLParam(r, "track_id"))
+
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	layout := r.URL.Query().Get("layout")
+
+	if layout == defaultLayoutName {
+		layout = ""
+	}
+
+	cars, err := th.trackManager.CarsForLayout(track.Name, layout)
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not find cars for layout: %s, track: %s", layout, track.Name)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	car := r.URL.Query().Get("car")
+
+	if car == "" && len(cars) > 0 {
+		car = cars[0]
+	}
+
+	leaderboard, err := BuildLeaderboard(track.Name, layout, car, LeaderboardFilterFromRequest(r))
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not build leaderboard for layout: %s, track: %s, car: %s", layout, track.Name, car)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	th.viewRenderer.MustLoadTemplate(w, r, "content/track-leaderboard.html", &trackLeaderboardTemplateVars{
+		BaseTemplateVars: BaseTemplateVars{
+			WideContainer: true,
+		},
+		Track:       track,
+		Layout:      layout,
+		Cars:        cars,
+		Leaderboard: leaderboard,
+	})
+}
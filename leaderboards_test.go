@@ -0,0 +1,71 @@
+package servermanager
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewLeaderboard(t *testing.T) {
+	results := []SessionResults{
+		{
+			Type: SessionTypePractice,
+			Laps: []*SessionLap{
+				{DriverGUID: "1", CarModel: "car", LapTime: 90000, Sectors: []int{30000, 30000, 30000}, Tyre: "SM"},
+				{DriverGUID: "1", CarModel: "car", LapTime: 89000, Sectors: []int{29000, 31000, 29000}, Tyre: "SM"},
+				{DriverGUID: "2", CarModel: "car", LapTime: 85000, Sectors: []int{28000, 28000, 29000}, Tyre: "SM", Cuts: 1},
+				{DriverGUID: "2", CarModel: "car", LapTime: 91000, Sectors: []int{30000, 31000, 30000}, Tyre: "SH", BallastKG: 20},
+				{DriverGUID: "3", CarModel: "other_car", LapTime: 80000, Sectors: []int{26000, 27000, 27000}, Tyre: "SM"},
+			},
+		},
+		{
+			Type: SessionTypeRace,
+			Laps: []*SessionLap{
+				{DriverGUID: "2", CarModel: "car", LapTime: 88500, Sectors: []int{29500, 29500, 29500}, Tyre: "SM"},
+			},
+		},
+	}
+
+	leaderboard := newLeaderboard("track", "", "car", AnyLeaderboardFilter(), results)
+
+	if len(leaderboard.Entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(leaderboard.Entries))
+	}
+
+	if leaderboard.Entries[0].DriverGUID != "2" || leaderboard.Entries[0].LapTime != 88500*time.Millisecond {
+		t.Errorf("Expected driver 2 to lead with a 1:28.500, got driver %s with %s", leaderboard.Entries[0].DriverGUID, leaderboard.Entries[0].LapTime)
+	}
+
+	if leaderboard.Entries[0].SessionType != SessionTypeRace {
+		t.Errorf("Expected the leading lap to be from the race, got %s", leaderboard.Entries[0].SessionType)
+	}
+
+	if theoreticalBest := leaderboard.Entries[1].TheoreticalBest; theoreticalBest != 88000*time.Millisecond {
+		t.Errorf("Expected driver 1's theoretical best to be 1:28.000, got %s", theoreticalBest)
+	}
+
+	if gap := leaderboard.Gap(leaderboard.Entries[1]); gap != 500*time.Millisecond {
+		t.Errorf("Expected a gap of 0.5s, got %s", gap)
+	}
+
+	if len(leaderboard.Tyres) != 2 || len(leaderboard.BallastKGs) != 2 {
+		t.Errorf("Expected 2 tyres and 2 ballast values, got %v and %v", leaderboard.Tyres, leaderboard.BallastKGs)
+	}
+
+	filter := AnyLeaderboardFilter()
+	filter.BallastKG = 20
+
+	leaderboard = newLeaderboard("track", "", "car", filter, results)
+
+	if len(leaderboard.Entries) != 1 || leaderboard.Entries[0].LapTime != 91000*time.Millisecond {
+		t.Errorf("Expected only driver 2's ballasted lap, got %d entries", len(leaderboard.Entries))
+	}
+
+	filter = AnyLeaderboardFilter()
+	filter.SessionType = SessionTypePractice
+
+	leaderboard = newLeaderboard("track", "", "car", filter, results)
+
+	if len(leaderboard.Entries) != 2 || leaderboard.Entries[0].DriverGUID != "1" {
+		t.Errorf("Expected driver 1 to lead in practice")
+	}
+}
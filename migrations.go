@@ -18,12 +18,23 @@ const (
 	versionMetaKey = "version"
 )
 
-func Migrate(store Store) error {
+// StoreVersion returns the number of migrations which have been run on the store.
+func StoreVersion(store Store) (int, error) {
 	var storeVersion int
 
 	err := store.GetMeta(versionMetaKey, &storeVersion)
 
 	if err != nil && err != ErrValueNotSet {
+		return 0, err
+	}
+
+	return storeVersion, nil
+}
+
+func Migrate(store Store) error {
+	storeVersion, err := StoreVersion(store)
+
+	if err != nil {
 		return err
 	}
 
@@ -89,6 +100,10 @@ var (
 		addSplitTypeToRaceWeekends,
 		fixCarDuplicationInRaceSetups,
 		addRealPenaltyAppUDPPort,
+		enableSessionRecordings,
+		migrateChampionshipPolePositionPoints,
+		setRecycleBinRetentionDays,
+		enableScheduledBackups,
 	}
 )
 
@@ -121,7 +136,7 @@ func addEntrantIDToChampionships(rs Store) error {
 }
 
 func addAdminAccount(rs Store) error {
-	if err := initServerID(rs); err != nil {
+	if err := InitServerID(rs); err != nil {
 		return err
 	}
 
@@ -621,7 +636,7 @@ func addContentExamples(s Store) error {
 func addServerIDToScheduledEvents(s Store) error {
 	logrus.Infof("Running migration: Add Server ID to Scheduled Events")
 
-	if err := initServerID(s); err != nil {
+	if err := InitServerID(s); err != nil {
 		return err
 	}
 
@@ -707,7 +722,7 @@ func addServerIDToScheduledEvents(s Store) error {
 func addLoopServerToCustomRace(s Store) error {
 	logrus.Infof("Running migration: Add Loop Per Server to Custom Race")
 
-	if err := initServerID(s); err != nil {
+	if err := InitServerID(s); err != nil {
 		return err
 	}
 
@@ -893,7 +908,7 @@ func enableLoggingWith5LogsKept(s Store) error {
 func convertAccountGroupToServerIDGroupMap(s Store) error {
 	logrus.Infof("Running migration: Convert Account Group to Server ID Group Map")
 
-	if err := initServerID(s); err != nil {
+	if err := InitServerID(s); err != nil {
 		return err
 	}
 
@@ -1196,3 +1211,69 @@ func addRealPenaltyAppUDPPort(s Store) error {
 
 	return s.UpsertRealPenaltyOptions(rpOpts)
 }
+
+func enableSessionRecordings(s Store) error {
+	logrus.Infof("Running migration: Enable Session Recordings")
+
+	opts, err := s.LoadServerOptions()
+
+	if err != nil {
+		return err
+	}
+
+	opts.RecordSessions = 1
+	opts.SessionRecordingRetentionDays = 14
+
+	return s.UpsertServerOptions(opts)
+}
+
+func migrateChampionshipPolePositionPoints(s Store) error {
+	logrus.Infof("Running migration: Migrate Championship Pole Position Points to Qualifying Positions")
+
+	championships, err := s.ListChampionships()
+
+	if err != nil {
+		return err
+	}
+
+	// PolePosition points are moved to QualifyingPlaces when a Championship is loaded (see
+	// ChampionshipPoints.UnmarshalJSON), so saving each Championship migrates it.
+	for _, championship := range championships {
+		err := s.UpsertChampionship(championship)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func setRecycleBinRetentionDays(s Store) error {
+	logrus.Infof("Running migration: Set Recycle Bin Retention Days")
+
+	opts, err := s.LoadServerOptions()
+
+	if err != nil {
+		return err
+	}
+
+	opts.RecycleBinRetentionDays = 30
+
+	return s.UpsertServerOptions(opts)
+}
+
+func enableScheduledBackups(s Store) error {
+	logrus.Infof("Running migration: Enable Scheduled Backups")
+
+	opts, err := s.LoadServerOptions()
+
+	if err != nil {
+		return err
+	}
+
+	opts.BackupIntervalHours = 24
+	opts.BackupsToKeep = 7
+
+	return s.UpsertServerOptions(opts)
+}
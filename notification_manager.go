@@ -4,10 +4,13 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+
 	"github.com/hako/durafmt"
 	"github.com/sirupsen/logrus"
 )
@@ -18,26 +21,35 @@ type NotificationDispatcher interface {
 	SendMessage(title string, msg string) error
 	SendMessageWithLink(title string, msg string, linkText string, link *url.URL) error
 	SendRaceStartMessage(config ServerConfig, event RaceEvent) error
+	SendEventValidationFailedMessage(event RaceEvent, validation *EventValidation) error
 	SendRaceScheduledMessage(event *CustomRace, date time.Time) error
 	SendRaceCancelledMessage(event *CustomRace, date time.Time) error
+	SendEventScheduledWebhook(details *WebhookEventDetails)
+	SendEventCancelledWebhook(details *WebhookEventDetails)
 	SendRaceReminderMessage(event *CustomRace, timer int) error
 	SendChampionshipReminderMessage(championship *Championship, event *ChampionshipEvent, timer int) error
 	SendRaceWeekendReminderMessage(raceWeekend *RaceWeekend, session *RaceWeekendSession, timer int) error
+	SendLapRecordMessage(record *LapRecord) error
+	SendSessionEndedMessage(results *SessionResults) error
+	SendChampionshipResultsMessage(championship *Championship, event *ChampionshipEvent) error
+	SendChampionshipSignUpMessage(championship *Championship, signUp *ChampionshipSignUpResponse) error
 	SaveServerOptions(oldServerOpts *GlobalServerConfig, newServerOpts *GlobalServerConfig) error
 }
 
 // NotificationManager is the generic notification handler, which calls the individual notification
-// managers. Initially, only a Discord manager is implemented.
+// managers: Discord, and any configured webhooks.
 type NotificationManager struct {
 	discordManager *DiscordManager
+	webhookManager *WebhookManager
 	carManager     *CarManager
 	store          Store
 	testing        bool
 }
 
-func NewNotificationManager(discord *DiscordManager, cars *CarManager, store Store) *NotificationManager {
+func NewNotificationManager(discord *DiscordManager, webhooks *WebhookManager, cars *CarManager, store Store) *NotificationManager {
 	return &NotificationManager{
 		discordManager: discord,
+		webhookManager: webhooks,
 		carManager:     cars,
 		store:          store,
 		testing:        os.Getenv("NOTIFICATION_TEST_MODE") == "true",
@@ -98,6 +110,12 @@ func (nm *NotificationManager) GetNotificationReminders() []int {
 
 // SendMessage sends a message (surprise surprise)
 func (nm *NotificationManager) SendMessage(title string, msg string) error {
+	nm.sendWebhook(WebhookEventMessage, title, msg, nil)
+
+	return nm.sendMessage(title, msg)
+}
+
+func (nm *NotificationManager) sendMessage(title string, msg string) error {
 	var err error
 
 	// Call all message senders here ... atm just discord.  The manager will know if it's enabled or not, so just call it
@@ -110,6 +128,12 @@ func (nm *NotificationManager) SendMessage(title string, msg string) error {
 
 // SendMessageWithLink sends a message with an embedded CM join link
 func (nm *NotificationManager) SendMessageWithLink(title string, msg string, linkText string, link *url.URL) error {
+	nm.sendWebhook(WebhookEventMessage, title, msg, &WebhookLink{Text: linkText, URL: link.String()})
+
+	return nm.sendMessageWithLink(title, msg, linkText, link)
+}
+
+func (nm *NotificationManager) sendMessageWithLink(title string, msg string, linkText string, link *url.URL) error {
 	var err error
 
 	// Call all message senders here ... atm just discord.  The manager will know if it's enabled or not, so just call it
@@ -120,6 +144,15 @@ func (nm *NotificationManager) SendMessageWithLink(title string, msg string, lin
 	return err
 }
 
+// sendWebhook sends an event to any webhooks which are subscribed to it.
+func (nm *NotificationManager) sendWebhook(event WebhookEvent, title, msg string, data interface{}) {
+	if nm.testing || nm.webhookManager == nil {
+		return
+	}
+
+	nm.webhookManager.Send(event, title, msg, data)
+}
+
 // SendRaceStartMessage sends a message as a race session is started
 func (nm *NotificationManager) SendRaceStartMessage(config ServerConfig, event RaceEvent) error {
 	serverOpts, err := nm.store.LoadServerOptions()
@@ -157,6 +190,15 @@ func (nm *NotificationManager) SendRaceStartMessage(config ServerConfig, event R
 
 	title := fmt.Sprintf("Event starting at %s", trackInfo)
 
+	nm.sendWebhook(WebhookEventRaceStarted, title, msg, &WebhookEventDetails{
+		Name:        eventName,
+		Track:       config.CurrentRaceConfig.Track,
+		TrackLayout: config.CurrentRaceConfig.TrackLayout,
+		Cars:        varSplit(config.CurrentRaceConfig.Cars),
+		Date:        time.Now(),
+		URL:         event.GetURL(),
+	})
+
 	if config.GlobalServerConfig.ShowContentManagerJoinLink == 1 {
 		link, err := getContentManagerJoinLink(config.GlobalServerConfig)
 		linkText := ""
@@ -164,20 +206,20 @@ func (nm *NotificationManager) SendRaceStartMessage(config ServerConfig, event R
 		if err != nil {
 			logrus.WithError(err).Errorf("could not get CM join link")
 
-			return nm.SendMessage(title, msg)
+			return nm.sendMessage(title, msg)
 		}
 
 		linkText = "Content Manager join link"
 
 		// delay sending message by 20 seconds to give server time to register with lobby so CM link works
 		time.AfterFunc(time.Duration(20)*time.Second, func() {
-			_ = nm.SendMessageWithLink(title, msg, linkText, link)
+			_ = nm.sendMessageWithLink(title, msg, linkText, link)
 		})
 
 		return nil
 	}
 
-	return nm.SendMessage(title, msg)
+	return nm.sendMessage(title, msg)
 }
 
 // GetCarList takes a ; sep string of cars from a race config, returns , sep of UI names with download links added
@@ -217,6 +259,35 @@ func (nm *NotificationManager) GetTrackInfo(track string, layout string, downloa
 	return trackInfo
 }
 
+// SendEventValidationFailedMessage sends a message when a scheduled event isn't started because it failed validation
+func (nm *NotificationManager) SendEventValidationFailedMessage(event RaceEvent, validation *EventValidation) error {
+	raceConfig := event.GetRaceConfig()
+	trackInfo := trackSummary(raceConfig.Track, raceConfig.TrackLayout)
+
+	title := fmt.Sprintf("Scheduled event at %s was not started", trackInfo)
+
+	if eventName := event.EventName(); eventName != "" {
+		title = fmt.Sprintf("Scheduled event %s at %s was not started", eventName, trackInfo)
+	}
+
+	msg := "The event failed validation:\n" + validation.String()
+
+	nm.sendWebhook(WebhookEventValidationFailed, title, msg, &WebhookEventValidation{
+		WebhookEventDetails: WebhookEventDetails{
+			Name:        event.EventName(),
+			Track:       raceConfig.Track,
+			TrackLayout: raceConfig.TrackLayout,
+			Cars:        varSplit(raceConfig.Cars),
+			Date:        time.Now(),
+			URL:         event.GetURL(),
+		},
+		Errors:   validation.Errors,
+		Warnings: validation.Warnings,
+	})
+
+	return nm.sendMessage(title, msg)
+}
+
 // SendRaceScheduledMessage sends a notification when a race is scheduled
 func (nm *NotificationManager) SendRaceScheduledMessage(event *CustomRace, date time.Time) error {
 	serverOpts, err := nm.store.LoadServerOptions()
@@ -226,10 +297,6 @@ func (nm *NotificationManager) SendRaceScheduledMessage(event *CustomRace, date
 		return err
 	}
 
-	if serverOpts.NotifyWhenScheduled != 1 {
-		return nil
-	}
-
 	msg := "A new event has been scheduled\n"
 	msg += fmt.Sprintf("Server: %s\n", serverOpts.Name)
 	eventName := event.EventName()
@@ -245,7 +312,11 @@ func (nm *NotificationManager) SendRaceScheduledMessage(event *CustomRace, date
 	msg += fmt.Sprintf("Car(s): %s\n", carNames)
 	title := fmt.Sprintf("Event scheduled at %s", nm.GetTrackInfo(event.RaceConfig.Track, event.RaceConfig.TrackLayout, false))
 
-	return nm.SendMessage(title, msg)
+	if serverOpts.NotifyWhenScheduled != 1 {
+		return nil
+	}
+
+	return nm.sendMessage(title, msg)
 }
 
 // SendRaceCancelledMessage sends a notification when a race is cancelled
@@ -257,10 +328,6 @@ func (nm *NotificationManager) SendRaceCancelledMessage(event *CustomRace, date
 		return err
 	}
 
-	if serverOpts.NotifyWhenScheduled != 1 {
-		return nil
-	}
-
 	dateStr := date.Format("Mon, 02 Jan 2006 15:04:05 MST")
 
 	msg := "The following scheduled Event has been cancelled\n"
@@ -276,7 +343,45 @@ func (nm *NotificationManager) SendRaceCancelledMessage(event *CustomRace, date
 	msg += fmt.Sprintf("Track: %s\n", trackInfo)
 	title := fmt.Sprintf("Event cancelled at %s", trackInfo)
 
-	return nm.SendMessage(title, msg)
+	if serverOpts.NotifyWhenScheduled != 1 {
+		return nil
+	}
+
+	return nm.sendMessage(title, msg)
+}
+
+// SendEventScheduledWebhook notifies webhooks that a custom race, championship event or race weekend session has
+// been scheduled. Discord is notified separately by SendRaceScheduledMessage.
+func (nm *NotificationManager) SendEventScheduledWebhook(details *WebhookEventDetails) {
+	trackInfo := trackSummary(details.Track, details.TrackLayout)
+
+	msg := "A new event has been scheduled\n"
+
+	if details.Name != "" {
+		msg += fmt.Sprintf("Event name: %s\n", details.Name)
+	}
+
+	msg += fmt.Sprintf("Date: %s\n", details.Date.Format("Mon, 02 Jan 2006 15:04:05 MST"))
+	msg += fmt.Sprintf("Track: %s\n", trackInfo)
+
+	nm.sendWebhook(WebhookEventRaceScheduled, fmt.Sprintf("Event scheduled at %s", trackInfo), msg, details)
+}
+
+// SendEventCancelledWebhook notifies webhooks that a scheduled custom race, championship event or race weekend
+// session has been cancelled. Discord is notified separately by SendRaceCancelledMessage.
+func (nm *NotificationManager) SendEventCancelledWebhook(details *WebhookEventDetails) {
+	trackInfo := trackSummary(details.Track, details.TrackLayout)
+
+	msg := "The following scheduled Event has been cancelled\n"
+
+	if details.Name != "" {
+		msg += fmt.Sprintf("Event name: %s\n", details.Name)
+	}
+
+	msg += fmt.Sprintf("Date: %s\n", details.Date.Format("Mon, 02 Jan 2006 15:04:05 MST"))
+	msg += fmt.Sprintf("Track: %s\n", trackInfo)
+
+	nm.sendWebhook(WebhookEventRaceCancelled, fmt.Sprintf("Event cancelled at %s", trackInfo), msg, details)
 }
 
 // SendRaceReminderMessage sends a reminder a configurable number of minutes prior to a race starting
@@ -294,7 +399,13 @@ func (nm *NotificationManager) SendRaceReminderMessage(event *CustomRace, timer
 	}
 
 	title := fmt.Sprintf("Event reminder - %s", reminder)
-	return nm.SendMessage(title, msg)
+
+	details := customRaceWebhookDetails(event, event.Scheduled)
+	details.ReminderMinutes = timer
+
+	nm.sendWebhook(WebhookEventRaceReminder, title, msg, details)
+
+	return nm.sendMessage(title, msg)
 }
 
 // SendChampionshipReminderMessage sends a reminder a configurable number of minutes prior to a championship race starting
@@ -303,7 +414,13 @@ func (nm *NotificationManager) SendChampionshipReminderMessage(championship *Cha
 	title := fmt.Sprintf("Event reminder - %s", reminder)
 	trackInfo := nm.GetTrackInfo(event.RaceSetup.Track, event.RaceSetup.TrackLayout, true)
 	msg := fmt.Sprintf("%s event at %s starts in %s", championship.Name, trackInfo, reminder)
-	return nm.SendMessage(title, msg)
+
+	details := championshipEventWebhookDetails(championship, event, event.Scheduled)
+	details.ReminderMinutes = timer
+
+	nm.sendWebhook(WebhookEventRaceReminder, title, msg, details)
+
+	return nm.sendMessage(title, msg)
 }
 
 // SendRaceWeekendReminderMessage sends a reminder a configurable number of minutes prior to a RaceWeekendSession starting
@@ -312,5 +429,131 @@ func (nm *NotificationManager) SendRaceWeekendReminderMessage(raceWeekend *RaceW
 	title := fmt.Sprintf("Event reminder - %s", reminder)
 	trackInfo := nm.GetTrackInfo(session.RaceConfig.Track, session.RaceConfig.TrackLayout, true)
 	msg := fmt.Sprintf("%s at %s (%s Race Weekend) starts in %s", session.Name(), raceWeekend.Name, trackInfo, reminder)
-	return nm.SendMessage(title, msg)
+
+	details := raceWeekendSessionWebhookDetails(raceWeekend, session, session.ScheduledTime)
+	details.ReminderMinutes = timer
+
+	nm.sendWebhook(WebhookEventRaceReminder, title, msg, details)
+
+	return nm.sendMessage(title, msg)
+}
+
+// SendLapRecordMessage sends a notification when the lap record for a track layout and car is broken
+func (nm *NotificationManager) SendLapRecordMessage(record *LapRecord) error {
+	trackInfo := trackSummary(record.Track, record.TrackLayout)
+	carName := prettifyName(record.Car, true)
+
+	if car, err := nm.carManager.LoadCar(record.Car, nil); err == nil && car.Details.Name != "" {
+		carName = car.Details.Name
+	}
+
+	title := fmt.Sprintf("New lap record at %s", trackInfo)
+	msg := fmt.Sprintf("%s set a %s in the %s", driverName(record.Record.DriverName), formatDuration(record.Record.LapTime, true), carName)
+
+	if record.Previous != nil {
+		msg += fmt.Sprintf(", beating the previous record of %s by %s", formatDuration(record.Previous.LapTime, true), driverName(record.Previous.DriverName))
+	}
+
+	nm.sendWebhook(WebhookEventLapRecord, title, msg, record)
+
+	return nm.sendMessage(title, msg)
+}
+
+// SendSessionEndedMessage sends a summary of a session's results: the podium, fastest lap, winning margin and any
+// penalties. Championship and race weekend sessions also include the updated championship standings.
+func (nm *NotificationManager) SendSessionEndedMessage(results *SessionResults) error {
+	championship, err := nm.loadResultsChampionship(results)
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not load championship for session results, standings will not be included")
+	}
+
+	summary := NewResultsSummary(results, championship)
+	title := summary.Title()
+	msg := summary.String()
+
+	nm.sendWebhook(WebhookEventSessionEnded, title, msg, summary)
+
+	serverOpts, err := nm.store.LoadServerOptions()
+
+	if err != nil {
+		logrus.WithError(err).Errorf("couldn't load server options, skipping notification")
+		return err
+	}
+
+	if serverOpts.NotifyWhenSessionEnded != 1 {
+		return nil
+	}
+
+	return nm.sendMessage(title, msg)
+}
+
+// loadResultsChampionship loads the championship that a session's results belong to.
+func (nm *NotificationManager) loadResultsChampionship(results *SessionResults) (*Championship, error) {
+	if results.ChampionshipID == "" {
+		return nil, nil
+	}
+
+	return loadChampionshipWithRaceWeekends(nm.store, results.ChampionshipID)
+}
+
+// SendChampionshipResultsMessage notifies webhooks that a championship event has been completed, with the
+// championship standings after it
+func (nm *NotificationManager) SendChampionshipResultsMessage(championship *Championship, event *ChampionshipEvent) error {
+	trackInfo := trackSummary(event.RaceSetup.Track, event.RaceSetup.TrackLayout)
+	title := fmt.Sprintf("%s results at %s", championship.Name, trackInfo)
+	msg := fmt.Sprintf("The %s event at %s is complete", championship.Name, trackInfo)
+
+	data := &WebhookChampionshipResults{
+		ChampionshipID:   championship.ID.String(),
+		ChampionshipName: championship.Name,
+		EventID:          event.ID.String(),
+		Track:            event.RaceSetup.Track,
+		TrackLayout:      event.RaceSetup.TrackLayout,
+		URL:              championship.GetURL(),
+		Standings:        summariseChampionshipStandings(championship),
+	}
+
+	nm.sendWebhook(WebhookEventChampionshipResults, title, msg, data)
+
+	return nil
+}
+
+// SendChampionshipSignUpMessage notifies webhooks that a driver has signed up to a championship
+func (nm *NotificationManager) SendChampionshipSignUpMessage(championship *Championship, signUp *ChampionshipSignUpResponse) error {
+	title := fmt.Sprintf("New sign up for %s", championship.Name)
+	msg := fmt.Sprintf("%s has signed up to %s. Their registration is %s", driverName(signUp.Name), championship.Name, strings.ToLower(string(signUp.Status)))
+
+	nm.sendWebhook(WebhookEventChampionshipSignUp, title, msg, &WebhookSignUp{
+		ChampionshipID:   championship.ID.String(),
+		ChampionshipName: championship.Name,
+		DriverGUID:       signUp.GUID,
+		DriverName:       driverName(signUp.Name),
+		Team:             signUp.Team,
+		Car:              signUp.Car,
+		Status:           signUp.Status,
+	})
+
+	return nil
+}
+
+// UDPCallback sends a session ended notification when the server writes a results file. It is called after the
+// championship and race weekend managers have saved the results, so standings are up to date.
+func (nm *NotificationManager) UDPCallback(message udp.Message) {
+	if endSession, ok := message.(udp.EndSession); ok {
+		filename := filepath.Base(string(endSession))
+
+		go panicCapture(func() {
+			results, err := LoadResult(filename, LoadResultWithoutPluginFire)
+
+			if err != nil {
+				logrus.WithError(err).Errorf("Could not load results file for session ended notification: %s", filename)
+				return
+			}
+
+			if err := nm.SendSessionEndedMessage(results); err != nil {
+				logrus.WithError(err).Errorf("Could not send session ended notification")
+			}
+		})
+	}
 }
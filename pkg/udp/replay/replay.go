@@ -2,6 +2,7 @@ package replay
 
 import (
 	"bytes"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"sort"
@@ -169,10 +170,13 @@ func (e *Entry) UnmarshalJSON(b []byte) error {
 
 var BucketName = []byte("sessions")
 
+// now is the time messages are received at, it can be changed in tests.
+var now = time.Now
+
 func RecordUDPMessages(db *bbolt.DB) (callbackFunc udp.CallbackFunc) {
 	return func(message udp.Message) {
 		e := Entry{
-			Received:  time.Now(),
+			Received:  now(),
 			EventType: message.Event(),
 			Data:      message,
 		}
@@ -195,7 +199,19 @@ func RecordUDPMessages(db *bbolt.DB) (callbackFunc udp.CallbackFunc) {
 				return err
 			}
 
-			return bkt.Put([]byte(e.Received.Format(time.RFC3339Nano)), buf.Bytes())
+			// entries are keyed by a sequence rather than their receive time, as messages sent together can be
+			// received at the same time on platforms with a coarse clock, which would overwrite each other.
+			// big endian keys keep the entries in the order they were received.
+			seq, err := bkt.NextSequence()
+
+			if err != nil {
+				return err
+			}
+
+			key := make([]byte, 8)
+			binary.BigEndian.PutUint64(key, seq)
+
+			return bkt.Put(key, buf.Bytes())
 		})
 
 		if err != nil {
@@ -231,7 +247,8 @@ func UDPMessages(db *bbolt.DB, multiplier int, callbackFunc udp.CallbackFunc, wa
 			return nil
 		}
 
-		sort.Sort(loadedEntries)
+		// entries received at the same time are kept in the order they were recorded.
+		sort.Stable(loadedEntries)
 
 		timeStart := loadedEntries[0].Received
 
@@ -0,0 +1,93 @@
+package replay
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+
+	"github.com/etcd-io/bbolt"
+)
+
+func TestRecordUDPMessages(t *testing.T) {
+	dir, err := ioutil.TempDir("", "replay")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	db, err := bbolt.Open(filepath.Join(dir, "recording.db"), 0644, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	// messages sent together can be received at the same time on platforms with a coarse clock.
+	received := time.Now()
+
+	now = func() time.Time {
+		return received
+	}
+
+	defer func() {
+		now = time.Now
+	}()
+
+	messages := []udp.Message{
+		udp.SessionInfo{Track: "ks_vallelunga", EventType: udp.EventNewSession},
+		udp.SessionCarInfo{CarID: 1, DriverName: "Driver 1", EventType: udp.EventNewConnection},
+		udp.ClientLoaded(1),
+		udp.SessionCarInfo{CarID: 2, DriverName: "Driver 2", EventType: udp.EventNewConnection},
+		udp.ClientLoaded(2),
+	}
+
+	record := RecordUDPMessages(db)
+
+	for _, message := range messages {
+		record(message)
+	}
+
+	var replayed []udp.Message
+
+	if err := UDPMessages(db, 1, func(message udp.Message) {
+		replayed = append(replayed, message)
+	}, time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(replayed) != len(messages) {
+		t.Fatalf("Expected %d messages to be replayed, got %d", len(messages), len(replayed))
+	}
+
+	err = db.View(func(tx *bbolt.Tx) error {
+		i := 0
+
+		return tx.Bucket(BucketName).ForEach(func(k, v []byte) error {
+			var entry *Entry
+
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return err
+			}
+
+			if entry.EventType != messages[i].Event() {
+				t.Errorf("Expected entry %d to be event %d, got %d", i, messages[i].Event(), entry.EventType)
+			}
+
+			i++
+
+			return nil
+		})
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,804 @@
+package servermanager
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const protestsMetaKey = "protests"
+
+var (
+	ErrProtestNotFound        = errors.New("servermanager: protest not found")
+	ErrProtestNotParticipant  = errors.New("servermanager: only drivers in a session can file a protest against it")
+	ErrProtestInvalidAccused  = errors.New("servermanager: the protested driver must be another driver in the session")
+	ErrProtestNoDescription   = errors.New("servermanager: protests must have a description")
+	ErrProtestInvalidVideo    = errors.New("servermanager: video links must be http or https URLs")
+	ErrProtestNotOpen         = errors.New("servermanager: protest is not waiting for a decision")
+	ErrProtestCannotAppeal    = errors.New("servermanager: protest cannot be appealed")
+	ErrProtestInvalidDecision = errors.New("servermanager: invalid protest decision")
+)
+
+type ProtestStatus string
+
+const (
+	ProtestStatusOpen      ProtestStatus = "Open"
+	ProtestStatusAppealed  ProtestStatus = "Under Appeal"
+	ProtestStatusUpheld    ProtestStatus = "Upheld"
+	ProtestStatusDismissed ProtestStatus = "Dismissed"
+)
+
+type ProtestPenaltyType string
+
+const (
+	ProtestPenaltyNone               ProtestPenaltyType = ""
+	ProtestPenaltyTime               ProtestPenaltyType = "Time Penalty"
+	ProtestPenaltyDisqualification   ProtestPenaltyType = "Disqualification"
+	ProtestPenaltyChampionshipPoints ProtestPenaltyType = "Championship Points"
+)
+
+var ProtestPenaltyTypes = []ProtestPenaltyType{
+	ProtestPenaltyTime,
+	ProtestPenaltyDisqualification,
+	ProtestPenaltyChampionshipPoints,
+}
+
+// ProtestPenalty is a penalty given to the protested driver when a protest is upheld.
+type ProtestPenalty struct {
+	Type    ProtestPenaltyType `json:"Type"`
+	Seconds float64            `json:"Seconds"`
+	Points  int                `json:"Points"`
+}
+
+func (p ProtestPenalty) String() string {
+	switch p.Type {
+	case ProtestPenaltyTime:
+		return fmt.Sprintf("%s Time Penalty", time.Duration(p.Seconds*float64(time.Second)).Round(100*time.Millisecond))
+	case ProtestPenaltyDisqualification:
+		return string(p.Type)
+	case ProtestPenaltyChampionshipPoints:
+		return fmt.Sprintf("%d Championship Point Penalty", p.Points)
+	default:
+		return "No Penalty"
+	}
+}
+
+type ProtestDriver struct {
+	GUID     string `json:"GUID"`
+	Name     string `json:"Name"`
+	CarModel string `json:"CarModel"`
+}
+
+// ProtestHistoryEntry records each step of a protest, from filing through to any appeal.
+type ProtestHistoryEntry struct {
+	Time    time.Time      `json:"Time"`
+	Action  string         `json:"Action"`
+	By      string         `json:"By"`
+	Status  ProtestStatus  `json:"Status"`
+	Penalty ProtestPenalty `json:"Penalty"`
+	Notes   string         `json:"Notes"`
+}
+
+// A Protest is filed by a driver against another driver in a session. Stewards decide whether to uphold it and which
+// penalty to apply, and either driver may appeal the decision once.
+type Protest struct {
+	ID      uuid.UUID `json:"ID"`
+	Created time.Time `json:"Created"`
+	Updated time.Time `json:"Updated"`
+
+	SessionFile    string      `json:"SessionFile"`
+	SessionType    SessionType `json:"SessionType"`
+	Track          string      `json:"Track"`
+	TrackLayout    string      `json:"TrackLayout"`
+	ChampionshipID string      `json:"ChampionshipID"`
+	RaceWeekendID  string      `json:"RaceWeekendID"`
+
+	Protester   ProtestDriver `json:"Protester"`
+	Accused     ProtestDriver `json:"Accused"`
+	Lap         int           `json:"Lap"`
+	Description string        `json:"Description"`
+	VideoLinks  []string      `json:"VideoLinks"`
+
+	Status ProtestStatus `json:"Status"`
+
+	// Penalty is the penalty which is currently applied to the protested driver.
+	Penalty  ProtestPenalty         `json:"Penalty"`
+	Appealed bool                   `json:"Appealed"`
+	History  []*ProtestHistoryEntry `json:"History"`
+}
+
+func (p *Protest) IsOpen() bool {
+	return p.Status == ProtestStatusOpen || p.Status == ProtestStatusAppealed
+}
+
+// Involves returns true if the account belongs to the protesting or protested driver.
+func (p *Protest) Involves(account *Account) bool {
+	return account != nil && account.GUID != "" && (account.GUID == p.Protester.GUID || account.GUID == p.Accused.GUID)
+}
+
+func (p *Protest) CanView(account *Account) bool {
+	return p.Involves(account) || (account != nil && account.HasGroupPrivilege(GroupWrite))
+}
+
+// CanAppeal returns true if the account belongs to a driver involved in a decided protest which hasn't been appealed.
+func (p *Protest) CanAppeal(account *Account) bool {
+	return !p.IsOpen() && !p.Appealed && p.Involves(account)
+}
+
+func (p *Protest) Summary() string {
+	summary := fmt.Sprintf("%s protested %s", driverName(p.Protester.Name), driverName(p.Accused.Name))
+
+	if p.Lap > 0 {
+		summary += fmt.Sprintf(" (lap %d)", p.Lap)
+	}
+
+	return summary
+}
+
+func (p *Protest) addHistory(action string, account *Account, notes string) {
+	entry := &ProtestHistoryEntry{
+		Time:    time.Now(),
+		Action:  action,
+		Status:  p.Status,
+		Penalty: p.Penalty,
+		Notes:   notes,
+	}
+
+	if account != nil {
+		entry.By = account.Name
+	}
+
+	p.History = append(p.History, entry)
+	p.Updated = entry.Time
+}
+
+type ProtestManager struct {
+	store               Store
+	penaltiesManager    *PenaltiesManager
+	championshipManager *ChampionshipManager
+
+	// mutex is held while protests are changed, as they are all saved together.
+	mutex sync.Mutex
+}
+
+func NewProtestManager(store Store, penaltiesManager *PenaltiesManager, championshipManager *ChampionshipManager) *ProtestManager {
+	return &ProtestManager{
+		store:               store,
+		penaltiesManager:    penaltiesManager,
+		championshipManager: championshipManager,
+	}
+}
+
+// ListProtests returns all protests, newest first.
+func (pm *ProtestManager) ListProtests() ([]*Protest, error) {
+	var protests []*Protest
+
+	err := pm.store.GetMeta(protestsMetaKey, &protests)
+
+	if err != nil && err != ErrValueNotSet {
+		return nil, err
+	}
+
+	sort.Slice(protests, func(i, j int) bool {
+		return protests[i].Created.After(protests[j].Created)
+	})
+
+	return protests, nil
+}
+
+func (pm *ProtestManager) ListProtestsForChampionship(championshipID string) ([]*Protest, error) {
+	protests, err := pm.ListProtests()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var out []*Protest
+
+	for _, protest := range protests {
+		if protest.ChampionshipID == championshipID {
+			out = append(out, protest)
+		}
+	}
+
+	return out, nil
+}
+
+func (pm *ProtestManager) FindProtest(id string) (*Protest, error) {
+	protests, err := pm.ListProtests()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, protest := range protests {
+		if protest.ID.String() == id {
+			return protest, nil
+		}
+	}
+
+	return nil, ErrProtestNotFound
+}
+
+// upsertProtest saves a new or changed protest. pm.mutex must be held.
+func (pm *ProtestManager) upsertProtest(protest *Protest) error {
+	protests, err := pm.ListProtests()
+
+	if err != nil {
+		return err
+	}
+
+	found := false
+
+	for i, existing := range protests {
+		if existing.ID == protest.ID {
+			protests[i] = protest
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		protests = append(protests, protest)
+	}
+
+	return pm.store.SetMeta(protestsMetaKey, protests)
+}
+
+func (pm *ProtestManager) audit(account *Account, protest *Protest, details string) {
+	entry := &AuditEntry{
+		Method:  "PROTEST",
+		URL:     "/protests/" + protest.ID.String(),
+		Time:    time.Now(),
+		Details: details,
+	}
+
+	if account != nil {
+		entry.User = account.Name
+		entry.UserGroup = account.Group()
+	}
+
+	if err := pm.store.AddAuditEntry(entry); err != nil {
+		logrus.WithError(err).Errorf("Couldn't add audit entry for protest: %s", protest.ID)
+	}
+}
+
+func parseProtestVideoLinks(links string) ([]string, error) {
+	var out []string
+
+	for _, link := range strings.Fields(links) {
+		u, err := url.Parse(link)
+
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, ErrProtestInvalidVideo
+		}
+
+		out = append(out, link)
+	}
+
+	return out, nil
+}
+
+// FileProtest files a protest from the driver whose GUID matches the account against another driver in the session.
+func (pm *ProtestManager) FileProtest(account *Account, sessionFile, accusedGUID string, lap int, description, videoLinks string) (*Protest, error) {
+	results, err := LoadResult(sessionFile + ".json")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if account == nil || !results.HasDriver(account.GUID) {
+		return nil, ErrProtestNotParticipant
+	}
+
+	var accused *SessionResult
+
+	for _, result := range results.Result {
+		if result.DriverGUID == accusedGUID {
+			accused = result
+			break
+		}
+	}
+
+	if accused == nil || accusedGUID == account.GUID {
+		return nil, ErrProtestInvalidAccused
+	}
+
+	description = strings.TrimSpace(description)
+
+	if description == "" {
+		return nil, ErrProtestNoDescription
+	}
+
+	links, err := parseProtestVideoLinks(videoLinks)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if lap < 0 {
+		lap = 0
+	}
+
+	protesterName := account.DriverName
+
+	for _, result := range results.Result {
+		if result.DriverGUID == account.GUID {
+			protesterName = result.DriverName
+			break
+		}
+	}
+
+	if protesterName == "" {
+		protesterName = account.Name
+	}
+
+	protest := &Protest{
+		ID:             uuid.New(),
+		Created:        time.Now(),
+		SessionFile:    results.SessionFile,
+		SessionType:    results.Type,
+		Track:          results.TrackName,
+		TrackLayout:    results.TrackConfig,
+		ChampionshipID: results.ChampionshipID,
+		RaceWeekendID:  results.RaceWeekendID,
+		Protester:      ProtestDriver{GUID: account.GUID, Name: protesterName},
+		Accused:        ProtestDriver{GUID: accused.DriverGUID, Name: accused.DriverName, CarModel: accused.CarModel},
+		Lap:            lap,
+		Description:    description,
+		VideoLinks:     links,
+		Status:         ProtestStatusOpen,
+	}
+
+	protest.addHistory("Filed", account, description)
+
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	if err := pm.upsertProtest(protest); err != nil {
+		return nil, err
+	}
+
+	pm.audit(account, protest, "Filed protest: "+protest.Summary())
+
+	return protest, nil
+}
+
+// Appeal re-opens a decided protest for the stewards to review again.
+func (pm *ProtestManager) Appeal(id string, account *Account, reason string) (*Protest, error) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	protest, err := pm.FindProtest(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !protest.CanAppeal(account) {
+		return nil, ErrProtestCannotAppeal
+	}
+
+	reason = strings.TrimSpace(reason)
+
+	if reason == "" {
+		return nil, ErrProtestNoDescription
+	}
+
+	protest.Status = ProtestStatusAppealed
+	protest.Appealed = true
+	protest.addHistory("Appealed", account, reason)
+
+	if err := pm.upsertProtest(protest); err != nil {
+		return nil, err
+	}
+
+	pm.audit(account, protest, "Appealed protest: "+protest.Summary())
+
+	return protest, nil
+}
+
+// Decide records the stewards' decision on an open protest. If the protest is upheld, the penalty is applied to the
+// protested driver. When deciding an appeal, the original penalty is removed before the new one is applied.
+func (pm *ProtestManager) Decide(id string, account *Account, status ProtestStatus, penalty ProtestPenalty, notes string) (*Protest, error) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	protest, err := pm.FindProtest(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !protest.IsOpen() {
+		return nil, ErrProtestNotOpen
+	}
+
+	switch status {
+	case ProtestStatusDismissed:
+		penalty = ProtestPenalty{}
+	case ProtestStatusUpheld:
+		switch penalty.Type {
+		case ProtestPenaltyNone, ProtestPenaltyDisqualification:
+			penalty = ProtestPenalty{Type: penalty.Type}
+		case ProtestPenaltyTime:
+			if penalty.Seconds <= 0 {
+				return nil, ErrProtestInvalidDecision
+			}
+
+			penalty.Points = 0
+		case ProtestPenaltyChampionshipPoints:
+			if penalty.Points <= 0 || protest.ChampionshipID == "" {
+				return nil, ErrProtestInvalidDecision
+			}
+
+			penalty.Seconds = 0
+		default:
+			return nil, ErrProtestInvalidDecision
+		}
+	default:
+		return nil, ErrProtestInvalidDecision
+	}
+
+	action := "Decided"
+
+	if protest.Status == ProtestStatusAppealed {
+		action = "Appeal Decided"
+	}
+
+	if protest.Penalty != penalty {
+		if err := pm.revertPenalty(protest); err != nil {
+			return nil, err
+		}
+
+		protest.Penalty = ProtestPenalty{}
+
+		if err := pm.applyPenalty(protest, penalty); err != nil {
+			return nil, err
+		}
+
+		protest.Penalty = penalty
+	}
+
+	protest.Status = status
+	protest.addHistory(action, account, notes)
+
+	if err := pm.upsertProtest(protest); err != nil {
+		return nil, err
+	}
+
+	pm.audit(account, protest, fmt.Sprintf("%s protest: %s - %s, %s", action, protest.Summary(), status, penalty))
+
+	return protest, nil
+}
+
+// accusedResult finds the protested driver's current penalties in the results file.
+func (pm *ProtestManager) accusedResult(protest *Protest) (*SessionResult, error) {
+	results, err := LoadResult(protest.SessionFile+".json", LoadResultWithoutPluginFire)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range results.Result {
+		if result.DriverGUID == protest.Accused.GUID && result.CarModel == protest.Accused.CarModel {
+			return result, nil
+		}
+	}
+
+	return nil, ErrSessionCarNotFound
+}
+
+// setResultPenalty sets the protested driver's time penalty and disqualification in the results file.
+func (pm *ProtestManager) setResultPenalty(protest *Protest, penaltyTime time.Duration, disqualified bool) error {
+	guid, carModel := protest.Accused.GUID, protest.Accused.CarModel
+
+	if err := pm.penaltiesManager.applyPenalty(protest.SessionFile, guid, carModel, 0, false); err != nil {
+		return err
+	}
+
+	if penaltyTime > 0 {
+		if err := pm.penaltiesManager.applyPenalty(protest.SessionFile, guid, carModel, penaltyTime.Seconds(), true); err != nil {
+			return err
+		}
+	}
+
+	if disqualified {
+		// a penalty of zero disqualifies the driver
+		return pm.penaltiesManager.applyPenalty(protest.SessionFile, guid, carModel, 0, true)
+	}
+
+	return nil
+}
+
+// adjustChampionshipPoints adds points to the protested driver's championship points penalty in their class.
+func (pm *ProtestManager) adjustChampionshipPoints(protest *Protest, points int) error {
+	championship, err := pm.championshipManager.LoadChampionship(protest.ChampionshipID)
+
+	if err != nil {
+		return err
+	}
+
+	class, err := championship.FindClassForCarModel(protest.Accused.CarModel)
+
+	if err != nil {
+		return err
+	}
+
+	current := class.DriverPenalties[protest.Accused.GUID]
+
+	if current+points <= 0 {
+		return pm.championshipManager.ModifyDriverPenalty(protest.ChampionshipID, class.ID.String(), protest.Accused.GUID, RemovePenalty, 0)
+	}
+
+	return pm.championshipManager.ModifyDriverPenalty(protest.ChampionshipID, class.ID.String(), protest.Accused.GUID, SetPenalty, current+points)
+}
+
+func (pm *ProtestManager) applyPenalty(protest *Protest, penalty ProtestPenalty) error {
+	switch penalty.Type {
+	case ProtestPenaltyTime, ProtestPenaltyDisqualification:
+		result, err := pm.accusedResult(protest)
+
+		if err != nil {
+			return err
+		}
+
+		penaltyTime := result.PenaltyTime
+		disqualified := result.Disqualified
+
+		if penalty.Type == ProtestPenaltyTime {
+			penaltyTime += time.Duration(penalty.Seconds * float64(time.Second))
+		} else {
+			disqualified = true
+		}
+
+		return pm.setResultPenalty(protest, penaltyTime, disqualified)
+	case ProtestPenaltyChampionshipPoints:
+		return pm.adjustChampionshipPoints(protest, penalty.Points)
+	}
+
+	return nil
+}
+
+// revertPenalty removes the penalty which is currently applied for the protest, leaving any other penalties in place.
+func (pm *ProtestManager) revertPenalty(protest *Protest) error {
+	switch protest.Penalty.Type {
+	case ProtestPenaltyTime, ProtestPenaltyDisqualification:
+		result, err := pm.accusedResult(protest)
+
+		if err != nil {
+			return err
+		}
+
+		penaltyTime := result.PenaltyTime
+		disqualified := result.Disqualified
+
+		if protest.Penalty.Type == ProtestPenaltyTime {
+			penaltyTime -= time.Duration(protest.Penalty.Seconds * float64(time.Second))
+		} else {
+			disqualified = false
+		}
+
+		return pm.setResultPenalty(protest, penaltyTime, disqualified)
+	case ProtestPenaltyChampionshipPoints:
+		return pm.adjustChampionshipPoints(protest, -protest.Penalty.Points)
+	}
+
+	return nil
+}
+
+type ProtestsHandler struct {
+	*BaseHandler
+
+	protestManager *ProtestManager
+}
+
+func NewProtestsHandler(baseHandler *BaseHandler, protestManager *ProtestManager) *ProtestsHandler {
+	return &ProtestsHandler{
+		BaseHandler:    baseHandler,
+		protestManager: protestManager,
+	}
+}
+
+type protestListTemplateVars struct {
+	BaseTemplateVars
+
+	Protests []*Protest
+}
+
+func (ph *ProtestsHandler) list(w http.ResponseWriter, r *http.Request) {
+	protests, err := ph.protestManager.ListProtests()
+
+	if err != nil {
+		logrus.WithError(err).Errorf("couldn't list protests")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	account := AccountFromRequest(r)
+
+	var visible []*Protest
+
+	for _, protest := range protests {
+		if protest.CanView(account) {
+			visible = append(visible, protest)
+		}
+	}
+
+	ph.viewRenderer.MustLoadTemplate(w, r, "protests/index.html", &protestListTemplateVars{
+		Protests: visible,
+	})
+}
+
+type protestFormTemplateVars struct {
+	BaseTemplateVars
+
+	Results *SessionResults
+	Account *Account
+}
+
+func (ph *ProtestsHandler) protestForm(w http.ResponseWriter, r *http.Request) {
+	results, err := LoadResult(chi.URLParam(r, "fileName") + ".json")
+
+	if err != nil {
+		logrus.WithError(err).Errorf("couldn't load results")
+		http.NotFound(w, r)
+		return
+	}
+
+	account := AccountFromRequest(r)
+
+	if !results.HasDriver(account.GUID) {
+		AddErrorFlash(w, r, "Only drivers who took part in a session can file a protest against it. Make sure your Driver GUID is set on your account.")
+		http.Redirect(w, r, "/results/"+results.SessionFile, http.StatusFound)
+		return
+	}
+
+	ph.viewRenderer.MustLoadTemplate(w, r, "protests/new.html", &protestFormTemplateVars{
+		Results: results,
+		Account: account,
+	})
+}
+
+func (ph *ProtestsHandler) file(w http.ResponseWriter, r *http.Request) {
+	fileName := chi.URLParam(r, "fileName")
+
+	if err := r.ParseForm(); err != nil {
+		logrus.WithError(err).Errorf("couldn't parse protest form")
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	lap, _ := strconv.Atoi(r.FormValue("Lap"))
+
+	protest, err := ph.protestManager.FileProtest(AccountFromRequest(r), fileName, r.FormValue("Accused"), lap, r.FormValue("Description"), r.FormValue("VideoLinks"))
+
+	switch err {
+	case nil:
+		AddFlash(w, r, "Your protest has been filed. The stewards will review it soon.")
+		http.Redirect(w, r, "/protests/"+protest.ID.String(), http.StatusFound)
+		return
+	case ErrProtestNotParticipant:
+		AddErrorFlash(w, r, "Only drivers who took part in a session can file a protest against it")
+	case ErrProtestInvalidAccused:
+		AddErrorFlash(w, r, "Please choose another driver from the session to protest")
+	case ErrProtestNoDescription:
+		AddErrorFlash(w, r, "Please describe what happened")
+	case ErrProtestInvalidVideo:
+		AddErrorFlash(w, r, "Video links must start with http:// or https://")
+	default:
+		logrus.WithError(err).Errorf("couldn't file protest")
+		AddErrorFlash(w, r, "Couldn't file protest")
+	}
+
+	http.Redirect(w, r, "/results/"+fileName+"/protest", http.StatusFound)
+}
+
+type protestViewTemplateVars struct {
+	BaseTemplateVars
+
+	Protest      *Protest
+	CanAppeal    bool
+	PenaltyTypes []ProtestPenaltyType
+}
+
+func (ph *ProtestsHandler) view(w http.ResponseWriter, r *http.Request) {
+	protest, err := ph.protestManager.FindProtest(chi.URLParam(r, "protestID"))
+
+	if err == ErrProtestNotFound {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		logrus.WithError(err).Errorf("couldn't load protest")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	account := AccountFromRequest(r)
+
+	if !protest.CanView(account) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	ph.viewRenderer.MustLoadTemplate(w, r, "protests/view.html", &protestViewTemplateVars{
+		Protest:      protest,
+		CanAppeal:    protest.CanAppeal(account),
+		PenaltyTypes: ProtestPenaltyTypes,
+	})
+}
+
+func (ph *ProtestsHandler) appeal(w http.ResponseWriter, r *http.Request) {
+	protestID := chi.URLParam(r, "protestID")
+
+	if err := r.ParseForm(); err != nil {
+		logrus.WithError(err).Errorf("couldn't parse appeal form")
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	_, err := ph.protestManager.Appeal(protestID, AccountFromRequest(r), r.FormValue("Reason"))
+
+	switch err {
+	case nil:
+		AddFlash(w, r, "Your appeal has been filed. The stewards will review the protest again.")
+	case ErrProtestNotFound:
+		http.NotFound(w, r)
+		return
+	case ErrProtestCannotAppeal:
+		AddErrorFlash(w, r, "This protest can't be appealed. Each protest can only be appealed once, by one of the drivers involved.")
+	case ErrProtestNoDescription:
+		AddErrorFlash(w, r, "Please give a reason for your appeal")
+	default:
+		logrus.WithError(err).Errorf("couldn't appeal protest")
+		AddErrorFlash(w, r, "Couldn't appeal protest")
+	}
+
+	http.Redirect(w, r, "/protests/"+protestID, http.StatusFound)
+}
+
+func (ph *ProtestsHandler) decide(w http.ResponseWriter, r *http.Request) {
+	protestID := chi.URLParam(r, "protestID")
+
+	if err := r.ParseForm(); err != nil {
+		logrus.WithError(err).Errorf("couldn't parse protest decision form")
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	penalty := ProtestPenalty{
+		Type: ProtestPenaltyType(r.FormValue("PenaltyType")),
+	}
+
+	penalty.Seconds, _ = strconv.ParseFloat(r.FormValue("PenaltySeconds"), 64)
+	penalty.Points, _ = strconv.Atoi(r.FormValue("PenaltyPoints"))
+
+	protest, err := ph.protestManager.Decide(protestID, AccountFromRequest(r), ProtestStatus(r.FormValue("Status")), penalty, r.FormValue("Notes"))
+
+	switch err {
+	case nil:
+		AddFlash(w, r, fmt.Sprintf("Protest %s (%s)", strings.ToLower(string(protest.Status)), protest.Penalty))
+	case ErrProtestNotFound:
+		http.NotFound(w, r)
+		return
+	case ErrProtestNotOpen:
+		AddErrorFlash(w, r, "A decision has already been made for this protest")
+	case ErrProtestInvalidDecision:
+		AddErrorFlash(w, r, "Please choose a decision, and enter the penalty time or points. Championship point penalties can only be given in Championship sessions.")
+	default:
+		logrus.WithError(err).Errorf("couldn't decide protest")
+		AddErrorFlash(w, r, "Couldn't apply the decision. Please check the results file still exists.")
+	}
+
+	http.Redirect(w, r, "/protests/"+protestID, http.StatusFound)
+}
@@ -0,0 +1,168 @@
+package servermanager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestProtestManager(t *testing.T) {
+	const (
+		sessionFile   = "2019_3_2_17_27_PRACTICE"
+		protesterGUID = "76561198029578060"
+		accusedGUID   = "76561198023931313"
+	)
+
+	installPath, err := ioutil.TempDir("", "protests")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(installPath)
+
+	data, err := ioutil.ReadFile(filepath.Join("cmd", "server-manager", "assetto", "results", sessionFile+".json"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.MkdirAll(filepath.Join(installPath, "results"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(installPath, "results", sessionFile+".json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	oldInstallPath := ServerInstallPath
+	ServerInstallPath = installPath
+
+	defer func() {
+		ServerInstallPath = oldInstallPath
+	}()
+
+	protestManager := NewProtestManager(testStore, NewPenaltiesManager(testStore), nil)
+
+	protester := &Account{Name: "protester", GUID: protesterGUID}
+	steward := &Account{Name: "steward"}
+
+	t.Run("Only drivers in the session can protest", func(t *testing.T) {
+		_, err := protestManager.FileProtest(&Account{GUID: "123"}, sessionFile, accusedGUID, 1, "Divebomb", "")
+
+		if err != ErrProtestNotParticipant {
+			t.Errorf("Expected ErrProtestNotParticipant, got: %v", err)
+		}
+	})
+
+	t.Run("Drivers cannot protest themselves", func(t *testing.T) {
+		_, err := protestManager.FileProtest(protester, sessionFile, protesterGUID, 1, "Divebomb", "")
+
+		if err != ErrProtestInvalidAccused {
+			t.Errorf("Expected ErrProtestInvalidAccused, got: %v", err)
+		}
+	})
+
+	t.Run("Video links must be URLs", func(t *testing.T) {
+		_, err := protestManager.FileProtest(protester, sessionFile, accusedGUID, 1, "Divebomb", "javascript:alert(1)")
+
+		if err != ErrProtestInvalidVideo {
+			t.Errorf("Expected ErrProtestInvalidVideo, got: %v", err)
+		}
+	})
+
+	protest, err := protestManager.FileProtest(protester, sessionFile, accusedGUID, 3, "Divebomb into turn 1", "https://example.com/video")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	accusedPenalty := func() time.Duration {
+		result, err := protestManager.accusedResult(protest)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return result.PenaltyTime
+	}
+
+	t.Run("Upheld protests apply the penalty", func(t *testing.T) {
+		protest, err := protestManager.Decide(protest.ID.String(), steward, ProtestStatusUpheld, ProtestPenalty{Type: ProtestPenaltyTime, Seconds: 5}, "")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if penalty := accusedPenalty(); penalty != 5*time.Second {
+			t.Errorf("Expected a 5s penalty, got: %s", penalty)
+		}
+
+		if _, err := protestManager.Decide(protest.ID.String(), steward, ProtestStatusDismissed, ProtestPenalty{}, ""); err != ErrProtestNotOpen {
+			t.Errorf("Expected ErrProtestNotOpen, got: %v", err)
+		}
+	})
+
+	t.Run("Protests can be appealed once", func(t *testing.T) {
+		if _, err := protestManager.Appeal(protest.ID.String(), steward, "Not involved"); err != ErrProtestCannotAppeal {
+			t.Errorf("Expected ErrProtestCannotAppeal, got: %v", err)
+		}
+
+		if _, err := protestManager.Appeal(protest.ID.String(), &Account{GUID: accusedGUID}, "It was a racing incident"); err != nil {
+			t.Fatal(err)
+		}
+
+		protest, err := protestManager.Decide(protest.ID.String(), steward, ProtestStatusDismissed, ProtestPenalty{}, "Racing incident")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if penalty := accusedPenalty(); penalty != 0 {
+			t.Errorf("Expected the penalty to be removed, got: %s", penalty)
+		}
+
+		if len(protest.History) != 4 {
+			t.Errorf("Expected 4 history entries, got %d", len(protest.History))
+		}
+
+		if _, err := protestManager.Appeal(protest.ID.String(), protester, "Again"); err != ErrProtestCannotAppeal {
+			t.Errorf("Expected ErrProtestCannotAppeal, got: %v", err)
+		}
+	})
+	t.Run("Protests filed at the same time are all saved", func(t *testing.T) {
+		const numProtests = 10
+
+		var wg sync.WaitGroup
+		filed := make(chan *Protest, numProtests)
+
+		for i := 0; i < numProtests; i++ {
+			wg.Add(1)
+
+			go func(lap int) {
+				defer wg.Done()
+
+				protest, err := protestManager.FileProtest(protester, sessionFile, accusedGUID, lap, "Blocking", "")
+
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				filed <- protest
+			}(i)
+		}
+
+		wg.Wait()
+		close(filed)
+
+		for protest := range filed {
+			if _, err := protestManager.FindProtest(protest.ID.String()); err != nil {
+				t.Errorf("Expected protest for lap %d to be saved, got: %v", protest.Lap, err)
+			}
+		}
+	})
+}
@@ -21,6 +21,7 @@ type RaceControl struct {
 	process          ServerProcess
 	store            Store
 	penaltiesManager *PenaltiesManager
+	blocklistManager *BlocklistManager
 
 	SessionInfo                udp.SessionInfo `json:"SessionInfo"`
 	TrackMapData               TrackMapData    `json:"TrackMapData"`
@@ -51,9 +52,20 @@ type RaceControl struct {
 	persistStoreDataMutex sync.Mutex
 
 	// driver swap
-	driverSwapTimers         map[int]*time.Timer
-	driverSwapPenaltiesMutex sync.Mutex
-	driverSwapPenalties      map[udp.DriverGUID]*driverSwapPenalty
+	driverSwapTimers map[int]*time.Timer
+
+	// sessionPenalties are applied to the results file at the end of the session
+	sessionPenaltiesMutex sync.Mutex
+	sessionPenalties      map[udp.DriverGUID]*sessionPenalty
+
+	// chat commands
+	scheduledRacesManager *ScheduledRacesManager
+	voteKicks             map[udp.DriverGUID]*voteKick
+	voteKicksMutex        sync.Mutex
+
+	// stewarding
+	stewardIncidents      []*StewardIncident
+	stewardIncidentsMutex sync.Mutex
 }
 
 // RaceControl piggyback's on the udp.Message interface so that the entire data can be sent to newly connected clients.
@@ -77,24 +89,36 @@ type Collision struct {
 	Speed           float64        `json:"Speed"`
 }
 
-func NewRaceControl(broadcaster Broadcaster, trackDataGateway TrackDataGateway, process ServerProcess, store Store, penaltiesManager *PenaltiesManager) *RaceControl {
+func NewRaceControl(broadcaster Broadcaster, trackDataGateway TrackDataGateway, process ServerProcess, store Store, penaltiesManager *PenaltiesManager, blocklistManager *BlocklistManager) *RaceControl {
+	rc := newRaceControl(broadcaster, trackDataGateway, process, store, penaltiesManager, blocklistManager)
+
+	go panicCapture(rc.watchForTimedOutDrivers)
+
+	return rc
+}
+
+// newRaceControl creates a RaceControl which doesn't disconnect timed out drivers. Session replays use it directly, as
+// pausing a replay would otherwise disconnect every driver.
+func newRaceControl(broadcaster Broadcaster, trackDataGateway TrackDataGateway, process ServerProcess, store Store, penaltiesManager *PenaltiesManager, blocklistManager *BlocklistManager) *RaceControl {
 	rc := &RaceControl{
-		broadcaster:          broadcaster,
-		trackDataGateway:     trackDataGateway,
-		process:              process,
-		store:                store,
-		driverSwapTimers:     make(map[int]*time.Timer),
-		penaltiesManager:     penaltiesManager,
-		carUpdaters:          make(map[udp.CarID]chan udp.CarUpdate),
-		serverProcessStopped: make(chan struct{}),
+		broadcaster:           broadcaster,
+		trackDataGateway:      trackDataGateway,
+		process:               process,
+		store:                 store,
+		driverSwapTimers:      make(map[int]*time.Timer),
+		sessionPenalties:      make(map[udp.DriverGUID]*sessionPenalty),
+		penaltiesManager:      penaltiesManager,
+		blocklistManager:      blocklistManager,
+		scheduledRacesManager: NewScheduledRacesManager(store),
+		voteKicks:             make(map[udp.DriverGUID]*voteKick),
+		carUpdaters:           make(map[udp.CarID]chan udp.CarUpdate),
+		serverProcessStopped:  make(chan struct{}),
 	}
 
 	process.NotifyDone(rc.serverProcessStopped)
 
 	rc.clearAllDrivers()
 
-	go panicCapture(rc.watchForTimedOutDrivers)
-
 	return rc
 }
 
@@ -151,6 +175,10 @@ func (rc *RaceControl) UDPCallback(message udp.Message) {
 		if err == nil {
 			m.DriverGUID = driver.CarInfo.DriverGUID
 			m.DriverName = driver.CarInfo.DriverName
+
+			if rc.handleChatCommand(driver, m.Message) {
+				return
+			}
 		} else if m.DriverGUID == "" && m.DriverName == "" {
 			m.DriverGUID = "0"
 			m.DriverName = "Server"
@@ -265,6 +293,15 @@ func (rc *RaceControl) OnCarUpdate(update udp.CarUpdate) error {
 	return nil
 }
 
+// stopCarUpdaters stops the goroutines which handle car updates. It must only be called once no more UDP messages
+// will be sent to RaceControl.
+func (rc *RaceControl) stopCarUpdaters() {
+	for carID, ch := range rc.carUpdaters {
+		close(ch)
+		delete(rc.carUpdaters, carID)
+	}
+}
+
 func (rc *RaceControl) handleCarUpdate(update udp.CarUpdate) error {
 	driver, err := rc.findConnectedDriverByCarID(update.CarID)
 
@@ -302,9 +339,13 @@ func (rc *RaceControl) OnNewSession(sessionInfo udp.SessionInfo) error {
 
 	emptyCarInfo := true
 
-	rc.driverSwapPenaltiesMutex.Lock()
-	rc.driverSwapPenalties = make(map[udp.DriverGUID]*driverSwapPenalty)
-	rc.driverSwapPenaltiesMutex.Unlock()
+	rc.sessionPenaltiesMutex.Lock()
+	rc.sessionPenalties = make(map[udp.DriverGUID]*sessionPenalty)
+	rc.sessionPenaltiesMutex.Unlock()
+
+	rc.stewardIncidentsMutex.Lock()
+	rc.stewardIncidents = nil
+	rc.stewardIncidentsMutex.Unlock()
 
 	if (rc.ConnectedDrivers.Len() > 0 || rc.DisconnectedDrivers.Len() > 0) && sessionInfo.Type == udp.SessionTypePractice {
 		if oldSessionInfo.Type == sessionInfo.Type && oldSessionInfo.Track == sessionInfo.Track && oldSessionInfo.TrackConfig == sessionInfo.TrackConfig && oldSessionInfo.Name == sessionInfo.Name {
@@ -481,6 +522,8 @@ func (rc *RaceControl) OnEndSession(sessionFile udp.EndSession) error {
 	filename := filepath.Base(string(sessionFile))
 	logrus.Infof("End Session, file outputted at: %s", filename)
 
+	indexResultsFile(filename)
+
 	config := rc.process.Event().GetRaceConfig()
 
 	if config.DriverSwapEnabled == 1 {
@@ -502,8 +545,7 @@ func (rc *RaceControl) OnEndSession(sessionFile udp.EndSession) error {
 			return nil
 		})
 
-		rc.driverSwapPenaltiesMutex.Lock()
-		defer rc.driverSwapPenaltiesMutex.Unlock()
+		rc.sessionPenaltiesMutex.Lock()
 
 		if config.DriverSwapMinimumNumberOfSwaps > 0 {
 			results, err := LoadResult(filename, LoadResultWithoutPluginFire)
@@ -518,10 +560,10 @@ func (rc *RaceControl) OnEndSession(sessionFile udp.EndSession) error {
 						guid := udp.DriverGUID(result.DriverGUID)
 						penaltyTime := time.Duration((config.DriverSwapMinimumNumberOfSwaps-numSwaps)*config.DriverSwapNotEnoughSwapsPenalty) * time.Second
 
-						if _, ok := rc.driverSwapPenalties[guid]; ok {
-							rc.driverSwapPenalties[guid].penalty += penaltyTime
+						if _, ok := rc.sessionPenalties[guid]; ok {
+							rc.sessionPenalties[guid].penalty += penaltyTime
 						} else {
-							rc.driverSwapPenalties[guid] = &driverSwapPenalty{
+							rc.sessionPenalties[guid] = &sessionPenalty{
 								carModel: result.CarModel,
 								penalty:  penaltyTime,
 							}
@@ -531,16 +573,34 @@ func (rc *RaceControl) OnEndSession(sessionFile udp.EndSession) error {
 			}
 		}
 
-		for guid, penalty := range rc.driverSwapPenalties {
-			err := rc.penaltiesManager.applyPenalty(filename, string(guid), penalty.carModel, penalty.penalty.Seconds(), true)
+		rc.sessionPenaltiesMutex.Unlock()
+	}
 
-			if err != nil {
-				logrus.WithError(err).Errorf("could not apply driver swap penalty of %s to driver %s", penalty.penalty.String(), guid)
-				continue
-			}
+	rc.sessionPenaltiesMutex.Lock()
+
+	for guid, penalty := range rc.sessionPenalties {
+		penaltySeconds := penalty.penalty.Seconds()
+
+		if penalty.disqualified {
+			// a penalty of zero disqualifies the driver
+			penaltySeconds = 0
+		} else if penaltySeconds <= 0 {
+			continue
+		}
+
+		err := rc.penaltiesManager.applyPenalty(filename, string(guid), penalty.carModel, penaltySeconds, true)
+
+		if err != nil {
+			logrus.WithError(err).Errorf("could not apply session penalty of %s to driver %s", penalty.penalty.String(), guid)
+			continue
 		}
 	}
 
+	rc.sessionPenalties = make(map[udp.DriverGUID]*sessionPenalty)
+	rc.sessionPenaltiesMutex.Unlock()
+
+	rc.recordStewardDecisions(filename)
+
 	if rc.currentTimeAttackEvent != nil && Premium() {
 		filename := filepath.Base(string(sessionFile))
 
@@ -691,9 +751,10 @@ func (rc *RaceControl) OnClientDisconnect(client udp.SessionCarInfo) error {
 	return err
 }
 
-type driverSwapPenalty struct {
-	penalty  time.Duration
-	carModel string
+type sessionPenalty struct {
+	penalty      time.Duration
+	carModel     string
+	disqualified bool
 }
 
 func (rc *RaceControl) handleDriverSwap(ticker *time.Ticker, config CurrentRaceConfig, client udp.SessionCarInfo, driver *RaceControlDriver) {
@@ -848,18 +909,18 @@ func (rc *RaceControl) handleDriverSwap(ticker *time.Ticker, config CurrentRaceC
 						currentDriver.LastPos = udp.Vec{X: 0, Y: 0, Z: 0}
 					} else if countdown >= (time.Second * time.Duration(config.DriverSwapPenaltyTime)) {
 
-						rc.driverSwapPenaltiesMutex.Lock()
+						rc.sessionPenaltiesMutex.Lock()
 						{
-							if _, ok := rc.driverSwapPenalties[currentDriver.CarInfo.DriverGUID]; ok {
-								rc.driverSwapPenalties[currentDriver.CarInfo.DriverGUID].penalty += countdown + (time.Second * 5)
+							if _, ok := rc.sessionPenalties[currentDriver.CarInfo.DriverGUID]; ok {
+								rc.sessionPenalties[currentDriver.CarInfo.DriverGUID].penalty += countdown + (time.Second * 5)
 							} else {
-								rc.driverSwapPenalties[currentDriver.CarInfo.DriverGUID] = &driverSwapPenalty{
+								rc.sessionPenalties[currentDriver.CarInfo.DriverGUID] = &sessionPenalty{
 									penalty:  countdown + (time.Second * 5),
 									carModel: currentDriver.CarInfo.CarModel,
 								}
 							}
 						}
-						rc.driverSwapPenaltiesMutex.Unlock()
+						rc.sessionPenaltiesMutex.Unlock()
 
 						sendChat, err := udp.NewSendChat(
 							currentDriver.CarInfo.CarID,
@@ -1006,17 +1067,20 @@ func (rc *RaceControl) OnClientLoaded(loadedCar udp.ClientLoaded) error {
 	return err
 }
 
-func (rc *RaceControl) sendChampionshipPlayerSummaryMessage(driver *RaceControlDriver) error {
-	var championshipID uuid.UUID
-
+// currentChampionshipID is the ID of the championship that the running event is part of, if there is one.
+func (rc *RaceControl) currentChampionshipID() uuid.UUID {
 	if championship, ok := rc.process.Event().(*ActiveChampionship); ok {
-		championshipID = championship.ChampionshipID
+		return championship.ChampionshipID
 	} else if raceWeekend, ok := rc.process.Event().(*ActiveRaceWeekend); ok {
-		championshipID = raceWeekend.ChampionshipID
-	} else {
-		return nil
+		return raceWeekend.ChampionshipID
 	}
 
+	return uuid.Nil
+}
+
+func (rc *RaceControl) sendChampionshipPlayerSummaryMessage(driver *RaceControlDriver) error {
+	championshipID := rc.currentChampionshipID()
+
 	if championshipID == uuid.Nil {
 		return nil
 	}
@@ -1099,6 +1163,8 @@ func (rc *RaceControl) OnLapCompleted(lap udp.LapCompleted) error {
 
 	currentCar.TopSpeedThisLap = 0
 
+	rc.recordTrackLimitsIncident(driver, int(lap.Cuts))
+
 	rc.ConnectedDrivers.sort()
 
 	if rc.SessionInfo.Type == udp.SessionTypeRace {
@@ -1268,6 +1334,8 @@ func (rc *RaceControl) OnCollisionWithCar(collision udp.CollisionWithCar) error
 	if err == nil {
 		c.OtherDriverGUID = otherDriver.CarInfo.DriverGUID
 		c.OtherDriverName = otherDriver.CarInfo.DriverName
+
+		rc.recordCollisionIncident(driver, otherDriver, c.Speed, collision.WorldPos)
 	}
 
 	driver.Collisions = append(driver.Collisions, c)
@@ -1421,6 +1489,23 @@ func (rc *RaceControl) splitAndBroadcastChat(message string, account *Account) e
 	return nil
 }
 
+// KickDriver kicks the connected driver with the given GUID from the server.
+func (rc *RaceControl) KickDriver(guid string) error {
+	return rc.ConnectedDrivers.Each(func(driverGUID udp.DriverGUID, driver *RaceControlDriver) error {
+		if string(driverGUID) != guid {
+			return nil
+		}
+
+		command, err := udp.NewAdminCommand("/kick " + driver.CarInfo.DriverName)
+
+		if err != nil {
+			return err
+		}
+
+		return rc.process.SendUDPMessage(command)
+	})
+}
+
 func (rc *RaceControl) splitAndSendChat(message, guid string) error {
 	var carID uint8
 
@@ -142,16 +142,19 @@ type RaceControlHandler struct {
 	raceManager    *RaceManager
 	raceControl    *RaceControl
 	raceControlHub *RaceControlHub
+
+	blocklistManager *BlocklistManager
 }
 
-func NewRaceControlHandler(baseHandler *BaseHandler, store Store, raceManager *RaceManager, raceControl *RaceControl, raceControlHub *RaceControlHub, serverProcess ServerProcess) *RaceControlHandler {
+func NewRaceControlHandler(baseHandler *BaseHandler, store Store, raceManager *RaceManager, raceControl *RaceControl, raceControlHub *RaceControlHub, serverProcess ServerProcess, blocklistManager *BlocklistManager) *RaceControlHandler {
 	return &RaceControlHandler{
-		BaseHandler:    baseHandler,
-		store:          store,
-		raceManager:    raceManager,
-		raceControl:    raceControl,
-		raceControlHub: raceControlHub,
-		serverProcess:  serverProcess,
+		BaseHandler:      baseHandler,
+		store:            store,
+		raceManager:      raceManager,
+		raceControl:      raceControl,
+		raceControlHub:   raceControlHub,
+		serverProcess:    serverProcess,
+		blocklistManager: blocklistManager,
 	}
 }
 
@@ -167,6 +170,7 @@ type liveTimingTemplateVars struct {
 	IsKissMyRankEnabled         bool
 	KissMyRankWebStatsPublicURL string
 	STrackerInterfacePublicURL  string
+	ActiveBans                  []*BlocklistEntry
 }
 
 func (rch *RaceControlHandler) liveTiming(w http.ResponseWriter, r *http.Request) {
@@ -228,6 +232,16 @@ func (rch *RaceControlHandler) liveTiming(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	var activeBans []*BlocklistEntry
+
+	if AccountFromRequest(r).HasGroupPrivilege(GroupAdmin) {
+		activeBans, err = rch.blocklistManager.ActiveBans()
+
+		if err != nil {
+			logrus.WithError(err).Errorf("couldn't load active bans")
+		}
+	}
+
 	rch.viewRenderer.MustLoadTemplate(w, r, "live-timing.html", &liveTimingTemplateVars{
 		BaseTemplateVars: BaseTemplateVars{
 			WideContainer: true,
@@ -241,6 +255,7 @@ func (rch *RaceControlHandler) liveTiming(w http.ResponseWriter, r *http.Request
 		IsKissMyRankEnabled:         IsKissMyRankInstalled() && kissMyRankOptions.EnableKissMyRank,
 		KissMyRankWebStatsPublicURL: kissMyRankOptions.WebStatsPublicURL,
 		STrackerInterfacePublicURL:  sTrackerPublicURL,
+		ActiveBans:                  activeBans,
 	})
 }
 
@@ -348,21 +363,7 @@ func (rch *RaceControlHandler) kickUser(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err := rch.raceControl.ConnectedDrivers.Each(func(driverGUID udp.DriverGUID, driver *RaceControlDriver) error {
-		if string(driverGUID) != guid {
-			return nil
-		}
-
-		command, err := udp.NewAdminCommand("/kick " + driver.CarInfo.DriverName)
-
-		if err != nil {
-			return err
-		}
-
-		return rch.serverProcess.SendUDPMessage(command)
-	})
-
-	if err != nil {
+	if err := rch.raceControl.KickDriver(guid); err != nil {
 		logrus.WithError(err).Errorf("Unable to send kick command")
 	}
 }
@@ -32,6 +32,7 @@ type RaceManager struct {
 	trackManager        *TrackManager
 	raceControl         *RaceControl
 	notificationManager NotificationDispatcher
+	blocklistManager    *BlocklistManager
 
 	currentRace      *ServerConfig
 	currentEntryList EntryList
@@ -56,6 +57,7 @@ func NewRaceManager(
 	trackManager *TrackManager,
 	notificationManager NotificationDispatcher,
 	raceControl *RaceControl,
+	blocklistManager *BlocklistManager,
 ) *RaceManager {
 	return &RaceManager{
 		store:                    store,
@@ -64,6 +66,7 @@ func NewRaceManager(
 		trackManager:             trackManager,
 		notificationManager:      notificationManager,
 		raceControl:              raceControl,
+		blocklistManager:         blocklistManager,
 		customRaceStartTimers:    make(map[string]*when.Timer),
 		customRaceReminderTimers: make(map[string]*when.Timer),
 	}
@@ -249,6 +252,11 @@ func (rm *RaceManager) applyConfigAndStart(event RaceEvent) error {
 		return err
 	}
 
+	// make sure that expired bans are removed from (and new bans are added to) the blacklist before the server reads it
+	if err := rm.blocklistManager.SyncBlocklistFile(); err != nil {
+		logrus.WithError(err).Errorf("Could not sync %s", BlocklistFilename)
+	}
+
 	numEntrantsWithAnyCar := 0
 
 	for _, entrant := range entryList {
@@ -504,6 +512,23 @@ func formValueAsFloat(val string) float64 {
 	return i
 }
 
+// formValueAsIntSlice parses a comma separated list of numbers, e.g. "3, 2, 1".
+func formValueAsIntSlice(val string) []int {
+	var out []int
+
+	for _, part := range strings.Split(val, ",") {
+		part = strings.TrimSpace(part)
+
+		if part == "" {
+			continue
+		}
+
+		out = append(out, formValueAsInt(part))
+	}
+
+	return out
+}
+
 func (rm *RaceManager) BuildEntryList(r *http.Request, start, length int) (EntryList, error) {
 	entryList := EntryList{}
 
@@ -985,6 +1010,7 @@ type RaceTemplateVars struct {
 	Championship                   *Championship
 	ChampionshipHasAtLeastOnceRace bool
 	ChampionshipEventIndex         int
+	ChampionshipEventGridRules     ChampionshipGridRules
 
 	IsRaceWeekend                   bool
 	RaceWeekend                     *RaceWeekend
@@ -1336,6 +1362,8 @@ func (rm *RaceManager) ScheduleRace(uuid string, date time.Time, action string,
 			return err
 		}
 
+		rm.notificationManager.SendEventScheduledWebhook(customRaceWebhookDetails(race, race.Scheduled))
+
 		if rm.notificationManager.HasNotificationReminders() {
 			_ = rm.notificationManager.SendRaceScheduledMessage(race, race.Scheduled)
 
@@ -1353,6 +1381,7 @@ func (rm *RaceManager) ScheduleRace(uuid string, date time.Time, action string,
 		}
 
 	} else {
+		rm.notificationManager.SendEventCancelledWebhook(customRaceWebhookDetails(race, originalDate))
 		_ = rm.notificationManager.SendRaceCancelledMessage(race, originalDate)
 		race.ClearRecurrenceRule()
 	}
@@ -1378,6 +1407,28 @@ func eventSchedulePlugin(race *CustomRace) error {
 }
 
 func (rm *RaceManager) StartScheduledRace(race *CustomRace) error {
+	if validationErr := rm.validateScheduledEvent(race); validationErr != nil {
+		// the race won't be started, but it still needs rescheduling (or unscheduling)
+		storedRace, err := rm.store.FindCustomRaceByID(race.UUID.String())
+
+		if err != nil {
+			return err
+		}
+
+		if storedRace.HasRecurrenceRule() {
+			err = rm.ScheduleNextFromRecurrence(storedRace)
+		} else {
+			storedRace.Scheduled = time.Time{}
+			err = rm.store.UpsertCustomRace(storedRace)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		return validationErr
+	}
+
 	startedRace, err := rm.StartCustomRace(race.UUID.String(), false)
 
 	if err != nil {
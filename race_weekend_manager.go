@@ -468,6 +468,8 @@ func (rwm *RaceWeekendManager) SaveRaceWeekendSession(r *http.Request) (raceWeek
 			}
 
 			pts.BestLap = formValueAsInt(r.Form["Points.BestLap"][i])
+			pts.BestLapMaxPosition = formValueAsInt(r.Form["Points.BestLapMaxPosition"][i])
+			pts.MinimumRaceDistance = formValueAsInt(r.Form["Points.MinimumRaceDistance"][i])
 			pts.CollisionWithDriver = formValueAsInt(r.Form["Points.CollisionWithDriver"][i])
 			pts.CollisionWithEnv = formValueAsInt(r.Form["Points.CollisionWithEnv"][i])
 			pts.CutTrack = formValueAsInt(r.Form["Points.CutTrack"][i])
@@ -525,47 +527,12 @@ func (rwm *RaceWeekendManager) StartSession(raceWeekendID string, raceWeekendSes
 		}
 	}
 
-	raceWeekendEntryList, err := session.GetRaceWeekendEntryList(raceWeekend, nil, "")
+	entryList, err := rwm.sessionEntryList(raceWeekend, session, isPracticeSession)
 
 	if err != nil {
 		return err
 	}
 
-	entryList := raceWeekendEntryList.AsEntryList()
-
-	if isPracticeSession && !raceWeekend.SessionCanBeRun(session) {
-		// practice sessions run with the whole race weekend entry list if they are not yet available
-		entryList = raceWeekend.GetEntryList()
-	}
-
-	for k, entrant := range entryList {
-		if entrant.IsPlaceHolder {
-			// placeholder entrants should not be added to our final entry list
-			delete(entryList, k)
-			continue
-		}
-
-		// look through the user configured entry list and apply any of the options that they set to this entrant.
-		for _, raceWeekendEntrant := range raceWeekend.GetEntryList() {
-			if raceWeekendEntrant.GUID == entrant.GUID {
-				entrant.Model = raceWeekendEntrant.Model
-				entrant.Ballast = raceWeekendEntrant.Ballast
-				entrant.Restrictor = raceWeekendEntrant.Restrictor
-				if entrant.FixedSetup == "" {
-					entrant.FixedSetup = raceWeekendEntrant.FixedSetup
-				}
-				entrant.Skin = raceWeekendEntrant.Skin
-				break
-			}
-		}
-	}
-
-	if raceWeekend.HasSpectatorCar() {
-		car := raceWeekend.GetSpectatorCar()
-
-		entryList.AddInPitBox(&car, maxEntryListSize+1)
-	}
-
 	session.RaceConfig.MaxClients = len(entryList)
 	session.RaceConfig.Cars = strings.Join(entryList.CarIDs(), ";")
 	session.RaceConfig.LockedEntryList = 1
@@ -625,6 +592,52 @@ func (rwm *RaceWeekendManager) StartSession(raceWeekendID string, raceWeekendSes
 	return rwm.applyConfigAndStart(raceWeekendRaceEvent)
 }
 
+// sessionEntryList builds the entry list that a Race Weekend session is started with.
+func (rwm *RaceWeekendManager) sessionEntryList(raceWeekend *RaceWeekend, session *RaceWeekendSession, isPracticeSession bool) (EntryList, error) {
+	raceWeekendEntryList, err := session.GetRaceWeekendEntryList(raceWeekend, nil, "")
+
+	if err != nil {
+		return nil, err
+	}
+
+	entryList := raceWeekendEntryList.AsEntryList()
+
+	if isPracticeSession && !raceWeekend.SessionCanBeRun(session) {
+		// practice sessions run with the whole race weekend entry list if they are not yet available
+		entryList = raceWeekend.GetEntryList()
+	}
+
+	for k, entrant := range entryList {
+		if entrant.IsPlaceHolder {
+			// placeholder entrants should not be added to our final entry list
+			delete(entryList, k)
+			continue
+		}
+
+		// look through the user configured entry list and apply any of the options that they set to this entrant.
+		for _, raceWeekendEntrant := range raceWeekend.GetEntryList() {
+			if raceWeekendEntrant.GUID == entrant.GUID {
+				entrant.Model = raceWeekendEntrant.Model
+				entrant.Ballast = raceWeekendEntrant.Ballast
+				entrant.Restrictor = raceWeekendEntrant.Restrictor
+				if entrant.FixedSetup == "" {
+					entrant.FixedSetup = raceWeekendEntrant.FixedSetup
+				}
+				entrant.Skin = raceWeekendEntrant.Skin
+				break
+			}
+		}
+	}
+
+	if raceWeekend.HasSpectatorCar() {
+		car := raceWeekend.GetSpectatorCar()
+
+		entryList.AddInPitBox(&car, maxEntryListSize+1)
+	}
+
+	return entryList, nil
+}
+
 func (rwm *RaceWeekendManager) UDPCallback(message udp.Message) {
 	rwm.mutex.Lock()
 	defer rwm.mutex.Unlock()
@@ -874,7 +887,11 @@ func (rwm *RaceWeekendManager) ImportSession(raceWeekendID string, raceWeekendSe
 }
 
 func (rwm *RaceWeekendManager) ListAvailableResultsFilesForSorting(raceWeekend *RaceWeekend, session *RaceWeekendSession) ([]SessionResults, error) {
-	results, err := ListAllResults()
+	results, _, err := FindResults(ResultsQuery{
+		Track:            session.RaceConfig.Track,
+		TrackLayout:      session.RaceConfig.TrackLayout,
+		MatchTrackLayout: true,
+	}, LoadResultWithoutPluginFire)
 
 	if err != nil {
 		return nil, err
@@ -897,7 +914,7 @@ func (rwm *RaceWeekendManager) ListAvailableResultsFilesForSorting(raceWeekend *
 			}
 		}
 
-		if result.TrackName == session.RaceConfig.Track && result.TrackConfig == session.RaceConfig.TrackLayout && found {
+		if found {
 			filteredResults = append(filteredResults, result)
 		}
 	}
@@ -918,21 +935,17 @@ func (rwm *RaceWeekendManager) ListAvailableResultsFilesForSession(raceWeekendID
 		return nil, nil, err
 	}
 
-	results, err := ListAllResults()
+	results, _, err := FindResults(ResultsQuery{
+		Track:            session.RaceConfig.Track,
+		TrackLayout:      session.RaceConfig.TrackLayout,
+		MatchTrackLayout: true,
+	}, LoadResultWithoutPluginFire)
 
 	if err != nil {
 		return nil, nil, err
 	}
 
-	var filteredResults []SessionResults
-
-	for _, result := range results {
-		if result.TrackName == session.RaceConfig.Track && result.TrackConfig == session.RaceConfig.TrackLayout {
-			filteredResults = append(filteredResults, result)
-		}
-	}
-
-	return session, filteredResults, nil
+	return session, results, nil
 }
 
 func (rwm *RaceWeekendManager) FindSession(raceWeekendID, sessionID string) (*RaceWeekend, *RaceWeekendSession, error) {
@@ -1197,24 +1210,9 @@ func (rwm *RaceWeekendManager) setupScheduledSessionTimer(raceWeekend *RaceWeeke
 	var err error
 
 	rwm.scheduledSessionTimers[session.ID.String()], err = when.When(session.ScheduledTime, func() {
-		err := rwm.StartSession(raceWeekend.ID.String(), session.ID.String(), false)
-
-		if err != nil {
+		if err := rwm.StartScheduledSession(raceWeekend.ID.String(), session.ID.String()); err != nil {
 			logrus.WithError(err).Errorf("Could not start scheduled race weekend session")
 		}
-
-		raceWeekend, session, err := rwm.FindSession(raceWeekend.ID.String(), session.ID.String())
-
-		if err != nil {
-			logrus.WithError(err).Error("Could not clear scheduled time on started Race Weekend Session")
-			return
-		}
-
-		session.ScheduledTime = time.Time{}
-
-		if err := rwm.UpsertRaceWeekend(raceWeekend); err != nil {
-			logrus.WithError(err).Error("Could not update race weekend with cleared scheduled time")
-		}
 	})
 
 	if err != nil {
@@ -1247,6 +1245,34 @@ func (rwm *RaceWeekendManager) setupScheduledSessionTimer(raceWeekend *RaceWeeke
 	return nil
 }
 
+// StartScheduledSession starts a race weekend session at its scheduled time. The schedule is cleared even if the
+// session fails validation and isn't started, so that it isn't shown as scheduled in the past.
+func (rwm *RaceWeekendManager) StartScheduledSession(raceWeekendID, sessionID string) error {
+	raceWeekend, session, err := rwm.FindSession(raceWeekendID, sessionID)
+
+	if err != nil {
+		return err
+	}
+
+	session.ScheduledTime = time.Time{}
+
+	if err := rwm.UpsertRaceWeekend(raceWeekend); err != nil {
+		return err
+	}
+
+	raceEvent, err := rwm.raceWeekendRaceEvent(raceWeekendID, sessionID)
+
+	if err != nil {
+		return err
+	}
+
+	if err := rwm.raceManager.validateScheduledEvent(raceEvent); err != nil {
+		return err
+	}
+
+	return rwm.StartSession(raceWeekendID, sessionID, false)
+}
+
 func (rwm *RaceWeekendManager) ScheduleSession(raceWeekendID, sessionID string, date time.Time, startWhenParentFinishes bool) error {
 	raceWeekend, session, err := rwm.FindSession(raceWeekendID, sessionID)
 
@@ -1254,6 +1280,8 @@ func (rwm *RaceWeekendManager) ScheduleSession(raceWeekendID, sessionID string,
 		return err
 	}
 
+	originalDate := session.ScheduledTime
+
 	session.ScheduledTime = date
 	session.StartWhenParentHasFinished = startWhenParentFinishes
 	session.ScheduledServerID = serverID
@@ -1272,6 +1300,11 @@ func (rwm *RaceWeekendManager) ScheduleSession(raceWeekendID, sessionID string,
 		if err != nil {
 			return err
 		}
+
+		rwm.notificationManager.SendEventScheduledWebhook(raceWeekendSessionWebhookDetails(raceWeekend, session, session.ScheduledTime))
+	} else if !originalDate.IsZero() {
+		rwm.clearScheduledSessionTimer(session)
+		rwm.notificationManager.SendEventCancelledWebhook(raceWeekendSessionWebhookDetails(raceWeekend, session, originalDate))
 	}
 
 	return rwm.UpsertRaceWeekend(raceWeekend)
@@ -1301,10 +1334,16 @@ func (rwm *RaceWeekendManager) DeScheduleSession(raceWeekendID, sessionID string
 		return err
 	}
 
+	originalDate := session.ScheduledTime
+
 	session.ScheduledTime = time.Time{}
 	session.StartWhenParentHasFinished = false
 
 	rwm.clearScheduledSessionTimer(session)
 
+	if !originalDate.IsZero() {
+		rwm.notificationManager.SendEventCancelledWebhook(raceWeekendSessionWebhookDetails(raceWeekend, session, originalDate))
+	}
+
 	return rwm.UpsertRaceWeekend(raceWeekend)
 }
@@ -0,0 +1,79 @@
+package servermanager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRaceWeekendManager_StartScheduledSessionValidationFailed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scheduled-race-weekend")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	oldInstallPath := ServerInstallPath
+	ServerInstallPath = dir
+
+	defer func() {
+		ServerInstallPath = oldInstallPath
+	}()
+
+	store := NewJSONStore(filepath.Join(dir, "store"), filepath.Join(dir, "shared"))
+
+	raceManager := NewRaceManager(
+		store,
+		dummyServerProcess{},
+		NewCarManager(NewTrackManager(), false, false),
+		NewTrackManager(),
+		&dummyNotificationManager{},
+		NewRaceControl(NilBroadcaster{}, nilTrackData{}, dummyServerProcess{}, store, NewPenaltiesManager(store), NewBlocklistManager(store)),
+		NewBlocklistManager(store),
+	)
+
+	rwm := NewRaceWeekendManager(
+		raceManager,
+		NewChampionshipManager(raceManager, &ACSRClient{Enabled: false}),
+		store,
+		dummyServerProcess{},
+		&dummyNotificationManager{},
+		&ACSRClient{Enabled: false},
+		NewCarManager(NewTrackManager(), false, false),
+	)
+
+	raceWeekend := NewRaceWeekend()
+	raceWeekend.Name = "Scheduled Race Weekend"
+	raceWeekend.EntryList = EntryList{}
+	raceWeekend.EntryList.AddToBackOfGrid(&Entrant{Name: "Driver 1", Model: "ks_mazda_mx5_cup"})
+
+	// the track isn't installed, so the session fails validation.
+	session := NewRaceWeekendSession()
+	session.RaceConfig = ConfigIniDefault().CurrentRaceConfig
+	session.RaceConfig.Track = "not_installed"
+	session.ScheduledTime = time.Now().Add(-time.Minute)
+
+	raceWeekend.AddSession(session, nil)
+
+	if err := store.UpsertRaceWeekend(raceWeekend); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rwm.StartScheduledSession(raceWeekend.ID.String(), session.ID.String()); err != ErrEventValidationFailed {
+		t.Fatalf("Expected ErrEventValidationFailed, got: %v", err)
+	}
+
+	_, session, err = rwm.FindSession(raceWeekend.ID.String(), session.ID.String())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !session.ScheduledTime.IsZero() {
+		t.Errorf("Expected the schedule to be cleared, got %s", session.ScheduledTime)
+	}
+}
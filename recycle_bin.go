@@ -0,0 +1,480 @@
+package servermanager
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const recycleBinPurgeInterval = time.Hour
+
+var (
+	ErrRecycleBinItemNotFound  = errors.New("servermanager: recycle bin item not found")
+	ErrRecycleBinInvalidType   = errors.New("servermanager: invalid recycle bin item type")
+	ErrRecycleBinCannotRestore = errors.New("servermanager: recycle bin item cannot be restored")
+	ErrRecycleBinAccountExists = errors.New("servermanager: an account with this name already exists")
+)
+
+type RecycleBinItemType string
+
+const (
+	RecycleBinItemCustomRace   RecycleBinItemType = "custom-race"
+	RecycleBinItemChampionship RecycleBinItemType = "championship"
+	RecycleBinItemRaceWeekend  RecycleBinItemType = "race-weekend"
+	RecycleBinItemAccount      RecycleBinItemType = "account"
+)
+
+// RecycleBinItem is a soft deleted Custom Race, Championship, Race Weekend or Account.
+type RecycleBinItem struct {
+	Type    RecycleBinItemType
+	ID      string
+	Name    string
+	Deleted time.Time
+
+	// CanRestore is false for Race Weekends which were deleted along with a Championship event, as the
+	// event they belonged to no longer exists.
+	CanRestore bool
+}
+
+func (i *RecycleBinItem) TypeName() string {
+	switch i.Type {
+	case RecycleBinItemCustomRace:
+		return "Custom Race"
+	case RecycleBinItemChampionship:
+		return "Championship"
+	case RecycleBinItemRaceWeekend:
+		return "Race Weekend"
+	case RecycleBinItemAccount:
+		return "Account"
+	default:
+		return string(i.Type)
+	}
+}
+
+// PurgeTime is the time at which the item will be permanently deleted, or the zero time if
+// deleted items are kept forever.
+func (i *RecycleBinItem) PurgeTime(retentionDays int) time.Time {
+	if retentionDays <= 0 {
+		return time.Time{}
+	}
+
+	return i.Deleted.AddDate(0, 0, retentionDays)
+}
+
+type RecycleBinManager struct {
+	store Store
+}
+
+func NewRecycleBinManager(store Store) *RecycleBinManager {
+	return &RecycleBinManager{
+		store: store,
+	}
+}
+
+// ListItems returns everything in the recycle bin, most recently deleted first.
+func (rbm *RecycleBinManager) ListItems() ([]*RecycleBinItem, error) {
+	var items []*RecycleBinItem
+
+	customRaces, err := rbm.store.ListDeletedCustomRaces()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, customRace := range customRaces {
+		items = append(items, &RecycleBinItem{
+			Type:       RecycleBinItemCustomRace,
+			ID:         customRace.UUID.String(),
+			Name:       customRace.Name,
+			Deleted:    customRace.Deleted,
+			CanRestore: true,
+		})
+	}
+
+	championships, err := rbm.store.ListDeletedChampionships()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, championship := range championships {
+		items = append(items, &RecycleBinItem{
+			Type:       RecycleBinItemChampionship,
+			ID:         championship.ID.String(),
+			Name:       championship.Name,
+			Deleted:    championship.Deleted,
+			CanRestore: true,
+		})
+	}
+
+	raceWeekends, err := rbm.store.ListDeletedRaceWeekends()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, raceWeekend := range raceWeekends {
+		items = append(items, &RecycleBinItem{
+			Type:       RecycleBinItemRaceWeekend,
+			ID:         raceWeekend.ID.String(),
+			Name:       raceWeekend.Name,
+			Deleted:    raceWeekend.Deleted,
+			CanRestore: !raceWeekend.HasLinkedChampionship(),
+		})
+	}
+
+	accounts, err := rbm.store.ListDeletedAccounts()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, account := range accounts {
+		items = append(items, &RecycleBinItem{
+			Type:       RecycleBinItemAccount,
+			ID:         account.ID.String(),
+			Name:       account.Name,
+			Deleted:    account.Deleted,
+			CanRestore: true,
+		})
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Deleted.After(items[j].Deleted)
+	})
+
+	return items, nil
+}
+
+func (rbm *RecycleBinManager) findItem(itemType RecycleBinItemType, id string) (*RecycleBinItem, error) {
+	switch itemType {
+	case RecycleBinItemCustomRace, RecycleBinItemChampionship, RecycleBinItemRaceWeekend, RecycleBinItemAccount:
+	default:
+		return nil, ErrRecycleBinInvalidType
+	}
+
+	items, err := rbm.ListItems()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		if item.Type == itemType && item.ID == id {
+			return item, nil
+		}
+	}
+
+	return nil, ErrRecycleBinItemNotFound
+}
+
+// Restore takes an item out of the recycle bin. Restored Custom Races are not rescheduled.
+func (rbm *RecycleBinManager) Restore(itemType RecycleBinItemType, id string) (*RecycleBinItem, error) {
+	item, err := rbm.findItem(itemType, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !item.CanRestore {
+		return nil, ErrRecycleBinCannotRestore
+	}
+
+	switch item.Type {
+	case RecycleBinItemCustomRace:
+		customRace, err := rbm.store.FindCustomRaceByID(id)
+
+		if err != nil {
+			return nil, err
+		}
+
+		customRace.Deleted = time.Time{}
+		customRace.Updated = time.Now()
+
+		err = rbm.store.UpsertCustomRace(customRace)
+
+		if err != nil {
+			return nil, err
+		}
+	case RecycleBinItemChampionship:
+		championship, err := rbm.store.LoadChampionship(id)
+
+		if err != nil {
+			return nil, err
+		}
+
+		championship.Deleted = time.Time{}
+		championship.Updated = time.Now()
+
+		err = rbm.store.UpsertChampionship(championship)
+
+		if err != nil {
+			return nil, err
+		}
+	case RecycleBinItemRaceWeekend:
+		raceWeekend, err := rbm.store.LoadRaceWeekend(id)
+
+		if err != nil {
+			return nil, err
+		}
+
+		raceWeekend.Deleted = time.Time{}
+		raceWeekend.Updated = time.Now()
+
+		err = rbm.store.UpsertRaceWeekend(raceWeekend)
+
+		if err != nil {
+			return nil, err
+		}
+	case RecycleBinItemAccount:
+		account, err := rbm.findDeletedAccount(id)
+
+		if err != nil {
+			return nil, err
+		}
+
+		// accounts are stored by name, a new account may have been created with the same name since this one
+		// was deleted.
+		if existing, err := rbm.store.FindAccountByName(account.Name); err == nil && existing.Deleted.IsZero() {
+			return nil, ErrRecycleBinAccountExists
+		} else if err != nil && err != ErrAccountNotFound {
+			return nil, err
+		}
+
+		account.Deleted = time.Time{}
+		account.Updated = time.Now()
+
+		err = rbm.store.UpsertAccount(account)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	logrus.Infof("Restored %s from the recycle bin: %s", item.TypeName(), item.Name)
+
+	return item, nil
+}
+
+// Purge permanently deletes an item from the recycle bin. Purging a Championship also purges the Race Weekends
+// that are used by its events.
+func (rbm *RecycleBinManager) Purge(itemType RecycleBinItemType, id string) (*RecycleBinItem, error) {
+	item, err := rbm.findItem(itemType, id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rbm.purge(item); err != nil {
+		return nil, err
+	}
+
+	return item, nil
+}
+
+func (rbm *RecycleBinManager) purge(item *RecycleBinItem) error {
+	switch item.Type {
+	case RecycleBinItemCustomRace:
+		if err := rbm.store.PurgeCustomRace(item.ID); err != nil {
+			return err
+		}
+	case RecycleBinItemChampionship:
+		championship, err := rbm.store.LoadChampionship(item.ID)
+
+		if err != nil {
+			return err
+		}
+
+		for _, event := range championship.Events {
+			if event.RaceWeekendID == uuid.Nil {
+				continue
+			}
+
+			if err := rbm.store.PurgeRaceWeekend(event.RaceWeekendID.String()); err != nil {
+				logrus.WithError(err).Errorf("Could not purge Race Weekend: %s for Championship: %s", event.RaceWeekendID, item.Name)
+			}
+		}
+
+		if err := rbm.store.PurgeChampionship(item.ID); err != nil {
+			return err
+		}
+	case RecycleBinItemRaceWeekend:
+		if err := rbm.store.PurgeRaceWeekend(item.ID); err != nil {
+			return err
+		}
+	case RecycleBinItemAccount:
+		account, err := rbm.findDeletedAccount(item.ID)
+
+		if err != nil {
+			return err
+		}
+
+		if err := rbm.store.PurgeAccount(account.Name); err != nil {
+			return err
+		}
+	default:
+		return ErrRecycleBinInvalidType
+	}
+
+	logrus.Infof("Purged %s from the recycle bin: %s", item.TypeName(), item.Name)
+
+	return nil
+}
+
+func (rbm *RecycleBinManager) findDeletedAccount(id string) (*Account, error) {
+	accounts, err := rbm.store.ListDeletedAccounts()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, account := range accounts {
+		if account.ID.String() == id {
+			return account, nil
+		}
+	}
+
+	return nil, ErrRecycleBinItemNotFound
+}
+
+// RetentionDays is the number of days that items are kept in the recycle bin for, 0 meaning forever.
+func (rbm *RecycleBinManager) RetentionDays() (int, error) {
+	serverOptions, err := rbm.store.LoadServerOptions()
+
+	if err != nil {
+		return 0, err
+	}
+
+	return serverOptions.RecycleBinRetentionDays, nil
+}
+
+// PurgeExpiredItems permanently deletes items which have been in the recycle bin for longer than the
+// retention period in the server options.
+func (rbm *RecycleBinManager) PurgeExpiredItems() error {
+	retentionDays, err := rbm.RetentionDays()
+
+	if err != nil {
+		return err
+	}
+
+	if retentionDays <= 0 {
+		return nil
+	}
+
+	items, err := rbm.ListItems()
+
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+
+	for _, item := range items {
+		if item.PurgeTime(retentionDays).After(now) {
+			continue
+		}
+
+		if err := rbm.purge(item); err != nil {
+			logrus.WithError(err).Errorf("Could not purge %s from the recycle bin: %s", item.TypeName(), item.Name)
+		}
+	}
+
+	return nil
+}
+
+// WatchForExpiredItems periodically purges items which have been in the recycle bin for longer than the
+// retention period.
+func (rbm *RecycleBinManager) WatchForExpiredItems() {
+	ticker := time.NewTicker(recycleBinPurgeInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := rbm.PurgeExpiredItems(); err != nil {
+			logrus.WithError(err).Errorf("Could not purge expired items from the recycle bin")
+		}
+	}
+}
+
+type RecycleBinHandler struct {
+	*BaseHandler
+
+	recycleBinManager *RecycleBinManager
+}
+
+func NewRecycleBinHandler(baseHandler *BaseHandler, recycleBinManager *RecycleBinManager) *RecycleBinHandler {
+	return &RecycleBinHandler{
+		BaseHandler:       baseHandler,
+		recycleBinManager: recycleBinManager,
+	}
+}
+
+type recycleBinTemplateVars struct {
+	BaseTemplateVars
+
+	Items         []*RecycleBinItem
+	RetentionDays int
+}
+
+func (rbh *RecycleBinHandler) list(w http.ResponseWriter, r *http.Request) {
+	items, err := rbh.recycleBinManager.ListItems()
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not list recycle bin items")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	retentionDays, err := rbh.recycleBinManager.RetentionDays()
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not load server options")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	rbh.viewRenderer.MustLoadTemplate(w, r, "server/recycle-bin.html", &recycleBinTemplateVars{
+		Items:         items,
+		RetentionDays: retentionDays,
+	})
+}
+
+func (rbh *RecycleBinHandler) restore(w http.ResponseWriter, r *http.Request) {
+	item, err := rbh.recycleBinManager.Restore(RecycleBinItemType(chi.URLParam(r, "type")), chi.URLParam(r, "id"))
+
+	switch err {
+	case nil:
+		AddFlash(w, r, "Successfully restored "+item.TypeName()+": "+item.Name)
+	case ErrRecycleBinItemNotFound, ErrRecycleBinInvalidType:
+		AddErrorFlash(w, r, "Could not find that item in the Recycle Bin")
+	case ErrRecycleBinCannotRestore:
+		AddErrorFlash(w, r, "This Race Weekend was part of a Championship event which has been deleted, so it cannot be restored")
+	case ErrRecycleBinAccountExists:
+		AddErrorFlash(w, r, "An account with this name already exists, so this account cannot be restored")
+	default:
+		logrus.WithError(err).Errorf("Could not restore recycle bin item")
+		AddErrorFlash(w, r, "Could not restore item")
+	}
+
+	http.Redirect(w, r, r.Referer(), http.StatusFound)
+}
+
+func (rbh *RecycleBinHandler) purge(w http.ResponseWriter, r *http.Request) {
+	item, err := rbh.recycleBinManager.Purge(RecycleBinItemType(chi.URLParam(r, "type")), chi.URLParam(r, "id"))
+
+	switch err {
+	case nil:
+		AddFlash(w, r, "Permanently deleted "+item.TypeName()+": "+item.Name)
+	case ErrRecycleBinItemNotFound, ErrRecycleBinInvalidType:
+		AddErrorFlash(w, r, "Could not find that item in the Recycle Bin")
+	default:
+		logrus.WithError(err).Errorf("Could not purge recycle bin item")
+		AddErrorFlash(w, r, "Could not permanently delete item")
+	}
+
+	http.Redirect(w, r, r.Referer(), http.StatusFound)
+}
@@ -0,0 +1,158 @@
+package servermanager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRecycleBinManager(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recycle-bin")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	store := NewJSONStore(filepath.Join(dir, "store"), filepath.Join(dir, "store-shared"))
+	recycleBinManager := NewRecycleBinManager(store)
+
+	customRace := &CustomRace{Name: "Deleted Race", UUID: uuid.New()}
+
+	if err := store.UpsertCustomRace(customRace); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.DeleteCustomRace(customRace); err != nil {
+		t.Fatal(err)
+	}
+
+	championship := NewChampionship("Deleted Championship")
+
+	if err := store.UpsertChampionship(championship); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.DeleteChampionship(championship.ID.String()); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Deleted items are listed", func(t *testing.T) {
+		items, err := recycleBinManager.ListItems()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(items) != 2 {
+			t.Errorf("Expected 2 items in the recycle bin, got %d", len(items))
+		}
+	})
+
+	t.Run("Items can be restored", func(t *testing.T) {
+		if _, err := recycleBinManager.Restore(RecycleBinItemCustomRace, customRace.UUID.String()); err != nil {
+			t.Fatal(err)
+		}
+
+		customRaces, err := store.ListCustomRaces()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(customRaces) != 1 || customRaces[0].UUID != customRace.UUID {
+			t.Errorf("Expected the restored custom race to be listed, got %v", customRaces)
+		}
+
+		if _, err := recycleBinManager.Restore(RecycleBinItemCustomRace, customRace.UUID.String()); err != ErrRecycleBinItemNotFound {
+			t.Errorf("Expected ErrRecycleBinItemNotFound, got: %v", err)
+		}
+	})
+
+	t.Run("Items can be purged", func(t *testing.T) {
+		if _, err := recycleBinManager.Purge(RecycleBinItemChampionship, championship.ID.String()); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := store.LoadChampionship(championship.ID.String()); err == nil {
+			t.Errorf("Expected the purged championship to be gone")
+		}
+	})
+
+	t.Run("Items which are not deleted cannot be purged", func(t *testing.T) {
+		if _, err := recycleBinManager.Purge(RecycleBinItemCustomRace, customRace.UUID.String()); err != ErrRecycleBinItemNotFound {
+			t.Errorf("Expected ErrRecycleBinItemNotFound, got: %v", err)
+		}
+
+		if _, err := recycleBinManager.Purge("unknown", customRace.UUID.String()); err != ErrRecycleBinInvalidType {
+			t.Errorf("Expected ErrRecycleBinInvalidType, got: %v", err)
+		}
+	})
+
+	t.Run("Accounts can be restored", func(t *testing.T) {
+		account := NewAccount()
+		account.Name = "driver"
+
+		if err := store.UpsertAccount(account); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := store.DeleteAccount(account.ID.String()); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := store.FindAccountByID(account.ID.String()); err != ErrAccountNotFound {
+			t.Fatalf("Expected the deleted account not to be found, got: %v", err)
+		}
+
+		if _, err := recycleBinManager.Restore(RecycleBinItemAccount, account.ID.String()); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := store.FindAccountByID(account.ID.String()); err != nil {
+			t.Errorf("Expected the restored account to be found, got: %v", err)
+		}
+	})
+
+	t.Run("Expired items are purged", func(t *testing.T) {
+		expired := &CustomRace{Name: "Expired Race", UUID: uuid.New(), Deleted: time.Now().AddDate(0, 0, -31)}
+		recent := &CustomRace{Name: "Recent Race", UUID: uuid.New(), Deleted: time.Now().AddDate(0, 0, -1)}
+
+		for _, race := range []*CustomRace{expired, recent} {
+			if err := store.UpsertCustomRace(race); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		opts, err := store.LoadServerOptions()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		opts.RecycleBinRetentionDays = 30
+
+		if err := store.UpsertServerOptions(opts); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := recycleBinManager.PurgeExpiredItems(); err != nil {
+			t.Fatal(err)
+		}
+
+		customRaces, err := store.ListDeletedCustomRaces()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(customRaces) != 1 || customRaces[0].UUID != recent.UUID {
+			t.Errorf("Expected only the recently deleted race to be kept, got %v", customRaces)
+		}
+	})
+}
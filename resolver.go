@@ -50,6 +50,27 @@ type Resolver struct {
 	healthCheck                 *HealthCheck
 	kissMyRankHandler           *KissMyRankHandler
 	realPenaltyHandler          *RealPenaltyHandler
+	apiHandler                  *APIHandler
+	driversHandler              *DriversHandler
+	leaderboardManager          *LeaderboardManager
+	webhookManager              *WebhookManager
+	webhooksHandler             *WebhooksHandler
+	instanceManager             *InstanceManager
+	instancesHandler            *InstancesHandler
+	protestManager              *ProtestManager
+	protestsHandler             *ProtestsHandler
+	sessionRecorder             *SessionRecorder
+	sessionRecordingsHandler    *SessionRecordingsHandler
+	lapTelemetryRecorder        *LapTelemetryRecorder
+	lapTelemetryHandler         *LapTelemetryHandler
+	blocklistManager            *BlocklistManager
+	blocklistHandler            *BlocklistHandler
+	recycleBinManager           *RecycleBinManager
+	recycleBinHandler           *RecycleBinHandler
+	backupManager               *BackupManager
+	backupsHandler              *BackupsHandler
+	configYAMLManager           *ConfigYAMLManager
+	configYAMLHandler           *ConfigYAMLHandler
 }
 
 func NewResolver(templateLoader TemplateLoader, reloadTemplates bool, store Store) (*Resolver, error) {
@@ -72,14 +93,18 @@ func NewResolver(templateLoader TemplateLoader, reloadTemplates bool, store Stor
 
 func (r *Resolver) UDPCallback(message udp.Message) {
 	if !config.Server.PerformanceMode {
+		r.resolveSessionRecorder().UDPCallback(message)
+		r.resolveLapTelemetryRecorder().UDPCallback(message)
 		r.ResolveRaceControl().UDPCallback(message)
 	}
 
 	if message.Event() != udp.EventCarUpdate {
-		r.resolveChampionshipManager().ChampionshipEventCallback(message)
+		r.ResolveChampionshipManager().ChampionshipEventCallback(message)
 		r.resolveRaceWeekendManager().UDPCallback(message)
 		r.resolveRaceManager().LoopCallback(message)
 		r.resolveContentManagerWrapper().UDPCallback(message)
+		r.resolveLeaderboardManager().UDPCallback(message)
+		r.resolveNotificationManager().UDPCallback(message)
 	}
 }
 
@@ -151,6 +176,7 @@ func (r *Resolver) resolveRaceManager() *RaceManager {
 		r.resolveTrackManager(),
 		r.resolveNotificationManager(),
 		r.ResolveRaceControl(),
+		r.resolveBlocklistManager(),
 	)
 
 	return r.raceManager
@@ -175,7 +201,7 @@ func (r *Resolver) resolveCustomRaceHandler() *CustomRaceHandler {
 		r.resolveBaseHandler(),
 		r.resolveRaceManager(),
 		r.ResolveStore(),
-		r.resolveChampionshipManager(),
+		r.ResolveChampionshipManager(),
 		r.resolveRaceWeekendManager(),
 	)
 
@@ -246,7 +272,7 @@ func (r *Resolver) resolveCarsHandler() *CarsHandler {
 	return r.carsHandler
 }
 
-func (r *Resolver) resolveChampionshipManager() *ChampionshipManager {
+func (r *Resolver) ResolveChampionshipManager() *ChampionshipManager {
 	if r.championshipManager != nil {
 		return r.championshipManager
 	}
@@ -264,7 +290,7 @@ func (r *Resolver) resolveChampionshipsHandler() *ChampionshipsHandler {
 		return r.championshipsHandler
 	}
 
-	r.championshipsHandler = NewChampionshipsHandler(r.resolveBaseHandler(), r.resolveChampionshipManager())
+	r.championshipsHandler = NewChampionshipsHandler(r.resolveBaseHandler(), r.ResolveChampionshipManager(), r.resolveProtestManager())
 
 	return r.championshipsHandler
 }
@@ -358,7 +384,7 @@ func (r *Resolver) resolveServerAdministrationHandler() *ServerAdministrationHan
 		r.resolveBaseHandler(),
 		r.ResolveStore(),
 		r.resolveRaceManager(),
-		r.resolveChampionshipManager(),
+		r.ResolveChampionshipManager(),
 		r.resolveRaceWeekendManager(),
 		r.resolveServerProcess(),
 		r.acsrClient,
@@ -399,6 +425,7 @@ func (r *Resolver) ResolveRaceControl() *RaceControl {
 		r.resolveServerProcess(),
 		r.ResolveStore(),
 		r.resolvePenaltiesManager(),
+		r.resolveBlocklistManager(),
 	)
 
 	return r.raceControl
@@ -420,6 +447,7 @@ func (r *Resolver) resolveRaceControlHandler() *RaceControlHandler {
 		r.ResolveRaceControl(),
 		r.resolveRaceControlHub(),
 		r.resolveServerProcess(),
+		r.resolveBlocklistManager(),
 	)
 
 	return r.raceControlHandler
@@ -432,7 +460,7 @@ func (r *Resolver) resolveRaceWeekendManager() *RaceWeekendManager {
 
 	r.raceWeekendManager = NewRaceWeekendManager(
 		r.resolveRaceManager(),
-		r.resolveChampionshipManager(),
+		r.ResolveChampionshipManager(),
 		r.ResolveStore(),
 		r.resolveServerProcess(),
 		r.resolveNotificationManager(),
@@ -469,7 +497,7 @@ func (r *Resolver) resolveNotificationManager() *NotificationManager {
 		return r.notificationManager
 	}
 
-	r.notificationManager = NewNotificationManager(r.resolveDiscordManager(), r.resolveCarManager(), r.store)
+	r.notificationManager = NewNotificationManager(r.resolveDiscordManager(), r.resolveWebhookManager(), r.resolveCarManager(), r.store)
 
 	return r.notificationManager
 }
@@ -520,6 +548,223 @@ func (r *Resolver) resolveRealPenaltyHandler() *RealPenaltyHandler {
 	return r.realPenaltyHandler
 }
 
+func (r *Resolver) resolveAPIHandler() *APIHandler {
+	if r.apiHandler != nil {
+		return r.apiHandler
+	}
+
+	r.apiHandler = NewAPIHandler(
+		r.resolveBaseHandler(),
+		r.ResolveStore(),
+		r.resolveServerProcess(),
+		r.resolveRaceManager(),
+		r.ResolveChampionshipManager(),
+		r.resolveRaceWeekendManager(),
+	)
+
+	return r.apiHandler
+}
+
+func (r *Resolver) resolveDriversHandler() *DriversHandler {
+	if r.driversHandler != nil {
+		return r.driversHandler
+	}
+
+	r.driversHandler = NewDriversHandler(r.resolveBaseHandler())
+
+	return r.driversHandler
+}
+
+func (r *Resolver) resolveLeaderboardManager() *LeaderboardManager {
+	if r.leaderboardManager != nil {
+		return r.leaderboardManager
+	}
+
+	r.leaderboardManager = NewLeaderboardManager(r.ResolveStore(), r.resolveNotificationManager())
+
+	return r.leaderboardManager
+}
+
+func (r *Resolver) resolveWebhookManager() *WebhookManager {
+	if r.webhookManager != nil {
+		return r.webhookManager
+	}
+
+	r.webhookManager = NewWebhookManager(r.ResolveStore())
+
+	return r.webhookManager
+}
+
+func (r *Resolver) resolveWebhooksHandler() *WebhooksHandler {
+	if r.webhooksHandler != nil {
+		return r.webhooksHandler
+	}
+
+	r.webhooksHandler = NewWebhooksHandler(r.resolveBaseHandler(), r.resolveWebhookManager())
+
+	return r.webhooksHandler
+}
+
+func (r *Resolver) resolveInstanceManager() *InstanceManager {
+	if r.instanceManager != nil {
+		return r.instanceManager
+	}
+
+	r.instanceManager = NewInstanceManager()
+
+	return r.instanceManager
+}
+
+func (r *Resolver) resolveInstancesHandler() *InstancesHandler {
+	if r.instancesHandler != nil {
+		return r.instancesHandler
+	}
+
+	r.instancesHandler = NewInstancesHandler(r.resolveBaseHandler(), r.resolveInstanceManager(), r.ResolveStore())
+
+	return r.instancesHandler
+}
+
+func (r *Resolver) resolveProtestManager() *ProtestManager {
+	if r.protestManager != nil {
+		return r.protestManager
+	}
+
+	r.protestManager = NewProtestManager(r.ResolveStore(), r.resolvePenaltiesManager(), r.ResolveChampionshipManager())
+
+	return r.protestManager
+}
+
+func (r *Resolver) resolveProtestsHandler() *ProtestsHandler {
+	if r.protestsHandler != nil {
+		return r.protestsHandler
+	}
+
+	r.protestsHandler = NewProtestsHandler(r.resolveBaseHandler(), r.resolveProtestManager())
+
+	return r.protestsHandler
+}
+
+func (r *Resolver) resolveSessionRecorder() *SessionRecorder {
+	if r.sessionRecorder != nil {
+		return r.sessionRecorder
+	}
+
+	r.sessionRecorder = NewSessionRecorder(r.ResolveStore())
+
+	return r.sessionRecorder
+}
+
+func (r *Resolver) resolveSessionRecordingsHandler() *SessionRecordingsHandler {
+	if r.sessionRecordingsHandler != nil {
+		return r.sessionRecordingsHandler
+	}
+
+	r.sessionRecordingsHandler = NewSessionRecordingsHandler(r.resolveBaseHandler(), r.ResolveStore(), filesystemTrackData{})
+
+	return r.sessionRecordingsHandler
+}
+
+func (r *Resolver) resolveLapTelemetryRecorder() *LapTelemetryRecorder {
+	if r.lapTelemetryRecorder != nil {
+		return r.lapTelemetryRecorder
+	}
+
+	r.lapTelemetryRecorder = NewLapTelemetryRecorder()
+
+	return r.lapTelemetryRecorder
+}
+
+func (r *Resolver) resolveLapTelemetryHandler() *LapTelemetryHandler {
+	if r.lapTelemetryHandler != nil {
+		return r.lapTelemetryHandler
+	}
+
+	r.lapTelemetryHandler = NewLapTelemetryHandler(r.resolveBaseHandler(), r.ResolveStore())
+
+	return r.lapTelemetryHandler
+}
+
+func (r *Resolver) resolveBlocklistManager() *BlocklistManager {
+	if r.blocklistManager != nil {
+		return r.blocklistManager
+	}
+
+	r.blocklistManager = NewBlocklistManager(r.ResolveStore())
+
+	return r.blocklistManager
+}
+
+func (r *Resolver) resolveBlocklistHandler() *BlocklistHandler {
+	if r.blocklistHandler != nil {
+		return r.blocklistHandler
+	}
+
+	r.blocklistHandler = NewBlocklistHandler(r.resolveBaseHandler(), r.resolveBlocklistManager(), r.ResolveRaceControl())
+
+	return r.blocklistHandler
+}
+
+func (r *Resolver) resolveRecycleBinManager() *RecycleBinManager {
+	if r.recycleBinManager != nil {
+		return r.recycleBinManager
+	}
+
+	r.recycleBinManager = NewRecycleBinManager(r.ResolveStore())
+
+	return r.recycleBinManager
+}
+
+func (r *Resolver) resolveRecycleBinHandler() *RecycleBinHandler {
+	if r.recycleBinHandler != nil {
+		return r.recycleBinHandler
+	}
+
+	r.recycleBinHandler = NewRecycleBinHandler(r.resolveBaseHandler(), r.resolveRecycleBinManager())
+
+	return r.recycleBinHandler
+}
+
+func (r *Resolver) resolveBackupManager() *BackupManager {
+	if r.backupManager != nil {
+		return r.backupManager
+	}
+
+	r.backupManager = NewBackupManager(r.ResolveStore(), config.Store)
+
+	return r.backupManager
+}
+
+func (r *Resolver) resolveBackupsHandler() *BackupsHandler {
+	if r.backupsHandler != nil {
+		return r.backupsHandler
+	}
+
+	r.backupsHandler = NewBackupsHandler(r.resolveBaseHandler(), r.resolveBackupManager())
+
+	return r.backupsHandler
+}
+
+func (r *Resolver) ResolveConfigYAMLManager() *ConfigYAMLManager {
+	if r.configYAMLManager != nil {
+		return r.configYAMLManager
+	}
+
+	r.configYAMLManager = NewConfigYAMLManager(r.ResolveStore())
+
+	return r.configYAMLManager
+}
+
+func (r *Resolver) resolveConfigYAMLHandler() *ConfigYAMLHandler {
+	if r.configYAMLHandler != nil {
+		return r.configYAMLHandler
+	}
+
+	r.configYAMLHandler = NewConfigYAMLHandler(r.resolveBaseHandler(), r.ResolveConfigYAMLManager())
+
+	return r.configYAMLHandler
+}
+
 func (r *Resolver) ResolveRouter(fs http.FileSystem) http.Handler {
 	return Router(
 		fs,
@@ -542,6 +787,17 @@ func (r *Resolver) ResolveRouter(fs http.FileSystem) http.Handler {
 		r.resolveHealthCheck(),
 		r.resolveKissMyRankHandler(),
 		r.resolveRealPenaltyHandler(),
+		r.resolveAPIHandler(),
+		r.resolveDriversHandler(),
+		r.resolveWebhooksHandler(),
+		r.resolveInstancesHandler(),
+		r.resolveProtestsHandler(),
+		r.resolveSessionRecordingsHandler(),
+		r.resolveLapTelemetryHandler(),
+		r.resolveBlocklistHandler(),
+		r.resolveRecycleBinHandler(),
+		r.resolveBackupsHandler(),
+		r.resolveConfigYAMLHandler(),
 	)
 }
 
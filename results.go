@@ -38,6 +38,8 @@ type SessionResults struct {
 	SessionFile    string           `json:"SessionFile"`
 	ChampionshipID string           `json:"ChampionshipID"`
 	RaceWeekendID  string           `json:"RaceWeekendID"`
+
+	StewardDecisions []*StewardIncident `json:"StewardDecisions,omitempty"`
 }
 
 var ErrSessionCarNotFound = errors.New("servermanager: session car not found")
@@ -152,6 +154,27 @@ func (s *SessionResults) FindCarByGUIDAndModel(guid, model string) (*SessionCar,
 	return nil, ErrSessionCarNotFound
 }
 
+// HasDriver returns true if the driver GUID took part in the session.
+func (s *SessionResults) HasDriver(guid string) bool {
+	if guid == "" {
+		return false
+	}
+
+	for _, result := range s.Result {
+		if result.DriverGUID == guid {
+			return true
+		}
+	}
+
+	for _, car := range s.Cars {
+		if car.Driver.GUID == guid {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (s *SessionResults) Anonymize() {
 	for _, car := range s.Cars {
 		car.Driver.GUID = AnonymiseDriverGUID(car.Driver.GUID)
@@ -174,7 +197,7 @@ func (s *SessionResults) Anonymize() {
 		}
 
 		for i, guid := range event.OtherDriver.GuidsList {
-			event.Driver.GuidsList[i] = AnonymiseDriverGUID(guid)
+			event.OtherDriver.GuidsList[i] = AnonymiseDriverGUID(guid)
 		}
 	}
 
@@ -189,6 +212,10 @@ func (s *SessionResults) Anonymize() {
 
 		result.DriverName = shortenDriverName(result.DriverName)
 	}
+
+	for _, incident := range s.StewardDecisions {
+		incident.Anonymize()
+	}
 }
 
 func (s *SessionResults) NormaliseDriverSwapGUIDs() {
@@ -218,6 +245,117 @@ func AnonymiseDriverGUID(guid string) string {
 	return hex.EncodeToString(hasher.Sum(nil))
 }
 
+// IsAnonymised is true if every driver GUID in the results has been replaced by AnonymiseDriverGUID.
+func (s *SessionResults) IsAnonymised() bool {
+	for _, car := range s.Cars {
+		if !isAnonymisedDriverGUID(car.Driver.GUID) {
+			return false
+		}
+	}
+
+	for _, result := range s.Result {
+		if !isAnonymisedDriverGUID(result.DriverGUID) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isAnonymisedDriverGUID(guid string) bool {
+	if len(guid) != hex.EncodedLen(md5.Size) {
+		return false
+	}
+
+	_, err := hex.DecodeString(guid)
+
+	return err == nil
+}
+
+// AnonymiseResultsFile replaces the driver GUIDs and names in a results file with anonymised versions. Results files
+// which have already been anonymised are left as they are. The session's telemetry is anonymised too, and its
+// recording is deleted. fileName includes the .json extension.
+func AnonymiseResultsFile(fileName string) (anonymised bool, err error) {
+	// the file is read directly rather than with LoadResult, which leaves out drivers that didn't set a time.
+	data, err := ioutil.ReadFile(filepath.Join(ServerInstallPath, "results", fileName))
+
+	if err != nil {
+		return false, err
+	}
+
+	var results *SessionResults
+
+	if err := json.Unmarshal(data, &results); err != nil {
+		return false, err
+	}
+
+	if !results.IsAnonymised() {
+		results.Anonymize()
+
+		if err := saveResults(fileName, results); err != nil {
+			return false, err
+		}
+
+		anonymised = true
+	}
+
+	// telemetry and recordings are checked even if the results were already anonymised, in case they were
+	// anonymised before telemetry and recordings were kept.
+	sessionFile := strings.TrimSuffix(fileName, ".json")
+
+	if err := anonymiseSessionTelemetry(sessionFile); err != nil {
+		return anonymised, err
+	}
+
+	return anonymised, deleteSessionRecording(sessionFile)
+}
+
+var (
+	ErrResultsFileExists  = errors.New("servermanager: a results file with that name already exists")
+	ErrInvalidResultsFile = errors.New("servermanager: file is not an Assetto Corsa results file")
+)
+
+// ImportResultsFile copies a results file into the results directory and adds it to the results index. Existing
+// results files are only replaced if overwrite is true.
+func ImportResultsFile(location string, overwrite bool) (*SessionResults, error) {
+	fileName := filepath.Base(location)
+
+	if filepath.Ext(fileName) != ".json" {
+		return nil, ErrInvalidResultsFile
+	}
+
+	data, err := ioutil.ReadFile(location)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var results *SessionResults
+
+	if err := json.Unmarshal(data, &results); err != nil || results == nil || results.TrackName == "" || results.Type == "" {
+		return nil, ErrInvalidResultsFile
+	}
+
+	resultsPath := filepath.Join(ServerInstallPath, "results")
+	destination := filepath.Join(resultsPath, fileName)
+
+	if _, err := os.Stat(destination); err == nil && !overwrite {
+		return nil, ErrResultsFileExists
+	}
+
+	if err := os.MkdirAll(resultsPath, 0755); err != nil {
+		return nil, err
+	}
+
+	if err := ioutil.WriteFile(destination, data, 0644); err != nil {
+		return nil, err
+	}
+
+	indexResultsFile(fileName)
+
+	return LoadResult(fileName, LoadResultWithoutPluginFire)
+}
+
 func (s *SessionResults) MaskDriverNames() {
 	for _, car := range s.Cars {
 		car.Driver.Name = driverName(car.Driver.Name)
@@ -1144,25 +1282,23 @@ const pageSize = 25
 
 var ErrResultsPageNotFound = errors.New("servermanager: results page not found")
 
-func listResults(page int) ([]SessionResults, []int, error) {
-	resultsPath := filepath.Join(ServerInstallPath, "results")
-	resultFiles, err := ioutil.ReadDir(resultsPath)
+func listResults(rq ResultsQuery) ([]SessionResults, []int, error) {
+	page := rq.Page
 
-	if err != nil {
-		return nil, nil, err
+	if page < 0 {
+		return nil, nil, ErrResultsPageNotFound
 	}
 
-	sort.Slice(resultFiles, func(i, j int) bool {
-		d1, _ := GetResultDate(resultFiles[i].Name())
-		d2, _ := GetResultDate(resultFiles[j].Name())
+	results, total, err := FindResults(rq)
 
-		return d1.After(d2)
-	})
+	if err != nil {
+		return nil, nil, err
+	}
 
-	pages := float64(len(resultFiles)) / float64(pageSize)
+	pages := float64(total) / float64(pageSize)
 	pagesRound := math.Ceil(pages)
 
-	if page > int(pages) || page < 0 {
+	if page > int(pages) {
 		return nil, nil, ErrResultsPageNotFound
 	}
 
@@ -1172,30 +1308,23 @@ func listResults(page int) ([]SessionResults, []int, error) {
 		pagesSlice = append(pagesSlice, x)
 	}
 
-	// get result files for selected page probably
-	if len(resultFiles) > page*pageSize+pageSize {
-		resultFiles = resultFiles[page*pageSize : page*pageSize+pageSize]
-	} else {
-		resultFiles = resultFiles[page*pageSize:]
-	}
-
-	var results []SessionResults
-
-	for _, resultFile := range resultFiles {
-		result, err := LoadResult(resultFile.Name())
-
-		if err != nil {
-			logrus.WithError(err).Errorf("Could not load results file: %s", resultFile.Name())
-			continue
-		}
+	return results, pagesSlice, nil
+}
 
-		results = append(results, *result)
+// ListAllResults loads every results file, newest first. Where possible, use FindResults to load only the results
+// that are needed.
+func ListAllResults() ([]SessionResults, error) {
+	if resultsIndex == nil {
+		return scanAllResults()
 	}
 
-	return results, pagesSlice, nil
+	results, _, err := FindResults(ResultsQuery{}, LoadResultWithoutPluginFire)
+
+	return results, err
 }
 
-func ListAllResults() ([]SessionResults, error) {
+// scanAllResults reads every file in the results directory, newest first.
+func scanAllResults() ([]SessionResults, error) {
 	resultsPath := filepath.Join(ServerInstallPath, "results")
 	resultFiles, err := ioutil.ReadDir(resultsPath)
 
@@ -1353,16 +1482,13 @@ type resultsListTemplateVars struct {
 	Results     []SessionResults
 	Pages       []int
 	CurrentPage int
+	Query       ResultsQuery
 }
 
 func (rh *ResultsHandler) list(w http.ResponseWriter, r *http.Request) {
-	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	query := ResultsQueryFromRequest(r)
 
-	if err != nil {
-		page = 0
-	}
-
-	results, pages, err := listResults(page)
+	results, pages, err := listResults(query)
 
 	if err == ErrResultsPageNotFound {
 		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
@@ -1376,7 +1502,8 @@ func (rh *ResultsHandler) list(w http.ResponseWriter, r *http.Request) {
 	rh.viewRenderer.MustLoadTemplate(w, r, "results/index.html", &resultsListTemplateVars{
 		Results:     results,
 		Pages:       pages,
-		CurrentPage: page,
+		CurrentPage: query.Page,
+		Query:       query,
 	})
 }
 
@@ -1512,6 +1639,8 @@ func (rh *ResultsHandler) upload(r *http.Request) (bool, error) {
 		return matched, err
 	}
 
+	indexResultsFile(header.Filename)
+
 	return matched, nil
 }
 
@@ -1774,5 +1903,11 @@ func saveResults(jsonFileName string, results *SessionResults) error {
 	encoder := json.NewEncoder(file)
 	encoder.SetIndent("", "\t")
 
-	return encoder.Encode(results)
+	if err := encoder.Encode(results); err != nil {
+		return err
+	}
+
+	indexResultsFile(jsonFileName)
+
+	return nil
 }
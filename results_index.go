@@ -0,0 +1,625 @@
+package servermanager
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blevesearch/bleve"
+	"github.com/blevesearch/bleve/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/mapping"
+	"github.com/blevesearch/bleve/search/query"
+	"github.com/etcd-io/bbolt"
+	"github.com/sirupsen/logrus"
+)
+
+// resultsIndex is the persistent index of all results files. It is nil until InitResultsIndex is called, in which case
+// results are found by reading the results directory.
+var resultsIndex *ResultsIndex
+
+// ResultsIndexEntry is the information about a results file that is stored in the ResultsIndex.
+type ResultsIndexEntry struct {
+	SessionFile    string
+	TrackName      string
+	TrackLayout    string
+	Type           string
+	Date           time.Time
+	ChampionshipID string
+	RaceWeekendID  string
+	Cars           []string
+	DriverGUIDs    []string
+}
+
+func newResultsIndexEntry(results *SessionResults) *ResultsIndexEntry {
+	entry := &ResultsIndexEntry{
+		SessionFile:    results.SessionFile,
+		TrackName:      results.TrackName,
+		TrackLayout:    resultsIndexTrackLayoutKey(results.TrackName, results.TrackConfig),
+		Type:           string(results.Type),
+		Date:           results.Date,
+		ChampionshipID: results.ChampionshipID,
+		RaceWeekendID:  results.RaceWeekendID,
+	}
+
+	cars := make(map[string]bool)
+	guids := make(map[string]bool)
+
+	for _, result := range results.Result {
+		cars[result.CarModel] = true
+
+		// cars shared in a driver swap are found by each of their drivers.
+		for _, guid := range strings.Split(result.DriverGUID, driverSwapEntrantSeparator) {
+			guids[guid] = true
+		}
+	}
+
+	for _, lap := range results.Laps {
+		guids[lap.DriverGUID] = true
+	}
+
+	for car := range cars {
+		if car != "" {
+			entry.Cars = append(entry.Cars, car)
+		}
+	}
+
+	for guid := range guids {
+		if guid != "" {
+			entry.DriverGUIDs = append(entry.DriverGUIDs, guid)
+		}
+	}
+
+	return entry
+}
+
+// resultsIndexTrackLayoutKey combines a track and layout into a single term, so that results on the default
+// layout (which has no name) can be found.
+func resultsIndexTrackLayoutKey(track, layout string) string {
+	return track + "/" + layout
+}
+
+// ResultsQuery filters the results files returned by the ResultsIndex. Empty fields are not filtered on.
+type ResultsQuery struct {
+	Track string
+	// TrackLayout is only used if Track is set. Set MatchTrackLayout to find results for a track's default layout.
+	TrackLayout      string
+	MatchTrackLayout bool
+
+	Car            string
+	DriverGUID     string
+	SessionType    SessionType
+	ChampionshipID string
+	RaceWeekendID  string
+
+	From, To time.Time
+
+	// Page is zero indexed, negative pages are not found. If PageSize is 0, all matching results are returned.
+	Page, PageSize int
+}
+
+func (rq ResultsQuery) query() query.Query {
+	var conjuncts []query.Query
+
+	term := func(field, value string) {
+		q := bleve.NewTermQuery(value)
+		q.SetField(field)
+
+		conjuncts = append(conjuncts, q)
+	}
+
+	if rq.Track != "" {
+		if rq.TrackLayout != "" || rq.MatchTrackLayout {
+			term("TrackLayout", resultsIndexTrackLayoutKey(rq.Track, rq.TrackLayout))
+		} else {
+			term("TrackName", rq.Track)
+		}
+	}
+
+	if rq.Car != "" {
+		term("Cars", rq.Car)
+	}
+
+	if rq.DriverGUID != "" {
+		term("DriverGUIDs", rq.DriverGUID)
+	}
+
+	if rq.SessionType != "" {
+		term("Type", string(rq.SessionType))
+	}
+
+	if rq.ChampionshipID != "" {
+		term("ChampionshipID", rq.ChampionshipID)
+	}
+
+	if rq.RaceWeekendID != "" {
+		term("RaceWeekendID", rq.RaceWeekendID)
+	}
+
+	if !rq.From.IsZero() || !rq.To.IsZero() {
+		q := bleve.NewDateRangeQuery(rq.From, rq.To)
+		q.SetField("Date")
+
+		conjuncts = append(conjuncts, q)
+	}
+
+	if len(conjuncts) == 0 {
+		return bleve.NewMatchAllQuery()
+	}
+
+	return bleve.NewConjunctionQuery(conjuncts...)
+}
+
+// matches is used to filter results when no index is available.
+func (rq ResultsQuery) matches(entry *ResultsIndexEntry) bool {
+	if rq.Track != "" {
+		if rq.TrackLayout != "" || rq.MatchTrackLayout {
+			if entry.TrackLayout != resultsIndexTrackLayoutKey(rq.Track, rq.TrackLayout) {
+				return false
+			}
+		} else if entry.TrackName != rq.Track {
+			return false
+		}
+	}
+
+	if rq.Car != "" && !stringInSlice(rq.Car, entry.Cars) {
+		return false
+	}
+
+	if rq.DriverGUID != "" && !stringInSlice(rq.DriverGUID, entry.DriverGUIDs) {
+		return false
+	}
+
+	if rq.SessionType != "" && entry.Type != string(rq.SessionType) {
+		return false
+	}
+
+	if rq.ChampionshipID != "" && entry.ChampionshipID != rq.ChampionshipID {
+		return false
+	}
+
+	if rq.RaceWeekendID != "" && entry.RaceWeekendID != rq.RaceWeekendID {
+		return false
+	}
+
+	if !rq.From.IsZero() && entry.Date.Before(rq.From) {
+		return false
+	}
+
+	if !rq.To.IsZero() && !entry.Date.Before(rq.To) {
+		return false
+	}
+
+	return true
+}
+
+const resultsQueryDateFormat = "2006-01-02"
+
+// ResultsQueryFromRequest builds a ResultsQuery from a request's query string, e.g.
+// ?track=ks_vallelunga&layout=club_circuit&car=ks_mazda_mx5_cup&guid=7656...&type=RACE&championship=<id>&from=2020-01-01&to=2020-12-31&page=0
+func ResultsQueryFromRequest(r *http.Request) ResultsQuery {
+	q := r.URL.Query()
+
+	rq := ResultsQuery{
+		Track:          q.Get("track"),
+		TrackLayout:    q.Get("layout"),
+		Car:            q.Get("car"),
+		DriverGUID:     q.Get("guid"),
+		SessionType:    SessionType(q.Get("type")),
+		ChampionshipID: q.Get("championship"),
+		RaceWeekendID:  q.Get("raceWeekend"),
+		Page:           formValueAsInt(q.Get("page")),
+		PageSize:       pageSize,
+	}
+
+	if from, err := time.ParseInLocation(resultsQueryDateFormat, q.Get("from"), time.Local); err == nil {
+		rq.From = from
+	}
+
+	if to, err := time.ParseInLocation(resultsQueryDateFormat, q.Get("to"), time.Local); err == nil {
+		// include the whole of the 'to' day
+		rq.To = to.AddDate(0, 0, 1)
+	}
+
+	return rq
+}
+
+// FromDate and ToDate are used to fill in date inputs in templates.
+func (rq ResultsQuery) FromDate() string {
+	if rq.From.IsZero() {
+		return ""
+	}
+
+	return rq.From.Format(resultsQueryDateFormat)
+}
+
+func (rq ResultsQuery) ToDate() string {
+	if rq.To.IsZero() {
+		return ""
+	}
+
+	return rq.To.AddDate(0, 0, -1).Format(resultsQueryDateFormat)
+}
+
+func stringInSlice(s string, slice []string) bool {
+	for _, x := range slice {
+		if x == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ResultsIndex is a persistent search index of results files, so that results can be found without reading
+// every file in the results directory.
+type ResultsIndex struct {
+	index bleve.Index
+	mutex sync.RWMutex
+}
+
+func resultsIndexMapping() *mapping.IndexMappingImpl {
+	keywordField := bleve.NewTextFieldMapping()
+	keywordField.Analyzer = keyword.Name
+
+	dateField := bleve.NewDateTimeFieldMapping()
+
+	entryMapping := bleve.NewDocumentStaticMapping()
+
+	for _, field := range []string{"SessionFile", "TrackName", "TrackLayout", "Type", "ChampionshipID", "RaceWeekendID", "Cars", "DriverGUIDs"} {
+		entryMapping.AddFieldMappingsAt(field, keywordField)
+	}
+
+	entryMapping.AddFieldMappingsAt("Date", dateField)
+
+	indexMapping := bleve.NewIndexMapping()
+	indexMapping.DefaultMapping = entryMapping
+
+	return indexMapping
+}
+
+// InitResultsIndex opens the results index (creating it if necessary), and brings it up to date with the
+// contents of the results directory.
+func InitResultsIndex() error {
+	index, err := bleve.Open(resultsIndexPath())
+
+	if err == bleve.ErrorIndexPathDoesNotExist {
+		logrus.Infof("Creating results index")
+
+		index, err = bleve.New(resultsIndexPath(), resultsIndexMapping())
+	}
+
+	if err != nil {
+		return err
+	}
+
+	ri := &ResultsIndex{index: index}
+
+	if err := ri.Sync(); err != nil {
+		_ = index.Close()
+		return err
+	}
+
+	resultsIndex = ri
+
+	return nil
+}
+
+func resultsIndexPath() string {
+	return filepath.Join(ServerInstallPath, "search-index", "results")
+}
+
+var ErrResultsIndexInUse = errors.New("servermanager: the results index is in use by another program")
+
+// CheckResultsIndexNotInUse returns ErrResultsIndexInUse if another program (usually a running Server Manager) has
+// the results index open. The index is opened without a timeout, so this should be checked before opening it
+// anywhere other than when Server Manager starts.
+func CheckResultsIndexNotInUse(timeout time.Duration) error {
+	// bleve keeps the index in a boltdb file named 'store' inside the index directory.
+	path := filepath.Join(resultsIndexPath(), "store")
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: timeout, ReadOnly: true})
+
+	if err == bbolt.ErrTimeout {
+		return ErrResultsIndexInUse
+	} else if err != nil {
+		return err
+	}
+
+	return db.Close()
+}
+
+// CloseResultsIndex closes the results index, if it has been initialised.
+func CloseResultsIndex() error {
+	if resultsIndex == nil {
+		return nil
+	}
+
+	ri := resultsIndex
+	resultsIndex = nil
+
+	ri.mutex.Lock()
+	defer ri.mutex.Unlock()
+
+	return ri.index.Close()
+}
+
+// RebuildResultsIndex deletes the results index and indexes every results file again, returning the number of
+// results files in the new index. The index is left open if it was open before.
+func RebuildResultsIndex() (int, error) {
+	wasOpen := resultsIndex != nil
+
+	if err := CloseResultsIndex(); err != nil {
+		return 0, err
+	}
+
+	if err := os.RemoveAll(resultsIndexPath()); err != nil {
+		return 0, err
+	}
+
+	if err := InitResultsIndex(); err != nil {
+		return 0, err
+	}
+
+	count, err := resultsIndex.index.DocCount()
+
+	if err != nil {
+		return 0, err
+	}
+
+	if !wasOpen {
+		return int(count), CloseResultsIndex()
+	}
+
+	return int(count), nil
+}
+
+// Sync adds any results files which are missing from the index, and removes any which no longer exist.
+func (ri *ResultsIndex) Sync() error {
+	started := time.Now()
+
+	resultFiles, err := ioutil.ReadDir(filepath.Join(ServerInstallPath, "results"))
+
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	indexed, err := ri.indexedSessionFiles()
+
+	if err != nil {
+		return err
+	}
+
+	numAdded := 0
+
+	for _, resultFile := range resultFiles {
+		if resultFile.IsDir() || filepath.Ext(resultFile.Name()) != ".json" {
+			continue
+		}
+
+		sessionFile := strings.TrimSuffix(resultFile.Name(), ".json")
+
+		if indexed[sessionFile] {
+			delete(indexed, sessionFile)
+			continue
+		}
+
+		if err := ri.IndexFile(resultFile.Name()); err != nil {
+			logrus.WithError(err).Errorf("Could not index results file: %s", resultFile.Name())
+			continue
+		}
+
+		numAdded++
+	}
+
+	for sessionFile := range indexed {
+		if err := ri.Remove(sessionFile); err != nil {
+			return err
+		}
+	}
+
+	if numAdded > 0 || len(indexed) > 0 {
+		logrus.Infof("Results index updated, %d added, %d removed (took: %s)", numAdded, len(indexed), time.Since(started).String())
+	}
+
+	return nil
+}
+
+func (ri *ResultsIndex) indexedSessionFiles() (map[string]bool, error) {
+	ri.mutex.RLock()
+	defer ri.mutex.RUnlock()
+
+	count, err := ri.index.DocCount()
+
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]bool, count)
+
+	if count == 0 {
+		return out, nil
+	}
+
+	results, err := ri.index.Search(bleve.NewSearchRequestOptions(bleve.NewMatchAllQuery(), int(count), 0, false))
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, hit := range results.Hits {
+		out[hit.ID] = true
+	}
+
+	return out, nil
+}
+
+// IndexFile adds (or updates) a results file in the index. fileName includes the .json extension.
+func (ri *ResultsIndex) IndexFile(fileName string) error {
+	results, err := LoadResult(fileName, LoadResultWithoutPluginFire)
+
+	if err != nil {
+		return err
+	}
+
+	return ri.Index(results)
+}
+
+// Index adds (or updates) results in the index.
+func (ri *ResultsIndex) Index(results *SessionResults) error {
+	ri.mutex.Lock()
+	defer ri.mutex.Unlock()
+
+	return ri.index.Index(results.SessionFile, newResultsIndexEntry(results))
+}
+
+// Remove deletes a session file from the index.
+func (ri *ResultsIndex) Remove(sessionFile string) error {
+	ri.mutex.Lock()
+	defer ri.mutex.Unlock()
+
+	return ri.index.Delete(sessionFile)
+}
+
+// Search finds session files matching the query, newest first, returning the session files for the requested
+// page and the total number of matches.
+func (ri *ResultsIndex) Search(ctx context.Context, rq ResultsQuery) ([]string, int, error) {
+	ri.mutex.RLock()
+	defer ri.mutex.RUnlock()
+
+	size, from := rq.PageSize, rq.Page*rq.PageSize
+
+	if size == 0 {
+		count, err := ri.index.DocCount()
+
+		if err != nil {
+			return nil, 0, err
+		}
+
+		size, from = int(count), 0
+	}
+
+	request := bleve.NewSearchRequestOptions(rq.query(), size, from, false)
+	request.SortBy([]string{"-Date", "-_id"})
+
+	results, err := ri.index.SearchInContext(ctx, request)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sessionFiles := make([]string, 0, len(results.Hits))
+
+	for _, hit := range results.Hits {
+		sessionFiles = append(sessionFiles, hit.ID)
+	}
+
+	return sessionFiles, int(results.Total), nil
+}
+
+// indexResultsFile updates the results index (if there is one) with a results file. The results file on disk
+// is the source of truth, so failures are logged rather than returned. fileName includes the .json extension.
+func indexResultsFile(fileName string) {
+	if resultsIndex == nil {
+		return
+	}
+
+	if err := resultsIndex.IndexFile(fileName); err != nil {
+		logrus.WithError(err).Errorf("Could not update results index for: %s", fileName)
+	}
+}
+
+// FindResults returns the results files matching the query, newest first, along with the total number of
+// matches. If the results index has not been initialised, the results directory is read instead.
+func FindResults(rq ResultsQuery, opts ...LoadResultOpts) ([]SessionResults, int, error) {
+	// negative pages, and pages so far in that their offset would overflow, can't have any results.
+	if rq.Page < 0 || (rq.PageSize > 0 && rq.Page > math.MaxInt32/rq.PageSize) {
+		return nil, 0, ErrResultsPageNotFound
+	}
+
+	for {
+		var sessionFiles []string
+		var total int
+		var err error
+
+		if resultsIndex != nil {
+			sessionFiles, total, err = resultsIndex.Search(context.Background(), rq)
+		} else {
+			sessionFiles, total, err = scanResults(rq)
+		}
+
+		if err != nil {
+			return nil, 0, err
+		}
+
+		var results []SessionResults
+		removedFromIndex := false
+
+		for _, sessionFile := range sessionFiles {
+			result, err := LoadResult(sessionFile+".json", opts...)
+
+			if os.IsNotExist(err) && resultsIndex != nil {
+				// the results file was deleted outside of Server Manager, so it shouldn't be in the index any more.
+				logrus.Infof("Results file: %s no longer exists, removing it from the results index", sessionFile)
+
+				if err := resultsIndex.Remove(sessionFile); err != nil {
+					return nil, 0, err
+				}
+
+				removedFromIndex = true
+				continue
+			} else if err != nil {
+				logrus.WithError(err).Errorf("Could not load results file: %s", sessionFile)
+				continue
+			}
+
+			results = append(results, *result)
+		}
+
+		if !removedFromIndex {
+			return results, total, nil
+		}
+
+		// search again now that the missing files have been removed, so that the page and total are correct.
+	}
+}
+
+// scanResults filters the results directory without an index.
+func scanResults(rq ResultsQuery) ([]string, int, error) {
+	allResults, err := scanAllResults()
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var sessionFiles []string
+
+	for _, result := range allResults {
+		result := result
+
+		if rq.matches(newResultsIndexEntry(&result)) {
+			sessionFiles = append(sessionFiles, result.SessionFile)
+		}
+	}
+
+	total := len(sessionFiles)
+
+	if rq.PageSize > 0 {
+		start := int(math.Min(float64(rq.Page*rq.PageSize), float64(total)))
+		end := int(math.Min(float64(start+rq.PageSize), float64(total)))
+
+		sessionFiles = sessionFiles[start:end]
+	}
+
+	return sessionFiles, total, nil
+}
@@ -0,0 +1,221 @@
+package servermanager
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blevesearch/bleve"
+)
+
+func TestResultsIndex_Search(t *testing.T) {
+	ServerInstallPath = filepath.Join("cmd", "server-manager", "assetto")
+
+	index, err := bleve.NewMemOnly(resultsIndexMapping())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ri := &ResultsIndex{index: index}
+
+	resultFiles, err := ioutil.ReadDir(filepath.Join(ServerInstallPath, "results"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var allResults []*SessionResults
+
+	for _, resultFile := range resultFiles {
+		results, err := LoadResult(resultFile.Name(), LoadResultWithoutPluginFire)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ri.Index(results); err != nil {
+			t.Fatal(err)
+		}
+
+		allResults = append(allResults, results)
+	}
+
+	if len(allResults) == 0 {
+		t.Skip("no results fixtures found")
+	}
+
+	// every query built from a results file should behave the same with and without the index.
+	example := allResults[0]
+
+	queries := map[string]ResultsQuery{
+		"all":          {},
+		"track":        {Track: example.TrackName},
+		"track layout": {Track: example.TrackName, TrackLayout: example.TrackConfig, MatchTrackLayout: true},
+		"session type": {SessionType: example.Type},
+		"car":          {Car: example.Result[0].CarModel},
+		"driver":       {DriverGUID: example.Result[0].DriverGUID},
+		"date range":   {From: example.Date, To: example.Date.AddDate(0, 0, 1)},
+		"paginated":    {Page: 1, PageSize: 4},
+	}
+
+	for name, query := range queries {
+		t.Run(name, func(t *testing.T) {
+			sessionFiles, total, err := ri.Search(context.Background(), query)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expectedTotal := 0
+
+			for _, results := range allResults {
+				if query.matches(newResultsIndexEntry(results)) {
+					expectedTotal++
+				}
+			}
+
+			if expectedTotal == 0 {
+				t.Fatal("expected query to match at least the example results file")
+			}
+
+			if total != expectedTotal {
+				t.Errorf("expected %d results, got %d", expectedTotal, total)
+			}
+
+			if query.PageSize == 0 && len(sessionFiles) != total {
+				t.Errorf("expected all %d session files to be returned, got %d", total, len(sessionFiles))
+			}
+
+			if query.PageSize > 0 && len(sessionFiles) > query.PageSize {
+				t.Errorf("expected at most %d session files, got %d", query.PageSize, len(sessionFiles))
+			}
+
+			for i := 1; i < len(sessionFiles); i++ {
+				previous, _ := GetResultDate(sessionFiles[i-1])
+				current, _ := GetResultDate(sessionFiles[i])
+
+				if current.After(previous) {
+					t.Errorf("expected results to be sorted newest first, %s is before %s", sessionFiles[i-1], sessionFiles[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFindResults_RemovesDeletedFilesFromIndex(t *testing.T) {
+	dir, err := ioutil.TempDir("", "results-index")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	oldInstallPath := ServerInstallPath
+	ServerInstallPath = dir
+
+	defer func() {
+		ServerInstallPath = oldInstallPath
+	}()
+
+	index, err := bleve.NewMemOnly(resultsIndexMapping())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldResultsIndex := resultsIndex
+	resultsIndex = &ResultsIndex{index: index}
+
+	defer func() {
+		resultsIndex = oldResultsIndex
+	}()
+
+	fixtures, err := filepath.Glob(filepath.Join("fixtures", "results", "*.json"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fixtures) < 2 {
+		t.Skip("not enough results fixtures found")
+	}
+
+	for _, fixture := range fixtures {
+		if _, err := ImportResultsFile(fixture, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// results files can be deleted outside of Server Manager, which leaves them in the index.
+	if err := os.Remove(filepath.Join(dir, "results", filepath.Base(fixtures[0]))); err != nil {
+		t.Fatal(err)
+	}
+
+	results, total, err := FindResults(ResultsQuery{PageSize: len(fixtures) - 1}, LoadResultWithoutPluginFire)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if total != len(fixtures)-1 || len(results) != len(fixtures)-1 {
+		t.Errorf("Expected %d results, got %d of %d", len(fixtures)-1, len(results), total)
+	}
+
+	indexed, err := resultsIndex.indexedSessionFiles()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if indexed[strings.TrimSuffix(filepath.Base(fixtures[0]), ".json")] || len(indexed) != len(fixtures)-1 {
+		t.Errorf("Expected the deleted results file to be removed from the index, got %v", indexed)
+	}
+}
+
+func TestListResults_PagesOutOfRange(t *testing.T) {
+	dir, err := ioutil.TempDir("", "results-index")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	oldInstallPath := ServerInstallPath
+	ServerInstallPath = dir
+
+	defer func() {
+		ServerInstallPath = oldInstallPath
+	}()
+
+	if _, err := ImportResultsFile(filepath.Join("fixtures", "results", "2019_2_15_21_16_RACE.json"), false); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, page := range []string{"-1", "9223372036854775807"} {
+		t.Run("Results page "+page, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			(&ResultsHandler{}).list(w, httptest.NewRequest(http.MethodGet, "/results?page="+page, nil))
+
+			if w.Code != http.StatusNotFound {
+				t.Errorf("Expected status 404, got %d", w.Code)
+			}
+		})
+
+		t.Run("API page "+page, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			(&APIHandler{}).listResults(w, httptest.NewRequest(http.MethodGet, apiPrefix+"/results?page="+page, nil))
+
+			if w.Code != http.StatusNotFound {
+				t.Errorf("Expected status 404, got %d: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
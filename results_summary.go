@@ -0,0 +1,339 @@
+package servermanager
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	resultsSummaryPodiumPlaces = 3
+	resultsSummaryNumStandings = 10
+)
+
+// ResultsSummary is a short description of how a session finished, used for end of session notifications.
+type ResultsSummary struct {
+	SessionType SessionType
+	Track       string
+	TrackLayout string
+	Date        time.Time
+	URL         string
+
+	ChampionshipID   string
+	ChampionshipName string
+	RaceWeekendID    string
+
+	Classes   []*ResultsSummaryClass
+	Penalties []*ResultsSummaryPenalty
+
+	// Standings are the championship standings after the session, for championship and race weekend sessions.
+	Standings []*ResultsSummaryStandings
+}
+
+// ResultsSummaryClass is the top of the results for a single class in a session.
+type ResultsSummaryClass struct {
+	Name string
+
+	// Podium is the top three in a race, or the three fastest drivers in any other session.
+	Podium     []*ResultsSummaryEntry
+	FastestLap *ResultsSummaryEntry
+
+	// WinningMargin is the gap from first to second place in a race. If second place finished laps down,
+	// WinningMarginLaps is set instead.
+	WinningMargin     time.Duration
+	WinningMarginLaps int
+}
+
+// ResultsSummaryEntry is a driver's result. Time is their total time in a race, and their best lap otherwise.
+type ResultsSummaryEntry struct {
+	Position   int
+	DriverGUID string
+	DriverName string
+	Car        string
+	Time       time.Duration
+}
+
+// ResultsSummaryPenalty is a penalty or disqualification given to a driver during the session.
+type ResultsSummaryPenalty struct {
+	DriverGUID   string
+	DriverName   string
+	Car          string
+	PenaltyTime  time.Duration
+	LapPenalty   int
+	Disqualified bool
+}
+
+// ResultsSummaryStandings are the standings for a championship class.
+type ResultsSummaryStandings struct {
+	ClassName string
+	Standings []ChampionshipStandingSummary
+}
+
+// ChampionshipStandingSummary is a single position in a championship class.
+type ChampionshipStandingSummary struct {
+	Position   int
+	DriverGUID string
+	DriverName string
+	Team       string
+	Car        string
+	Points     float64
+}
+
+// summariseChampionshipStandings lists the standings for each class in a championship, in class order.
+func summariseChampionshipStandings(championship *Championship) []*ResultsSummaryStandings {
+	var out []*ResultsSummaryStandings
+
+	for _, class := range championship.Classes {
+		standings := &ResultsSummaryStandings{
+			ClassName: class.Name,
+		}
+
+		for i, standing := range class.Standings(championship, championship.Events) {
+			standings.Standings = append(standings.Standings, ChampionshipStandingSummary{
+				Position:   i + 1,
+				DriverGUID: standing.Car.GetGUID(),
+				DriverName: driverName(standing.Car.GetName()),
+				Team:       standing.Car.GetTeam(),
+				Car:        standing.Car.GetCar(),
+				Points:     standing.Points,
+			})
+		}
+
+		out = append(out, standings)
+	}
+
+	return out
+}
+
+// NewResultsSummary summarises a session's results. If the session is part of a championship, the championship
+// (with any race weekends in it loaded) should be passed in so that class names and standings can be included.
+func NewResultsSummary(results *SessionResults, championship *Championship) *ResultsSummary {
+	summary := &ResultsSummary{
+		SessionType:    results.Type,
+		Track:          results.TrackName,
+		TrackLayout:    results.TrackConfig,
+		Date:           results.Date,
+		URL:            results.GetURL(),
+		ChampionshipID: results.ChampionshipID,
+		RaceWeekendID:  results.RaceWeekendID,
+	}
+
+	classes := make(map[uuid.UUID]*ResultsSummaryClass)
+
+	for _, result := range results.Result {
+		if result.HasPenalty || result.Disqualified {
+			summary.Penalties = append(summary.Penalties, &ResultsSummaryPenalty{
+				DriverGUID:   result.DriverGUID,
+				DriverName:   driverName(result.DriverName),
+				Car:          result.CarModel,
+				PenaltyTime:  result.PenaltyTime,
+				LapPenalty:   result.LapPenalty,
+				Disqualified: result.Disqualified,
+			})
+		}
+
+		class, ok := classes[result.ClassID]
+
+		if !ok {
+			class = &ResultsSummaryClass{}
+
+			if championship != nil {
+				if championshipClass, err := championship.ClassByID(result.ClassID.String()); err == nil {
+					class.Name = championshipClass.Name
+				}
+			}
+
+			if fastestLap := resultsSummaryFastestLap(results, result.ClassID); fastestLap != nil {
+				class.FastestLap = &ResultsSummaryEntry{
+					DriverGUID: fastestLap.DriverGUID,
+					DriverName: driverName(fastestLap.DriverName),
+					Car:        fastestLap.CarModel,
+					Time:       fastestLap.GetLapTime(),
+				}
+			}
+
+			classes[result.ClassID] = class
+			summary.Classes = append(summary.Classes, class)
+		}
+
+		if result.Disqualified || len(class.Podium) >= resultsSummaryPodiumPlaces {
+			continue
+		}
+
+		entry := &ResultsSummaryEntry{
+			Position:   len(class.Podium) + 1,
+			DriverGUID: result.DriverGUID,
+			DriverName: driverName(result.DriverName),
+			Car:        result.CarModel,
+		}
+
+		if results.Type == SessionTypeRace {
+			entry.Time = results.GetTime(result.TotalTime, result.DriverGUID, result.CarModel, true)
+
+			if entry.Position == 2 {
+				winner := class.Podium[0]
+
+				winnerLaps := results.GetNumLaps(winner.DriverGUID, winner.Car)
+				secondLaps := results.GetNumLaps(result.DriverGUID, result.CarModel)
+
+				if secondLaps < winnerLaps {
+					class.WinningMarginLaps = winnerLaps - secondLaps
+				} else {
+					class.WinningMargin = entry.Time - winner.Time
+				}
+			}
+		} else {
+			entry.Time = time.Duration(result.BestLap) * time.Millisecond
+		}
+
+		if entry.Time <= 0 {
+			// drivers that didn't set a time haven't finished on the podium
+			continue
+		}
+
+		class.Podium = append(class.Podium, entry)
+	}
+
+	if championship != nil {
+		summary.ChampionshipName = championship.Name
+		summary.Standings = summariseChampionshipStandings(championship)
+	}
+
+	return summary
+}
+
+// resultsSummaryFastestLap finds the fastest valid lap in a class, ignoring laps set by disqualified drivers.
+func resultsSummaryFastestLap(results *SessionResults, classID uuid.UUID) *SessionLap {
+	disqualified := make(map[int]bool)
+
+	for _, result := range results.Result {
+		if result.Disqualified {
+			disqualified[result.CarID] = true
+		}
+	}
+
+	var fastestLap *SessionLap
+
+	for _, lap := range results.Laps {
+		if lap.ClassID != classID || lap.Cuts > 0 || lap.LapTime <= 0 || disqualified[lap.CarID] {
+			continue
+		}
+
+		if fastestLap == nil || lap.LapTime < fastestLap.LapTime {
+			fastestLap = lap
+		}
+	}
+
+	return fastestLap
+}
+
+// Title describes the session which has ended.
+func (s *ResultsSummary) Title() string {
+	title := fmt.Sprintf("%s results at %s", prettifyName(s.SessionType.String(), false), trackSummary(s.Track, s.TrackLayout))
+
+	if s.ChampionshipName != "" {
+		title = s.ChampionshipName + ": " + title
+	}
+
+	return title
+}
+
+// String formats the summary as a plain text message.
+func (s *ResultsSummary) String() string {
+	var lines []string
+
+	podiumName := "Top 3"
+
+	if s.SessionType == SessionTypeRace {
+		podiumName = "Podium"
+	}
+
+	for _, class := range s.Classes {
+		if len(class.Podium) == 0 {
+			continue
+		}
+
+		if class.Name != "" && len(s.Classes) > 1 {
+			lines = append(lines, fmt.Sprintf("%s (%s):", podiumName, class.Name))
+		} else {
+			lines = append(lines, podiumName+":")
+		}
+
+		for _, entry := range class.Podium {
+			lines = append(lines, fmt.Sprintf("%d. %s (%s) %s", entry.Position, entry.DriverName, prettifyName(entry.Car, true), formatDuration(entry.Time, true)))
+		}
+
+		if class.WinningMarginLaps > 0 {
+			lines = append(lines, fmt.Sprintf("Winning margin: %d lap(s)", class.WinningMarginLaps))
+		} else if class.WinningMargin > 0 {
+			lines = append(lines, fmt.Sprintf("Winning margin: %s", formatDuration(class.WinningMargin, true)))
+		}
+
+		if class.FastestLap != nil {
+			lines = append(lines, fmt.Sprintf("Fastest lap: %s, %s (%s)", class.FastestLap.DriverName, formatDuration(class.FastestLap.Time, true), prettifyName(class.FastestLap.Car, true)))
+		}
+
+		lines = append(lines, "")
+	}
+
+	if len(s.Penalties) > 0 {
+		lines = append(lines, "Penalties:")
+
+		for _, penalty := range s.Penalties {
+			lines = append(lines, fmt.Sprintf("%s: %s", penalty.DriverName, penalty.Description()))
+		}
+
+		lines = append(lines, "")
+	}
+
+	for _, standings := range s.Standings {
+		if len(standings.Standings) == 0 {
+			continue
+		}
+
+		if standings.ClassName != "" && len(s.Standings) > 1 {
+			lines = append(lines, fmt.Sprintf("Standings (%s):", standings.ClassName))
+		} else {
+			lines = append(lines, "Standings:")
+		}
+
+		for i, standing := range standings.Standings {
+			if i >= resultsSummaryNumStandings {
+				break
+			}
+
+			lines = append(lines, fmt.Sprintf("%d. %s - %.f pts", standing.Position, standing.DriverName, standing.Points))
+		}
+
+		lines = append(lines, "")
+	}
+
+	lines = append(lines, fmt.Sprintf("Full results: %s", s.URL))
+
+	return strings.Join(lines, "\n")
+}
+
+// Description is a short explanation of the penalty, e.g. "5s time penalty".
+func (p *ResultsSummaryPenalty) Description() string {
+	if p.Disqualified {
+		return "disqualified"
+	}
+
+	var penalties []string
+
+	if p.PenaltyTime > 0 {
+		penalties = append(penalties, fmt.Sprintf("%s time penalty", p.PenaltyTime.Round(time.Millisecond)))
+	}
+
+	if p.LapPenalty > 0 {
+		penalties = append(penalties, fmt.Sprintf("%d lap penalty", p.LapPenalty))
+	}
+
+	if len(penalties) == 0 {
+		return "penalised"
+	}
+
+	return strings.Join(penalties, ", ")
+}
@@ -0,0 +1,73 @@
+package servermanager
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewResultsSummary(t *testing.T) {
+	results := &SessionResults{
+		Type: SessionTypeRace,
+		Cars: []*SessionCar{
+			{CarID: 0, Model: "car", Driver: SessionDriver{GUID: "1", Name: "Driver 1"}},
+			{CarID: 1, Model: "car", Driver: SessionDriver{GUID: "2", Name: "Driver 2"}},
+			{CarID: 2, Model: "car", Driver: SessionDriver{GUID: "3", Name: "Driver 3"}},
+			{CarID: 3, Model: "car", Driver: SessionDriver{GUID: "4", Name: "Driver 4"}},
+		},
+		Laps: []*SessionLap{
+			{CarID: 0, DriverGUID: "1", CarModel: "car", LapTime: 100000},
+			{CarID: 1, DriverGUID: "2", CarModel: "car", LapTime: 101000},
+			{CarID: 2, DriverGUID: "3", CarModel: "car", LapTime: 110000},
+			{CarID: 3, DriverGUID: "4", CarModel: "car", LapTime: 99000},
+			{CarID: 0, DriverGUID: "1", CarModel: "car", LapTime: 100000},
+			{CarID: 1, DriverGUID: "2", CarModel: "car", LapTime: 101500},
+			{CarID: 3, DriverGUID: "4", CarModel: "car", LapTime: 99000},
+		},
+		Result: []*SessionResult{
+			{CarID: 0, DriverGUID: "1", DriverName: "Driver 1", CarModel: "car", TotalTime: 200000, BestLap: 100000},
+			{CarID: 1, DriverGUID: "2", DriverName: "Driver 2", CarModel: "car", TotalTime: 202500, BestLap: 101000, HasPenalty: true, PenaltyTime: time.Second},
+			{CarID: 2, DriverGUID: "3", DriverName: "Driver 3", CarModel: "car", TotalTime: 110000, BestLap: 110000},
+			{CarID: 3, DriverGUID: "4", DriverName: "Driver 4", CarModel: "car", TotalTime: 198000, BestLap: 99000, Disqualified: true},
+		},
+	}
+
+	summary := NewResultsSummary(results, nil)
+
+	if len(summary.Classes) != 1 {
+		t.Fatalf("Expected 1 class, got %d", len(summary.Classes))
+	}
+
+	class := summary.Classes[0]
+
+	if len(class.Podium) != 3 {
+		t.Fatalf("Expected 3 drivers on the podium, got %d", len(class.Podium))
+	}
+
+	for i, guid := range []string{"1", "2", "3"} {
+		if class.Podium[i].DriverGUID != guid || class.Podium[i].Position != i+1 {
+			t.Errorf("Expected driver %s in P%d, got driver %s in P%d", guid, i+1, class.Podium[i].DriverGUID, class.Podium[i].Position)
+		}
+	}
+
+	// driver 2's time penalty counts towards the winning margin
+	if class.WinningMargin != 3500*time.Millisecond {
+		t.Errorf("Expected a winning margin of 3.5s, got %s", class.WinningMargin)
+	}
+
+	// the disqualified driver's laps don't count towards the fastest lap
+	if class.FastestLap == nil || class.FastestLap.DriverGUID != "1" {
+		t.Errorf("Expected driver 1 to have the fastest lap, got %v", class.FastestLap)
+	}
+
+	if len(summary.Penalties) != 2 {
+		t.Fatalf("Expected 2 penalties, got %d", len(summary.Penalties))
+	}
+
+	if description := summary.Penalties[0].Description(); description != "1s time penalty" {
+		t.Errorf("Expected a 1s time penalty, got %s", description)
+	}
+
+	if description := summary.Penalties[1].Description(); description != "disqualified" {
+		t.Errorf("Expected a disqualification, got %s", description)
+	}
+}
@@ -0,0 +1,121 @@
+package servermanager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+func TestImportAndAnonymiseResultsFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "results")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	oldInstallPath := ServerInstallPath
+	ServerInstallPath = dir
+
+	defer func() {
+		ServerInstallPath = oldInstallPath
+	}()
+
+	const fileName = "2019_2_15_21_16_RACE.json"
+
+	results, err := ImportResultsFile(filepath.Join("fixtures", "results", fileName), false)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if results.IsAnonymised() {
+		t.Fatal("Expected the fixture not to be anonymised")
+	}
+
+	if _, err := ImportResultsFile(filepath.Join("fixtures", "results", fileName), false); err != ErrResultsFileExists {
+		t.Errorf("Expected ErrResultsFileExists when importing the same file again, got: %v", err)
+	}
+
+	if _, err := ImportResultsFile(filepath.Join("fixtures", "barbagello.json"), false); err != ErrInvalidResultsFile {
+		t.Errorf("Expected ErrInvalidResultsFile when importing a file which is not a results file, got: %v", err)
+	}
+
+	guid := results.Result[0].DriverGUID
+	sessionFile := "2019_2_15_21_16_RACE"
+
+	results.StewardDecisions = []*StewardIncident{{
+		Driver:      StewardIncidentDriver{GUID: udp.DriverGUID(guid), Name: "Driver One"},
+		OtherDriver: &StewardIncidentDriver{GUID: "76561198000000002", Name: "Driver Two"},
+		Map:         &StewardIncidentMap{Cars: []StewardIncidentMapPoint{{DriverGUID: udp.DriverGUID(guid), DriverName: "Driver One"}}},
+	}}
+
+	if err := saveResults(fileName, results); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := saveSessionTelemetry(sessionFile, &SessionTelemetry{Laps: []*LapTelemetry{{DriverGUID: guid}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.MkdirAll(sessionRecordingsPath(), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(sessionRecordingPath(sessionFile), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	anonymised, err := AnonymiseResultsFile(fileName)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !anonymised {
+		t.Error("Expected the results file to be anonymised")
+	}
+
+	results, err = LoadResult(fileName, LoadResultWithoutPluginFire)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !results.IsAnonymised() {
+		t.Error("Expected the saved results file to be anonymised")
+	}
+
+	for _, driver := range results.StewardDecisions[0].Drivers() {
+		if !isAnonymisedDriverGUID(string(driver.GUID)) || !strings.HasSuffix(driver.Name, ".") {
+			t.Errorf("Expected the steward decision drivers to be anonymised, got %s %s", driver.GUID, driver.Name)
+		}
+	}
+
+	if car := results.StewardDecisions[0].Map.Cars[0]; car.DriverGUID != udp.DriverGUID(AnonymiseDriverGUID(guid)) {
+		t.Errorf("Expected the steward decision map to be anonymised, got %s", car.DriverGUID)
+	}
+
+	sessionTelemetry, err := LoadSessionTelemetry(sessionFile)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sessionTelemetry.Laps[0].DriverGUID != AnonymiseDriverGUID(guid) {
+		t.Errorf("Expected the telemetry to be anonymised, got %s", sessionTelemetry.Laps[0].DriverGUID)
+	}
+
+	if results.HasRecording() {
+		t.Error("Expected the session recording to be deleted")
+	}
+
+	if anonymised, err := AnonymiseResultsFile(fileName); err != nil || anonymised {
+		t.Errorf("Expected an anonymised results file to be left as it is, got %t, %v", anonymised, err)
+	}
+}
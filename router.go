@@ -65,6 +65,17 @@ func Router(
 	healthCheck *HealthCheck,
 	kissMyRankHandler *KissMyRankHandler,
 	realPenaltyHandler *RealPenaltyHandler,
+	apiHandler *APIHandler,
+	driversHandler *DriversHandler,
+	webhooksHandler *WebhooksHandler,
+	instancesHandler *InstancesHandler,
+	protestsHandler *ProtestsHandler,
+	sessionRecordingsHandler *SessionRecordingsHandler,
+	lapTelemetryHandler *LapTelemetryHandler,
+	blocklistHandler *BlocklistHandler,
+	recycleBinHandler *RecycleBinHandler,
+	backupsHandler *BackupsHandler,
+	configYAMLHandler *ConfigYAMLHandler,
 ) http.Handler {
 	r := chi.NewRouter()
 
@@ -116,6 +127,7 @@ func Router(
 		r.Get("/car/{car_id}", carsHandler.view)
 		r.Get("/tracks", tracksHandler.list)
 		r.Get("/track/{track_id}", tracksHandler.view)
+		r.Get("/track/{track_id}/leaderboard", tracksHandler.leaderboard)
 		r.Get("/weather", weatherHandler.list)
 
 		r.Get("/events.ics", scheduledRacesHandler.allScheduledRacesICalHandler)
@@ -126,6 +138,9 @@ func Router(
 		r.Get("/results/{fileName}", resultsHandler.view)
 		r.HandleFunc("/results/{fileName}/collisions", resultsHandler.renderCollisions)
 		r.HandleFunc("/results/download/{fileName}", resultsHandler.file)
+		r.Get("/results/{fileName}/replay", sessionRecordingsHandler.replay)
+		r.Get("/api/results/{fileName}/replay", sessionRecordingsHandler.replayWebsocket)
+		r.Get("/results/{fileName}/telemetry", lapTelemetryHandler.compare)
 
 		r.Get("/custom", customRaceHandler.list)
 
@@ -133,6 +148,9 @@ func Router(
 		r.Get("/championships", championshipsHandler.list)
 		r.Get("/championship/{championshipID}", championshipsHandler.view)
 		r.Get("/championship/{championshipID}/export", championshipsHandler.export)
+		r.Get("/championship/{championshipID}/export/xlsx", championshipsHandler.exportXLSX)
+		r.Get("/championship/{championshipID}/export/csv/{table}", championshipsHandler.exportCSV)
+		r.Get("/championship/{championshipID}/print", championshipsHandler.print)
 		r.HandleFunc("/championship/{championshipID}/export-results", championshipsHandler.exportResults)
 		r.Get("/championship/{championshipID}/ics", championshipsHandler.icalFeed)
 		r.Get("/championship/{championshipID}/sign-up", championshipsHandler.signUpForm)
@@ -172,6 +190,8 @@ func Router(
 		}))
 		r.HandleFunc("/accounts/dismiss-changelog", accountHandler.dismissChangelog)
 		r.HandleFunc("/accounts/dismiss-intro", accountHandler.dismissIntro)
+		r.HandleFunc("/accounts/api-tokens", accountHandler.apiTokens)
+		r.HandleFunc("/accounts/api-tokens/{id}/revoke", accountHandler.revokeAPIToken)
 
 		FileServer(r, "/content", http.Dir(filepath.Join(ServerInstallPath, "content")), true)
 		FileServer(r, "/setups/download", http.Dir(filepath.Join(ServerInstallPath, "setups")), true)
@@ -190,6 +210,33 @@ func Router(
 		r.Post("/race-weekend/{raceWeekendID}/grid-preview", raceWeekendHandler.gridPreview)
 		r.Get("/race-weekend/{raceWeekendID}/entrylist-preview", raceWeekendHandler.entryListPreview)
 		r.Get("/race-weekend/{raceWeekendID}/export", raceWeekendHandler.export)
+
+		// drivers
+		r.Get("/driver/{guid}", driversHandler.view)
+
+		// instances
+		r.Get("/instances", instancesHandler.list)
+
+		// protests
+		r.Get("/protests", protestsHandler.list)
+		r.Get("/protests/{protestID}", protestsHandler.view)
+		r.Post("/protests/{protestID}/appeal", protestsHandler.appeal)
+		r.Get("/results/{fileName}/protest", protestsHandler.protestForm)
+		r.Post("/results/{fileName}/protest", protestsHandler.file)
+
+		// api
+		r.Get(apiPrefix+"/custom-races", apiHandler.listCustomRaces)
+		r.Get(apiPrefix+"/custom-races/{uuid}", apiHandler.getCustomRace)
+		r.Get(apiPrefix+"/championships", apiHandler.listChampionships)
+		r.Get(apiPrefix+"/championships/{championshipID}", apiHandler.getChampionship)
+		r.Get(apiPrefix+"/championships/{championshipID}/events", apiHandler.listChampionshipEvents)
+		r.Get(apiPrefix+"/championships/{championshipID}/events/{eventID}", apiHandler.getChampionshipEvent)
+		r.Get(apiPrefix+"/race-weekends", apiHandler.listRaceWeekends)
+		r.Get(apiPrefix+"/race-weekends/{raceWeekendID}", apiHandler.getRaceWeekend)
+		r.Get(apiPrefix+"/race-weekends/{raceWeekendID}/sessions", apiHandler.listRaceWeekendSessions)
+		r.Get(apiPrefix+"/race-weekends/{raceWeekendID}/sessions/{sessionID}", apiHandler.getRaceWeekendSession)
+		r.Get(apiPrefix+"/results", apiHandler.listResults)
+		r.Get(apiPrefix+"/drivers/{guid}", apiHandler.getDriver)
 	})
 
 	// writers
@@ -213,6 +260,7 @@ func Router(
 		r.Post("/quick/submit", quickRaceHandler.submit)
 		r.Get("/custom/new", customRaceHandler.createOrEdit)
 		r.Get("/custom/load/{uuid}", customRaceHandler.start)
+		r.Get("/custom/validate/{uuid}", customRaceHandler.validate)
 		r.Post("/custom/schedule/{uuid}", customRaceHandler.schedule)
 		r.Get("/custom/schedule/{uuid}/remove", customRaceHandler.removeSchedule)
 		r.Get("/custom/edit/{uuid}", customRaceHandler.createOrEdit)
@@ -234,6 +282,7 @@ func Router(
 		r.Get("/championship/{championshipID}/event", championshipsHandler.eventConfiguration)
 		r.Post("/championship/{championshipID}/event/submit", championshipsHandler.submitEventConfiguration)
 		r.Get("/championship/{championshipID}/event/{eventID}/start", championshipsHandler.startEvent)
+		r.Get("/championship/{championshipID}/event/{eventID}/validate", championshipsHandler.validateEvent)
 		r.Post("/championship/{championshipID}/event/{eventID}/schedule", championshipsHandler.scheduleEvent)
 		r.Get("/championship/{championshipID}/event/{eventID}/schedule/remove", championshipsHandler.scheduleEventRemove)
 		r.Get("/championship/{championshipID}/event/{eventID}/edit", championshipsHandler.eventConfiguration)
@@ -248,6 +297,10 @@ func Router(
 		r.Get("/championship/{championshipID}/entrants.csv", championshipsHandler.signedUpEntrantsCSV)
 		r.Get("/championship/{championshipID}/entrant/{entrantGUID}", championshipsHandler.modifyEntrantStatus)
 		r.Post("/championship/{championshipID}/reorder-events", championshipsHandler.reorderEvents)
+		r.Get("/championship/{championshipID}/teams", championshipsHandler.teams)
+		r.Post("/championship/{championshipID}/teams", championshipsHandler.submitTeam)
+		r.Post("/championship/{championshipID}/teams/transfer", championshipsHandler.transferTeamDriver)
+		r.Post("/championship/{championshipID}/event/{eventID}/nominations", championshipsHandler.nominateTeamDrivers)
 
 		r.Get("/championship/import", championshipsHandler.importChampionship)
 		r.Post("/championship/import", championshipsHandler.importChampionship)
@@ -268,6 +321,13 @@ func Router(
 		// live timings
 		r.Post("/live-timing/save-frames", raceControlHandler.saveIFrames)
 
+		// protests
+		r.Post("/protests/{protestID}/decision", protestsHandler.decide)
+
+		// stewarding
+		r.Get("/stewards", raceControlHandler.stewards)
+		r.Post("/stewards/incident/{incidentID}/decision", raceControlHandler.stewardDecision)
+
 		// endpoints
 		r.Post("/api/track/upload", contentUploadHandler.upload(ContentTypeTrack))
 		r.Post("/api/car/upload", contentUploadHandler.upload(ContentTypeCar))
@@ -283,6 +343,7 @@ func Router(
 		r.Post("/race-weekend/{raceWeekendID}/session/submit", raceWeekendHandler.submitSessionConfiguration)
 		r.Get("/race-weekend/{raceWeekendID}/session/{sessionID}/edit", raceWeekendHandler.sessionConfiguration)
 		r.Get("/race-weekend/{raceWeekendID}/session/{sessionID}/start", raceWeekendHandler.startSession)
+		r.Get("/race-weekend/{raceWeekendID}/session/{sessionID}/validate", raceWeekendHandler.validateSession)
 		r.Get("/race-weekend/{raceWeekendID}/session/{sessionID}/practice", raceWeekendHandler.startPracticeSession)
 		r.Get("/race-weekend/{raceWeekendID}/session/{sessionID}/restart", raceWeekendHandler.restartSession)
 		r.Get("/race-weekend/{raceWeekendID}/session/{sessionID}/cancel", raceWeekendHandler.cancelSession)
@@ -294,6 +355,38 @@ func Router(
 		r.Post("/race-weekend/import", raceWeekendHandler.importRaceWeekend)
 		r.Post("/race-weekend/{raceWeekendID}/session/{sessionID}/schedule", raceWeekendHandler.scheduleSession)
 		r.Get("/race-weekend/{raceWeekendID}/session/{sessionID}/schedule/remove", raceWeekendHandler.removeSessionSchedule)
+
+		// instances
+		r.Post("/instances/{name}/schedule", instancesHandler.schedule)
+
+		// api
+		r.Post(apiPrefix+"/custom-races", apiHandler.createCustomRace)
+		r.Put(apiPrefix+"/custom-races/{uuid}", apiHandler.updateCustomRace)
+		r.Post(apiPrefix+"/custom-races/{uuid}/start", apiHandler.startCustomRace)
+		r.Get(apiPrefix+"/custom-races/{uuid}/validate", apiHandler.validateCustomRace)
+		r.Post(apiPrefix+"/custom-races/{uuid}/stop", apiHandler.stopCustomRace)
+		r.Post(apiPrefix+"/custom-races/{uuid}/schedule", apiHandler.scheduleCustomRace)
+		r.Delete(apiPrefix+"/custom-races/{uuid}/schedule", apiHandler.removeCustomRaceSchedule)
+
+		r.Post(apiPrefix+"/championships", apiHandler.createChampionship)
+		r.Put(apiPrefix+"/championships/{championshipID}", apiHandler.updateChampionship)
+		r.Post(apiPrefix+"/championships/{championshipID}/events", apiHandler.createChampionshipEvent)
+		r.Put(apiPrefix+"/championships/{championshipID}/events/{eventID}", apiHandler.updateChampionshipEvent)
+		r.Post(apiPrefix+"/championships/{championshipID}/events/{eventID}/start", apiHandler.startChampionshipEvent)
+		r.Get(apiPrefix+"/championships/{championshipID}/events/{eventID}/validate", apiHandler.validateChampionshipEvent)
+		r.Post(apiPrefix+"/championships/{championshipID}/events/{eventID}/stop", apiHandler.stopChampionshipEvent)
+		r.Post(apiPrefix+"/championships/{championshipID}/events/{eventID}/schedule", apiHandler.scheduleChampionshipEvent)
+		r.Delete(apiPrefix+"/championships/{championshipID}/events/{eventID}/schedule", apiHandler.removeChampionshipEventSchedule)
+
+		r.Post(apiPrefix+"/race-weekends", apiHandler.createRaceWeekend)
+		r.Put(apiPrefix+"/race-weekends/{raceWeekendID}", apiHandler.updateRaceWeekend)
+		r.Post(apiPrefix+"/race-weekends/{raceWeekendID}/sessions", apiHandler.createRaceWeekendSession)
+		r.Put(apiPrefix+"/race-weekends/{raceWeekendID}/sessions/{sessionID}", apiHandler.updateRaceWeekendSession)
+		r.Post(apiPrefix+"/race-weekends/{raceWeekendID}/sessions/{sessionID}/start", apiHandler.startRaceWeekendSession)
+		r.Get(apiPrefix+"/race-weekends/{raceWeekendID}/sessions/{sessionID}/validate", apiHandler.validateRaceWeekendSession)
+		r.Post(apiPrefix+"/race-weekends/{raceWeekendID}/sessions/{sessionID}/stop", apiHandler.stopRaceWeekendSession)
+		r.Post(apiPrefix+"/race-weekends/{raceWeekendID}/sessions/{sessionID}/schedule", apiHandler.scheduleRaceWeekendSession)
+		r.Delete(apiPrefix+"/race-weekends/{raceWeekendID}/sessions/{sessionID}/schedule", apiHandler.removeRaceWeekendSessionSchedule)
 	})
 
 	// deleters
@@ -305,6 +398,8 @@ func Router(
 
 		r.Get("/championship/{championshipID}/event/{eventID}/delete", championshipsHandler.deleteEvent)
 		r.Get("/championship/{championshipID}/delete", championshipsHandler.delete)
+		r.Get("/championship/{championshipID}/team/{teamID}/delete", championshipsHandler.deleteTeam)
+		r.Get("/championship/{championshipID}/team/{teamID}/member/{memberIndex}/remove", championshipsHandler.removeTeamMember)
 		r.Get("/custom/delete/{uuid}", customRaceHandler.delete)
 
 		r.Get("/track/{name}/delete", tracksHandler.delete)
@@ -318,6 +413,13 @@ func Router(
 
 		// race weekend
 		r.Get("/race-weekend/{raceWeekendID}/session/{sessionID}/delete", raceWeekendHandler.deleteSession)
+
+		// api
+		r.Delete(apiPrefix+"/custom-races/{uuid}", apiHandler.deleteCustomRace)
+		r.Delete(apiPrefix+"/championships/{championshipID}", apiHandler.deleteChampionship)
+		r.Delete(apiPrefix+"/championships/{championshipID}/events/{eventID}", apiHandler.deleteChampionshipEvent)
+		r.Delete(apiPrefix+"/race-weekends/{raceWeekendID}", apiHandler.deleteRaceWeekend)
+		r.Delete(apiPrefix+"/race-weekends/{raceWeekendID}/sessions/{sessionID}", apiHandler.deleteRaceWeekendSession)
 	})
 
 	// admins
@@ -328,7 +430,23 @@ func Router(
 		}
 
 		r.HandleFunc("/server-options", serverAdministrationHandler.options)
-		r.HandleFunc("/blacklist", serverAdministrationHandler.blacklist)
+		r.Get("/blacklist", blocklistHandler.list)
+		r.Post("/blacklist/ban", blocklistHandler.ban)
+		r.Post("/blacklist/unban", blocklistHandler.unban)
+		r.Get("/recycle-bin", recycleBinHandler.list)
+		r.HandleFunc("/recycle-bin/{type}/{id}/restore", recycleBinHandler.restore)
+		r.HandleFunc("/recycle-bin/{type}/{id}/purge", recycleBinHandler.purge)
+		r.Get("/backups", backupsHandler.list)
+		r.Post("/backups/create", backupsHandler.create)
+		r.Post("/backups/upload", backupsHandler.upload)
+		r.Post("/backups/restore/cancel", backupsHandler.cancelRestore)
+		r.Get("/backups/{name}/download", backupsHandler.download)
+		r.Post("/backups/{name}/restore", backupsHandler.restore)
+		r.HandleFunc("/backups/{name}/delete", backupsHandler.delete)
+		r.Get("/configuration", configYAMLHandler.view)
+		r.Get("/configuration/export", configYAMLHandler.export)
+		r.Post("/configuration/import", configYAMLHandler.importConfig)
+		r.Post("/configuration/import/{token}/apply", configYAMLHandler.applyImport)
 		r.HandleFunc("/motd", serverAdministrationHandler.motd)
 		r.HandleFunc("/current-config", serverAdministrationHandler.currentConfig)
 		r.HandleFunc("/audit-logs", auditLogHandler.viewLogs)
@@ -352,6 +470,17 @@ func Router(
 		r.HandleFunc("/kissmyrank/options", kissMyRankHandler.options)
 		r.HandleFunc("/realpenalty/options", realPenaltyHandler.options)
 		r.HandleFunc("/realpenalty/logs", realPenaltyHandler.downloadLogs)
+
+		r.Get("/webhooks", webhooksHandler.list)
+		r.Post("/webhooks", webhooksHandler.save)
+		r.HandleFunc("/webhooks/{id}/delete", webhooksHandler.delete)
+		r.HandleFunc("/webhooks/{id}/test", webhooksHandler.test)
+
+		// instances
+		r.HandleFunc("/instances/{name}/start", instancesHandler.start)
+		r.HandleFunc("/instances/{name}/stop", instancesHandler.stop)
+		r.HandleFunc("/instances/{name}/restart", instancesHandler.restart)
+		r.Get("/instances/{name}/logs", instancesHandler.logs)
 	})
 
 	FileServer(r, "/static", fs, false)
@@ -7,7 +7,6 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/go-chi/chi"
@@ -215,55 +214,6 @@ func (sah *ServerAdministrationHandler) options(w http.ResponseWriter, r *http.R
 	})
 }
 
-type serverBlacklistTemplateVars struct {
-	BaseTemplateVars
-
-	Text string
-}
-
-func (sah *ServerAdministrationHandler) blacklist(w http.ResponseWriter, r *http.Request) {
-	if r.Method == http.MethodPost {
-		// save to blacklist.txt
-		var text string
-
-		if r.FormValue("type") == "single" {
-			// we're adding a single GUID, load the existing blacklist list then append
-			b, err := ioutil.ReadFile(filepath.Join(ServerInstallPath, "blacklist.txt"))
-			if err != nil {
-				logrus.WithError(err).Error("couldn't find blacklist.txt")
-			}
-
-			text = string(b) + r.FormValue("blacklist")
-		} else {
-			text = r.FormValue("blacklist")
-		}
-
-		if !strings.HasSuffix(text, "\n") {
-			text += "\n"
-		}
-
-		err := ioutil.WriteFile(filepath.Join(ServerInstallPath, "blacklist.txt"), []byte(text), 0644)
-
-		if err != nil {
-			logrus.WithError(err).Error("couldn't save blacklist")
-			AddErrorFlash(w, r, "Failed to save Server blacklist changes")
-		} else {
-			AddFlash(w, r, "Server blacklist successfully changed!")
-		}
-	}
-
-	// load blacklist.txt
-	b, err := ioutil.ReadFile(filepath.Join(ServerInstallPath, "blacklist.txt")) // just pass the file name
-	if err != nil {
-		logrus.WithError(err).Error("couldn't find blacklist.txt")
-	}
-
-	// render blacklist edit page
-	sah.viewRenderer.MustLoadTemplate(w, r, "server/blacklist.html", &serverBlacklistTemplateVars{
-		Text: string(b),
-	})
-}
-
 type autoFillEntrantListTemplateVars struct {
 	BaseTemplateVars
 
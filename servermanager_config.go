@@ -26,6 +26,7 @@ type Configuration struct {
 	Monitoring    MonitoringConfig    `yaml:"monitoring"`
 	Championships ChampionshipsConfig `yaml:"championships"`
 	Lua           LuaConfig           `yaml:"lua"`
+	Instances     InstancesConfig     `yaml:"instances"`
 }
 
 type ChampionshipsConfig struct {
@@ -71,6 +72,19 @@ type LuaConfig struct {
 	Enabled bool `yaml:"enabled"`
 }
 
+// InstancesConfig lists other Server Managers which this Server Manager should run and supervise.
+type InstancesConfig struct {
+	Servers []*InstanceConfig `yaml:"servers"`
+}
+
+type InstanceConfig struct {
+	Name       string `yaml:"name"`
+	Directory  string `yaml:"directory"`
+	URL        string `yaml:"url"`
+	APIToken   string `yaml:"api_token"`
+	Executable string `yaml:"executable"`
+}
+
 const (
 	sessionStoreCookie     = "cookie"
 	sessionStoreFilesystem = "filesystem"
@@ -118,9 +132,29 @@ type StoreConfig struct {
 	Path                    string        `yaml:"path"`
 	SharedPath              string        `yaml:"shared_data_path"`
 	ScheduledEventCheckLoop time.Duration `yaml:"scheduled_event_check_loop"`
+
+	// OpenTimeout is how long to wait for another program to close a boltdb store before giving up. If it is
+	// zero, the store waits forever.
+	OpenTimeout time.Duration `yaml:"-"`
 }
 
+// BuildStore opens the store and runs any migrations which have not yet been run.
 func (s *StoreConfig) BuildStore() (Store, error) {
+	rs, err := s.OpenStore()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := Migrate(rs); err != nil {
+		return nil, err
+	}
+
+	return rs, nil
+}
+
+// OpenStore opens the store without running any migrations.
+func (s *StoreConfig) OpenStore() (Store, error) {
 	var rs Store
 
 	if s.SharedPath == "" {
@@ -129,7 +163,10 @@ func (s *StoreConfig) BuildStore() (Store, error) {
 
 	switch s.Type {
 	case "boltdb":
-		bbdb, err := bbolt.Open(s.Path, 0644, nil)
+		options := *bbolt.DefaultOptions
+		options.Timeout = s.OpenTimeout
+
+		bbdb, err := bbolt.Open(s.Path, 0644, &options)
 
 		if err != nil {
 			return nil, err
@@ -138,12 +175,20 @@ func (s *StoreConfig) BuildStore() (Store, error) {
 		rs = NewBoltStore(bbdb)
 	case "json":
 		rs = NewJSONStore(s.Path, s.SharedPath)
-	default:
-		return nil, fmt.Errorf("invalid store type (%s), must be either boltdb/json", s.Type)
-	}
+	case "sqlite":
+		db, err := OpenSQLiteStore(s.Path)
 
-	if err := Migrate(rs); err != nil {
-		return nil, err
+		if err != nil {
+			return nil, err
+		}
+
+		rs, err = NewSQLiteStore(db)
+
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("invalid store type (%s), must be either boltdb/json/sqlite", s.Type)
 	}
 
 	return rs, nil
@@ -0,0 +1,818 @@
+package servermanager
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/etcd-io/bbolt"
+	"github.com/go-chi/chi"
+	"github.com/sirupsen/logrus"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp/replay"
+)
+
+const (
+	sessionRecordingsDirectory     = "recordings"
+	sessionRecordingExtension      = ".db"
+	sessionRecordingInProgressFile = "in-progress" + sessionRecordingExtension
+
+	sessionReplayStatusInterval = 500 * time.Millisecond
+	sessionReplayMinSpeed       = 0.25
+	sessionReplayMaxSpeed       = 16
+
+	// EventSessionReplayStatus is sent to session replay viewers alongside the usual RaceControl messages.
+	EventSessionReplayStatus udp.Event = 220
+)
+
+var ErrSessionRecordingNotFound = errors.New("servermanager: session recording not found")
+
+func sessionRecordingsPath() string {
+	return filepath.Join(ServerInstallPath, sessionRecordingsDirectory)
+}
+
+func sessionRecordingPath(sessionFile string) string {
+	return filepath.Join(sessionRecordingsPath(), sessionFile+sessionRecordingExtension)
+}
+
+// deleteSessionRecording removes a session's recording, if it has one. Recordings can't be anonymised, as every
+// message in them would need to be rewritten, so they are deleted when their results file is anonymised.
+func deleteSessionRecording(sessionFile string) error {
+	err := os.Remove(sessionRecordingPath(sessionFile))
+
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+// HasRecording returns true if the session was recorded, and so can be replayed.
+func (s *SessionResults) HasRecording() bool {
+	_, err := os.Stat(sessionRecordingPath(s.SessionFile))
+
+	return err == nil
+}
+
+// SessionRecorder records every UDP message in a session. When the session ends, the recording is named after its
+// results file, so that the session can be replayed from the results page.
+type SessionRecorder struct {
+	store Store
+
+	mutex  sync.Mutex
+	db     *bbolt.DB
+	record udp.CallbackFunc
+
+	// the server doesn't send connection messages for cars which stay connected between sessions, so connected cars
+	// are added to the start of each recording.
+	connectedCars map[udp.CarID]udp.SessionCarInfo
+	loadedCars    map[udp.CarID]bool
+}
+
+func NewSessionRecorder(store Store) *SessionRecorder {
+	return &SessionRecorder{
+		store:         store,
+		connectedCars: make(map[udp.CarID]udp.SessionCarInfo),
+		loadedCars:    make(map[udp.CarID]bool),
+	}
+}
+
+func (sr *SessionRecorder) UDPCallback(message udp.Message) {
+	sr.mutex.Lock()
+	defer sr.mutex.Unlock()
+
+	switch m := message.(type) {
+	case udp.Version:
+		// the server has started, so any recording in progress will never be finished.
+		sr.discardRecording()
+		sr.connectedCars = make(map[udp.CarID]udp.SessionCarInfo)
+		sr.loadedCars = make(map[udp.CarID]bool)
+		return
+	case udp.SessionInfo:
+		if m.Event() == udp.EventNewSession {
+			sr.startRecording(m)
+			return
+		}
+	case udp.SessionCarInfo:
+		if m.Event() == udp.EventNewConnection {
+			sr.connectedCars[m.CarID] = m
+		} else if m.Event() == udp.EventConnectionClosed {
+			delete(sr.connectedCars, m.CarID)
+			delete(sr.loadedCars, m.CarID)
+		}
+	case udp.ClientLoaded:
+		sr.loadedCars[udp.CarID(m)] = true
+	case udp.EndSession:
+		sr.finishRecording(filepath.Base(string(m)))
+		return
+	}
+
+	if sr.record != nil {
+		sr.record(message)
+	}
+}
+
+func (sr *SessionRecorder) startRecording(sessionInfo udp.SessionInfo) {
+	sr.discardRecording()
+
+	serverOptions, err := sr.store.LoadServerOptions()
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Couldn't load server options, not recording session")
+		return
+	}
+
+	if serverOptions.RecordSessions != 1 {
+		return
+	}
+
+	if err := os.MkdirAll(sessionRecordingsPath(), 0755); err != nil {
+		logrus.WithError(err).Errorf("Couldn't create session recordings directory")
+		return
+	}
+
+	db, err := bbolt.Open(filepath.Join(sessionRecordingsPath(), sessionRecordingInProgressFile), 0644, &bbolt.Options{Timeout: time.Second})
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Couldn't open session recording")
+		return
+	}
+
+	// the recording is synced to disk when the session ends, rather than after every car update.
+	db.NoSync = true
+
+	sr.db = db
+	sr.record = replay.RecordUDPMessages(db)
+	sr.record(sessionInfo)
+
+	var cars []udp.SessionCarInfo
+
+	for _, car := range sr.connectedCars {
+		cars = append(cars, car)
+	}
+
+	sort.Slice(cars, func(i, j int) bool {
+		return cars[i].CarID < cars[j].CarID
+	})
+
+	for _, car := range cars {
+		sr.record(car)
+
+		if sr.loadedCars[car.CarID] {
+			sr.record(udp.ClientLoaded(car.CarID))
+		}
+	}
+
+	logrus.Debugf("Recording session: %s at %s (%s)", sessionInfo.Type.String(), sessionInfo.Track, sessionInfo.TrackConfig)
+}
+
+func (sr *SessionRecorder) closeRecording() error {
+	if sr.db == nil {
+		return nil
+	}
+
+	db := sr.db
+
+	sr.db = nil
+	sr.record = nil
+
+	if err := db.Sync(); err != nil {
+		_ = db.Close()
+		return err
+	}
+
+	return db.Close()
+}
+
+// discardRecording removes any recording in progress, which happens when a session doesn't have a results file.
+func (sr *SessionRecorder) discardRecording() {
+	if err := sr.closeRecording(); err != nil {
+		logrus.WithError(err).Errorf("Couldn't close session recording")
+	}
+
+	if err := os.Remove(filepath.Join(sessionRecordingsPath(), sessionRecordingInProgressFile)); err != nil && !os.IsNotExist(err) {
+		logrus.WithError(err).Errorf("Couldn't remove unfinished session recording")
+	}
+}
+
+func (sr *SessionRecorder) finishRecording(resultsFile string) {
+	if sr.db == nil {
+		return
+	}
+
+	if err := sr.closeRecording(); err != nil {
+		logrus.WithError(err).Errorf("Couldn't close session recording")
+		return
+	}
+
+	sessionFile := strings.TrimSuffix(resultsFile, ".json")
+
+	if err := os.Rename(filepath.Join(sessionRecordingsPath(), sessionRecordingInProgressFile), sessionRecordingPath(sessionFile)); err != nil {
+		logrus.WithError(err).Errorf("Couldn't save session recording for: %s", resultsFile)
+		return
+	}
+
+	logrus.Infof("Session recording saved for: %s", resultsFile)
+
+	go panicCapture(sr.deleteExpiredRecordings)
+}
+
+// deleteExpiredRecordings removes recordings which are older than the retention period in the server options.
+func (sr *SessionRecorder) deleteExpiredRecordings() {
+	serverOptions, err := sr.store.LoadServerOptions()
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Couldn't load server options, not deleting expired session recordings")
+		return
+	}
+
+	if serverOptions.SessionRecordingRetentionDays <= 0 {
+		return
+	}
+
+	files, err := ioutil.ReadDir(sessionRecordingsPath())
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Couldn't list session recordings")
+		return
+	}
+
+	expiry := time.Now().AddDate(0, 0, -serverOptions.SessionRecordingRetentionDays)
+
+	for _, file := range files {
+		if file.IsDir() || file.Name() == sessionRecordingInProgressFile || filepath.Ext(file.Name()) != sessionRecordingExtension {
+			continue
+		}
+
+		if file.ModTime().After(expiry) {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(sessionRecordingsPath(), file.Name())); err != nil {
+			logrus.WithError(err).Errorf("Couldn't delete expired session recording: %s", file.Name())
+			continue
+		}
+
+		logrus.Infof("Deleted expired session recording: %s", file.Name())
+	}
+}
+
+// SessionReplayStatus tells a replay viewer how far through the replay they are.
+type SessionReplayStatus struct {
+	// Position and Duration are in milliseconds.
+	Position int64   `json:"Position"`
+	Duration int64   `json:"Duration"`
+	Speed    float64 `json:"Speed"`
+	Playing  bool    `json:"Playing"`
+}
+
+func (SessionReplayStatus) Event() udp.Event {
+	return EventSessionReplayStatus
+}
+
+type sessionReplayCommand struct {
+	Action string  `json:"Action"`
+	Speed  float64 `json:"Speed"`
+}
+
+// sessionReplayBroadcaster sends RaceControl messages to a single replay viewer.
+type sessionReplayBroadcaster struct {
+	mutex    sync.Mutex
+	muted    bool
+	closed   bool
+	messages chan []byte
+}
+
+func (b *sessionReplayBroadcaster) Send(message udp.Message) ([]byte, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.muted || b.closed {
+		return nil, nil
+	}
+
+	encoded, err := encodeRaceControlMessage(message)
+
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case b.messages <- encoded:
+	default:
+		// the viewer isn't keeping up. drop the message rather than holding up the replay.
+	}
+
+	return encoded, nil
+}
+
+func (b *sessionReplayBroadcaster) setMuted(muted bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.muted = muted
+}
+
+func (b *sessionReplayBroadcaster) close() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !b.closed {
+		b.closed = true
+		close(b.messages)
+	}
+}
+
+// sessionReplayServerProcess stands in for the server which ran a recorded session. Nothing is sent to the server
+// while the session is replayed.
+type sessionReplayServerProcess struct{}
+
+func (sessionReplayServerProcess) Start(event RaceEvent, udpPluginAddress string, udpPluginLocalPort int, forwardingAddress string, forwardListenPort int) error {
+	return nil
+}
+
+func (sessionReplayServerProcess) Stop() error {
+	return nil
+}
+
+func (sessionReplayServerProcess) Restart() error {
+	return nil
+}
+
+func (sessionReplayServerProcess) IsRunning() bool {
+	return false
+}
+
+func (sessionReplayServerProcess) Event() RaceEvent {
+	return &CustomRace{}
+}
+
+func (sessionReplayServerProcess) UDPCallback(message udp.Message) {}
+
+func (sessionReplayServerProcess) SendUDPMessage(message udp.Message) error {
+	return nil
+}
+
+func (sessionReplayServerProcess) NotifyDone(chan struct{}) {}
+
+func (sessionReplayServerProcess) Logs() string {
+	return ""
+}
+
+// sessionReplayStore stops a replay from overwriting the live timings of the current session.
+type sessionReplayStore struct {
+	Store
+}
+
+func (sessionReplayStore) UpsertLiveTimingsData(*LiveTimingsPersistedData) error {
+	return nil
+}
+
+func (sessionReplayStore) LoadLiveTimingsData() (*LiveTimingsPersistedData, error) {
+	return nil, nil
+}
+
+// sessionReplay plays a session recording back through its own RaceControl, so that the live map and timings can be
+// shown for a session which has finished.
+type sessionReplay struct {
+	db     *bbolt.DB
+	tx     *bbolt.Tx
+	cursor *bbolt.Cursor
+
+	raceControl *RaceControl
+	broadcaster *sessionReplayBroadcaster
+
+	start, end time.Time
+	position   time.Time
+	next       *replay.Entry
+	speed      float64
+	playing    bool
+	lastStatus time.Time
+}
+
+func openSessionReplay(sessionFile string, store Store, trackDataGateway TrackDataGateway) (*sessionReplay, error) {
+	db, err := bbolt.Open(sessionRecordingPath(sessionFile), 0644, &bbolt.Options{ReadOnly: true, Timeout: time.Second})
+
+	if os.IsNotExist(err) {
+		return nil, ErrSessionRecordingNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	sr := &sessionReplay{
+		db:          db,
+		broadcaster: &sessionReplayBroadcaster{messages: make(chan []byte, 1000)},
+		speed:       1,
+		playing:     true,
+	}
+
+	if err := sr.load(); err != nil {
+		sr.Close()
+		return nil, err
+	}
+
+	sr.raceControl = newRaceControl(sr.broadcaster, trackDataGateway, sessionReplayServerProcess{}, sessionReplayStore{Store: store}, nil, nil)
+
+	return sr, nil
+}
+
+// load finds the start and end of the recording, leaving the cursor at the first entry.
+func (sr *sessionReplay) load() error {
+	var err error
+
+	sr.tx, err = sr.db.Begin(false)
+
+	if err != nil {
+		return err
+	}
+
+	bkt := sr.tx.Bucket(replay.BucketName)
+
+	if bkt == nil {
+		return ErrSessionRecordingNotFound
+	}
+
+	sr.cursor = bkt.Cursor()
+
+	_, last := sr.cursor.Last()
+
+	if last == nil {
+		return ErrSessionRecordingNotFound
+	}
+
+	lastEntry, err := decodeSessionReplayEntry(last)
+
+	if err != nil {
+		return err
+	}
+
+	_, first := sr.cursor.First()
+
+	sr.next, err = decodeSessionReplayEntry(first)
+
+	if err != nil {
+		return err
+	}
+
+	sr.start = sr.next.Received
+	sr.end = lastEntry.Received
+	sr.position = sr.start
+
+	return nil
+}
+
+func decodeSessionReplayEntry(data []byte) (*replay.Entry, error) {
+	var entry *replay.Entry
+
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+func (sr *sessionReplay) nextEntry() (*replay.Entry, error) {
+	if sr.next != nil {
+		entry := sr.next
+		sr.next = nil
+
+		return entry, nil
+	}
+
+	k, v := sr.cursor.Next()
+
+	if k == nil {
+		return nil, nil
+	}
+
+	return decodeSessionReplayEntry(v)
+}
+
+func (sr *sessionReplay) deliver(entry *replay.Entry) {
+	if entry.Received.After(sr.position) {
+		sr.position = entry.Received
+	}
+
+	switch entry.Data.(type) {
+	case nil, udp.Version, udp.EndSession:
+		// the version would make viewers reload the page, and the session end has already been handled.
+		return
+	}
+
+	sr.raceControl.UDPCallback(entry.Data)
+}
+
+// skipTo plays every entry up to the given position without sending them to the viewer.
+func (sr *sessionReplay) skipTo(position time.Time) error {
+	sr.broadcaster.setMuted(true)
+
+	for {
+		entry, err := sr.nextEntry()
+
+		if err != nil {
+			sr.broadcaster.setMuted(false)
+			return err
+		}
+
+		if entry == nil {
+			break
+		}
+
+		if entry.Received.After(position) {
+			sr.next = entry
+			break
+		}
+
+		sr.deliver(entry)
+	}
+
+	if position.After(sr.position) {
+		sr.position = position
+	}
+
+	sr.broadcaster.setMuted(false)
+
+	_, err := sr.broadcaster.Send(sr.raceControl)
+
+	return err
+}
+
+func (sr *sessionReplay) status() SessionReplayStatus {
+	return SessionReplayStatus{
+		Position: int64(sr.position.Sub(sr.start) / time.Millisecond),
+		Duration: int64(sr.end.Sub(sr.start) / time.Millisecond),
+		Speed:    sr.speed,
+		Playing:  sr.playing,
+	}
+}
+
+func (sr *sessionReplay) sendStatus() {
+	sr.lastStatus = time.Now()
+
+	if _, err := sr.broadcaster.Send(sr.status()); err != nil {
+		logrus.WithError(err).Errorf("Couldn't send session replay status")
+	}
+}
+
+func (sr *sessionReplay) setSpeed(speed float64) {
+	if speed < sessionReplayMinSpeed {
+		speed = sessionReplayMinSpeed
+	} else if speed > sessionReplayMaxSpeed {
+		speed = sessionReplayMaxSpeed
+	}
+
+	sr.speed = speed
+}
+
+func (sr *sessionReplay) handleCommand(command sessionReplayCommand) {
+	switch command.Action {
+	case "play":
+		sr.playing = sr.position.Before(sr.end)
+	case "pause":
+		sr.playing = false
+	case "speed":
+		sr.setSpeed(command.Speed)
+	}
+
+	sr.sendStatus()
+}
+
+// Run plays the replay until the viewer leaves. The replay starts from position (relative to the start of the
+// recording), and can then be paused and sped up or slowed down by commands from the viewer.
+func (sr *sessionReplay) Run(position time.Duration, speed float64, commands <-chan sessionReplayCommand, done <-chan struct{}) error {
+	sr.setSpeed(speed)
+
+	if position > 0 {
+		if err := sr.skipTo(sr.start.Add(position)); err != nil {
+			return err
+		}
+	}
+
+	sr.sendStatus()
+
+replay:
+	for {
+		if !sr.playing {
+			select {
+			case command := <-commands:
+				sr.handleCommand(command)
+			case <-done:
+				return nil
+			}
+
+			continue
+		}
+
+		entry, err := sr.nextEntry()
+
+		if err != nil {
+			return err
+		}
+
+		if entry == nil {
+			sr.position = sr.end
+			sr.playing = false
+			sr.sendStatus()
+			continue
+		}
+
+		// wait until the entry was received (adjusted for the replay speed) in short steps, so that the viewer's
+		// position keeps moving through any gaps in the recording.
+		for sr.position.Before(entry.Received) {
+			step := entry.Received.Sub(sr.position)
+
+			if maxStep := time.Duration(float64(sessionReplayStatusInterval) * sr.speed); step > maxStep {
+				step = maxStep
+			}
+
+			timer := time.NewTimer(time.Duration(float64(step) / sr.speed))
+
+			select {
+			case <-timer.C:
+				sr.position = sr.position.Add(step)
+
+				if time.Since(sr.lastStatus) >= sessionReplayStatusInterval {
+					sr.sendStatus()
+				}
+			case command := <-commands:
+				timer.Stop()
+				sr.handleCommand(command)
+
+				if !sr.playing {
+					sr.next = entry
+					continue replay
+				}
+			case <-done:
+				timer.Stop()
+				return nil
+			}
+		}
+
+		sr.deliver(entry)
+
+		if time.Since(sr.lastStatus) >= sessionReplayStatusInterval {
+			sr.sendStatus()
+		}
+	}
+}
+
+func (sr *sessionReplay) Close() {
+	if sr.raceControl != nil {
+		sr.raceControl.stopCarUpdaters()
+	}
+
+	sr.broadcaster.close()
+
+	if sr.tx != nil {
+		_ = sr.tx.Rollback()
+	}
+
+	if err := sr.db.Close(); err != nil {
+		logrus.WithError(err).Errorf("Couldn't close session recording")
+	}
+}
+
+type SessionRecordingsHandler struct {
+	*BaseHandler
+
+	store            Store
+	trackDataGateway TrackDataGateway
+}
+
+func NewSessionRecordingsHandler(baseHandler *BaseHandler, store Store, trackDataGateway TrackDataGateway) *SessionRecordingsHandler {
+	return &SessionRecordingsHandler{
+		BaseHandler:      baseHandler,
+		store:            store,
+		trackDataGateway: trackDataGateway,
+	}
+}
+
+type sessionReplayTemplateVars struct {
+	BaseTemplateVars
+
+	Results         *SessionResults
+	Position        int64
+	Speed           float64
+	Speeds          []float64
+	FocusDriver     string
+	UseMPH          bool
+	CSSDotSmoothing int
+}
+
+func (srh *SessionRecordingsHandler) replay(w http.ResponseWriter, r *http.Request) {
+	results, err := LoadResult(chi.URLParam(r, "fileName")+".json", LoadResultWithoutPluginFire)
+
+	if err != nil {
+		logrus.WithError(err).Errorf("couldn't load results")
+		http.NotFound(w, r)
+		return
+	}
+
+	if !results.HasRecording() {
+		AddErrorFlash(w, r, "This session wasn't recorded, so it can't be replayed. You can turn on Session Recordings in Server Options.")
+		http.Redirect(w, r, "/results/"+results.SessionFile, http.StatusFound)
+		return
+	}
+
+	serverOpts, err := srh.store.LoadServerOptions()
+
+	if err != nil {
+		logrus.WithError(err).Errorf("couldn't load server options")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	position, _ := strconv.ParseInt(r.URL.Query().Get("position"), 10, 64)
+	speed, err := strconv.ParseFloat(r.URL.Query().Get("speed"), 64)
+
+	if err != nil || speed < sessionReplayMinSpeed || speed > sessionReplayMaxSpeed {
+		speed = 1
+	}
+
+	srh.viewRenderer.MustLoadTemplate(w, r, "results/replay.html", &sessionReplayTemplateVars{
+		BaseTemplateVars: BaseTemplateVars{
+			WideContainer: true,
+		},
+		Results:         results,
+		Position:        position,
+		Speed:           speed,
+		Speeds:          []float64{0.25, 0.5, 1, 2, 4, 8, 16},
+		FocusDriver:     r.URL.Query().Get("driver"),
+		UseMPH:          serverOpts.UseMPH == 1,
+		CSSDotSmoothing: udp.RealtimePosIntervalMs,
+	})
+}
+
+func (srh *SessionRecordingsHandler) replayWebsocket(w http.ResponseWriter, r *http.Request) {
+	results, err := LoadResult(chi.URLParam(r, "fileName")+".json", LoadResultWithoutPluginFire)
+
+	if err != nil {
+		logrus.WithError(err).Errorf("couldn't load results")
+		http.NotFound(w, r)
+		return
+	}
+
+	sessionReplay, err := openSessionReplay(results.SessionFile, srh.store, srh.trackDataGateway)
+
+	if err == ErrSessionRecordingNotFound {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		logrus.WithError(err).Errorf("couldn't open session recording for: %s", results.SessionFile)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	defer sessionReplay.Close()
+
+	position, _ := strconv.ParseInt(r.URL.Query().Get("position"), 10, 64)
+	speed, _ := strconv.ParseFloat(r.URL.Query().Get("speed"), 64)
+
+	c, err := upgrader.Upgrade(w, r, nil)
+
+	if err != nil {
+		logrus.Error(err)
+		return
+	}
+
+	client := &raceControlClient{conn: c, receive: sessionReplay.broadcaster.messages}
+
+	go client.writePump()
+
+	commands := make(chan sessionReplayCommand)
+	done := make(chan struct{})
+	finished := make(chan struct{})
+
+	defer close(finished)
+
+	go panicCapture(func() {
+		defer close(done)
+
+		for {
+			var command sessionReplayCommand
+
+			if err := c.ReadJSON(&command); err != nil {
+				return
+			}
+
+			select {
+			case commands <- command:
+			case <-finished:
+				return
+			}
+		}
+	})
+
+	if err := sessionReplay.Run(time.Duration(position)*time.Millisecond, speed, commands, done); err != nil {
+		logrus.WithError(err).Errorf("couldn't replay session: %s", results.SessionFile)
+	}
+}
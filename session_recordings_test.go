@@ -0,0 +1,131 @@
+package servermanager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+func TestSessionRecorder(t *testing.T) {
+	installPath, err := ioutil.TempDir("", "session-recordings")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(installPath)
+
+	oldInstallPath := ServerInstallPath
+	ServerInstallPath = installPath
+
+	defer func() {
+		ServerInstallPath = oldInstallPath
+	}()
+
+	serverOptions, err := testStore.LoadServerOptions()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldServerOptions := *serverOptions
+
+	defer func() {
+		_ = testStore.UpsertServerOptions(&oldServerOptions)
+	}()
+
+	serverOptions.RecordSessions = 1
+	serverOptions.SessionRecordingRetentionDays = 7
+
+	if err := testStore.UpsertServerOptions(serverOptions); err != nil {
+		t.Fatal(err)
+	}
+
+	const sessionFile = "2020_1_1_10_0_RACE"
+
+	recorder := NewSessionRecorder(testStore)
+
+	// the driver connects in the previous session, so isn't connected again in the recorded one
+	recorder.UDPCallback(drivers[0])
+	recorder.UDPCallback(udp.ClientLoaded(drivers[0].CarID))
+
+	messages := []udp.Message{
+		udp.SessionInfo{Track: "ks_test", Type: udp.SessionTypeRace, EventType: udp.EventNewSession},
+		udp.CarUpdate{CarID: drivers[0].CarID},
+		udp.LapCompleted{CarID: drivers[0].CarID, LapTime: 90000},
+		udp.EndSession(filepath.Join("results", sessionFile+".json")),
+	}
+
+	for _, message := range messages {
+		recorder.UDPCallback(message)
+	}
+
+	if _, err := os.Stat(filepath.Join(installPath, sessionRecordingsDirectory, sessionRecordingInProgressFile)); !os.IsNotExist(err) {
+		t.Errorf("Expected the in progress recording to be renamed, got: %v", err)
+	}
+
+	if !(&SessionResults{SessionFile: sessionFile}).HasRecording() {
+		t.Fatal("Expected the session to have a recording")
+	}
+
+	t.Run("Replay rebuilds the session", func(t *testing.T) {
+		sessionReplay, err := openSessionReplay(sessionFile, testStore, nilTrackData{})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer sessionReplay.Close()
+
+		if err := sessionReplay.skipTo(sessionReplay.end); err != nil {
+			t.Fatal(err)
+		}
+
+		driver, ok := sessionReplay.raceControl.ConnectedDrivers.Get(drivers[0].DriverGUID)
+
+		if !ok {
+			t.Fatal("Expected the driver to be connected in the replay")
+		}
+
+		if driver.TotalNumLaps != 1 {
+			t.Errorf("Expected the driver to have completed 1 lap, got %d", driver.TotalNumLaps)
+		}
+
+		if status := sessionReplay.status(); status.Position != status.Duration {
+			t.Errorf("Expected the replay to be at the end, got %d/%d", status.Position, status.Duration)
+		}
+	})
+
+	t.Run("Sessions without results are not kept", func(t *testing.T) {
+		recorder.UDPCallback(udp.SessionInfo{Track: "ks_test", Type: udp.SessionTypeRace, EventType: udp.EventNewSession})
+		recorder.UDPCallback(udp.Version(4))
+
+		files, err := ioutil.ReadDir(sessionRecordingsPath())
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(files) != 1 {
+			t.Errorf("Expected 1 recording, got %d", len(files))
+		}
+	})
+
+	t.Run("Expired recordings are deleted", func(t *testing.T) {
+		expired := time.Now().AddDate(0, 0, -8)
+
+		if err := os.Chtimes(sessionRecordingPath(sessionFile), expired, expired); err != nil {
+			t.Fatal(err)
+		}
+
+		recorder.deleteExpiredRecordings()
+
+		if (&SessionResults{SessionFile: sessionFile}).HasRecording() {
+			t.Error("Expected the expired recording to be deleted")
+		}
+	})
+}
@@ -0,0 +1,263 @@
+package servermanager
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// spreadsheetTable is a named table of cells which can be written as a CSV file, or as a sheet in an XLSX workbook.
+// The first row is the table's headers. Cells are strings, ints or float64s.
+type spreadsheetTable struct {
+	Name string
+	Rows [][]interface{}
+}
+
+func newSpreadsheetTable(name string, headers ...interface{}) *spreadsheetTable {
+	return &spreadsheetTable{
+		Name: name,
+		Rows: [][]interface{}{headers},
+	}
+}
+
+func (t *spreadsheetTable) AddRow(cells ...interface{}) {
+	t.Rows = append(t.Rows, cells)
+}
+
+// Headers is the first row of the table.
+func (t *spreadsheetTable) Headers() []interface{} {
+	if len(t.Rows) == 0 {
+		return nil
+	}
+
+	return t.Rows[0]
+}
+
+// Body is every row of the table after the headers.
+func (t *spreadsheetTable) Body() [][]interface{} {
+	if len(t.Rows) == 0 {
+		return nil
+	}
+
+	return t.Rows[1:]
+}
+
+// spreadsheetFormulaPrefixes are the characters which make spreadsheet programs treat a cell as a formula.
+const spreadsheetFormulaPrefixes = "=+-@\t\r"
+
+// spreadsheetCellString formats a cell. Strings often come from sign up forms, so any which would be opened as a
+// formula are prefixed with ' to be shown as text instead.
+func spreadsheetCellString(cell interface{}) string {
+	switch v := cell.(type) {
+	case nil:
+		return ""
+	case string:
+		if v != "" && strings.ContainsRune(spreadsheetFormulaPrefixes, rune(v[0])) {
+			return "'" + v
+		}
+
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// WriteCSV writes the table as a CSV file.
+func (t *spreadsheetTable) WriteCSV(w io.Writer) error {
+	wr := csv.NewWriter(w)
+	wr.UseCRLF = true
+
+	for _, row := range t.Rows {
+		record := make([]string, len(row))
+
+		for i, cell := range row {
+			record[i] = spreadsheetCellString(cell)
+		}
+
+		if err := wr.Write(record); err != nil {
+			return err
+		}
+	}
+
+	wr.Flush()
+
+	return wr.Error()
+}
+
+const (
+	xlsxHeader            = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+	xlsxMainNamespace     = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+	xlsxRelationshipTypes = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	xlsxMaxSheetName      = 31
+)
+
+// writeXLSX writes the tables as the sheets of an XLSX workbook. Only the parts of the format needed for plain tables
+// are written: the header row of each sheet is bold, and ints and float64s are written as numbers.
+func writeXLSX(w io.Writer, tables []*spreadsheetTable) error {
+	z := zip.NewWriter(w)
+
+	var contentTypes, workbook, workbookRels bytes.Buffer
+
+	contentTypes.WriteString(xlsxHeader)
+	contentTypes.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	contentTypes.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	contentTypes.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	contentTypes.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	contentTypes.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+
+	workbook.WriteString(xlsxHeader)
+	workbook.WriteString(`<workbook xmlns="` + xlsxMainNamespace + `" xmlns:r="` + xlsxRelationshipTypes + `"><sheets>`)
+
+	workbookRels.WriteString(xlsxHeader)
+	workbookRels.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+
+	sheetNames := make(map[string]bool)
+
+	for i, table := range tables {
+		sheetID := i + 1
+		sheetName := xlsxSheetName(table.Name, sheetID, sheetNames)
+
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, sheetID)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(sheetName), sheetID, sheetID)
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="%s/worksheet" Target="worksheets/sheet%d.xml"/>`, sheetID, xlsxRelationshipTypes, sheetID)
+
+		sheet, err := z.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", sheetID))
+
+		if err != nil {
+			return err
+		}
+
+		if err := writeXLSXSheet(sheet, table); err != nil {
+			return err
+		}
+	}
+
+	contentTypes.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets></workbook>`)
+	fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="%s/styles" Target="styles.xml"/>`, len(tables)+1, xlsxRelationshipTypes)
+	workbookRels.WriteString(`</Relationships>`)
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", contentTypes.String()},
+		{"_rels/.rels", xlsxHeader + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="` + xlsxRelationshipTypes + `/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", workbook.String()},
+		{"xl/_rels/workbook.xml.rels", workbookRels.String()},
+		{"xl/styles.xml", xlsxHeader + xlsxStyles},
+	}
+
+	for _, file := range files {
+		f, err := z.Create(file.name)
+
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.WriteString(f, file.content); err != nil {
+			return err
+		}
+	}
+
+	return z.Close()
+}
+
+// xlsxStyles has a regular cell style (0) and a bold cell style (1) for headers.
+const xlsxStyles = `<styleSheet xmlns="` + xlsxMainNamespace + `">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+	`</styleSheet>`
+
+func writeXLSXSheet(w io.Writer, table *spreadsheetTable) error {
+	var buf bytes.Buffer
+
+	buf.WriteString(xlsxHeader)
+	buf.WriteString(`<worksheet xmlns="` + xlsxMainNamespace + `"><sheetData>`)
+
+	for rowIndex, row := range table.Rows {
+		fmt.Fprintf(&buf, `<row r="%d">`, rowIndex+1)
+
+		style := ""
+
+		if rowIndex == 0 {
+			style = ` s="1"`
+		}
+
+		for colIndex, cell := range row {
+			ref := xlsxColumnName(colIndex) + strconv.Itoa(rowIndex+1)
+
+			switch v := cell.(type) {
+			case nil:
+				continue
+			case int, float64:
+				fmt.Fprintf(&buf, `<c r="%s"%s><v>%s</v></c>`, ref, style, spreadsheetCellString(v))
+			default:
+				fmt.Fprintf(&buf, `<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, style, xmlEscape(spreadsheetCellString(v)))
+			}
+		}
+
+		buf.WriteString(`</row>`)
+	}
+
+	buf.WriteString(`</sheetData></worksheet>`)
+
+	_, err := buf.WriteTo(w)
+
+	return err
+}
+
+// xlsxColumnName converts a zero-based column index to its name, e.g. 0 is A, 26 is AA.
+func xlsxColumnName(index int) string {
+	name := ""
+
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+
+	return name
+}
+
+// xlsxSheetName makes a valid, unique sheet name. Sheet names can't contain []:*?/\ and are at most 31 characters.
+func xlsxSheetName(name string, sheetID int, used map[string]bool) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return ' '
+		}
+
+		return r
+	}, strings.TrimSpace(name))
+
+	if runes := []rune(name); len(runes) > xlsxMaxSheetName {
+		name = string(runes[:xlsxMaxSheetName])
+	}
+
+	if name == "" || used[strings.ToLower(name)] {
+		name = fmt.Sprintf("Sheet%d", sheetID)
+	}
+
+	used[strings.ToLower(name)] = true
+
+	return name
+}
+
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+
+	_ = xml.EscapeText(&buf, []byte(s))
+
+	return buf.String()
+}
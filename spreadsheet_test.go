@@ -0,0 +1,125 @@
+package servermanager
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestSpreadsheetTable_WriteCSV(t *testing.T) {
+	table := newSpreadsheetTable("Standings", "Driver", "Points")
+	table.AddRow("Driver, 1", 10.5)
+	table.AddRow("Driver 2", 3)
+	table.AddRow("=HYPERLINK(\"http://example.com\")", -2)
+	table.AddRow("@Driver 4", 0)
+
+	var buf bytes.Buffer
+
+	if err := table.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "Driver,Points\r\n\"Driver, 1\",10.5\r\nDriver 2,3\r\n\"'=HYPERLINK(\"\"http://example.com\"\")\",-2\r\n'@Driver 4,0\r\n"
+
+	if buf.String() != expected {
+		t.Errorf("Expected csv %q, got %q", expected, buf.String())
+	}
+}
+
+func TestWriteXLSX(t *testing.T) {
+	standings := newSpreadsheetTable("Driver Standings", "Driver", "GUID", "Points")
+	standings.AddRow("Driver <1> & co", "76561198000000000", 25.0)
+	standings.AddRow("-1+1", "+76561198000000001", -1)
+
+	tables := []*spreadsheetTable{
+		standings,
+		newSpreadsheetTable("Driver Standings"),
+		newSpreadsheetTable("A very long name for a sheet [with] bad/characters"),
+	}
+
+	var buf bytes.Buffer
+
+	if err := writeXLSX(&buf, tables); err != nil {
+		t.Fatal(err)
+	}
+
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := make(map[string]string)
+
+	for _, f := range z.File {
+		r, err := f.Open()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := ioutil.ReadAll(r)
+		_ = r.Close()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// every part must be well formed xml
+		decoder := xml.NewDecoder(bytes.NewReader(data))
+
+		for {
+			if _, err := decoder.Token(); err != nil {
+				if err != io.EOF {
+					t.Errorf("%s is not valid xml: %s", f.Name, err)
+				}
+
+				break
+			}
+		}
+
+		files[f.Name] = string(data)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml", "xl/worksheets/sheet2.xml", "xl/worksheets/sheet3.xml"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("Expected the workbook to contain %s", name)
+		}
+	}
+
+	workbook := files["xl/workbook.xml"]
+
+	for _, sheetName := range []string{`name="Driver Standings"`, `name="Sheet2"`, `name="A very long name for a sheet  w"`} {
+		if !strings.Contains(workbook, sheetName) {
+			t.Errorf("Expected the workbook to contain a sheet with %s", sheetName)
+		}
+	}
+
+	sheet := files["xl/worksheets/sheet1.xml"]
+
+	for _, cell := range []string{
+		`<c r="A2" t="inlineStr"><is><t xml:space="preserve">Driver &lt;1&gt; &amp; co</t></is></c>`,
+		`<c r="B2" t="inlineStr"><is><t xml:space="preserve">76561198000000000</t></is></c>`,
+		`<c r="C2"><v>25</v></c>`,
+		`<c r="A3" t="inlineStr"><is><t xml:space="preserve">&#39;-1+1</t></is></c>`,
+		`<c r="B3" t="inlineStr"><is><t xml:space="preserve">&#39;+76561198000000001</t></is></c>`,
+		`<c r="C3"><v>-1</v></c>`,
+		`<c r="C1" s="1" t="inlineStr">`,
+	} {
+		if !strings.Contains(sheet, cell) {
+			t.Errorf("Expected the sheet to contain %s", cell)
+		}
+	}
+}
+
+func TestXLSXColumnName(t *testing.T) {
+	for index, name := range map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if actual := xlsxColumnName(index); actual != name {
+			t.Errorf("Expected column %d to be %s, got %s", index, name, actual)
+		}
+	}
+}
@@ -0,0 +1,573 @@
+package servermanager
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+var (
+	ErrStewardIncidentNotFound          = errors.New("servermanager: steward incident not found")
+	ErrStewardIncidentAlreadyDecided    = errors.New("servermanager: a decision has already been made for this incident")
+	ErrStewardDecisionInvalid           = errors.New("servermanager: invalid steward decision")
+	ErrStewardDecisionDriverNotInvolved = errors.New("servermanager: the penalised driver was not involved in the incident")
+)
+
+// stewardCollisionDuplicateWindow is how close together two collision reports between the same pair of cars must be
+// to be treated as the same incident. The server sends a collision message for each car involved.
+const stewardCollisionDuplicateWindow = 5 * time.Second
+
+type StewardIncidentType string
+
+const (
+	StewardIncidentCollision   StewardIncidentType = "Collision"
+	StewardIncidentTrackLimits StewardIncidentType = "Track Limits"
+)
+
+type StewardDecision string
+
+const (
+	StewardDecisionPending          StewardDecision = ""
+	StewardDecisionNoFurtherAction  StewardDecision = "No Further Action"
+	StewardDecisionWarning          StewardDecision = "Warning"
+	StewardDecisionTimePenalty      StewardDecision = "Time Penalty"
+	StewardDecisionDisqualification StewardDecision = "Disqualification"
+)
+
+var StewardDecisions = []StewardDecision{
+	StewardDecisionNoFurtherAction,
+	StewardDecisionWarning,
+	StewardDecisionTimePenalty,
+	StewardDecisionDisqualification,
+}
+
+type StewardIncidentDriver struct {
+	GUID     udp.DriverGUID `json:"GUID"`
+	Name     string         `json:"Name"`
+	CarID    udp.CarID      `json:"CarID"`
+	CarModel string         `json:"CarModel"`
+}
+
+// StewardIncidentMapPoint is a position on the track map image, as a percentage of the image's width and height.
+type StewardIncidentMapPoint struct {
+	DriverGUID udp.DriverGUID `json:"DriverGUID"`
+	DriverName string         `json:"DriverName"`
+	Involved   bool           `json:"Involved"`
+
+	X float64 `json:"X"`
+	Y float64 `json:"Y"`
+}
+
+// StewardIncidentMap is a snapshot of the track map at the time of an incident.
+type StewardIncidentMap struct {
+	ImageURL string                    `json:"ImageURL"`
+	Location *StewardIncidentMapPoint  `json:"Location"`
+	Cars     []StewardIncidentMapPoint `json:"Cars"`
+}
+
+// A StewardIncident is raised by RaceControl when a driver has a collision above the configured speed or cuts the
+// track. Stewards review incidents while the session is running, and their decisions are applied to the results file
+// at the end of the session.
+type StewardIncident struct {
+	ID          uuid.UUID           `json:"ID"`
+	Type        StewardIncidentType `json:"Type"`
+	Time        time.Time           `json:"Time"`
+	SessionType udp.SessionType     `json:"SessionType"`
+
+	Driver      StewardIncidentDriver  `json:"Driver"`
+	OtherDriver *StewardIncidentDriver `json:"OtherDriver"`
+	Speed       float64                `json:"Speed"`
+	Lap         int                    `json:"Lap"`
+	Cuts        int                    `json:"Cuts"`
+
+	Map *StewardIncidentMap `json:"Map"`
+
+	Decision        StewardDecision        `json:"Decision"`
+	PenalisedDriver *StewardIncidentDriver `json:"PenalisedDriver"`
+	PenaltySeconds  float64                `json:"PenaltySeconds"`
+	Notes           string                 `json:"Notes"`
+	DecidedBy       string                 `json:"DecidedBy"`
+	DecisionTime    time.Time              `json:"DecisionTime"`
+}
+
+func (i *StewardIncident) IsPending() bool {
+	return i.Decision == StewardDecisionPending
+}
+
+// Drivers returns the drivers involved in the incident, any of whom may be penalised.
+func (i *StewardIncident) Drivers() []StewardIncidentDriver {
+	drivers := []StewardIncidentDriver{i.Driver}
+
+	if i.OtherDriver != nil {
+		drivers = append(drivers, *i.OtherDriver)
+	}
+
+	return drivers
+}
+
+// Anonymize replaces the GUIDs and names of the drivers in the incident, as SessionResults.Anonymize does for the
+// rest of the results.
+func (i *StewardIncident) Anonymize() {
+	i.Driver.anonymize()
+
+	if i.OtherDriver != nil {
+		i.OtherDriver.anonymize()
+	}
+
+	if i.PenalisedDriver != nil {
+		i.PenalisedDriver.anonymize()
+	}
+
+	if i.Map != nil {
+		for index, car := range i.Map.Cars {
+			i.Map.Cars[index].DriverGUID = udp.DriverGUID(AnonymiseDriverGUID(string(car.DriverGUID)))
+			i.Map.Cars[index].DriverName = shortenDriverName(car.DriverName)
+		}
+	}
+}
+
+func (d *StewardIncidentDriver) anonymize() {
+	d.GUID = udp.DriverGUID(AnonymiseDriverGUID(string(d.GUID)))
+	d.Name = shortenDriverName(d.Name)
+}
+
+func (i *StewardIncident) Description() string {
+	switch i.Type {
+	case StewardIncidentCollision:
+		if i.OtherDriver != nil {
+			return fmt.Sprintf("Collision between %s and %s", driverName(i.Driver.Name), driverName(i.OtherDriver.Name))
+		}
+
+		return fmt.Sprintf("Collision involving %s", driverName(i.Driver.Name))
+	case StewardIncidentTrackLimits:
+		return fmt.Sprintf("%s cut the track %d time(s) on lap %d", driverName(i.Driver.Name), i.Cuts, i.Lap)
+	default:
+		return string(i.Type)
+	}
+}
+
+// DecisionSummary describes the decision, e.g. "5s Time Penalty for Driver Name"
+func (i *StewardIncident) DecisionSummary() string {
+	switch i.Decision {
+	case StewardDecisionPending:
+		return "Under investigation"
+	case StewardDecisionNoFurtherAction:
+		return string(i.Decision)
+	case StewardDecisionTimePenalty:
+		return fmt.Sprintf("%s Time Penalty for %s", stewardPenaltyDuration(i.PenaltySeconds), driverName(i.PenalisedDriver.Name))
+	default:
+		return fmt.Sprintf("%s for %s", i.Decision, driverName(i.PenalisedDriver.Name))
+	}
+}
+
+// announcement is the chat message broadcast to all drivers when a decision is made.
+func (i *StewardIncident) announcement() string {
+	var reason string
+
+	switch i.Type {
+	case StewardIncidentCollision:
+		reason = "causing a collision"
+	case StewardIncidentTrackLimits:
+		reason = "exceeding track limits"
+	}
+
+	switch i.Decision {
+	case StewardDecisionNoFurtherAction:
+		return fmt.Sprintf("Stewards: %s - no further action", i.Description())
+	case StewardDecisionWarning:
+		return fmt.Sprintf("Stewards: %s has been warned for %s", driverName(i.PenalisedDriver.Name), reason)
+	case StewardDecisionTimePenalty:
+		return fmt.Sprintf("Stewards: %s has been given a %s time penalty for %s", driverName(i.PenalisedDriver.Name), stewardPenaltyDuration(i.PenaltySeconds), reason)
+	case StewardDecisionDisqualification:
+		return fmt.Sprintf("Stewards: %s has been disqualified for %s", driverName(i.PenalisedDriver.Name), reason)
+	default:
+		return ""
+	}
+}
+
+func stewardPenaltyDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second)).Round(100 * time.Millisecond)
+}
+
+func stewardIncidentDriver(driver *RaceControlDriver) StewardIncidentDriver {
+	return StewardIncidentDriver{
+		GUID:     driver.CarInfo.DriverGUID,
+		Name:     driver.CarInfo.DriverName,
+		CarID:    driver.CarInfo.CarID,
+		CarModel: driver.CarInfo.CarModel,
+	}
+}
+
+// mapPoint converts a world position to a position on the track map image.
+func (t TrackMapData) mapPoint(pos udp.Vec) (x, y float64, ok bool) {
+	if t.ScaleFactor == 0 || t.Width == 0 || t.Height == 0 {
+		return 0, 0, false
+	}
+
+	x = (float64(pos.X) + t.OffsetX) / t.ScaleFactor / t.Width * 100
+	y = (float64(pos.Z) + t.OffsetZ) / t.ScaleFactor / t.Height * 100
+
+	return x, y, true
+}
+
+// stewardIncidentMap takes a snapshot of the positions of all connected cars on the track map. involved drivers are
+// highlighted. location is the position of the incident itself, if known.
+func (rc *RaceControl) stewardIncidentMap(location *udp.Vec, involved ...udp.DriverGUID) *StewardIncidentMap {
+	if _, _, ok := rc.TrackMapData.mapPoint(udp.Vec{}); !ok {
+		return nil
+	}
+
+	incidentMap := &StewardIncidentMap{
+		ImageURL: TrackMapImageURL(rc.SessionInfo.Track, rc.SessionInfo.TrackConfig),
+	}
+
+	if location != nil {
+		x, y, _ := rc.TrackMapData.mapPoint(*location)
+
+		incidentMap.Location = &StewardIncidentMapPoint{X: x, Y: y, Involved: true}
+	}
+
+	_ = rc.ConnectedDrivers.Each(func(driverGUID udp.DriverGUID, driver *RaceControlDriver) error {
+		x, y, _ := rc.TrackMapData.mapPoint(driver.LastPos)
+
+		point := StewardIncidentMapPoint{
+			DriverGUID: driverGUID,
+			DriverName: driver.CarInfo.DriverName,
+			X:          x,
+			Y:          y,
+		}
+
+		for _, guid := range involved {
+			if guid == driverGUID {
+				point.Involved = true
+			}
+		}
+
+		incidentMap.Cars = append(incidentMap.Cars, point)
+
+		return nil
+	})
+
+	return incidentMap
+}
+
+func (rc *RaceControl) addStewardIncident(incident *StewardIncident) {
+	rc.stewardIncidentsMutex.Lock()
+	defer rc.stewardIncidentsMutex.Unlock()
+
+	if incident.Type == StewardIncidentCollision && incident.OtherDriver != nil {
+		for _, existing := range rc.stewardIncidents {
+			if existing.Type != StewardIncidentCollision || existing.OtherDriver == nil || incident.Time.Sub(existing.Time) > stewardCollisionDuplicateWindow {
+				continue
+			}
+
+			if existing.Driver.GUID == incident.OtherDriver.GUID && existing.OtherDriver.GUID == incident.Driver.GUID {
+				// the other car's report of a collision we already know about
+				return
+			}
+		}
+	}
+
+	logrus.Infof("Stewards: new incident: %s", incident.Description())
+
+	rc.stewardIncidents = append(rc.stewardIncidents, incident)
+}
+
+// recordCollisionIncident raises an incident if a collision between two cars was faster than the speed configured in
+// the server options.
+func (rc *RaceControl) recordCollisionIncident(driver, otherDriver *RaceControlDriver, speed float64, location udp.Vec) {
+	if otherDriver == nil {
+		return
+	}
+
+	serverOptions, err := rc.store.LoadServerOptions()
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Couldn't load server options to check collision for stewards")
+		return
+	}
+
+	if serverOptions.StewardsCollisionSpeed <= 0 || speed < float64(serverOptions.StewardsCollisionSpeed) {
+		return
+	}
+
+	other := stewardIncidentDriver(otherDriver)
+
+	rc.addStewardIncident(&StewardIncident{
+		ID:          uuid.New(),
+		Type:        StewardIncidentCollision,
+		Time:        time.Now(),
+		SessionType: rc.SessionInfo.Type,
+		Driver:      stewardIncidentDriver(driver),
+		OtherDriver: &other,
+		Speed:       speed,
+		Lap:         driver.TotalNumLaps + 1,
+		Map:         rc.stewardIncidentMap(&location, driver.CarInfo.DriverGUID, otherDriver.CarInfo.DriverGUID),
+	})
+}
+
+// recordTrackLimitsIncident raises an incident for a lap with cuts, if turned on in the server options.
+func (rc *RaceControl) recordTrackLimitsIncident(driver *RaceControlDriver, cuts int) {
+	if cuts <= 0 {
+		return
+	}
+
+	serverOptions, err := rc.store.LoadServerOptions()
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Couldn't load server options to check cuts for stewards")
+		return
+	}
+
+	if serverOptions.StewardsTrackLimitsIncidents != 1 {
+		return
+	}
+
+	rc.addStewardIncident(&StewardIncident{
+		ID:          uuid.New(),
+		Type:        StewardIncidentTrackLimits,
+		Time:        time.Now(),
+		SessionType: rc.SessionInfo.Type,
+		Driver:      stewardIncidentDriver(driver),
+		Lap:         driver.TotalNumLaps,
+		Cuts:        cuts,
+		Map:         rc.stewardIncidentMap(nil, driver.CarInfo.DriverGUID),
+	})
+}
+
+// StewardIncidents returns the incidents in the current session, oldest first.
+func (rc *RaceControl) StewardIncidents() []*StewardIncident {
+	rc.stewardIncidentsMutex.Lock()
+	defer rc.stewardIncidentsMutex.Unlock()
+
+	incidents := make([]*StewardIncident, len(rc.stewardIncidents))
+	copy(incidents, rc.stewardIncidents)
+
+	return incidents
+}
+
+// DecideStewardIncident records the stewards' decision for an incident. Time penalties and disqualifications are
+// applied to the results file at the end of the session. All decisions are announced in the chat.
+func (rc *RaceControl) DecideStewardIncident(id string, decision StewardDecision, penalisedGUID udp.DriverGUID, penaltySeconds float64, notes string, account *Account) (*StewardIncident, error) {
+	rc.stewardIncidentsMutex.Lock()
+
+	var incident *StewardIncident
+
+	for _, i := range rc.stewardIncidents {
+		if i.ID.String() == id {
+			incident = i
+			break
+		}
+	}
+
+	if incident == nil {
+		rc.stewardIncidentsMutex.Unlock()
+		return nil, ErrStewardIncidentNotFound
+	}
+
+	if !incident.IsPending() {
+		rc.stewardIncidentsMutex.Unlock()
+		return nil, ErrStewardIncidentAlreadyDecided
+	}
+
+	var penalisedDriver *StewardIncidentDriver
+
+	if decision != StewardDecisionNoFurtherAction {
+		for _, driver := range incident.Drivers() {
+			if driver.GUID == penalisedGUID {
+				driver := driver
+				penalisedDriver = &driver
+			}
+		}
+
+		if penalisedDriver == nil {
+			rc.stewardIncidentsMutex.Unlock()
+			return nil, ErrStewardDecisionDriverNotInvolved
+		}
+	}
+
+	switch decision {
+	case StewardDecisionNoFurtherAction, StewardDecisionWarning, StewardDecisionDisqualification:
+		penaltySeconds = 0
+	case StewardDecisionTimePenalty:
+		if penaltySeconds <= 0 {
+			rc.stewardIncidentsMutex.Unlock()
+			return nil, ErrStewardDecisionInvalid
+		}
+	default:
+		rc.stewardIncidentsMutex.Unlock()
+		return nil, ErrStewardDecisionInvalid
+	}
+
+	incident.Decision = decision
+	incident.PenalisedDriver = penalisedDriver
+	incident.PenaltySeconds = penaltySeconds
+	incident.Notes = notes
+	incident.DecisionTime = time.Now()
+
+	if account != nil {
+		incident.DecidedBy = account.Name
+	}
+
+	rc.stewardIncidentsMutex.Unlock()
+
+	if decision == StewardDecisionTimePenalty || decision == StewardDecisionDisqualification {
+		rc.sessionPenaltiesMutex.Lock()
+
+		penalty, ok := rc.sessionPenalties[penalisedDriver.GUID]
+
+		if !ok {
+			penalty = &sessionPenalty{carModel: penalisedDriver.CarModel}
+			rc.sessionPenalties[penalisedDriver.GUID] = penalty
+		}
+
+		if decision == StewardDecisionDisqualification {
+			penalty.disqualified = true
+		} else {
+			penalty.penalty += stewardPenaltyDuration(penaltySeconds)
+		}
+
+		rc.sessionPenaltiesMutex.Unlock()
+	}
+
+	logrus.Infof("Stewards: %s: %s", incident.Description(), incident.DecisionSummary())
+
+	if err := rc.splitAndBroadcastChat(incident.announcement(), nil); err != nil {
+		logrus.WithError(err).Errorf("Could not announce stewards decision")
+	}
+
+	return incident, nil
+}
+
+// recordStewardDecisions adds the decided incidents to the results file for the session, so that they are kept with
+// the results (and any championship the results are part of). Incidents which were not decided are discarded.
+func (rc *RaceControl) recordStewardDecisions(filename string) {
+	rc.stewardIncidentsMutex.Lock()
+	incidents := rc.stewardIncidents
+	rc.stewardIncidents = nil
+	rc.stewardIncidentsMutex.Unlock()
+
+	var decided []*StewardIncident
+
+	for _, incident := range incidents {
+		if incident.IsPending() {
+			logrus.Warnf("Stewards: session ended before a decision was made on: %s", incident.Description())
+			continue
+		}
+
+		decided = append(decided, incident)
+	}
+
+	if len(decided) == 0 {
+		return
+	}
+
+	results, err := LoadResult(filename, LoadResultWithoutPluginFire)
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not load results file to record stewards decisions")
+		return
+	}
+
+	results.StewardDecisions = decided
+
+	if err := saveResults(filename, results); err != nil {
+		logrus.WithError(err).Errorf("Could not save stewards decisions to results file")
+	}
+}
+
+type stewardsTemplateVars struct {
+	BaseTemplateVars
+
+	SessionInfo udp.SessionInfo
+	Pending     []*StewardIncident
+	Decided     []*StewardIncident
+	Decisions   []StewardDecision
+	UseMPH      bool
+}
+
+func (rch *RaceControlHandler) stewards(w http.ResponseWriter, r *http.Request) {
+	serverOpts, err := rch.store.LoadServerOptions()
+
+	if err != nil {
+		logrus.WithError(err).Errorf("couldn't load server options")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	var pending, decided []*StewardIncident
+
+	for _, incident := range rch.raceControl.StewardIncidents() {
+		if incident.IsPending() {
+			pending = append(pending, incident)
+		} else {
+			decided = append([]*StewardIncident{incident}, decided...)
+		}
+	}
+
+	rch.viewRenderer.MustLoadTemplate(w, r, "stewards.html", &stewardsTemplateVars{
+		SessionInfo: rch.raceControl.SessionInfo,
+		Pending:     pending,
+		Decided:     decided,
+		Decisions:   StewardDecisions,
+		UseMPH:      serverOpts.UseMPH == 1,
+	})
+}
+
+func (rch *RaceControlHandler) stewardDecision(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		AddErrorFlash(w, r, "Could not parse decision form")
+		http.Redirect(w, r, "/stewards", http.StatusFound)
+		return
+	}
+
+	var penaltySeconds float64
+
+	if penalty := strings.TrimSpace(r.FormValue("PenaltySeconds")); penalty != "" {
+		var err error
+
+		penaltySeconds, err = strconv.ParseFloat(penalty, 64)
+
+		if err != nil {
+			AddErrorFlash(w, r, "Could not parse penalty time")
+			http.Redirect(w, r, "/stewards", http.StatusFound)
+			return
+		}
+	}
+
+	incident, err := rch.raceControl.DecideStewardIncident(
+		chi.URLParam(r, "incidentID"),
+		StewardDecision(r.FormValue("Decision")),
+		udp.DriverGUID(r.FormValue("PenalisedDriver")),
+		penaltySeconds,
+		r.FormValue("Notes"),
+		AccountFromRequest(r),
+	)
+
+	switch err {
+	case nil:
+		AddFlash(w, r, fmt.Sprintf("Decision recorded: %s", incident.DecisionSummary()))
+	case ErrStewardIncidentNotFound:
+		AddErrorFlash(w, r, "Could not find that incident. It may have been from a session which has ended.")
+	case ErrStewardIncidentAlreadyDecided:
+		AddErrorFlash(w, r, "A decision has already been made for that incident")
+	case ErrStewardDecisionDriverNotInvolved:
+		AddErrorFlash(w, r, "Please choose a driver who was involved in the incident")
+	case ErrStewardDecisionInvalid:
+		AddErrorFlash(w, r, "Please choose a decision, and enter a penalty time for time penalties")
+	default:
+		logrus.WithError(err).Errorf("Could not record stewards decision")
+		AddErrorFlash(w, r, "Could not record decision")
+	}
+
+	http.Redirect(w, r, "/stewards", http.StatusFound)
+}
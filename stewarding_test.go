@@ -0,0 +1,109 @@
+package servermanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/JustaPenguin/assetto-server-manager/pkg/udp"
+)
+
+func TestRaceControl_StewardIncidents(t *testing.T) {
+	serverOptions, err := testStore.LoadServerOptions()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldServerOptions := *serverOptions
+
+	defer func() {
+		_ = testStore.UpsertServerOptions(&oldServerOptions)
+	}()
+
+	serverOptions.StewardsCollisionSpeed = 50
+	serverOptions.StewardsTrackLimitsIncidents = 1
+
+	if err := testStore.UpsertServerOptions(serverOptions); err != nil {
+		t.Fatal(err)
+	}
+
+	raceControl := NewRaceControl(NilBroadcaster{}, nilTrackData{}, dummyServerProcess{}, testStore, NewPenaltiesManager(testStore), NewBlocklistManager(testStore))
+
+	for _, driver := range drivers[:3] {
+		if err := raceControl.OnClientConnect(driver); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	collisions := []udp.CollisionWithCar{
+		// below the threshold
+		{CarID: drivers[0].CarID, OtherCarID: drivers[1].CarID, ImpactSpeed: 10},
+		// the same collision, reported by both cars
+		{CarID: drivers[0].CarID, OtherCarID: drivers[1].CarID, ImpactSpeed: 20},
+		{CarID: drivers[1].CarID, OtherCarID: drivers[0].CarID, ImpactSpeed: 20},
+	}
+
+	for _, collision := range collisions {
+		if err := raceControl.OnCollisionWithCar(collision); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := raceControl.OnLapCompleted(udp.LapCompleted{CarID: drivers[2].CarID, LapTime: 90000, Cuts: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	incidents := raceControl.StewardIncidents()
+
+	if len(incidents) != 2 {
+		t.Fatalf("Expected 2 incidents, got %d", len(incidents))
+	}
+
+	collision, cut := incidents[0], incidents[1]
+
+	if collision.Type != StewardIncidentCollision || collision.Driver.GUID != drivers[0].DriverGUID || collision.OtherDriver.GUID != drivers[1].DriverGUID {
+		t.Errorf("Unexpected collision incident: %+v", collision)
+	}
+
+	if cut.Type != StewardIncidentTrackLimits || cut.Driver.GUID != drivers[2].DriverGUID || cut.Cuts != 2 || cut.Lap != 1 {
+		t.Errorf("Unexpected track limits incident: %+v", cut)
+	}
+
+	t.Run("Penalised driver must be involved", func(t *testing.T) {
+		_, err := raceControl.DecideStewardIncident(collision.ID.String(), StewardDecisionTimePenalty, drivers[2].DriverGUID, 5, "", nil)
+
+		if err != ErrStewardDecisionDriverNotInvolved {
+			t.Errorf("Expected ErrStewardDecisionDriverNotInvolved, got: %v", err)
+		}
+	})
+
+	t.Run("Time penalty is added to the session penalties", func(t *testing.T) {
+		if _, err := raceControl.DecideStewardIncident(collision.ID.String(), StewardDecisionTimePenalty, drivers[1].DriverGUID, 5, "Divebomb", nil); err != nil {
+			t.Fatal(err)
+		}
+
+		penalty, ok := raceControl.sessionPenalties[drivers[1].DriverGUID]
+
+		if !ok || penalty.penalty != 5*time.Second || penalty.disqualified {
+			t.Errorf("Unexpected session penalty: %+v", penalty)
+		}
+
+		if _, err := raceControl.DecideStewardIncident(collision.ID.String(), StewardDecisionWarning, drivers[1].DriverGUID, 0, "", nil); err != ErrStewardIncidentAlreadyDecided {
+			t.Errorf("Expected ErrStewardIncidentAlreadyDecided, got: %v", err)
+		}
+	})
+
+	t.Run("Warnings are not penalties", func(t *testing.T) {
+		if _, err := raceControl.DecideStewardIncident(cut.ID.String(), StewardDecisionWarning, drivers[2].DriverGUID, 10, "", nil); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok := raceControl.sessionPenalties[drivers[2].DriverGUID]; ok {
+			t.Error("Expected a warning not to add a session penalty")
+		}
+
+		if cut.PenaltySeconds != 0 || cut.DecisionSummary() != "Warning for Test 3" {
+			t.Errorf("Unexpected decision: %s", cut.DecisionSummary())
+		}
+	})
+}
@@ -1,5 +1,14 @@
 package servermanager
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/google/uuid"
+)
+
 type Store interface {
 	// Custom Races
 	UpsertCustomRace(race *CustomRace) error
@@ -64,6 +73,16 @@ type Store interface {
 	// RealPenalty options
 	UpsertRealPenaltyOptions(rpc *RealPenaltyConfig) error
 	LoadRealPenaltyOptions() (*RealPenaltyConfig, error)
+
+	// Recycle Bin, for soft deleted entities
+	ListDeletedCustomRaces() ([]*CustomRace, error)
+	ListDeletedChampionships() ([]*Championship, error)
+	ListDeletedRaceWeekends() ([]*RaceWeekend, error)
+	ListDeletedAccounts() ([]*Account, error)
+	PurgeCustomRace(uuid string) error
+	PurgeChampionship(id string) error
+	PurgeRaceWeekend(id string) error
+	PurgeAccount(name string) error
 }
 
 func loadChampionshipRaceWeekends(championship *Championship, store Store) error {
@@ -81,3 +100,328 @@ func loadChampionshipRaceWeekends(championship *Championship, store Store) error
 
 	return nil
 }
+
+// storeMetaKeys are the meta values which are copied by CopyStore.
+var storeMetaKeys = []string{versionMetaKey, serverIDMetaKey, serverAccountOptionsMetaKey, webhooksMetaKey, protestsMetaKey, blocklistMetaKey}
+
+// CopyStore copies everything in one Store to another, e.g. when moving from a JSON or Bolt store to an SQLite store.
+// Soft deleted entities are copied too, so that the recycle bin is kept. Existing entities in the destination with
+// matching IDs are overwritten.
+func CopyStore(from, to Store) error {
+	customRaces, err := from.ListCustomRaces()
+
+	if err != nil {
+		return fmt.Errorf("servermanager: could not list custom races: %w", err)
+	}
+
+	deletedCustomRaces, err := from.ListDeletedCustomRaces()
+
+	if err != nil {
+		return fmt.Errorf("servermanager: could not list deleted custom races: %w", err)
+	}
+
+	customRaces = append(customRaces, deletedCustomRaces...)
+
+	for _, customRace := range customRaces {
+		if err := to.UpsertCustomRace(customRace); err != nil {
+			return fmt.Errorf("servermanager: could not copy custom race %s: %w", customRace.UUID, err)
+		}
+	}
+
+	entrants, err := from.ListEntrants()
+
+	if err != nil {
+		return fmt.Errorf("servermanager: could not list entrants: %w", err)
+	}
+
+	for _, entrant := range entrants {
+		if err := to.UpsertEntrant(*entrant); err != nil {
+			return fmt.Errorf("servermanager: could not copy entrant %s: %w", entrant.ID(), err)
+		}
+	}
+
+	championships, err := from.ListChampionships()
+
+	if err != nil {
+		return fmt.Errorf("servermanager: could not list championships: %w", err)
+	}
+
+	deletedChampionships, err := from.ListDeletedChampionships()
+
+	if err != nil {
+		return fmt.Errorf("servermanager: could not list deleted championships: %w", err)
+	}
+
+	championships = append(championships, deletedChampionships...)
+
+	for _, championship := range championships {
+		if err := to.UpsertChampionship(championship); err != nil {
+			return fmt.Errorf("servermanager: could not copy championship %s: %w", championship.ID, err)
+		}
+	}
+
+	raceWeekends, err := from.ListRaceWeekends()
+
+	if err != nil {
+		return fmt.Errorf("servermanager: could not list race weekends: %w", err)
+	}
+
+	deletedRaceWeekends, err := from.ListDeletedRaceWeekends()
+
+	if err != nil {
+		return fmt.Errorf("servermanager: could not list deleted race weekends: %w", err)
+	}
+
+	raceWeekends = append(raceWeekends, deletedRaceWeekends...)
+
+	for _, raceWeekend := range raceWeekends {
+		if err := to.UpsertRaceWeekend(raceWeekend); err != nil {
+			return fmt.Errorf("servermanager: could not copy race weekend %s: %w", raceWeekend.ID, err)
+		}
+	}
+
+	accounts, err := from.ListAccounts()
+
+	if err != nil {
+		return fmt.Errorf("servermanager: could not list accounts: %w", err)
+	}
+
+	deletedAccounts, err := from.ListDeletedAccounts()
+
+	if err != nil {
+		return fmt.Errorf("servermanager: could not list deleted accounts: %w", err)
+	}
+
+	accounts = append(accounts, deletedAccounts...)
+
+	for _, account := range accounts {
+		if err := to.UpsertAccount(account); err != nil {
+			return fmt.Errorf("servermanager: could not copy account %s: %w", account.Name, err)
+		}
+	}
+
+	auditEntries, err := from.GetAuditEntries()
+
+	if err != nil && err != ErrValueNotSet && !os.IsNotExist(err) {
+		return fmt.Errorf("servermanager: could not load audit entries: %w", err)
+	}
+
+	for _, entry := range auditEntries {
+		if err := to.AddAuditEntry(entry); err != nil {
+			return fmt.Errorf("servermanager: could not copy audit entry: %w", err)
+		}
+	}
+
+	for _, key := range storeMetaKeys {
+		var value json.RawMessage
+
+		err := from.GetMeta(key, &value)
+
+		if err == ErrValueNotSet {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("servermanager: could not load meta value %s: %w", key, err)
+		}
+
+		if err := to.SetMeta(key, value); err != nil {
+			return fmt.Errorf("servermanager: could not copy meta value %s: %w", key, err)
+		}
+	}
+
+	serverOptions, err := from.LoadServerOptions()
+
+	if err != nil {
+		return fmt.Errorf("servermanager: could not load server options: %w", err)
+	}
+
+	if err := to.UpsertServerOptions(serverOptions); err != nil {
+		return fmt.Errorf("servermanager: could not copy server options: %w", err)
+	}
+
+	strackerOptions, err := from.LoadStrackerOptions()
+
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("servermanager: could not load stracker options: %w", err)
+	} else if err == nil {
+		if err := to.UpsertStrackerOptions(strackerOptions); err != nil {
+			return fmt.Errorf("servermanager: could not copy stracker options: %w", err)
+		}
+	}
+
+	kissMyRankOptions, err := from.LoadKissMyRankOptions()
+
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("servermanager: could not load kissmyrank options: %w", err)
+	} else if err == nil {
+		if err := to.UpsertKissMyRankOptions(kissMyRankOptions); err != nil {
+			return fmt.Errorf("servermanager: could not copy kissmyrank options: %w", err)
+		}
+	}
+
+	realPenaltyOptions, err := from.LoadRealPenaltyOptions()
+
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("servermanager: could not load real penalty options: %w", err)
+	} else if err == nil {
+		if err := to.UpsertRealPenaltyOptions(realPenaltyOptions); err != nil {
+			return fmt.Errorf("servermanager: could not copy real penalty options: %w", err)
+		}
+	}
+
+	frames, err := from.ListPrevFrames()
+
+	if err != nil {
+		return fmt.Errorf("servermanager: could not list live frames: %w", err)
+	}
+
+	if len(frames) > 0 {
+		if err := to.UpsertLiveFrames(frames); err != nil {
+			return fmt.Errorf("servermanager: could not copy live frames: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// storeIntegrityChecker is implemented by stores which can check their underlying files for corruption.
+type storeIntegrityChecker interface {
+	checkIntegrity() ([]string, error)
+}
+
+// VerifyStore checks a Store for corruption and for entities which refer to other entities that can't be loaded.
+// It returns a description of each problem found.
+func VerifyStore(store Store) ([]string, error) {
+	var problems []string
+
+	if checker, ok := store.(storeIntegrityChecker); ok {
+		integrityProblems, err := checker.checkIntegrity()
+
+		if err != nil {
+			return nil, fmt.Errorf("servermanager: could not check store integrity: %w", err)
+		}
+
+		problems = append(problems, integrityProblems...)
+	}
+
+	storeVersion, err := StoreVersion(store)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if storeVersion > CurrentMigrationVersion {
+		problems = append(problems, fmt.Sprintf("Store version %d was made by a newer version of Server Manager (this version supports up to %d)", storeVersion, CurrentMigrationVersion))
+	} else if storeVersion < CurrentMigrationVersion {
+		problems = append(problems, fmt.Sprintf("Store version %d is out of date, %d migrations need to be run", storeVersion, CurrentMigrationVersion-storeVersion))
+	}
+
+	if _, err := store.LoadServerOptions(); err != nil {
+		problems = append(problems, fmt.Sprintf("Server options could not be loaded: %s", err))
+	}
+
+	if _, err := store.ListEntrants(); err != nil {
+		problems = append(problems, fmt.Sprintf("Entrants could not be listed: %s", err))
+	}
+
+	customRaces, err := store.ListCustomRaces()
+
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("Custom races could not be listed: %s", err))
+	}
+
+	for _, customRace := range customRaces {
+		if customRace.UUID == uuid.Nil {
+			problems = append(problems, fmt.Sprintf("Custom race %q has no ID", customRace.Name))
+		}
+	}
+
+	if _, err := store.ListChampionships(); err != nil {
+		problems = append(problems, fmt.Sprintf("Championships could not be listed: %s", err))
+	}
+
+	raceWeekends, err := store.ListRaceWeekends()
+
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("Race weekends could not be listed: %s", err))
+	}
+
+	for _, raceWeekend := range raceWeekends {
+		if !raceWeekend.HasLinkedChampionship() {
+			continue
+		}
+
+		// championships which link to a race weekend that can't be loaded also fail to load, so they are
+		// found here rather than when listing championships.
+		if _, err := store.LoadChampionship(raceWeekend.ChampionshipID.String()); err != nil {
+			problems = append(problems, fmt.Sprintf("Race weekend %q belongs to Championship %s, which could not be loaded: %s", raceWeekend.Name, raceWeekend.ChampionshipID, err))
+		}
+	}
+
+	accounts, err := store.ListAccounts()
+
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("Accounts could not be listed: %s", err))
+	}
+
+	accountNames := make(map[string]bool)
+	hasAdmin := false
+
+	for _, account := range accounts {
+		if !account.Deleted.IsZero() {
+			continue
+		}
+
+		if accountNames[account.Name] {
+			problems = append(problems, fmt.Sprintf("There is more than one account named %q", account.Name))
+		}
+
+		accountNames[account.Name] = true
+
+		if account.accountGroup() == GroupAdmin {
+			hasAdmin = true
+		}
+	}
+
+	if len(accounts) > 0 && !hasAdmin {
+		problems = append(problems, "No accounts are in the admin group")
+	}
+
+	return problems, nil
+}
+
+var ErrStoreCannotBeCompacted = errors.New("servermanager: only boltdb and sqlite stores can be compacted")
+
+// CompactStore rewrites a boltdb or sqlite store to reclaim unused space, returning the size of the store before
+// and after compacting it. The store must not be open while it is compacted.
+func CompactStore(storeConfig *StoreConfig) (before, after int64, err error) {
+	if storeConfig.Type != "boltdb" && storeConfig.Type != "sqlite" {
+		return 0, 0, ErrStoreCannotBeCompacted
+	}
+
+	info, err := os.Stat(storeConfig.Path)
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	before = info.Size()
+
+	switch storeConfig.Type {
+	case "boltdb":
+		err = compactBoltStore(storeConfig.Path, storeConfig.OpenTimeout)
+	case "sqlite":
+		err = compactSQLiteStore(storeConfig.Path)
+	}
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	info, err = os.Stat(storeConfig.Path)
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return before, info.Size(), nil
+}
@@ -3,6 +3,8 @@ package servermanager
 import (
 	"encoding/json"
 	"errors"
+	"io"
+	"os"
 	"time"
 
 	"github.com/etcd-io/bbolt"
@@ -99,6 +101,14 @@ func (rs *BoltStore) FindCustomRaceByID(uuid string) (*CustomRace, error) {
 }
 
 func (rs *BoltStore) ListCustomRaces() ([]*CustomRace, error) {
+	return rs.listCustomRaces(false)
+}
+
+func (rs *BoltStore) ListDeletedCustomRaces() ([]*CustomRace, error) {
+	return rs.listCustomRaces(true)
+}
+
+func (rs *BoltStore) listCustomRaces(deleted bool) ([]*CustomRace, error) {
 	var customRaces []*CustomRace
 
 	err := rs.db.View(func(tx *bbolt.Tx) error {
@@ -119,8 +129,8 @@ func (rs *BoltStore) ListCustomRaces() ([]*CustomRace, error) {
 				return err
 			}
 
-			if !race.Deleted.IsZero() {
-				// soft deleted race, move on
+			if race.Deleted.IsZero() == deleted {
+				// not in the list we're looking for, move on
 				return nil
 			}
 
@@ -139,6 +149,18 @@ func (rs *BoltStore) DeleteCustomRace(race *CustomRace) error {
 	return rs.UpsertCustomRace(race)
 }
 
+func (rs *BoltStore) PurgeCustomRace(uuid string) error {
+	return rs.db.Update(func(tx *bbolt.Tx) error {
+		bkt, err := rs.customRaceBucket(tx)
+
+		if err != nil {
+			return err
+		}
+
+		return bkt.Delete([]byte(uuid))
+	})
+}
+
 func (rs *BoltStore) entrantsBucket(tx *bbolt.Tx) (*bbolt.Bucket, error) {
 	if !tx.Writable() {
 		bkt := tx.Bucket(entrantsBucketName)
@@ -373,6 +395,14 @@ func (rs *BoltStore) UpsertChampionship(c *Championship) error {
 }
 
 func (rs *BoltStore) ListChampionships() ([]*Championship, error) {
+	return rs.listChampionships(false)
+}
+
+func (rs *BoltStore) ListDeletedChampionships() ([]*Championship, error) {
+	return rs.listChampionships(true)
+}
+
+func (rs *BoltStore) listChampionships(deleted bool) ([]*Championship, error) {
 	var championships []*Championship
 
 	err := rs.db.View(func(tx *bbolt.Tx) error {
@@ -393,8 +423,8 @@ func (rs *BoltStore) ListChampionships() ([]*Championship, error) {
 				return err
 			}
 
-			if !championship.Deleted.IsZero() {
-				// championship deleted
+			if championship.Deleted.IsZero() == deleted {
+				// not in the list we're looking for
 				return nil // continue
 			}
 
@@ -451,6 +481,18 @@ func (rs *BoltStore) DeleteChampionship(id string) error {
 	return rs.UpsertChampionship(championship)
 }
 
+func (rs *BoltStore) PurgeChampionship(id string) error {
+	return rs.db.Update(func(tx *bbolt.Tx) error {
+		b, err := rs.championshipsBucket(tx)
+
+		if err != nil {
+			return err
+		}
+
+		return b.Delete([]byte(id))
+	})
+}
+
 func (rs *BoltStore) accountsBucket(tx *bbolt.Tx) (*bbolt.Bucket, error) {
 	if !tx.Writable() {
 		bkt := tx.Bucket(accountsBucketName)
@@ -466,6 +508,14 @@ func (rs *BoltStore) accountsBucket(tx *bbolt.Tx) (*bbolt.Bucket, error) {
 }
 
 func (rs *BoltStore) ListAccounts() ([]*Account, error) {
+	return rs.listAccounts(false)
+}
+
+func (rs *BoltStore) ListDeletedAccounts() ([]*Account, error) {
+	return rs.listAccounts(true)
+}
+
+func (rs *BoltStore) listAccounts(deleted bool) ([]*Account, error) {
 	var accounts []*Account
 
 	err := rs.db.View(func(tx *bbolt.Tx) error {
@@ -486,8 +536,8 @@ func (rs *BoltStore) ListAccounts() ([]*Account, error) {
 				return err
 			}
 
-			if !account.Deleted.IsZero() {
-				// account deleted
+			if account.Deleted.IsZero() == deleted {
+				// not in the list we're looking for
 				return nil // continue
 			}
 
@@ -576,6 +626,18 @@ func (rs *BoltStore) DeleteAccount(id string) error {
 	return rs.UpsertAccount(account)
 }
 
+func (rs *BoltStore) PurgeAccount(name string) error {
+	return rs.db.Update(func(tx *bbolt.Tx) error {
+		b, err := rs.accountsBucket(tx)
+
+		if err != nil {
+			return err
+		}
+
+		return b.Delete([]byte(name))
+	})
+}
+
 var metaBucketName = []byte("meta")
 
 func (rs *BoltStore) metaBucket(tx *bbolt.Tx) (*bbolt.Bucket, error) {
@@ -743,6 +805,14 @@ func (rs *BoltStore) UpsertRaceWeekend(rw *RaceWeekend) error {
 }
 
 func (rs *BoltStore) ListRaceWeekends() ([]*RaceWeekend, error) {
+	return rs.listRaceWeekends(false)
+}
+
+func (rs *BoltStore) ListDeletedRaceWeekends() ([]*RaceWeekend, error) {
+	return rs.listRaceWeekends(true)
+}
+
+func (rs *BoltStore) listRaceWeekends(deleted bool) ([]*RaceWeekend, error) {
 	var raceWeekends []*RaceWeekend
 
 	err := rs.db.View(func(tx *bbolt.Tx) error {
@@ -763,8 +833,8 @@ func (rs *BoltStore) ListRaceWeekends() ([]*RaceWeekend, error) {
 				return err
 			}
 
-			if !raceWeekend.Deleted.IsZero() {
-				// race weekend deleted
+			if raceWeekend.Deleted.IsZero() == deleted {
+				// not in the list we're looking for
 				return nil // continue
 			}
 
@@ -815,6 +885,18 @@ func (rs *BoltStore) DeleteRaceWeekend(id string) error {
 	return rs.UpsertRaceWeekend(raceWeekend)
 }
 
+func (rs *BoltStore) PurgeRaceWeekend(id string) error {
+	return rs.db.Update(func(tx *bbolt.Tx) error {
+		b, err := rs.raceWeekendsBucket(tx)
+
+		if err != nil {
+			return err
+		}
+
+		return b.Delete([]byte(id))
+	})
+}
+
 func (rs *BoltStore) UpsertStrackerOptions(sto *StrackerConfiguration) error {
 	return rs.db.Update(func(tx *bbolt.Tx) error {
 		bkt, err := rs.serverOptionsBucket(tx)
@@ -1045,3 +1127,111 @@ func (rs *BoltStore) ClearLastRaceEvent() error {
 		return bkt.Delete(lastRaceEventKey)
 	})
 }
+
+// backup adds a consistent copy of the bolt database to a backup.
+func (rs *BoltStore) backup(bw *backupWriter) error {
+	return rs.db.View(func(tx *bbolt.Tx) error {
+		return bw.writeFile(backupStoreFile, time.Now(), func(w io.Writer) error {
+			_, err := tx.WriteTo(w)
+
+			return err
+		})
+	})
+}
+
+func (rs *BoltStore) Close() error {
+	return rs.db.Close()
+}
+
+// checkIntegrity checks the consistency of the bolt database's pages.
+func (rs *BoltStore) checkIntegrity() ([]string, error) {
+	var problems []string
+
+	err := rs.db.View(func(tx *bbolt.Tx) error {
+		for err := range tx.Check() {
+			problems = append(problems, err.Error())
+		}
+
+		return nil
+	})
+
+	return problems, err
+}
+
+// compactBoltStore copies every bucket in the bolt database at path to a new database, leaving out free pages,
+// then replaces the original database with the copy.
+func compactBoltStore(path string, timeout time.Duration) error {
+	info, err := os.Stat(path)
+
+	if err != nil {
+		return err
+	}
+
+	src, err := bbolt.Open(path, info.Mode(), &bbolt.Options{Timeout: timeout, ReadOnly: true})
+
+	if err != nil {
+		return err
+	}
+
+	defer src.Close()
+
+	compactedPath := path + ".compact"
+
+	dst, err := bbolt.Open(compactedPath, info.Mode(), nil)
+
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(compactedPath)
+	defer dst.Close()
+
+	err = src.View(func(srcTx *bbolt.Tx) error {
+		return dst.Update(func(dstTx *bbolt.Tx) error {
+			return srcTx.ForEach(func(name []byte, srcBucket *bbolt.Bucket) error {
+				dstBucket, err := dstTx.CreateBucket(name)
+
+				if err != nil {
+					return err
+				}
+
+				return copyBoltBucket(srcBucket, dstBucket)
+			})
+		})
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if err := dst.Close(); err != nil {
+		return err
+	}
+
+	if err := src.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(compactedPath, path)
+}
+
+func copyBoltBucket(src, dst *bbolt.Bucket) error {
+	if err := dst.SetSequence(src.Sequence()); err != nil {
+		return err
+	}
+
+	return src.ForEach(func(k, v []byte) error {
+		if v != nil {
+			return dst.Put(k, v)
+		}
+
+		// a nil value is a nested bucket
+		dstChild, err := dst.CreateBucket(k)
+
+		if err != nil {
+			return err
+		}
+
+		return copyBoltBucket(src.Bucket(k), dstChild)
+	})
+}
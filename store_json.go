@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -15,7 +16,6 @@ const (
 	maxAuditEntries = 1000
 
 	// private data
-	accountsDir            = "accounts"
 	serverOptionsFile      = "server_options.json"
 	frameLinksFile         = "frame_links.json"
 	serverMetaDir          = "meta"
@@ -31,8 +31,12 @@ const (
 	raceWeekendsDir  = "race_weekends"
 	customRacesDir   = "custom_races"
 	entrantsFile     = "entrants.json"
+	accountsDir      = "accounts"
 )
 
+// jsonStoreSharedData is the data which is kept in the shared directory of a JSONStore.
+var jsonStoreSharedData = []string{championshipsDir, raceWeekendsDir, customRacesDir, entrantsFile, accountsDir}
+
 func NewJSONStore(dir string, sharedDir string) Store {
 	return &JSONStore{
 		base:   dir,
@@ -191,6 +195,14 @@ func (rs *JSONStore) FindCustomRaceByID(uuid string) (*CustomRace, error) {
 }
 
 func (rs *JSONStore) ListCustomRaces() ([]*CustomRace, error) {
+	return rs.listCustomRaces(false)
+}
+
+func (rs *JSONStore) ListDeletedCustomRaces() ([]*CustomRace, error) {
+	return rs.listCustomRaces(true)
+}
+
+func (rs *JSONStore) listCustomRaces(deleted bool) ([]*CustomRace, error) {
 	files, err := rs.listFiles(filepath.Join(rs.shared, customRacesDir))
 
 	if err != nil {
@@ -202,7 +214,7 @@ func (rs *JSONStore) ListCustomRaces() ([]*CustomRace, error) {
 	for _, file := range files {
 		race, err := rs.FindCustomRaceByID(file)
 
-		if err != nil || !race.Deleted.IsZero() {
+		if err != nil || race.Deleted.IsZero() == deleted {
 			continue
 		}
 
@@ -222,6 +234,10 @@ func (rs *JSONStore) DeleteCustomRace(race *CustomRace) error {
 	return rs.UpsertCustomRace(race)
 }
 
+func (rs *JSONStore) PurgeCustomRace(uuid string) error {
+	return rs.deleteFile(rs.shared, filepath.Join(customRacesDir, uuid+".json"))
+}
+
 func (rs *JSONStore) UpsertEntrant(entrant Entrant) error {
 	entrants, err := rs.ListEntrants()
 
@@ -311,6 +327,14 @@ func (rs *JSONStore) UpsertChampionship(c *Championship) error {
 }
 
 func (rs *JSONStore) ListChampionships() ([]*Championship, error) {
+	return rs.listChampionships(false)
+}
+
+func (rs *JSONStore) ListDeletedChampionships() ([]*Championship, error) {
+	return rs.listChampionships(true)
+}
+
+func (rs *JSONStore) listChampionships(deleted bool) ([]*Championship, error) {
 	files, err := rs.listFiles(filepath.Join(rs.shared, championshipsDir))
 
 	if err != nil {
@@ -322,7 +346,7 @@ func (rs *JSONStore) ListChampionships() ([]*Championship, error) {
 	for _, file := range files {
 		c, err := rs.LoadChampionship(file)
 
-		if err != nil || !c.Deleted.IsZero() {
+		if err != nil || c.Deleted.IsZero() == deleted {
 			continue
 		}
 
@@ -364,6 +388,10 @@ func (rs *JSONStore) DeleteChampionship(id string) error {
 	return rs.UpsertChampionship(c)
 }
 
+func (rs *JSONStore) PurgeChampionship(id string) error {
+	return rs.deleteFile(rs.shared, filepath.Join(championshipsDir, id+".json"))
+}
+
 func (rs *JSONStore) UpsertLiveFrames(frameLinks []string) error {
 	return rs.encodeFile(rs.base, frameLinksFile, frameLinks)
 }
@@ -383,6 +411,14 @@ func (rs *JSONStore) ListPrevFrames() ([]string, error) {
 }
 
 func (rs *JSONStore) ListAccounts() ([]*Account, error) {
+	return rs.listAccounts(false)
+}
+
+func (rs *JSONStore) ListDeletedAccounts() ([]*Account, error) {
+	return rs.listAccounts(true)
+}
+
+func (rs *JSONStore) listAccounts(deleted bool) ([]*Account, error) {
 	files, err := rs.listFiles(filepath.Join(rs.shared, accountsDir))
 
 	if err != nil {
@@ -394,7 +430,7 @@ func (rs *JSONStore) ListAccounts() ([]*Account, error) {
 	for _, file := range files {
 		a, err := rs.FindAccountByName(file)
 
-		if err != nil || !a.Deleted.IsZero() {
+		if err != nil || a.Deleted.IsZero() == deleted {
 			continue
 		}
 
@@ -452,6 +488,10 @@ func (rs *JSONStore) DeleteAccount(id string) error {
 	return rs.UpsertAccount(account)
 }
 
+func (rs *JSONStore) PurgeAccount(name string) error {
+	return rs.deleteFile(rs.shared, filepath.Join(accountsDir, name+".json"))
+}
+
 func (rs *JSONStore) SetMeta(key string, value interface{}) error {
 	return rs.encodeFile(rs.base, filepath.Join(serverMetaDir, key+".json"), value)
 }
@@ -495,6 +535,14 @@ func (rs *JSONStore) AddAuditEntry(entry *AuditEntry) error {
 }
 
 func (rs *JSONStore) ListRaceWeekends() ([]*RaceWeekend, error) {
+	return rs.listRaceWeekends(false)
+}
+
+func (rs *JSONStore) ListDeletedRaceWeekends() ([]*RaceWeekend, error) {
+	return rs.listRaceWeekends(true)
+}
+
+func (rs *JSONStore) listRaceWeekends(deleted bool) ([]*RaceWeekend, error) {
 	files, err := rs.listFiles(filepath.Join(rs.shared, raceWeekendsDir))
 
 	if err != nil {
@@ -506,7 +554,7 @@ func (rs *JSONStore) ListRaceWeekends() ([]*RaceWeekend, error) {
 	for _, file := range files {
 		rw, err := rs.LoadRaceWeekend(file)
 
-		if err != nil || !rw.Deleted.IsZero() {
+		if err != nil || rw.Deleted.IsZero() == deleted {
 			continue
 		}
 
@@ -552,6 +600,10 @@ func (rs *JSONStore) DeleteRaceWeekend(id string) error {
 	return rs.UpsertRaceWeekend(rw)
 }
 
+func (rs *JSONStore) PurgeRaceWeekend(id string) error {
+	return rs.deleteFile(rs.shared, filepath.Join(raceWeekendsDir, id+".json"))
+}
+
 func (rs *JSONStore) UpsertStrackerOptions(sto *StrackerConfiguration) error {
 	return rs.encodeFile(rs.base, strackerOptionsFile, sto)
 }
@@ -657,3 +709,88 @@ func (rs *JSONStore) ClearLastRaceEvent() error {
 
 	return err
 }
+
+// backup adds the store's files to a backup. Shared data is kept separately in the backup, so that it
+// can be restored to a store with a different shared directory.
+func (rs *JSONStore) backup(bw *backupWriter) error {
+	rs.mutex.RLock()
+	defer rs.mutex.RUnlock()
+
+	for _, name := range jsonStoreSharedData {
+		if err := bw.addPath(path.Join(backupStoreSharedDir, name), filepath.Join(rs.shared, name)); err != nil {
+			return err
+		}
+	}
+
+	files, err := ioutil.ReadDir(rs.base)
+
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for _, file := range files {
+		if filepath.Clean(rs.base) == filepath.Clean(rs.shared) && isJSONStoreSharedData(file.Name()) {
+			continue
+		}
+
+		if err := bw.addPath(path.Join(backupStorePrivateDir, file.Name()), filepath.Join(rs.base, file.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isJSONStoreSharedData(name string) bool {
+	for _, sharedName := range jsonStoreSharedData {
+		if name == sharedName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkIntegrity checks that every file in the store contains valid JSON.
+func (rs *JSONStore) checkIntegrity() ([]string, error) {
+	rs.mutex.RLock()
+	defer rs.mutex.RUnlock()
+
+	var problems []string
+
+	for _, dir := range []string{rs.base, rs.shared} {
+		err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+			if os.IsNotExist(err) {
+				return nil
+			} else if err != nil {
+				return err
+			}
+
+			if info.IsDir() || filepath.Ext(file) != ".json" {
+				return nil
+			}
+
+			data, err := ioutil.ReadFile(file)
+
+			if err != nil {
+				return err
+			}
+
+			if !json.Valid(data) {
+				problems = append(problems, "File does not contain valid JSON: "+file)
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		if filepath.Clean(rs.base) == filepath.Clean(rs.shared) {
+			break
+		}
+	}
+
+	return problems, nil
+}
@@ -0,0 +1,815 @@
+package servermanager
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite" // pure go sqlite driver for database/sql, so that builds don't need cgo
+)
+
+// sqliteSchema creates the tables used by the SQLiteStore. Entities are stored as JSON documents (the same encoding
+// as the BoltStore) alongside indexed columns that are used for lookups and filtering.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS custom_races (
+	id      TEXT PRIMARY KEY,
+	name    TEXT NOT NULL DEFAULT '',
+	updated DATETIME,
+	deleted BOOLEAN NOT NULL DEFAULT 0,
+	data    TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS custom_races_deleted ON custom_races (deleted, id);
+
+CREATE TABLE IF NOT EXISTS championships (
+	id      TEXT PRIMARY KEY,
+	name    TEXT NOT NULL DEFAULT '',
+	updated DATETIME,
+	deleted BOOLEAN NOT NULL DEFAULT 0,
+	data    TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS championships_deleted ON championships (deleted, id);
+
+CREATE TABLE IF NOT EXISTS race_weekends (
+	id      TEXT PRIMARY KEY,
+	name    TEXT NOT NULL DEFAULT '',
+	updated DATETIME,
+	deleted BOOLEAN NOT NULL DEFAULT 0,
+	data    TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS race_weekends_deleted ON race_weekends (deleted, id);
+
+CREATE TABLE IF NOT EXISTS accounts (
+	name    TEXT PRIMARY KEY,
+	id      TEXT NOT NULL,
+	updated DATETIME,
+	deleted BOOLEAN NOT NULL DEFAULT 0,
+	data    TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS accounts_id ON accounts (id);
+CREATE INDEX IF NOT EXISTS accounts_deleted ON accounts (deleted, name);
+
+CREATE TABLE IF NOT EXISTS entrants (
+	id   TEXT PRIMARY KEY,
+	data TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS options (
+	key  TEXT PRIMARY KEY,
+	data TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS meta (
+	key  TEXT PRIMARY KEY,
+	data TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS audit_entries (
+	id   INTEGER PRIMARY KEY AUTOINCREMENT,
+	time DATETIME NOT NULL,
+	data TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS audit_entries_time ON audit_entries (time);
+`
+
+const (
+	sqliteTableCustomRaces   = "custom_races"
+	sqliteTableChampionships = "championships"
+	sqliteTableRaceWeekends  = "race_weekends"
+)
+
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// OpenSQLiteStore opens (or creates) an sqlite database at path, ready to be used with NewSQLiteStore.
+func OpenSQLiteStore(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+
+	if err != nil {
+		return nil, err
+	}
+
+	// sqlite only supports a single writer, so serialise access through one connection to
+	// avoid 'database is locked' errors.
+	db.SetMaxOpenConns(1)
+
+	return db, nil
+}
+
+func NewSQLiteStore(db *sql.DB) (Store, error) {
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return nil, err
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+func (rs *SQLiteStore) encode(data interface{}) ([]byte, error) {
+	return json.Marshal(data)
+}
+
+func (rs *SQLiteStore) decode(data []byte, out interface{}) error {
+	return json.Unmarshal(data, out)
+}
+
+func (rs *SQLiteStore) upsertDocument(table, id, name string, updated, deleted time.Time, data interface{}) error {
+	encoded, err := rs.encode(data)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = rs.db.Exec(
+		`INSERT INTO `+table+` (id, name, updated, deleted, data) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET name = excluded.name, updated = excluded.updated, deleted = excluded.deleted, data = excluded.data`,
+		id, name, updated, !deleted.IsZero(), string(encoded),
+	)
+
+	return err
+}
+
+// purgeDocument permanently deletes the document with the given id.
+func (rs *SQLiteStore) purgeDocument(table, id string) error {
+	_, err := rs.db.Exec(`DELETE FROM `+table+` WHERE id = ?`, id)
+
+	return err
+}
+
+// findDocument decodes the document with the given id into out, returning notFound if it doesn't exist.
+func (rs *SQLiteStore) findDocument(table, id string, notFound error, out interface{}) error {
+	var data []byte
+
+	err := rs.db.QueryRow(`SELECT data FROM `+table+` WHERE id = ?`, id).Scan(&data)
+
+	if err == sql.ErrNoRows {
+		return notFound
+	} else if err != nil {
+		return err
+	}
+
+	return rs.decode(data, out)
+}
+
+// listDocuments calls fn for each document in the table which has (or has not) been soft deleted.
+func (rs *SQLiteStore) listDocuments(table string, deleted bool, fn func(data []byte) error) error {
+	rows, err := rs.db.Query(`SELECT data FROM `+table+` WHERE deleted = ? ORDER BY id`, deleted)
+
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var data []byte
+
+		if err := rows.Scan(&data); err != nil {
+			return err
+		}
+
+		if err := fn(data); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (rs *SQLiteStore) UpsertCustomRace(race *CustomRace) error {
+	race.Updated = time.Now()
+
+	return rs.upsertDocument(sqliteTableCustomRaces, race.UUID.String(), race.Name, race.Updated, race.Deleted, race)
+}
+
+func (rs *SQLiteStore) FindCustomRaceByID(uuid string) (*CustomRace, error) {
+	var customRace *CustomRace
+
+	err := rs.findDocument(sqliteTableCustomRaces, uuid, ErrCustomRaceNotFound, &customRace)
+
+	return customRace, err
+}
+
+func (rs *SQLiteStore) ListCustomRaces() ([]*CustomRace, error) {
+	return rs.listCustomRaces(false)
+}
+
+func (rs *SQLiteStore) ListDeletedCustomRaces() ([]*CustomRace, error) {
+	return rs.listCustomRaces(true)
+}
+
+func (rs *SQLiteStore) listCustomRaces(deleted bool) ([]*CustomRace, error) {
+	var customRaces []*CustomRace
+
+	err := rs.listDocuments(sqliteTableCustomRaces, deleted, func(data []byte) error {
+		var race *CustomRace
+
+		if err := rs.decode(data, &race); err != nil {
+			return err
+		}
+
+		customRaces = append(customRaces, race)
+
+		return nil
+	})
+
+	return customRaces, err
+}
+
+func (rs *SQLiteStore) DeleteCustomRace(race *CustomRace) error {
+	race.Deleted = time.Now()
+
+	return rs.UpsertCustomRace(race)
+}
+
+func (rs *SQLiteStore) PurgeCustomRace(uuid string) error {
+	return rs.purgeDocument(sqliteTableCustomRaces, uuid)
+}
+
+func (rs *SQLiteStore) UpsertEntrant(entrant Entrant) error {
+	// clear out some race specific values
+	entrant.Model = ""
+	entrant.Skin = ""
+	entrant.SpectatorMode = 0
+
+	encoded, err := rs.encode(entrant)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = rs.db.Exec(`INSERT INTO entrants (id, data) VALUES (?, ?) ON CONFLICT (id) DO UPDATE SET data = excluded.data`, entrant.ID(), string(encoded))
+
+	return err
+}
+
+func (rs *SQLiteStore) ListEntrants() ([]*Entrant, error) {
+	rows, err := rs.db.Query(`SELECT data FROM entrants ORDER BY id`)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var entrants []*Entrant
+
+	for rows.Next() {
+		var data []byte
+
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+
+		var entrant *Entrant
+
+		if err := rs.decode(data, &entrant); err != nil {
+			return nil, err
+		}
+
+		entrants = append(entrants, entrant)
+	}
+
+	return entrants, rows.Err()
+}
+
+func (rs *SQLiteStore) DeleteEntrant(id string) error {
+	_, err := rs.db.Exec(`DELETE FROM entrants WHERE id = ?`, id)
+
+	return err
+}
+
+const (
+	sqliteServerOptionsKey      = "serverOptions"
+	sqliteStrackerOptionsKey    = "strackerOptions"
+	sqliteKissMyRankOptionsKey  = "kissMyRankOptions"
+	sqliteRealPenaltyOptionsKey = "realPenaltyOptions"
+	sqliteLiveTimingsKey        = "liveTimings"
+	sqliteLastRaceEventKey      = "lastRaceEvent"
+	sqliteFrameLinksKey         = "frameLinks"
+)
+
+func (rs *SQLiteStore) putValue(table, key string, data []byte) error {
+	_, err := rs.db.Exec(`INSERT INTO `+table+` (key, data) VALUES (?, ?) ON CONFLICT (key) DO UPDATE SET data = excluded.data`, key, string(data))
+
+	return err
+}
+
+// getValue returns the value stored at key, or nil if it has not been set.
+func (rs *SQLiteStore) getValue(table, key string) ([]byte, error) {
+	var data []byte
+
+	err := rs.db.QueryRow(`SELECT data FROM `+table+` WHERE key = ?`, key).Scan(&data)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return data, err
+}
+
+func (rs *SQLiteStore) putOption(key string, value interface{}) error {
+	encoded, err := rs.encode(value)
+
+	if err != nil {
+		return err
+	}
+
+	return rs.putValue("options", key, encoded)
+}
+
+// loadOption decodes the option at key into out, reporting whether the option was found.
+func (rs *SQLiteStore) loadOption(key string, out interface{}) (bool, error) {
+	data, err := rs.getValue("options", key)
+
+	if err != nil || data == nil {
+		return false, err
+	}
+
+	return true, rs.decode(data, out)
+}
+
+func (rs *SQLiteStore) UpsertServerOptions(so *GlobalServerConfig) error {
+	return rs.putOption(sqliteServerOptionsKey, so)
+}
+
+func (rs *SQLiteStore) LoadServerOptions() (*GlobalServerConfig, error) {
+	// start with defaults
+	defaultConfig := ConfigIniDefault()
+
+	so := &defaultConfig.GlobalServerConfig
+
+	found, err := rs.loadOption(sqliteServerOptionsKey, &so)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		// no server options created yet, apply defaults
+		return so, rs.UpsertServerOptions(so)
+	}
+
+	return so, nil
+}
+
+func (rs *SQLiteStore) UpsertChampionship(c *Championship) error {
+	c.Updated = time.Now()
+
+	return rs.upsertDocument(sqliteTableChampionships, c.ID.String(), c.Name, c.Updated, c.Deleted, c)
+}
+
+func (rs *SQLiteStore) ListChampionships() ([]*Championship, error) {
+	return rs.listChampionships(false)
+}
+
+func (rs *SQLiteStore) ListDeletedChampionships() ([]*Championship, error) {
+	return rs.listChampionships(true)
+}
+
+func (rs *SQLiteStore) listChampionships(deleted bool) ([]*Championship, error) {
+	var championships []*Championship
+
+	err := rs.listDocuments(sqliteTableChampionships, deleted, func(data []byte) error {
+		var championship *Championship
+
+		if err := rs.decode(data, &championship); err != nil {
+			return err
+		}
+
+		championships = append(championships, championship)
+
+		return nil
+	})
+
+	return championships, err
+}
+
+func (rs *SQLiteStore) LoadChampionship(id string) (*Championship, error) {
+	var championship *Championship
+
+	if err := rs.findDocument(sqliteTableChampionships, id, ErrChampionshipNotFound, &championship); err != nil {
+		return nil, err
+	}
+
+	if err := loadChampionshipRaceWeekends(championship, rs); err != nil {
+		return nil, err
+	}
+
+	return championship, nil
+}
+
+func (rs *SQLiteStore) DeleteChampionship(id string) error {
+	championship, err := rs.LoadChampionship(id)
+
+	if err != nil {
+		return err
+	}
+
+	championship.Deleted = time.Now()
+
+	return rs.UpsertChampionship(championship)
+}
+
+func (rs *SQLiteStore) PurgeChampionship(id string) error {
+	return rs.purgeDocument(sqliteTableChampionships, id)
+}
+
+func (rs *SQLiteStore) UpsertLiveTimingsData(lt *LiveTimingsPersistedData) error {
+	return rs.putOption(sqliteLiveTimingsKey, lt)
+}
+
+func (rs *SQLiteStore) LoadLiveTimingsData() (*LiveTimingsPersistedData, error) {
+	var lt *LiveTimingsPersistedData
+
+	_, err := rs.loadOption(sqliteLiveTimingsKey, &lt)
+
+	return lt, err
+}
+
+func (rs *SQLiteStore) UpsertLastRaceEvent(r RaceEvent) error {
+	encoded, err := marshalRaceEvent(r)
+
+	if err != nil {
+		return err
+	}
+
+	return rs.putValue("options", sqliteLastRaceEventKey, encoded)
+}
+
+func (rs *SQLiteStore) LoadLastRaceEvent() (RaceEvent, error) {
+	data, err := rs.getValue("options", sqliteLastRaceEventKey)
+
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	return unmarshalRaceEvent(data)
+}
+
+func (rs *SQLiteStore) ClearLastRaceEvent() error {
+	_, err := rs.db.Exec(`DELETE FROM options WHERE key = ?`, sqliteLastRaceEventKey)
+
+	return err
+}
+
+func (rs *SQLiteStore) UpsertLiveFrames(frameLinks []string) error {
+	return rs.putOption(sqliteFrameLinksKey, frameLinks)
+}
+
+func (rs *SQLiteStore) ListPrevFrames() ([]string, error) {
+	var links []string
+
+	_, err := rs.loadOption(sqliteFrameLinksKey, &links)
+
+	return links, err
+}
+
+func (rs *SQLiteStore) SetMeta(key string, value interface{}) error {
+	encoded, err := rs.encode(value)
+
+	if err != nil {
+		return err
+	}
+
+	return rs.putValue("meta", key, encoded)
+}
+
+func (rs *SQLiteStore) GetMeta(key string, out interface{}) error {
+	data, err := rs.getValue("meta", key)
+
+	if err != nil {
+		return err
+	}
+
+	if data == nil {
+		return ErrValueNotSet
+	}
+
+	return rs.decode(data, &out)
+}
+
+func (rs *SQLiteStore) ListAccounts() ([]*Account, error) {
+	return rs.listAccounts(false)
+}
+
+func (rs *SQLiteStore) ListDeletedAccounts() ([]*Account, error) {
+	return rs.listAccounts(true)
+}
+
+func (rs *SQLiteStore) listAccounts(deleted bool) ([]*Account, error) {
+	rows, err := rs.db.Query(`SELECT data FROM accounts WHERE deleted = ? ORDER BY name`, deleted)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var accounts []*Account
+
+	for rows.Next() {
+		var data []byte
+
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+
+		var account *Account
+
+		if err := rs.decode(data, &account); err != nil {
+			return nil, err
+		}
+
+		accounts = append(accounts, account)
+	}
+
+	return accounts, rows.Err()
+}
+
+func (rs *SQLiteStore) UpsertAccount(a *Account) error {
+	a.Updated = time.Now()
+
+	encoded, err := rs.encode(a)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = rs.db.Exec(
+		`INSERT INTO accounts (name, id, updated, deleted, data) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET id = excluded.id, updated = excluded.updated, deleted = excluded.deleted, data = excluded.data`,
+		a.Name, a.ID.String(), a.Updated, !a.Deleted.IsZero(), string(encoded),
+	)
+
+	return err
+}
+
+func (rs *SQLiteStore) findAccount(query string, arg string) (*Account, error) {
+	var data []byte
+
+	err := rs.db.QueryRow(query, arg).Scan(&data)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrAccountNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	var account *Account
+
+	return account, rs.decode(data, &account)
+}
+
+func (rs *SQLiteStore) FindAccountByName(name string) (*Account, error) {
+	return rs.findAccount(`SELECT data FROM accounts WHERE name = ?`, name)
+}
+
+func (rs *SQLiteStore) FindAccountByID(id string) (*Account, error) {
+	return rs.findAccount(`SELECT data FROM accounts WHERE id = ? AND deleted = 0 ORDER BY name LIMIT 1`, id)
+}
+
+func (rs *SQLiteStore) DeleteAccount(id string) error {
+	account, err := rs.FindAccountByID(id)
+
+	if err != nil {
+		return err
+	}
+
+	account.Deleted = time.Now()
+
+	return rs.UpsertAccount(account)
+}
+
+func (rs *SQLiteStore) PurgeAccount(name string) error {
+	_, err := rs.db.Exec(`DELETE FROM accounts WHERE name = ?`, name)
+
+	return err
+}
+
+func (rs *SQLiteStore) GetAuditEntries() ([]*AuditEntry, error) {
+	rows, err := rs.db.Query(`SELECT data FROM audit_entries ORDER BY id`)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var entries []*AuditEntry
+
+	for rows.Next() {
+		var data []byte
+
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+
+		var entry *AuditEntry
+
+		if err := rs.decode(data, &entry); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+func (rs *SQLiteStore) AddAuditEntry(entry *AuditEntry) error {
+	encoded, err := rs.encode(entry)
+
+	if err != nil {
+		return err
+	}
+
+	tx, err := rs.db.Begin()
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`INSERT INTO audit_entries (time, data) VALUES (?, ?)`, entry.Time, string(encoded)); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM audit_entries WHERE id <= (SELECT MAX(id) FROM audit_entries) - ?`, maxAuditEntries); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (rs *SQLiteStore) ListRaceWeekends() ([]*RaceWeekend, error) {
+	return rs.listRaceWeekends(false)
+}
+
+func (rs *SQLiteStore) ListDeletedRaceWeekends() ([]*RaceWeekend, error) {
+	return rs.listRaceWeekends(true)
+}
+
+func (rs *SQLiteStore) listRaceWeekends(deleted bool) ([]*RaceWeekend, error) {
+	var raceWeekends []*RaceWeekend
+
+	err := rs.listDocuments(sqliteTableRaceWeekends, deleted, func(data []byte) error {
+		var raceWeekend *RaceWeekend
+
+		if err := rs.decode(data, &raceWeekend); err != nil {
+			return err
+		}
+
+		raceWeekends = append(raceWeekends, raceWeekend)
+
+		return nil
+	})
+
+	return raceWeekends, err
+}
+
+func (rs *SQLiteStore) UpsertRaceWeekend(rw *RaceWeekend) error {
+	rw.Updated = time.Now()
+
+	return rs.upsertDocument(sqliteTableRaceWeekends, rw.ID.String(), rw.Name, rw.Updated, rw.Deleted, rw)
+}
+
+func (rs *SQLiteStore) LoadRaceWeekend(id string) (*RaceWeekend, error) {
+	var raceWeekend *RaceWeekend
+
+	if err := rs.findDocument(sqliteTableRaceWeekends, id, ErrRaceWeekendNotFound, &raceWeekend); err != nil {
+		return nil, err
+	}
+
+	return raceWeekend, nil
+}
+
+func (rs *SQLiteStore) DeleteRaceWeekend(id string) error {
+	raceWeekend, err := rs.LoadRaceWeekend(id)
+
+	if err != nil {
+		return err
+	}
+
+	raceWeekend.Deleted = time.Now()
+
+	return rs.UpsertRaceWeekend(raceWeekend)
+}
+
+func (rs *SQLiteStore) PurgeRaceWeekend(id string) error {
+	return rs.purgeDocument(sqliteTableRaceWeekends, id)
+}
+
+func (rs *SQLiteStore) UpsertStrackerOptions(sto *StrackerConfiguration) error {
+	return rs.putOption(sqliteStrackerOptionsKey, sto)
+}
+
+func (rs *SQLiteStore) LoadStrackerOptions() (*StrackerConfiguration, error) {
+	// start with defaults
+	sto := DefaultStrackerIni()
+
+	_, err := rs.loadOption(sqliteStrackerOptionsKey, &sto)
+
+	return sto, err
+}
+
+func (rs *SQLiteStore) UpsertKissMyRankOptions(kmr *KissMyRankConfig) error {
+	return rs.putOption(sqliteKissMyRankOptionsKey, kmr)
+}
+
+func (rs *SQLiteStore) LoadKissMyRankOptions() (*KissMyRankConfig, error) {
+	// start with defaults
+	kmr := DefaultKissMyRankConfig()
+
+	_, err := rs.loadOption(sqliteKissMyRankOptionsKey, &kmr)
+
+	return kmr, err
+}
+
+func (rs *SQLiteStore) UpsertRealPenaltyOptions(rpc *RealPenaltyConfig) error {
+	return rs.putOption(sqliteRealPenaltyOptionsKey, rpc)
+}
+
+func (rs *SQLiteStore) LoadRealPenaltyOptions() (*RealPenaltyConfig, error) {
+	// start with defaults
+	rpc := DefaultRealPenaltyConfig()
+
+	_, err := rs.loadOption(sqliteRealPenaltyOptionsKey, &rpc)
+
+	return rpc, err
+}
+
+// backup adds a consistent copy of the sqlite database to a backup.
+func (rs *SQLiteStore) backup(bw *backupWriter) error {
+	dir, err := ioutil.TempDir("", "server-manager-backup")
+
+	if err != nil {
+		return err
+	}
+
+	defer os.RemoveAll(dir)
+
+	snapshot := filepath.Join(dir, "store.db")
+
+	// the database file can't be copied directly while it is open in WAL mode, VACUUM INTO
+	// writes a copy of it from a single read transaction instead.
+	if _, err := rs.db.Exec("VACUUM INTO ?", snapshot); err != nil {
+		return err
+	}
+
+	return bw.addPath(backupStoreFile, snapshot)
+}
+
+func (rs *SQLiteStore) Close() error {
+	return rs.db.Close()
+}
+
+// checkIntegrity runs sqlite's integrity check on the database.
+func (rs *SQLiteStore) checkIntegrity() ([]string, error) {
+	rows, err := rs.db.Query("PRAGMA integrity_check")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var problems []string
+
+	for rows.Next() {
+		var result string
+
+		if err := rows.Scan(&result); err != nil {
+			return nil, err
+		}
+
+		if result != "ok" {
+			problems = append(problems, result)
+		}
+	}
+
+	return problems, rows.Err()
+}
+
+// compactSQLiteStore rebuilds the sqlite database at path to reclaim unused space.
+func compactSQLiteStore(path string) error {
+	db, err := OpenSQLiteStore(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	if _, err := db.Exec("VACUUM"); err != nil {
+		return err
+	}
+
+	// VACUUM writes the rebuilt database to the WAL, copy it back to the database file.
+	_, err = db.Exec("PRAGMA wal_checkpoint(TRUNCATE)")
+
+	return err
+}
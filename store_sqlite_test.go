@@ -0,0 +1,280 @@
+package servermanager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func newTestSQLiteStore(t *testing.T) (Store, func()) {
+	dir, err := ioutil.TempDir("", "asm-sqlite-store")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := OpenSQLiteStore(filepath.Join(dir, "server_manager.sqlite"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewSQLiteStore(db)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return store, func() {
+		_ = db.Close()
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func TestSQLiteStore(t *testing.T) {
+	store, cleanup := newTestSQLiteStore(t)
+	defer cleanup()
+
+	t.Run("Custom races", func(t *testing.T) {
+		race := &CustomRace{Name: "Test Race", UUID: uuid.New()}
+
+		if err := store.UpsertCustomRace(race); err != nil {
+			t.Fatal(err)
+		}
+
+		found, err := store.FindCustomRaceByID(race.UUID.String())
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if found.Name != race.Name {
+			t.Errorf("expected race name %s, got %s", race.Name, found.Name)
+		}
+
+		if err := store.DeleteCustomRace(found); err != nil {
+			t.Fatal(err)
+		}
+
+		races, err := store.ListCustomRaces()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(races) != 0 {
+			t.Errorf("expected deleted race to be excluded from list, got %d races", len(races))
+		}
+
+		if _, err := store.FindCustomRaceByID("not-a-race"); err != ErrCustomRaceNotFound {
+			t.Errorf("expected ErrCustomRaceNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Championships", func(t *testing.T) {
+		championship := NewChampionship("Test Championship")
+
+		if err := store.UpsertChampionship(championship); err != nil {
+			t.Fatal(err)
+		}
+
+		loaded, err := store.LoadChampionship(championship.ID.String())
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if loaded.Name != championship.Name {
+			t.Errorf("expected championship name %s, got %s", championship.Name, loaded.Name)
+		}
+
+		if _, err := store.LoadChampionship("not-a-championship"); err != ErrChampionshipNotFound {
+			t.Errorf("expected ErrChampionshipNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Accounts", func(t *testing.T) {
+		account := NewAccount()
+		account.Name = "test"
+
+		if err := store.UpsertAccount(account); err != nil {
+			t.Fatal(err)
+		}
+
+		byID, err := store.FindAccountByID(account.ID.String())
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if byID.Name != account.Name {
+			t.Errorf("expected account name %s, got %s", account.Name, byID.Name)
+		}
+
+		if err := store.DeleteAccount(account.ID.String()); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := store.FindAccountByID(account.ID.String()); err != ErrAccountNotFound {
+			t.Errorf("expected ErrAccountNotFound, got %v", err)
+		}
+	})
+
+	t.Run("Meta", func(t *testing.T) {
+		var value int
+
+		if err := store.GetMeta("test", &value); err != ErrValueNotSet {
+			t.Errorf("expected ErrValueNotSet, got %v", err)
+		}
+
+		if err := store.SetMeta("test", 20); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := store.GetMeta("test", &value); err != nil || value != 20 {
+			t.Errorf("expected meta value 20, got %d (err: %v)", value, err)
+		}
+	})
+
+	t.Run("Audit entries are limited", func(t *testing.T) {
+		for i := 0; i < maxAuditEntries+5; i++ {
+			if err := store.AddAuditEntry(&AuditEntry{URL: "/test"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		entries, err := store.GetAuditEntries()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(entries) != maxAuditEntries {
+			t.Errorf("expected %d audit entries, got %d", maxAuditEntries, len(entries))
+		}
+	})
+}
+
+func TestCopyStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "asm-copy-store")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	from := NewJSONStore(dir, dir)
+
+	championship := NewChampionship("Copied Championship")
+
+	if err := from.UpsertChampionship(championship); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := from.UpsertEntrant(Entrant{Name: "Driver", GUID: "7656119"}); err != nil {
+		t.Fatal(err)
+	}
+
+	deletedChampionship := NewChampionship("Deleted Championship")
+
+	if err := from.UpsertChampionship(deletedChampionship); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := from.DeleteChampionship(deletedChampionship.ID.String()); err != nil {
+		t.Fatal(err)
+	}
+
+	deletedCustomRace := &CustomRace{Name: "Deleted Race", UUID: uuid.New()}
+
+	if err := from.DeleteCustomRace(deletedCustomRace); err != nil {
+		t.Fatal(err)
+	}
+
+	deletedRaceWeekend := NewRaceWeekend()
+
+	if err := from.UpsertRaceWeekend(deletedRaceWeekend); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := from.DeleteRaceWeekend(deletedRaceWeekend.ID.String()); err != nil {
+		t.Fatal(err)
+	}
+
+	deletedAccount := NewAccount()
+	deletedAccount.Name = "deleted"
+
+	if err := from.UpsertAccount(deletedAccount); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := from.DeleteAccount(deletedAccount.ID.String()); err != nil {
+		t.Fatal(err)
+	}
+
+	to, cleanup := newTestSQLiteStore(t)
+	defer cleanup()
+
+	if err := CopyStore(from, to); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := to.LoadChampionship(championship.ID.String()); err != nil {
+		t.Errorf("expected championship to be copied, got: %v", err)
+	}
+
+	entrants, err := to.ListEntrants()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entrants) != 1 || entrants[0].GUID != "7656119" {
+		t.Errorf("expected entrant to be copied, got: %v", entrants)
+	}
+
+	// soft deleted entities must be copied so that they stay in the recycle bin.
+	deletedChampionships, err := to.ListDeletedChampionships()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deletedChampionships) != 1 || deletedChampionships[0].ID != deletedChampionship.ID {
+		t.Errorf("expected the deleted championship to be copied, got: %v", deletedChampionships)
+	}
+
+	deletedCustomRaces, err := to.ListDeletedCustomRaces()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deletedCustomRaces) != 1 || deletedCustomRaces[0].UUID != deletedCustomRace.UUID {
+		t.Errorf("expected the deleted custom race to be copied, got: %v", deletedCustomRaces)
+	}
+
+	deletedRaceWeekends, err := to.ListDeletedRaceWeekends()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deletedRaceWeekends) != 1 || deletedRaceWeekends[0].ID != deletedRaceWeekend.ID {
+		t.Errorf("expected the deleted race weekend to be copied, got: %v", deletedRaceWeekends)
+	}
+
+	deletedAccounts, err := to.ListDeletedAccounts()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(deletedAccounts) != 1 || deletedAccounts[0].Name != "deleted" {
+		t.Errorf("expected the deleted account to be copied, got: %v", deletedAccounts)
+	}
+}
@@ -0,0 +1,164 @@
+package servermanager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "verify-store")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	store := NewJSONStore(filepath.Join(dir, "store"), filepath.Join(dir, "shared"))
+
+	t.Run("Stores that haven't been migrated are out of date", func(t *testing.T) {
+		problems, err := VerifyStore(store)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(problems) != 1 || !strings.Contains(problems[0], "out of date") {
+			t.Errorf("Expected the store to be out of date, got: %v", problems)
+		}
+	})
+
+	if err := Migrate(store); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Migrated stores have no problems", func(t *testing.T) {
+		problems, err := VerifyStore(store)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(problems) > 0 {
+			t.Errorf("Expected no problems, got: %v", problems)
+		}
+	})
+
+	t.Run("Corrupt files are found", func(t *testing.T) {
+		if err := ioutil.WriteFile(filepath.Join(dir, "store", "corrupt.json"), []byte(`{"Name": `), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		defer os.Remove(filepath.Join(dir, "store", "corrupt.json"))
+
+		problems, err := VerifyStore(store)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(problems) != 1 || !strings.Contains(problems[0], "corrupt.json") {
+			t.Errorf("Expected corrupt.json to be found, got: %v", problems)
+		}
+	})
+
+	t.Run("Stores without an admin account are found", func(t *testing.T) {
+		admin, err := store.FindAccountByName(adminUserName)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := NewAccountManager(store).SetGroup(admin, GroupWrite, true); err != nil {
+			t.Fatal(err)
+		}
+
+		problems, err := VerifyStore(store)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(problems) != 1 || problems[0] != "No accounts are in the admin group" {
+			t.Errorf("Expected no admin accounts to be found, got: %v", problems)
+		}
+	})
+}
+
+func TestCompactStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "compact-store")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	storeConfig := &StoreConfig{
+		Type:        "boltdb",
+		Path:        filepath.Join(dir, "store.db"),
+		OpenTimeout: time.Second,
+	}
+
+	store, err := storeConfig.BuildStore()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	customRaces, err := store.ListCustomRaces()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.(*BoltStore).db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	before, after, err := CompactStore(storeConfig)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if after > before {
+		t.Errorf("Expected the store not to grow, was %d bytes and is now %d bytes", before, after)
+	}
+
+	store, err = storeConfig.OpenStore()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer store.(*BoltStore).db.Close()
+
+	problems, err := VerifyStore(store)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(problems) > 0 {
+		t.Errorf("Expected no problems after compacting, got: %v", problems)
+	}
+
+	compactedCustomRaces, err := store.ListCustomRaces()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(compactedCustomRaces) != len(customRaces) {
+		t.Errorf("Expected %d custom races after compacting, got %d", len(customRaces), len(compactedCustomRaces))
+	}
+
+	if _, _, err := CompactStore(&StoreConfig{Type: "json"}); err != ErrStoreCannotBeCompacted {
+		t.Errorf("Expected ErrStoreCannotBeCompacted for json stores, got: %v", err)
+	}
+}
@@ -223,6 +223,7 @@ func (tr *Renderer) init() error {
 	funcs["trackLayoutURL"] = trackLayoutURL
 	funcs["stringArrayToCSV"] = stringArrayToCSV
 	funcs["dict"] = templateDict
+	funcs["blocklistDurations"] = func() []blocklistDuration { return blocklistDurations }
 	funcs["asset"] = NewAssetHelper("/", "", "", map[string]string{"cb": BuildVersion}).GetURL
 	funcs["SessionType"] = func(s string) SessionType { return SessionType(s) }
 	funcs["Config"] = func() *Configuration { return config }
@@ -549,6 +550,7 @@ type BaseTemplateVars struct {
 	BaseURLIsValid        bool
 	ServerID              ServerID
 	ShowEventDetailsPopup bool
+	InstancesEnabled      bool
 }
 
 func (b *BaseTemplateVars) Get() *BaseTemplateVars {
@@ -598,6 +600,7 @@ func (tr *Renderer) addData(w http.ResponseWriter, r *http.Request, vars Templat
 	data.ACSREnabled = opts.EnableACSR
 	data.ServerID = serverID
 	data.ShowEventDetailsPopup = opts.ShowEventDetailsPopup
+	data.InstancesEnabled = len(config.Instances.Servers) > 0 && !IsInstance()
 
 	if Premium() {
 		data.OGImage = opts.OGImage
@@ -0,0 +1,628 @@
+package servermanager
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	webhooksMetaKey = "webhooks"
+
+	webhookMaxAttempts      = 5
+	webhookMaxDeliveries    = 100
+	webhookRequestTimeout   = 10 * time.Second
+	webhookSignatureHeader  = "X-Server-Manager-Signature"
+	webhookEventHeader      = "X-Server-Manager-Event"
+	webhookDeliveryIDHeader = "X-Server-Manager-Delivery"
+)
+
+// webhookRetryBackoff is the delay before the first retry of a failed delivery. It doubles with each attempt.
+var webhookRetryBackoff = 15 * time.Second
+
+var (
+	ErrWebhookNotFound     = errors.New("servermanager: webhook not found")
+	ErrWebhookInvalidURL   = errors.New("servermanager: webhook url must be an absolute http or https url")
+	ErrWebhookNameRequired = errors.New("servermanager: webhook name is required")
+)
+
+// WebhookEvent is the type of notification sent to a Webhook.
+type WebhookEvent string
+
+const (
+	WebhookEventMessage             WebhookEvent = "message"
+	WebhookEventRaceScheduled       WebhookEvent = "race.scheduled"
+	WebhookEventRaceCancelled       WebhookEvent = "race.cancelled"
+	WebhookEventRaceReminder        WebhookEvent = "race.reminder"
+	WebhookEventRaceStarted         WebhookEvent = "race.started"
+	WebhookEventValidationFailed    WebhookEvent = "race.validation_failed"
+	WebhookEventSessionEnded        WebhookEvent = "session.ended"
+	WebhookEventChampionshipResults WebhookEvent = "championship.results"
+	WebhookEventChampionshipSignUp  WebhookEvent = "championship.signup"
+	WebhookEventLapRecord           WebhookEvent = "lap.record"
+	WebhookEventTest                WebhookEvent = "test"
+)
+
+type WebhookEventDescription struct {
+	Event       WebhookEvent
+	Description string
+}
+
+// WebhookEventDescriptions lists the events a Webhook can subscribe to, in the order they are shown in the UI.
+var WebhookEventDescriptions = []WebhookEventDescription{
+	{WebhookEventRaceScheduled, "An event is scheduled"},
+	{WebhookEventRaceCancelled, "A scheduled event is cancelled"},
+	{WebhookEventRaceReminder, "Reminders before a scheduled event starts"},
+	{WebhookEventRaceStarted, "An event is started"},
+	{WebhookEventValidationFailed, "A scheduled event fails validation and is not started"},
+	{WebhookEventSessionEnded, "A session ends"},
+	{WebhookEventChampionshipResults, "A championship event is completed"},
+	{WebhookEventChampionshipSignUp, "A championship sign up is received"},
+	{WebhookEventLapRecord, "A lap record is broken"},
+	{WebhookEventMessage, "Any other message sent to Discord"},
+}
+
+// Webhook is an HTTP endpoint which notifications are POSTed to as JSON.
+type Webhook struct {
+	ID      uuid.UUID
+	Name    string
+	URL     string
+	Events  []WebhookEvent
+	Enabled bool
+	Created time.Time
+
+	// Secret is used to sign the body of each request with HMAC-SHA256. If it is empty, requests are not signed.
+	Secret string
+}
+
+// Subscribes returns true if the Webhook should be sent the event.
+func (w *Webhook) Subscribes(event WebhookEvent) bool {
+	if event == WebhookEventTest {
+		return true
+	}
+
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WebhookPayload is the JSON body sent to a Webhook. Title and Message are the same as those sent to Discord.
+type WebhookPayload struct {
+	Event      WebhookEvent
+	Time       time.Time
+	ServerID   ServerID
+	ServerName string
+
+	Title   string
+	Message string
+	Data    interface{} `json:",omitempty"`
+}
+
+// WebhookLink is sent as the Data of a message that contains a link, e.g. the Content Manager join link.
+type WebhookLink struct {
+	Text string
+	URL  string
+}
+
+// WebhookEventDetails is sent as the Data of scheduling, reminder and start notifications.
+type WebhookEventDetails struct {
+	ID          string `json:",omitempty"`
+	Name        string
+	Track       string
+	TrackLayout string
+	Cars        []string
+	Date        time.Time
+	URL         string `json:",omitempty"`
+
+	ReminderMinutes int    `json:",omitempty"`
+	ChampionshipID  string `json:",omitempty"`
+	RaceWeekendID   string `json:",omitempty"`
+}
+
+func customRaceWebhookDetails(race *CustomRace, date time.Time) *WebhookEventDetails {
+	return &WebhookEventDetails{
+		ID:          race.UUID.String(),
+		Name:        race.EventName(),
+		Track:       race.RaceConfig.Track,
+		TrackLayout: race.RaceConfig.TrackLayout,
+		Cars:        varSplit(race.RaceConfig.Cars),
+		Date:        date,
+		URL:         race.GetURL(),
+	}
+}
+
+func championshipEventWebhookDetails(championship *Championship, event *ChampionshipEvent, date time.Time) *WebhookEventDetails {
+	return &WebhookEventDetails{
+		ID:             event.ID.String(),
+		Name:           championship.Name,
+		Track:          event.RaceSetup.Track,
+		TrackLayout:    event.RaceSetup.TrackLayout,
+		Cars:           varSplit(event.RaceSetup.Cars),
+		Date:           date,
+		ChampionshipID: championship.ID.String(),
+		URL:            "/championship/" + championship.ID.String(),
+	}
+}
+
+func raceWeekendSessionWebhookDetails(raceWeekend *RaceWeekend, session *RaceWeekendSession, date time.Time) *WebhookEventDetails {
+	return &WebhookEventDetails{
+		ID:            session.ID.String(),
+		Name:          session.Name(),
+		Track:         session.RaceConfig.Track,
+		TrackLayout:   session.RaceConfig.TrackLayout,
+		Cars:          varSplit(session.RaceConfig.Cars),
+		Date:          date,
+		RaceWeekendID: raceWeekend.ID.String(),
+		URL:           "/race-weekend/" + raceWeekend.ID.String(),
+	}
+}
+
+// WebhookEventValidation is sent as the Data of a notification that a scheduled event failed validation.
+type WebhookEventValidation struct {
+	WebhookEventDetails
+
+	Errors   []EventValidationIssue
+	Warnings []EventValidationIssue
+}
+
+// WebhookChampionshipResults is sent as the Data of a championship results notification.
+type WebhookChampionshipResults struct {
+	ChampionshipID   string
+	ChampionshipName string
+	EventID          string
+	Track            string
+	TrackLayout      string
+	URL              string
+
+	// Standings are the championship standings after the event, for each class.
+	Standings []*ResultsSummaryStandings
+}
+
+// WebhookSignUp is sent as the Data of a championship sign up notification. Email addresses and answers to
+// sign up questions are not included.
+type WebhookSignUp struct {
+	ChampionshipID   string
+	ChampionshipName string
+
+	DriverGUID string
+	DriverName string
+	Team       string
+	Car        string
+	Status     ChampionshipEntrantStatus
+}
+
+// WebhookDelivery records the outcome of sending a WebhookPayload to a Webhook.
+type WebhookDelivery struct {
+	ID          uuid.UUID
+	WebhookID   uuid.UUID
+	WebhookName string
+	Event       WebhookEvent
+	Created     time.Time
+
+	Attempts    int
+	LastAttempt time.Time
+	StatusCode  int
+	Error       string
+	Succeeded   bool
+	Pending     bool
+}
+
+// WebhookManager sends notifications to the configured Webhooks, retrying failed deliveries with backoff.
+type WebhookManager struct {
+	store  Store
+	client *http.Client
+
+	deliveries      []*WebhookDelivery
+	deliveriesMutex sync.Mutex
+}
+
+func NewWebhookManager(store Store) *WebhookManager {
+	return &WebhookManager{
+		store: store,
+		client: &http.Client{
+			Timeout: webhookRequestTimeout,
+		},
+	}
+}
+
+func (wm *WebhookManager) ListWebhooks() ([]*Webhook, error) {
+	var webhooks []*Webhook
+
+	err := wm.store.GetMeta(webhooksMetaKey, &webhooks)
+
+	if err != nil && err != ErrValueNotSet {
+		return nil, err
+	}
+
+	sort.Slice(webhooks, func(i, j int) bool {
+		return webhooks[i].Created.Before(webhooks[j].Created)
+	})
+
+	return webhooks, nil
+}
+
+func (wm *WebhookManager) FindWebhook(id string) (*Webhook, error) {
+	webhooks, err := wm.ListWebhooks()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, webhook := range webhooks {
+		if webhook.ID.String() == id {
+			return webhook, nil
+		}
+	}
+
+	return nil, ErrWebhookNotFound
+}
+
+func (wm *WebhookManager) UpsertWebhook(webhook *Webhook) error {
+	webhook.Name = strings.TrimSpace(webhook.Name)
+
+	if webhook.Name == "" {
+		return ErrWebhookNameRequired
+	}
+
+	u, err := url.Parse(webhook.URL)
+
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrWebhookInvalidURL
+	}
+
+	webhooks, err := wm.ListWebhooks()
+
+	if err != nil {
+		return err
+	}
+
+	found := false
+
+	for i, existing := range webhooks {
+		if existing.ID == webhook.ID {
+			webhooks[i] = webhook
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		webhooks = append(webhooks, webhook)
+	}
+
+	return wm.store.SetMeta(webhooksMetaKey, webhooks)
+}
+
+func (wm *WebhookManager) DeleteWebhook(id string) error {
+	webhooks, err := wm.ListWebhooks()
+
+	if err != nil {
+		return err
+	}
+
+	for i, webhook := range webhooks {
+		if webhook.ID.String() == id {
+			webhooks = append(webhooks[:i], webhooks[i+1:]...)
+
+			return wm.store.SetMeta(webhooksMetaKey, webhooks)
+		}
+	}
+
+	return ErrWebhookNotFound
+}
+
+// Send delivers an event to every enabled Webhook which subscribes to it. Deliveries happen in the background.
+func (wm *WebhookManager) Send(event WebhookEvent, title, message string, data interface{}) {
+	webhooks, err := wm.ListWebhooks()
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not list webhooks, skipping %s notification", event)
+		return
+	}
+
+	for _, webhook := range webhooks {
+		if !webhook.Enabled || !webhook.Subscribes(event) {
+			continue
+		}
+
+		wm.sendTo(webhook, event, title, message, data)
+	}
+}
+
+// SendTest sends a test event to a Webhook, regardless of whether it is enabled.
+func (wm *WebhookManager) SendTest(webhook *Webhook) {
+	wm.sendTo(webhook, WebhookEventTest, "Test notification", "This is a test notification from Server Manager", nil)
+}
+
+func (wm *WebhookManager) sendTo(webhook *Webhook, event WebhookEvent, title, message string, data interface{}) {
+	payload := &WebhookPayload{
+		Event:    event,
+		Time:     time.Now(),
+		ServerID: serverID,
+		Title:    title,
+		Message:  message,
+		Data:     data,
+	}
+
+	if serverOpts, err := wm.store.LoadServerOptions(); err == nil {
+		payload.ServerName = serverOpts.Name
+	}
+
+	body, err := json.Marshal(payload)
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not encode %s webhook payload", event)
+		return
+	}
+
+	delivery := &WebhookDelivery{
+		ID:          uuid.New(),
+		WebhookID:   webhook.ID,
+		WebhookName: webhook.Name,
+		Event:       event,
+		Created:     time.Now(),
+		Pending:     true,
+	}
+
+	wm.addDelivery(delivery)
+
+	go panicCapture(func() {
+		wm.deliver(webhook, delivery, body)
+	})
+}
+
+func (wm *WebhookManager) deliver(webhook *Webhook, delivery *WebhookDelivery, body []byte) {
+	backoff := webhookRetryBackoff
+
+	for attempt := 1; attempt <= webhookMaxAttempts; attempt++ {
+		statusCode, err := wm.post(webhook, delivery, body)
+
+		wm.deliveriesMutex.Lock()
+		delivery.Attempts = attempt
+		delivery.LastAttempt = time.Now()
+		delivery.StatusCode = statusCode
+		delivery.Succeeded = err == nil
+		delivery.Pending = err != nil && attempt < webhookMaxAttempts
+
+		if err != nil {
+			delivery.Error = err.Error()
+		} else {
+			delivery.Error = ""
+		}
+		wm.deliveriesMutex.Unlock()
+
+		if err == nil {
+			return
+		}
+
+		logrus.WithError(err).Warnf("Could not deliver %s to webhook: %s (attempt %d of %d)", delivery.Event, webhook.Name, attempt, webhookMaxAttempts)
+
+		if attempt < webhookMaxAttempts {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+}
+
+func (wm *WebhookManager) post(webhook *Webhook, delivery *WebhookDelivery, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Assetto Server Manager/"+BuildVersion)
+	req.Header.Set(webhookEventHeader, string(delivery.Event))
+	req.Header.Set(webhookDeliveryIDHeader, delivery.ID.String())
+
+	if webhook.Secret != "" {
+		req.Header.Set(webhookSignatureHeader, "sha256="+signWebhookBody(webhook.Secret, body))
+	}
+
+	resp, err := wm.client.Do(req)
+
+	if err != nil {
+		return 0, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("servermanager: webhook responded with status: %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// signWebhookBody returns the hex encoded HMAC-SHA256 of body. Receivers can verify a request by computing the
+// same value with their copy of the secret and comparing it to the X-Server-Manager-Signature header.
+func signWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (wm *WebhookManager) addDelivery(delivery *WebhookDelivery) {
+	wm.deliveriesMutex.Lock()
+	defer wm.deliveriesMutex.Unlock()
+
+	wm.deliveries = append(wm.deliveries, delivery)
+
+	if len(wm.deliveries) > webhookMaxDeliveries {
+		wm.deliveries = wm.deliveries[len(wm.deliveries)-webhookMaxDeliveries:]
+	}
+}
+
+// Deliveries returns the most recent deliveries, newest first.
+func (wm *WebhookManager) Deliveries() []WebhookDelivery {
+	wm.deliveriesMutex.Lock()
+	defer wm.deliveriesMutex.Unlock()
+
+	deliveries := make([]WebhookDelivery, 0, len(wm.deliveries))
+
+	for i := len(wm.deliveries) - 1; i >= 0; i-- {
+		deliveries = append(deliveries, *wm.deliveries[i])
+	}
+
+	return deliveries
+}
+
+type WebhooksHandler struct {
+	*BaseHandler
+
+	webhookManager *WebhookManager
+}
+
+func NewWebhooksHandler(baseHandler *BaseHandler, webhookManager *WebhookManager) *WebhooksHandler {
+	return &WebhooksHandler{
+		BaseHandler:    baseHandler,
+		webhookManager: webhookManager,
+	}
+}
+
+type webhooksTemplateVars struct {
+	BaseTemplateVars
+
+	Webhooks   []*Webhook
+	Deliveries []WebhookDelivery
+	Edit       *Webhook
+	Events     []WebhookEventDescription
+}
+
+func (wh *WebhooksHandler) list(w http.ResponseWriter, r *http.Request) {
+	webhooks, err := wh.webhookManager.ListWebhooks()
+
+	if err != nil {
+		logrus.WithError(err).Errorf("Could not list webhooks")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	edit := &Webhook{Enabled: true}
+
+	if id := r.URL.Query().Get("edit"); id != "" {
+		edit, err = wh.webhookManager.FindWebhook(id)
+
+		if err == ErrWebhookNotFound {
+			http.NotFound(w, r)
+			return
+		} else if err != nil {
+			logrus.WithError(err).Errorf("Could not load webhook: %s", id)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	wh.viewRenderer.MustLoadTemplate(w, r, "server/webhooks.html", &webhooksTemplateVars{
+		Webhooks:   webhooks,
+		Deliveries: wh.webhookManager.Deliveries(),
+		Edit:       edit,
+		Events:     WebhookEventDescriptions,
+	})
+}
+
+func (wh *WebhooksHandler) save(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+
+	webhook := &Webhook{
+		ID:      uuid.New(),
+		Created: time.Now(),
+	}
+
+	if id := r.FormValue("ID"); id != "" {
+		existing, err := wh.webhookManager.FindWebhook(id)
+
+		if err == ErrWebhookNotFound {
+			http.NotFound(w, r)
+			return
+		} else if err != nil {
+			logrus.WithError(err).Errorf("Could not load webhook: %s", id)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		webhook = existing
+	}
+
+	webhook.Name = r.FormValue("Name")
+	webhook.URL = strings.TrimSpace(r.FormValue("URL"))
+	webhook.Enabled = r.FormValue("Enabled") == "on"
+	webhook.Events = nil
+
+	for _, event := range r.Form["Events"] {
+		webhook.Events = append(webhook.Events, WebhookEvent(event))
+	}
+
+	// the secret is never shown once saved, so leaving it blank when editing keeps the current one.
+	if secret := r.FormValue("Secret"); secret != "" || r.FormValue("RemoveSecret") == "on" {
+		webhook.Secret = secret
+	}
+
+	err := wh.webhookManager.UpsertWebhook(webhook)
+
+	switch err {
+	case nil:
+		AddFlash(w, r, "Webhook successfully saved")
+	case ErrWebhookNameRequired:
+		AddErrorFlash(w, r, "Please give the webhook a name")
+	case ErrWebhookInvalidURL:
+		AddErrorFlash(w, r, "Please enter a valid http or https URL for the webhook")
+	default:
+		logrus.WithError(err).Errorf("Could not save webhook")
+		AddErrorFlash(w, r, "Unable to save webhook")
+	}
+
+	http.Redirect(w, r, "/webhooks", http.StatusFound)
+}
+
+func (wh *WebhooksHandler) delete(w http.ResponseWriter, r *http.Request) {
+	if err := wh.webhookManager.DeleteWebhook(chi.URLParam(r, "id")); err != nil {
+		logrus.WithError(err).Errorf("Could not delete webhook")
+		AddErrorFlash(w, r, "Unable to delete webhook")
+	} else {
+		AddFlash(w, r, "Webhook successfully deleted")
+	}
+
+	http.Redirect(w, r, "/webhooks", http.StatusFound)
+}
+
+func (wh *WebhooksHandler) test(w http.ResponseWriter, r *http.Request) {
+	webhook, err := wh.webhookManager.FindWebhook(chi.URLParam(r, "id"))
+
+	if err == ErrWebhookNotFound {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		logrus.WithError(err).Errorf("Could not load webhook")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	wh.webhookManager.SendTest(webhook)
+
+	AddFlash(w, r, "A test notification has been sent. Its delivery status is shown below.")
+	http.Redirect(w, r, "/webhooks", http.StatusFound)
+}
@@ -0,0 +1,73 @@
+package servermanager
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestWebhookManager_Deliver(t *testing.T) {
+	webhookRetryBackoff = time.Millisecond
+
+	var requests int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		body, err := ioutil.ReadAll(r.Body)
+
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		if signature := r.Header.Get(webhookSignatureHeader); signature != "sha256="+signWebhookBody("secret", body) {
+			t.Errorf("Invalid signature: %s", signature)
+		}
+
+		if r.Header.Get(webhookEventHeader) != string(WebhookEventTest) {
+			t.Errorf("Expected event header to be %s, got %s", WebhookEventTest, r.Header.Get(webhookEventHeader))
+		}
+
+		// fail the first two attempts, so the delivery is retried
+		if requests < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	wm := NewWebhookManager(testStore)
+
+	webhook := &Webhook{
+		ID:     uuid.New(),
+		Name:   "Test",
+		URL:    server.URL,
+		Secret: "secret",
+	}
+
+	delivery := &WebhookDelivery{
+		ID:    uuid.New(),
+		Event: WebhookEventTest,
+	}
+
+	wm.deliver(webhook, delivery, []byte(`{"Event":"test"}`))
+
+	if !delivery.Succeeded || delivery.Pending {
+		t.Errorf("Expected delivery to succeed, got error: %s", delivery.Error)
+	}
+
+	if delivery.Attempts != 3 || requests != 3 {
+		t.Errorf("Expected 3 attempts, got %d (%d requests)", delivery.Attempts, requests)
+	}
+
+	if delivery.StatusCode != http.StatusNoContent {
+		t.Errorf("Expected status code %d, got %d", http.StatusNoContent, delivery.StatusCode)
+	}
+}